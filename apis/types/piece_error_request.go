@@ -27,9 +27,11 @@ type PieceErrorRequest struct {
 	//
 	DstPid string `json:"dstPid,omitempty"`
 
-	// the error type when failed to download from supernode that dfget will report to supernode
+	// the error type when failed to download from supernode that dfget will report to supernode.
+	// CONNECTION_REFUSED, TIMEOUT and TRUNCATED_BODY are the errors caused by the service
+	// of the target peer, which will be reported when failed to download from other peers.
 	//
-	// Enum: [FILE_NOT_EXIST FILE_MD5_NOT_MATCH]
+	// Enum: [FILE_NOT_EXIST FILE_MD5_NOT_MATCH CONNECTION_REFUSED TIMEOUT TRUNCATED_BODY]
 	ErrorType string `json:"errorType,omitempty"`
 
 	// the MD5 value of piece which returned by the supernode that
@@ -74,7 +76,7 @@ var pieceErrorRequestTypeErrorTypePropEnum []interface{}
 
 func init() {
 	var res []string
-	if err := json.Unmarshal([]byte(`["FILE_NOT_EXIST","FILE_MD5_NOT_MATCH"]`), &res); err != nil {
+	if err := json.Unmarshal([]byte(`["FILE_NOT_EXIST","FILE_MD5_NOT_MATCH","CONNECTION_REFUSED","TIMEOUT","TRUNCATED_BODY"]`), &res); err != nil {
 		panic(err)
 	}
 	for _, v := range res {
@@ -89,6 +91,15 @@ const (
 
 	// PieceErrorRequestErrorTypeFILEMD5NOTMATCH captures enum value "FILE_MD5_NOT_MATCH"
 	PieceErrorRequestErrorTypeFILEMD5NOTMATCH string = "FILE_MD5_NOT_MATCH"
+
+	// PieceErrorRequestErrorTypeCONNECTIONREFUSED captures enum value "CONNECTION_REFUSED"
+	PieceErrorRequestErrorTypeCONNECTIONREFUSED string = "CONNECTION_REFUSED"
+
+	// PieceErrorRequestErrorTypeTIMEOUT captures enum value "TIMEOUT"
+	PieceErrorRequestErrorTypeTIMEOUT string = "TIMEOUT"
+
+	// PieceErrorRequestErrorTypeTRUNCATEDBODY captures enum value "TRUNCATED_BODY"
+	PieceErrorRequestErrorTypeTRUNCATEDBODY string = "TRUNCATED_BODY"
 )
 
 // prop value enum
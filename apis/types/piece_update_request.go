@@ -23,6 +23,11 @@ type PieceUpdateRequest struct {
 	//
 	ClientID string `json:"clientID,omitempty"`
 
+	// The time in milliseconds spent on downloading the piece from the uploader.
+	// It is used to estimate the upload throughput of the uploader.
+	//
+	Cost int64 `json:"cost,omitempty"`
+
 	// the uploader peerID
 	//
 	DstPID string `json:"dstPID,omitempty"`
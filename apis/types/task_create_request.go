@@ -6,6 +6,8 @@ package types
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
+	"encoding/json"
+
 	strfmt "github.com/go-openapi/strfmt"
 
 	"github.com/go-openapi/errors"
@@ -66,6 +68,11 @@ type TaskCreateRequest struct {
 	//
 	Md5 string `json:"md5,omitempty"`
 
+	// The namespace which the task belongs to. It's resolved by supernode from the callSystem
+	// or the auth token of the registration, and the task is in the default namespace if it's empty.
+	//
+	Namespace string `json:"namespace,omitempty"`
+
 	// path is used in one peer A for uploading functionality. When peer B hopes
 	// to get piece C from peer A, B must provide a URL for piece C.
 	// Then when creating a task in supernode, peer A must provide this URL in request.
@@ -77,6 +84,13 @@ type TaskCreateRequest struct {
 	//
 	PeerID string `json:"peerID,omitempty"`
 
+	// The priority of the task which decides how it shares the resources of supernode with others,
+	// such as the bandwidth of CDN and the upload slots of supernode.
+	// Tasks with higher priority get a larger share when the resources are contended.
+	// The default value is normal.
+	// Enum: [critical normal background]
+	Priority string `json:"priority,omitempty"`
+
 	// The is the resource's URL which user uses dfget to download. The location of URL can be anywhere, LAN or WAN.
 	// For image distribution, this is image layer's URL in image registry.
 	// The resource url is provided by command line parameter.
@@ -100,6 +114,10 @@ func (m *TaskCreateRequest) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validatePriority(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -119,6 +137,52 @@ func (m *TaskCreateRequest) validateCallSystem(formats strfmt.Registry) error {
 	return nil
 }
 
+var taskCreateRequestTypePriorityPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["critical","normal","background"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		taskCreateRequestTypePriorityPropEnum = append(taskCreateRequestTypePriorityPropEnum, v)
+	}
+}
+
+const (
+
+	// TaskCreateRequestPriorityCritical captures enum value "critical"
+	TaskCreateRequestPriorityCritical string = "critical"
+
+	// TaskCreateRequestPriorityNormal captures enum value "normal"
+	TaskCreateRequestPriorityNormal string = "normal"
+
+	// TaskCreateRequestPriorityBackground captures enum value "background"
+	TaskCreateRequestPriorityBackground string = "background"
+)
+
+// prop value enum
+func (m *TaskCreateRequest) validatePriorityEnum(path, location string, value string) error {
+	if err := validate.Enum(path, location, value, taskCreateRequestTypePriorityPropEnum); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *TaskCreateRequest) validatePriority(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Priority) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validatePriorityEnum("priority", "body", m.Priority); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // MarshalBinary interface implementation
 func (m *TaskCreateRequest) MarshalBinary() ([]byte, error) {
 	if m == nil {
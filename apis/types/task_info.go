@@ -57,6 +57,10 @@ type TaskInfo struct {
 	//
 	Md5 string `json:"md5,omitempty"`
 
+	// The namespace which the task belongs to, and the task is accounted to the quotas of it.
+	//
+	Namespace string `json:"namespace,omitempty"`
+
 	// The size of pieces which is calculated as per the following strategy
 	// 1. If file's total size is less than 200MB, then the piece size is 4MB by default.
 	// 2. Otherwise, it equals to the smaller value between totalSize/100MB + 2 MB and 15MB.
@@ -66,6 +70,13 @@ type TaskInfo struct {
 	// piece total
 	PieceTotal int32 `json:"pieceTotal,omitempty"`
 
+	// The priority of the task which decides how it shares the resources of supernode with others,
+	// such as the bandwidth of CDN and the upload slots of supernode.
+	// Tasks with higher priority get a larger share when the resources are contended.
+	// The default value is normal.
+	// Enum: [critical normal background]
+	Priority string `json:"priority,omitempty"`
+
 	// The is the resource's URL which user uses dfget to download. The location of URL can be anywhere, LAN or WAN.
 	// For image distribution, this is image layer's URL in image registry.
 	// The resource url is provided by command line parameter.
@@ -92,6 +103,10 @@ func (m *TaskInfo) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validatePriority(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -150,6 +165,52 @@ func (m *TaskInfo) validateCdnStatus(formats strfmt.Registry) error {
 	return nil
 }
 
+var taskInfoTypePriorityPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["critical","normal","background"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		taskInfoTypePriorityPropEnum = append(taskInfoTypePriorityPropEnum, v)
+	}
+}
+
+const (
+
+	// TaskInfoPriorityCritical captures enum value "critical"
+	TaskInfoPriorityCritical string = "critical"
+
+	// TaskInfoPriorityNormal captures enum value "normal"
+	TaskInfoPriorityNormal string = "normal"
+
+	// TaskInfoPriorityBackground captures enum value "background"
+	TaskInfoPriorityBackground string = "background"
+)
+
+// prop value enum
+func (m *TaskInfo) validatePriorityEnum(path, location string, value string) error {
+	if err := validate.Enum(path, location, value, taskInfoTypePriorityPropEnum); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *TaskInfo) validatePriority(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Priority) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validatePriorityEnum("priority", "body", m.Priority); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // MarshalBinary interface implementation
 func (m *TaskInfo) MarshalBinary() ([]byte, error) {
 	if m == nil {
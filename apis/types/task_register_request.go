@@ -6,6 +6,8 @@ package types
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
+	"encoding/json"
+
 	strfmt "github.com/go-openapi/strfmt"
 
 	"github.com/go-openapi/errors"
@@ -84,12 +86,24 @@ type TaskRegisterRequest struct {
 	// Minimum: 15000
 	Port int32 `json:"port,omitempty"`
 
+	// The priority of the task which decides how it shares the resources of supernode with others,
+	// such as the bandwidth of CDN and the upload slots of supernode.
+	// Tasks with higher priority get a larger share when the resources are contended.
+	// The default value is normal.
+	// Enum: [critical normal background]
+	Priority string `json:"priority,omitempty"`
+
 	// The is the resource's URL which user uses dfget to download. The location of URL can be anywhere, LAN or WAN.
 	// For image distribution, this is image layer's URL in image registry.
 	// The resource url is provided by command line parameter.
 	//
 	RawURL string `json:"rawURL,omitempty"`
 
+	// tells whether the request has been redirected by another supernode of the cluster
+	// to the supernode which owns the task. The redirected request won't be redirected again.
+	//
+	Redirected bool `json:"redirected,omitempty"`
+
 	// The root ca cert from client used to download the remote source file.
 	//
 	RootCAs []strfmt.Base64 `json:"rootCAs"`
@@ -126,6 +140,10 @@ func (m *TaskRegisterRequest) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validatePriority(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateRootCAs(formats); err != nil {
 		res = append(res, err)
 	}
@@ -192,6 +210,52 @@ func (m *TaskRegisterRequest) validatePort(formats strfmt.Registry) error {
 	return nil
 }
 
+var taskRegisterRequestTypePriorityPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["critical","normal","background"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		taskRegisterRequestTypePriorityPropEnum = append(taskRegisterRequestTypePriorityPropEnum, v)
+	}
+}
+
+const (
+
+	// TaskRegisterRequestPriorityCritical captures enum value "critical"
+	TaskRegisterRequestPriorityCritical string = "critical"
+
+	// TaskRegisterRequestPriorityNormal captures enum value "normal"
+	TaskRegisterRequestPriorityNormal string = "normal"
+
+	// TaskRegisterRequestPriorityBackground captures enum value "background"
+	TaskRegisterRequestPriorityBackground string = "background"
+)
+
+// prop value enum
+func (m *TaskRegisterRequest) validatePriorityEnum(path, location string, value string) error {
+	if err := validate.Enum(path, location, value, taskRegisterRequestTypePriorityPropEnum); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *TaskRegisterRequest) validatePriority(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Priority) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validatePriorityEnum("priority", "body", m.Priority); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (m *TaskRegisterRequest) validateRootCAs(formats strfmt.Registry) error {
 
 	if swag.IsZero(m.RootCAs) { // not required
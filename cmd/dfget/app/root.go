@@ -229,6 +229,10 @@ func initFlags() {
 		"the usage of identifier is making different downloading tasks generate different downloading task IDs even if they have the same URLs. conflict with --md5.")
 	flagSet.StringVar(&cfg.CallSystem, "callsystem", "",
 		"the name of dfget caller which is for debugging. Once set, it will be passed to all components around the request to make debugging easy")
+	flagSet.StringVar(&cfg.Token, "token", "",
+		"the bearer token to register the task to the supernode, which assigns the task to the namespace of the token")
+	flagSet.StringVar(&cfg.Priority, "priority", "",
+		"the priority of the downloading task which decides how it shares the resources of supernode with others, must be critical/normal/background")
 	flagSet.StringSliceVar(&cfg.Cacerts, "cacerts", nil,
 		"the cacert file which is used to verify remote server when supernode interact with the source.")
 	flagSet.StringVarP(&cfg.Pattern, "pattern", "p", "p2p",
@@ -111,6 +111,14 @@ var rootCmd = &cobra.Command{
 			return err
 		}
 
+		// reload the config file with the same flags.
+		d.SetConfigLoader(func() (*config.Config, error) {
+			if err := readConfigFile(supernodeViper, cmd); err != nil {
+				return nil, errors.Wrap(err, "read config file")
+			}
+			return getConfigFromViper(supernodeViper)
+		})
+
 		// register supernode
 		if err := d.RegisterSuperNode(); err != nil {
 			logrus.Errorf("failed to register super node: %v", err)
@@ -147,6 +155,9 @@ func setupFlags(cmd *cobra.Command) {
 	flagSet.Int("download-port", defaultBaseProperties.DownloadPort,
 		"downloadPort is the port for download files from supernode")
 
+	flagSet.Bool("file-server", defaultBaseProperties.EnableFileServer,
+		"file server sets whether supernode serves the downloaded files on the download port by itself")
+
 	flagSet.String("home-dir", defaultBaseProperties.HomeDir,
 		"homeDir is the working directory of supernode")
 
@@ -174,6 +185,15 @@ func setupFlags(cmd *cobra.Command) {
 	flagSet.String("advertise-ip", "",
 		"the supernode ip is the ip we advertise to other peers in the p2p-network")
 
+	flagSet.StringSlice("cdn-siblings", defaultBaseProperties.CDNSiblings,
+		"cdn siblings are the addresses(ip:port) of the supernodes which are queried for the cache before downloading from the source")
+
+	flagSet.Int("replica-count", defaultBaseProperties.ReplicaCount,
+		"replica count is the number of the cdn siblings which the hot tasks are replicated to")
+
+	flagSet.StringSlice("cluster-members", defaultBaseProperties.ClusterMembers,
+		"cluster members are the addresses(ip:port) of the supernodes which own the tasks through consistent hashing")
+
 	flagSet.Duration("fail-access-interval", defaultBaseProperties.FailAccessInterval,
 		"fail access interval is the interval time after failed to access the URL")
 
@@ -189,6 +209,9 @@ func setupFlags(cmd *cobra.Command) {
 	flagSet.Duration("peer-gc-delay", defaultBaseProperties.PeerGCDelay,
 		"peer gc delay is the delay time to execute the GC after the peer has reported the offline")
 
+	flagSet.Duration("drain-timeout", defaultBaseProperties.DrainTimeout,
+		"drain timeout is the max time to wait for the active downloads to finish when draining")
+
 	exitOnError(bindRootFlags(supernodeViper), "bind root command flags")
 }
 
@@ -210,6 +233,10 @@ func bindRootFlags(v *viper.Viper) error {
 			key:  "base.downloadPort",
 			flag: "download-port",
 		},
+		{
+			key:  "base.enableFileServer",
+			flag: "file-server",
+		},
 		{
 			key:  "base.homeDir",
 			flag: "home-dir",
@@ -246,6 +273,18 @@ func bindRootFlags(v *viper.Viper) error {
 			key:  "base.advertiseIP",
 			flag: "advertise-ip",
 		},
+		{
+			key:  "base.cdnSiblings",
+			flag: "cdn-siblings",
+		},
+		{
+			key:  "base.replicaCount",
+			flag: "replica-count",
+		},
+		{
+			key:  "base.clusterMembers",
+			flag: "cluster-members",
+		},
 		{
 			key:  "base.failAccessInterval",
 			flag: "fail-access-interval",
@@ -266,6 +305,10 @@ func bindRootFlags(v *viper.Viper) error {
 			key:  "base.peerGCDelay",
 			flag: "peer-gc-delay",
 		},
+		{
+			key:  "base.drainTimeout",
+			flag: "drain-timeout",
+		},
 	}
 
 	for _, f := range flags {
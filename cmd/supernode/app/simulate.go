@@ -0,0 +1,119 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package app
+
+import (
+	"github.com/dragonflyoss/Dragonfly/pkg/printer"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/simulator"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// simulateDescription is used to describe simulate command in detail and auto generate command doc.
+var simulateDescription = "Replay a swarm trace with the scheduler of supernode in virtual time, " +
+	"and print the distribution of the completion times of the peers and the egress of each run. " +
+	"The runs use the consecutive seeds starting from the seed, so the output is reproducible."
+
+var simulateCmd = &cobra.Command{
+	Use:           "simulate",
+	Short:         "Replay a swarm trace with the scheduler of supernode",
+	Long:          simulateDescription,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSimulate(cmd)
+	},
+	Example: simulateExample(),
+}
+
+func init() {
+	flagSet := simulateCmd.Flags()
+	flagSet.String("trace", "", "the path of the swarm trace file in json format")
+	flagSet.String("config", "", "the path of supernode's configuration file, and the default configuration is used if it's empty")
+	flagSet.Int64("seed", 1, "the seed of the first run")
+	flagSet.Int("runs", 1, "the number of runs")
+	rootCmd.AddCommand(simulateCmd)
+}
+
+func runSimulate(cmd *cobra.Command) error {
+	flagSet := cmd.Flags()
+	tracePath, _ := flagSet.GetString("trace")
+	configPath, _ := flagSet.GetString("config")
+	seed, _ := flagSet.GetInt64("seed")
+	runs, _ := flagSet.GetInt("runs")
+	if stringutils.IsEmptyStr(tracePath) {
+		return errors.New("the trace is required")
+	}
+	if runs <= 0 {
+		return errors.Errorf("invalid runs: %d", runs)
+	}
+
+	cfg := config.NewConfig()
+	if !stringutils.IsEmptyStr(configPath) {
+		v := viper.New()
+		v.SetConfigFile(configPath)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return errors.Wrap(err, "read config file")
+		}
+		var err error
+		if cfg, err = getConfigFromViper(v); err != nil {
+			return errors.Wrap(err, "get config from viper")
+		}
+	}
+
+	// only the distributions are printed, and the logs of the scheduling are too verbose.
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	trace, err := simulator.LoadTrace(tracePath)
+	if err != nil {
+		return errors.Wrapf(err, "load trace %s", tracePath)
+	}
+
+	for i := 0; i < runs; i++ {
+		sim, err := simulator.NewSimulator(cfg, trace, seed+int64(i))
+		if err != nil {
+			return err
+		}
+		result, err := sim.Run()
+		if err != nil {
+			return errors.Wrapf(err, "run simulation with seed %d", seed+int64(i))
+		}
+		printer.Printf("seed: %d\n%s\n", seed+int64(i), result)
+	}
+	return nil
+}
+
+// simulateExample shows examples in simulate command, and is used in auto-generated cli docs.
+func simulateExample() string {
+	return `supernode simulate --trace supernode/simulator/testdata/swarm.json --runs 2
+seed: 1
+finished: 19, unfinished: 1, elapsed: 53.875s
+completion time: min=13.032s mean=39.84768421s p50=40.499s p90=49.055s p99=52.875s max=52.875s
+egress: supernode=872415232 peers=4232052736 supernodeRatio=0.171
+failed pieces: 8
+
+seed: 2
+...
+`
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type simulateTestSuite struct {
+	suite.Suite
+}
+
+func (ts *simulateTestSuite) TestSimulate() {
+	r := ts.Require()
+	flagSet := simulateCmd.Flags()
+
+	r.Error(runSimulate(simulateCmd))
+
+	r.Nil(flagSet.Set("trace", "../../../supernode/simulator/testdata/swarm.json"))
+	r.Nil(flagSet.Set("runs", "0"))
+	r.Error(runSimulate(simulateCmd))
+
+	r.Nil(flagSet.Set("runs", "2"))
+	r.Nil(runSimulate(simulateCmd))
+
+	r.Nil(flagSet.Set("config", "foo.yml"))
+	r.Error(runSimulate(simulateCmd))
+}
+
+func TestSimulateCommand(t *testing.T) {
+	suite.Run(t, &simulateTestSuite{})
+}
@@ -173,6 +173,14 @@ type Config struct {
 	// CallSystem system name that executes dfget.
 	CallSystem string `json:"callSystem,omitempty"`
 
+	// Token the bearer token to register the task to the supernode, which decides the namespace of the task.
+	// It's not printed with the config.
+	Token string `json:"-"`
+
+	// Priority the priority of the download task, must be 'critical' or 'normal' or 'background'.
+	// The supernode shares its resources among tasks in proportion to their priorities.
+	Priority string `json:"priority,omitempty"`
+
 	// Pattern download pattern, must be 'p2p' or 'cdn' or 'source',
 	// default:`p2p`.
 	Pattern string `json:"pattern,omitempty"`
@@ -274,9 +282,22 @@ func AssertConfig(cfg *Config) (err error) {
 	if err := checkOutput(cfg); err != nil {
 		return errors.Wrapf(errortypes.ErrInvalidValue, "output: %v", err)
 	}
+
+	if err := checkPriority(cfg); err != nil {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "priority: %v", err)
+	}
 	return nil
 }
 
+// checkPriority checks the priority which is empty or one of critical/normal/background.
+func checkPriority(cfg *Config) error {
+	switch cfg.Priority {
+	case "", PriorityCritical, PriorityNormal, PriorityBackground:
+		return nil
+	}
+	return fmt.Errorf("%s is not one of %s/%s/%s", cfg.Priority, PriorityCritical, PriorityNormal, PriorityBackground)
+}
+
 // This function must be called after checkURL
 func checkOutput(cfg *Config) error {
 	if stringutils.IsEmptyStr(cfg.Output) {
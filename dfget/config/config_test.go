@@ -155,6 +155,16 @@ func (suite *ConfigSuite) TestCheckOutput(c *check.C) {
 	}
 }
 
+func (suite *ConfigSuite) TestCheckPriority(c *check.C) {
+	for _, v := range []string{"", PriorityCritical, PriorityNormal, PriorityBackground} {
+		cfg.Priority = v
+		c.Assert(checkPriority(cfg), check.IsNil, check.Commentf("%s", v))
+	}
+	cfg.Priority = "urgent"
+	c.Assert(checkPriority(cfg), check.NotNil)
+	cfg.Priority = ""
+}
+
 func (suite *ConfigSuite) TestProperties_Load(c *check.C) {
 	dirName, _ := ioutil.TempDir("/tmp", "dfget-TestProperties_Load-")
 	defer os.RemoveAll(dirName)
@@ -45,6 +45,13 @@ const (
 	PatternSource = "source"
 )
 
+/* task priority */
+const (
+	PriorityCritical   = "critical"
+	PriorityNormal     = "normal"
+	PriorityBackground = "background"
+)
+
 /* properties */
 const (
 	DefaultYamlConfigFile  = "/etc/dragonfly/dfget.yml"
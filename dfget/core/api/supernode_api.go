@@ -76,7 +76,13 @@ func (api *supernodeAPI) Register(node string, req *types.RegisterRequest) (
 	)
 	url := fmt.Sprintf("%s://%s%s",
 		api.Scheme, node, peerRegisterPath)
-	if code, body, e = api.HTTPClient.PostJSON(url, req, api.Timeout); e != nil {
+	if req.Token != "" {
+		headers := map[string]string{"Authorization": "Bearer " + req.Token}
+		code, body, e = api.HTTPClient.PostJSONWithHeaders(url, headers, req, api.Timeout)
+	} else {
+		code, body, e = api.HTTPClient.PostJSON(url, req, api.Timeout)
+	}
+	if e != nil {
 		return nil, e
 	}
 	if !httputils.HTTPStatusOk(code) {
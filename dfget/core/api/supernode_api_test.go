@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/dfget/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/constants"
@@ -85,6 +86,24 @@ func (s *SupernodeAPITestSuite) TestSupernodeAPI_Register(c *check.C) {
 	c.Assert(r.Data.FileLength, check.Equals, res.Data.FileLength)
 }
 
+func (s *SupernodeAPITestSuite) TestSupernodeAPI_RegisterWithToken(c *check.C) {
+	res := types.RegisterResponse{BaseResponse: &types.BaseResponse{Code: constants.Success}}
+	var headers map[string]string
+	s.mock.PostJSONWithHeadersFunc = func(url string, h map[string]string, body interface{},
+		timeout time.Duration) (int, []byte, error) {
+		headers = h
+		return 200, []byte(res.String()), nil
+	}
+
+	req := createRegisterRequest()
+	req.Token = "secret"
+	r, e := s.api.Register(localhost, req)
+	c.Assert(e, check.IsNil)
+	c.Assert(r.Code, check.Equals, constants.Success)
+	c.Assert(headers, check.DeepEquals, map[string]string{"Authorization": "Bearer secret"})
+	c.Assert(strings.Contains(req.String(), "secret"), check.Equals, false)
+}
+
 func (s *SupernodeAPITestSuite) TestSupernodeAPI_PullPieceTask(c *check.C) {
 	res := &types.PullPieceTaskResponse{BaseResponse: &types.BaseResponse{}}
 	res.Code = constants.CodePeerFinish
@@ -207,6 +207,7 @@ func (cw *ClientWriter) sendSuccessPiece(piece *Piece, cost time.Duration) {
 		Cid:        cw.cfg.RV.Cid,
 		DstCid:     piece.DstCid,
 		PieceRange: piece.Range,
+		Cost:       int64(piece.ReadCost / time.Millisecond),
 	})
 	if cost.Seconds() > 2.0 {
 		logrus.Infof(
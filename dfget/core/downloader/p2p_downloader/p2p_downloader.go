@@ -91,6 +91,9 @@ type P2PDownloader struct {
 	// false: if the range is in processing
 	// not in: the range hasn't been processed
 	pieceSet map[string]bool
+	// runningPieces range -> *runningPiece
+	// maintains the downloads of the ranges which are in processing.
+	runningPieces map[string]*runningPiece
 	// total indicates the total length of the downloaded file.
 	total int64
 
@@ -108,6 +111,16 @@ type P2PDownloader struct {
 
 var _ downloader.Downloader = &P2PDownloader{}
 
+// runningPiece maintains the downloads of a range which is in processing.
+// A range may be downloaded from more than one peers at the same time in the endgame,
+// and the others will be canceled once the first copy arrives.
+type runningPiece struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// count is the number of the downloads in processing.
+	count int
+}
+
 // NewP2PDownloader creates a P2PDownloader.
 func NewP2PDownloader(cfg *config.Config,
 	api api.SupernodeAPI,
@@ -143,6 +156,7 @@ func (p2p *P2PDownloader) init() {
 	p2p.serviceFilePath = helper.GetServiceFile(p2p.taskFileName, p2p.cfg.RV.DataDir)
 
 	p2p.pieceSet = make(map[string]bool)
+	p2p.runningPieces = make(map[string]*runningPiece)
 
 	p2p.rateLimiter = ratelimiter.NewRateLimiter(int64(p2p.cfg.LocalLimit), 2)
 	p2p.pullRateTime = time.Now().Add(-3 * time.Second)
@@ -318,7 +332,7 @@ func (p2p *P2PDownloader) getPullRate(data *types.PullPieceTaskResponseContinueD
 	p2p.rateLimiter.SetRate(ratelimiter.TransRate(int64(reqRate)))
 }
 
-func (p2p *P2PDownloader) startTask(data *types.PullPieceTaskResponseContinueData) {
+func (p2p *P2PDownloader) startTask(ctx context.Context, data *types.PullPieceTaskResponseContinueData) {
 	powerClient := &PowerClient{
 		taskID:      p2p.taskID,
 		node:        p2p.node,
@@ -328,6 +342,7 @@ func (p2p *P2PDownloader) startTask(data *types.PullPieceTaskResponseContinueDat
 		clientQueue: p2p.clientQueue,
 		rateLimiter: p2p.rateLimiter,
 		downloadAPI: api.NewDownloadAPI(),
+		ctx:         ctx,
 	}
 	if err := powerClient.Run(); err != nil && powerClient.ClientError() != nil {
 		p2p.API.ReportClientError(p2p.node, powerClient.ClientError())
@@ -358,7 +373,8 @@ func (p2p *P2PDownloader) getItem(latestItem *Piece) (bool, *Piece) {
 				item.Result == constants.ResultSuc) {
 				p2p.total += int64(item.Content.Len())
 				p2p.pieceSet[item.Range] = true
-			} else if !v {
+				p2p.finishRunningPiece(item.Range)
+			} else if !v && !p2p.failRunningPiece(item.Range) {
 				delete(p2p.pieceSet, item.Range)
 			}
 		}
@@ -413,7 +429,15 @@ func (p2p *P2PDownloader) processPiece(response *types.PullPieceTaskResponse,
 		if !ok {
 			p2p.pieceSet[pieceRange] = false
 			p2p.getPullRate(pieceTask)
-			go p2p.startTask(pieceTask)
+			go p2p.startTask(p2p.startRunningPiece(pieceRange), pieceTask)
+			hasTask = true
+			continue
+		}
+		// the running piece is scheduled to another peer in the endgame.
+		if rp, ok := p2p.runningPieces[pieceRange]; ok {
+			logrus.Infof("start to download running piece:%s from another peer:%s", pieceRange, pieceTask.PeerIP)
+			rp.count++
+			go p2p.startTask(rp.ctx, pieceTask)
 			hasTask = true
 		}
 	}
@@ -425,6 +449,42 @@ func (p2p *P2PDownloader) processPiece(response *types.PullPieceTaskResponse,
 	}
 }
 
+// startRunningPiece records a new download of the range and returns the context
+// which is used to cancel it.
+func (p2p *P2PDownloader) startRunningPiece(pieceRange string) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	p2p.runningPieces[pieceRange] = &runningPiece{
+		ctx:    ctx,
+		cancel: cancel,
+		count:  1,
+	}
+	return ctx
+}
+
+// finishRunningPiece cancels the other downloads of the range
+// because it has been downloaded successfully.
+func (p2p *P2PDownloader) finishRunningPiece(pieceRange string) {
+	if rp, ok := p2p.runningPieces[pieceRange]; ok {
+		rp.cancel()
+		delete(p2p.runningPieces, pieceRange)
+	}
+}
+
+// failRunningPiece records a failed download of the range,
+// and returns whether the range is still being downloaded from other peers.
+func (p2p *P2PDownloader) failRunningPiece(pieceRange string) bool {
+	rp, ok := p2p.runningPieces[pieceRange]
+	if !ok {
+		return false
+	}
+	if rp.count > 1 {
+		rp.count--
+		return true
+	}
+	p2p.finishRunningPiece(pieceRange)
+	return false
+}
+
 func (p2p *P2PDownloader) finishTask(response *types.PullPieceTaskResponse, clientWriter *ClientWriter) {
 	// wait client writer finished
 	logrus.Infof("remaining piece to be written count:%d", p2p.clientQueue.Len())
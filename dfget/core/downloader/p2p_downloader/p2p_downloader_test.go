@@ -32,3 +32,27 @@ type P2PDownloaderTestSuite struct {
 func init() {
 	check.Suite(&P2PDownloaderTestSuite{})
 }
+
+func (s *P2PDownloaderTestSuite) TestRunningPiece(c *check.C) {
+	p2p := &P2PDownloader{
+		runningPieces: make(map[string]*runningPiece),
+	}
+
+	// the range is downloaded from two peers and one of them fails
+	ctx := p2p.startRunningPiece("0-9")
+	p2p.runningPieces["0-9"].count++
+	c.Check(p2p.failRunningPiece("0-9"), check.Equals, true)
+	c.Check(ctx.Err(), check.IsNil)
+
+	// the other one succeeds
+	p2p.finishRunningPiece("0-9")
+	c.Check(ctx.Err(), check.NotNil)
+	_, ok := p2p.runningPieces["0-9"]
+	c.Check(ok, check.Equals, false)
+
+	// the range is downloaded from only one peer and it fails
+	ctx = p2p.startRunningPiece("10-19")
+	c.Check(p2p.failRunningPiece("10-19"), check.Equals, false)
+	c.Check(ctx.Err(), check.NotNil)
+	c.Check(p2p.failRunningPiece("20-29"), check.Equals, false)
+}
@@ -19,6 +19,7 @@ package downloader
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/pkg/constants"
 )
@@ -49,6 +50,9 @@ type Piece struct {
 	// PieceNum represents the position of the piece in the pieces list by cutting files.
 	PieceNum int `json:"pieceNum"`
 
+	// ReadCost records how long it took to download the piece from DstCid.
+	ReadCost time.Duration `json:"-"`
+
 	// Content uses a buffer to temporarily store the piece content.
 	Content *bytes.Buffer `json:"-"`
 }
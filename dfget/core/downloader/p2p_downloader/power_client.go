@@ -18,6 +18,7 @@ package downloader
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -67,13 +68,18 @@ type PowerClient struct {
 
 	// total indicates the total length of the downloaded piece.
 	total int64
-	// readCost records how long it took to download the piece.
+	// readCost records how long it took to download the piece from the peer,
+	// excluding the time waiting for the rateLimiter.
 	readCost time.Duration
 
 	// downloadAPI holds an instance of DownloadAPI.
 	downloadAPI api.DownloadAPI
 
 	clientError *types.ClientErrorRequest
+
+	// ctx is used to cancel the download when the piece has been
+	// downloaded from another peer in the endgame.
+	ctx context.Context
 }
 
 // Run starts run the task.
@@ -81,6 +87,11 @@ func (pc *PowerClient) Run() error {
 	startTime := time.Now()
 
 	content, err := pc.downloadPiece()
+	if pc.isCanceled() {
+		logrus.Debugf("client range:%s from peer:%s:%d is canceled",
+			pc.pieceTask.Range, pc.pieceTask.PeerIP, pc.pieceTask.PeerPort)
+		return nil
+	}
 
 	timeDuring := time.Since(startTime).Seconds()
 	logrus.Debugf("client range:%s cost:%.3f from peer:%s:%d, readCost:%.3f, length:%d",
@@ -116,6 +127,7 @@ func (pc *PowerClient) downloadPiece() (content *bytes.Buffer, e error) {
 	// check that the target download peer is available
 	if dstIP != pc.node {
 		if _, e = httputils.CheckConnect(dstIP, peerPort, -1); e != nil {
+			pc.initPeerServiceError(dstIP, e)
 			return nil, e
 		}
 	}
@@ -125,10 +137,23 @@ func (pc *PowerClient) downloadPiece() (content *bytes.Buffer, e error) {
 	timeout := netutils.CalculateTimeout(int64(pc.pieceTask.PieceSize), pc.cfg.MinRate, config.DefaultMinRate, 10*time.Second)
 	resp, err := pc.downloadAPI.Download(dstIP, peerPort, pc.createDownloadRequest(), timeout)
 	if err != nil {
+		pc.initPeerServiceError(dstIP, err)
 		return nil, err
 	}
 	logrus.Debugf("success to get resp timeSince(%v)", time.Since(startTime))
 	defer resp.Body.Close()
+	if pc.ctx != nil {
+		// interrupt the reading when the download is canceled.
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-pc.ctx.Done():
+				resp.Body.Close()
+			case <-done:
+			}
+		}()
+	}
 	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
 		return nil, errortypes.ErrRangeNotSatisfiable
 	}
@@ -141,12 +166,18 @@ func (pc *PowerClient) downloadPiece() (content *bytes.Buffer, e error) {
 
 	// start to read data from resp
 	// use limitReader to limit the download speed
-	limitReader := limitreader.NewLimitReaderWithLimiter(pc.rateLimiter, resp.Body, pieceMD5 != "")
+	// and costReader to exclude the time waiting for the rateLimiter from the readCost
+	body := newCostReader(resp.Body, time.Now)
+	limitReader := limitreader.NewLimitReaderWithLimiter(pc.rateLimiter, body, pieceMD5 != "")
 	content = &bytes.Buffer{}
+	respCost := time.Since(startTime)
 	if pc.total, e = content.ReadFrom(limitReader); e != nil {
+		if !pc.isCanceled() {
+			pc.initPeerReadError(dstIP, e)
+		}
 		return nil, e
 	}
-	pc.readCost = time.Since(startTime)
+	pc.readCost = respCost + body.cost
 
 	// Verify md5 code
 	if realMd5 := limitReader.Md5(); realMd5 != pieceMD5 {
@@ -162,6 +193,11 @@ func (pc *PowerClient) downloadPiece() (content *bytes.Buffer, e error) {
 	return content, nil
 }
 
+// isCanceled returns whether the download has been canceled.
+func (pc *PowerClient) isCanceled() bool {
+	return pc.ctx != nil && pc.ctx.Err() != nil
+}
+
 func (pc *PowerClient) createDownloadRequest() *api.DownloadRequest {
 	return &api.DownloadRequest{
 		Path:       pc.pieceTask.Path,
@@ -176,6 +212,7 @@ func (pc *PowerClient) successPiece(content *bytes.Buffer) *Piece {
 		constants.ResultSemiSuc, constants.TaskStatusRunning, content)
 	piece.PieceSize = pc.pieceTask.PieceSize
 	piece.PieceNum = pc.pieceTask.PieceNum
+	piece.ReadCost = pc.readCost
 	return piece
 }
 
@@ -206,6 +243,60 @@ func (pc *PowerClient) initFileMd5NotMatchError(dstIP, realMd5, expectedMd5 stri
 	}
 }
 
+// initPeerServiceError classifies the err occurred when connecting to the peer,
+// and the err which isn't caused by the service of the peer will be ignored.
+func (pc *PowerClient) initPeerServiceError(dstIP string, err error) {
+	switch {
+	case netutils.IsConnectionRefused(err):
+		pc.initPeerError(constants.ClientErrorConnectionRefused, dstIP)
+	case netutils.IsTimeout(err):
+		pc.initPeerError(constants.ClientErrorTimeout, dstIP)
+	}
+}
+
+// initPeerReadError classifies the err occurred when reading the piece from the peer.
+// It's considered as a truncated body unless it's a timeout,
+// because the peer has stopped serving the rest of the piece.
+func (pc *PowerClient) initPeerReadError(dstIP string, err error) {
+	if netutils.IsTimeout(err) {
+		pc.initPeerError(constants.ClientErrorTimeout, dstIP)
+		return
+	}
+	pc.initPeerError(constants.ClientErrorTruncatedBody, dstIP)
+}
+
+func (pc *PowerClient) initPeerError(errorType, dstIP string) {
+	pc.clientError = &types.ClientErrorRequest{
+		ErrorType: errorType,
+		SrcCid:    pc.cfg.RV.Cid,
+		DstCid:    pc.pieceTask.Cid,
+		DstIP:     dstIP,
+		TaskID:    pc.taskID,
+		Range:     pc.pieceTask.Range,
+	}
+}
+
+// costReader records the time spent in reading from src.
+type costReader struct {
+	src  io.Reader
+	now  func() time.Time
+	cost time.Duration
+}
+
+func newCostReader(src io.Reader, now func() time.Time) *costReader {
+	return &costReader{
+		src: src,
+		now: now,
+	}
+}
+
+func (r *costReader) Read(p []byte) (int, error) {
+	start := r.now()
+	n, err := r.src.Read(p)
+	r.cost += r.now().Sub(start)
+	return n, err
+}
+
 func (pc *PowerClient) is2xxStatus(code int) bool {
 	return code >= 200 && code < 300
 }
@@ -23,11 +23,15 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/dfget/config"
 	"github.com/dragonflyoss/Dragonfly/dfget/core/api"
 	"github.com/dragonflyoss/Dragonfly/dfget/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/constants"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/pkg/ratelimiter"
 
@@ -53,7 +57,7 @@ func (s *PowerClientTestSuite) SetUpSuite(c *check.C) {
 	s.upServer(port)
 }
 
-func (s *PowerClientTestSuite) TearDownTest(c *check.C) {
+func (s *PowerClientTestSuite) TearDownSuite(c *check.C) {
 	s.ln.Close()
 }
 
@@ -140,6 +144,89 @@ func (s *PowerClientTestSuite) TestDownloadPiece(c *check.C) {
 	c.Check(err, check.IsNil)
 }
 
+func (s *PowerClientTestSuite) TestCostReader(c *check.C) {
+	now := time.Unix(0, 0)
+	clock := func() time.Time { return now }
+	src := &slowReader{
+		src:   strings.NewReader("hello"),
+		delay: func() { now = now.Add(10 * time.Millisecond) },
+	}
+	r := newCostReader(src, clock)
+
+	// the time waiting between the reads, such as for the rateLimiter, isn't taken into account.
+	p := make([]byte, 2)
+	for {
+		_, err := r.Read(p)
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		now = now.Add(time.Second)
+	}
+	c.Check(r.cost, check.Equals, 40*time.Millisecond)
+}
+
+// slowReader is an io.Reader that calls delay before each read.
+type slowReader struct {
+	src   io.Reader
+	delay func()
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	r.delay()
+	return r.src.Read(p)
+}
+
+func (s *PowerClientTestSuite) TestDownloadPieceClientError(c *check.C) {
+	defer s.reset()
+
+	var cases = []struct {
+		resp      *http.Response
+		err       error
+		errorType string
+	}{
+		{
+			err:       &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			errorType: constants.ClientErrorConnectionRefused,
+		},
+		{
+			err:       &net.DNSError{Err: "i/o timeout", IsTimeout: true},
+			errorType: constants.ClientErrorTimeout,
+		},
+		{
+			resp: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(io.MultiReader(bytes.NewReader([]byte("he")), &errReader{io.ErrUnexpectedEOF})),
+			},
+			errorType: constants.ClientErrorTruncatedBody,
+		},
+		{
+			err:       fmt.Errorf("error"),
+			errorType: "",
+		},
+	}
+
+	for _, v := range cases {
+		s.reset()
+		s.powerClient.pieceTask.Range = "0-4"
+		resp, err := v.resp, v.err
+		downloadMock = func() (*http.Response, error) {
+			return resp, err
+		}
+		content, err := s.powerClient.downloadPiece()
+		c.Check(content, check.IsNil)
+		c.Check(err, check.NotNil)
+		if v.errorType == "" {
+			c.Check(s.powerClient.ClientError(), check.IsNil)
+			continue
+		}
+		c.Assert(s.powerClient.ClientError(), check.NotNil)
+		c.Check(s.powerClient.ClientError().ErrorType, check.Equals, v.errorType)
+		c.Check(s.powerClient.ClientError().DstIP, check.Equals, "127.0.0.1")
+		c.Check(s.powerClient.ClientError().Range, check.Equals, "0-4")
+	}
+}
+
 func (s *PowerClientTestSuite) TestReadBody(c *check.C) {
 	powerClient := &PowerClient{}
 	var cases = []struct {
@@ -192,3 +279,12 @@ func NewMockDownloadAPI() api.DownloadAPI {
 func (d *downloadMockAPI) Download(ip string, port int, req *api.DownloadRequest, timeout time.Duration) (*http.Response, error) {
 	return downloadMock()
 }
+
+// errReader is an io.Reader that always returns err.
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -198,6 +198,36 @@ func CreateRegisterFunc() RegisterFuncType {
 			return newResponse(constants.CodeWaitAuth, "wait auth"), nil
 		case "http://x.com":
 			return newResponse(constants.CodeURLNotReachable, "not reachable"), nil
+		case "http://redirect.com":
+			if ip == "owner" && req.Redirected {
+				resp := newResponse(constants.Success, "")
+				resp.Data = &types.RegisterResponseData{
+					TaskID:     "b",
+					FileLength: 100,
+					PieceSize:  10,
+				}
+				return resp, nil
+			}
+			resp := newResponse(constants.CodeTaskRedirect, "task is owned by another supernode")
+			resp.Data = &types.RegisterResponseData{Node: "owner"}
+			return resp, nil
+		case "http://redirect-fail.com":
+			// the owner is unavailable, and the redirected registration falls back to the node.
+			if ip == "owner" {
+				return nil, fmt.Errorf("connection refused")
+			}
+			if req.Redirected {
+				resp := newResponse(constants.Success, "")
+				resp.Data = &types.RegisterResponseData{
+					TaskID:     "c",
+					FileLength: 100,
+					PieceSize:  10,
+				}
+				return resp, nil
+			}
+			resp := newResponse(constants.CodeTaskRedirect, "task is owned by another supernode")
+			resp.Data = &types.RegisterResponseData{Node: "owner"}
+			return resp, nil
 		case "http://lowzj.com":
 			resp := newResponse(constants.Success, "")
 			resp.Data = &types.RegisterResponseData{
@@ -60,6 +60,7 @@ func (s *supernodeRegister) Register(peerPort int) (*RegisterResult, *errortypes
 		resp       *types.RegisterResponse
 		e          error
 		i          int
+		node       string
 		retryTimes = 0
 		start      = time.Now()
 	)
@@ -68,11 +69,15 @@ func (s *supernodeRegister) Register(peerPort int) (*RegisterResult, *errortypes
 	nodes, nLen := s.cfg.Nodes, len(s.cfg.Nodes)
 	req := s.constructRegisterRequest(peerPort)
 	for i = 0; i < nLen; i++ {
-		req.SupernodeIP = netutils.ExtractHost(nodes[i])
-		resp, e = s.api.Register(nodes[i], req)
-		logrus.Infof("do register to %s, res:%s error:%v", nodes[i], resp, e)
+		node = nodes[i]
+		req.SupernodeIP = netutils.ExtractHost(node)
+		resp, e = s.api.Register(node, req)
+		logrus.Infof("do register to %s, res:%s error:%v", node, resp, e)
+		if e == nil && resp != nil && resp.Code == constants.CodeTaskRedirect {
+			node, resp, e = s.redirect(node, resp, req)
+		}
 		if e != nil {
-			logrus.Errorf("register to node:%s error:%v", nodes[i], e)
+			logrus.Errorf("register to node:%s error:%v", node, e)
 			continue
 		}
 		if resp.Code == constants.Success || resp.Code == constants.CodeNeedAuth ||
@@ -92,7 +97,7 @@ func (s *supernodeRegister) Register(peerPort int) (*RegisterResult, *errortypes
 		return nil, err
 	}
 
-	result := NewRegisterResult(nodes[i], s.cfg.Nodes, s.cfg.URL,
+	result := NewRegisterResult(node, s.cfg.Nodes, s.cfg.URL,
 		resp.Data.TaskID, resp.Data.FileLength, resp.Data.PieceSize)
 
 	logrus.Infof("do register result:%s and cost:%.3fs", resp,
@@ -100,6 +105,38 @@ func (s *supernodeRegister) Register(peerPort int) (*RegisterResult, *errortypes
 	return result, nil
 }
 
+// redirect registers to the supernode which owns the task according to the redirected response.
+// The redirected registration won't be redirected again by the owner.
+// If it fails to register to the owner, it falls back to registering to the node
+// which redirects it, and the node accepts the registration because it's marked redirected.
+//
+// NOTE: the dfget clients before the supernode cluster don't recognize the redirect code,
+// and treat it as a failure of the node and try the next one. So the supernodes which are
+// registered by such clients shouldn't join a cluster.
+func (s *supernodeRegister) redirect(node string, resp *types.RegisterResponse, req *types.RegisterRequest) (
+	string, *types.RegisterResponse, error) {
+	redirectReq := *req
+	redirectReq.Redirected = true
+
+	if resp.Data != nil && !stringutils.IsEmptyStr(resp.Data.Node) {
+		owner := resp.Data.Node
+		redirectReq.SupernodeIP = netutils.ExtractHost(owner)
+		resp, e := s.api.Register(owner, &redirectReq)
+		logrus.Infof("do register redirected to %s, res:%s error:%v", owner, resp, e)
+		if e == nil && resp != nil && resp.Code == constants.Success {
+			return owner, resp, e
+		}
+		logrus.Warnf("failed to register to the owner %s, fall back to %s", owner, node)
+	} else {
+		logrus.Warnf("empty owner of the redirected register, fall back to %s", node)
+	}
+
+	redirectReq.SupernodeIP = netutils.ExtractHost(node)
+	resp, e := s.api.Register(node, &redirectReq)
+	logrus.Infof("do register redirected back to %s, res:%s error:%v", node, resp, e)
+	return node, resp, e
+}
+
 func (s *supernodeRegister) checkResponse(resp *types.RegisterResponse, e error) *errortypes.DfError {
 	if e != nil {
 		return errortypes.New(constants.HTTPError, e.Error())
@@ -141,6 +178,8 @@ func (s *supernodeRegister) constructRegisterRequest(port int) *types.RegisterRe
 		Headers:    cfg.Header,
 		Dfdaemon:   cfg.DFDaemon,
 		Insecure:   cfg.Insecure,
+		Priority:   cfg.Priority,
+		Token:      cfg.Token,
 	}
 	if cfg.Md5 != "" {
 		req.Md5 = cfg.Md5
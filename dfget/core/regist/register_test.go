@@ -105,6 +105,18 @@ func (s *RegistTestSuite) TestSupernodeRegister_Register(c *check.C) {
 	cfg.URL = "http://github.com"
 	f(constants.CodeWaitAuth, "wait auth", nil)
 
+	cfg.Nodes = []string{"x", "y"}
+	cfg.URL = "http://redirect.com"
+	f(constants.Success, "", &RegisterResult{
+		Node: "owner", RemainderNodes: []string{"y"}, URL: cfg.URL, TaskID: "b",
+		FileLength: 100, PieceSize: 10})
+
+	cfg.Nodes = []string{"x", "y"}
+	cfg.URL = "http://redirect-fail.com"
+	f(constants.Success, "", &RegisterResult{
+		Node: "x", RemainderNodes: []string{"y"}, URL: cfg.URL, TaskID: "c",
+		FileLength: 100, PieceSize: 10})
+
 	cfg.Nodes = []string{"x"}
 	cfg.URL = "http://lowzj.com"
 	f(constants.Success, "", &RegisterResult{
@@ -39,6 +39,11 @@ type RegisterRequest struct {
 	Dfdaemon    bool     `json:"dfdaemon,omitempty"`
 	Insecure    bool     `json:"insecure,omitempty"`
 	RootCAs     [][]byte `json:"rootCAs,omitempty"`
+	Priority    string   `json:"priority,omitempty"`
+	Redirected  bool     `json:"redirected,omitempty"`
+
+	// Token is sent as the bearer token in the Authorization header instead of the body.
+	Token string `json:"-"`
 }
 
 func (r *RegisterRequest) String() string {
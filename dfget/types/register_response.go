@@ -38,4 +38,8 @@ type RegisterResponseData struct {
 	TaskID     string `json:"taskId"`
 	FileLength int64  `json:"fileLength"`
 	PieceSize  int32  `json:"pieceSize"`
+
+	// Node is the address of the supernode which owns the task,
+	// and it's only set when the registration is redirected.
+	Node string `json:"node,omitempty"`
 }
@@ -23,4 +23,8 @@ type ReportPieceRequest struct {
 	Cid        string `request:"cid"`
 	DstCid     string `request:"dstCid"`
 	PieceRange string `request:"pieceRange"`
+
+	// Cost is the time in milliseconds spent on downloading the piece from
+	// DstCid, the supernode uses it to estimate the upload throughput of DstCid.
+	Cost int64 `request:"cost"`
 }
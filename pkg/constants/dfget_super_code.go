@@ -37,6 +37,9 @@ func init() {
 	cmmap[CodeURLNotReachable] = "url is not reachable"
 	cmmap[CodeNeedAuth] = "need auth"
 	cmmap[CodeWaitAuth] = "wait auth"
+	cmmap[CodeTaskRedirect] = "task is owned by another supernode"
+	cmmap[CodeSupernodeDraining] = "supernode is draining"
+	cmmap[CodeNamespaceQuotaExceeded] = "namespace quota exceeded"
 }
 
 // GetMsgByCode gets the description of the code.
@@ -61,19 +64,22 @@ const (
 	CodeParamError     = 501
 	CodeTargetNotFound = 502
 
-	CodePeerFinish      = 600
-	CodePeerContinue    = 601
-	CodePeerWait        = 602
-	CodePeerLimited     = 603
-	CodeSuperFail       = 604
-	CodeUnknownError    = 605
-	CodeTaskConflict    = 606
-	CodeURLNotReachable = 607
-	CodeNeedAuth        = 608
-	CodeWaitAuth        = 609
-	CodeSourceError     = 610
-	CodeGetPieceReport  = 611
-	CodeGetPeerDown     = 612
+	CodePeerFinish             = 600
+	CodePeerContinue           = 601
+	CodePeerWait               = 602
+	CodePeerLimited            = 603
+	CodeSuperFail              = 604
+	CodeUnknownError           = 605
+	CodeTaskConflict           = 606
+	CodeURLNotReachable        = 607
+	CodeNeedAuth               = 608
+	CodeWaitAuth               = 609
+	CodeSourceError            = 610
+	CodeGetPieceReport         = 611
+	CodeGetPeerDown            = 612
+	CodeTaskRedirect           = 613
+	CodeSupernodeDraining      = 614
+	CodeNamespaceQuotaExceeded = 615
 )
 
 /* the code of task result that dfget will report to supernode */
@@ -97,3 +103,10 @@ const (
 	ClientErrorFileNotExist    = "FILE_NOT_EXIST"
 	ClientErrorFileMd5NotMatch = "FILE_MD5_NOT_MATCH"
 )
+
+/* the client error caused by the service of the peer when downloading from other peers */
+const (
+	ClientErrorConnectionRefused = "CONNECTION_REFUSED"
+	ClientErrorTimeout           = "TIMEOUT"
+	ClientErrorTruncatedBody     = "TRUNCATED_BODY"
+)
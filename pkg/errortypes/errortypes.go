@@ -61,6 +61,9 @@ const (
 	codeURLNotReachable
 	codeTaskIDDuplicate
 	codeAuthenticationRequired
+	codeDiskFull
+	codeSupernodeDraining
+	codeNamespaceQuotaExceeded
 )
 
 // DfError represents a Dragonfly error.
@@ -45,6 +45,15 @@ var (
 
 	// ErrAuthenticationRequired represents the authentication is required.
 	ErrAuthenticationRequired = DfError{codeAuthenticationRequired, "authentication required"}
+
+	// ErrDiskFull represents the disk of supernode is critically full.
+	ErrDiskFull = DfError{codeDiskFull, "disk full"}
+
+	// ErrSupernodeDraining represents the supernode is draining and refuses the new tasks.
+	ErrSupernodeDraining = DfError{codeSupernodeDraining, "supernode is draining"}
+
+	// ErrNamespaceQuotaExceeded represents the namespace has reached its limit of tasks.
+	ErrNamespaceQuotaExceeded = DfError{codeNamespaceQuotaExceeded, "namespace quota exceeded"}
 )
 
 // IsSystemError checks the error is a system error or not.
@@ -91,3 +100,18 @@ func IsTaskIDDuplicate(err error) bool {
 func IsAuthenticationRequired(err error) bool {
 	return checkError(err, codeAuthenticationRequired)
 }
+
+// IsDiskFull checks the error is a DiskFull error or not.
+func IsDiskFull(err error) bool {
+	return checkError(err, codeDiskFull)
+}
+
+// IsSupernodeDraining checks the error is a SupernodeDraining error or not.
+func IsSupernodeDraining(err error) bool {
+	return checkError(err, codeSupernodeDraining)
+}
+
+// IsNamespaceQuotaExceeded checks the error is a NamespaceQuotaExceeded error or not.
+func IsNamespaceQuotaExceeded(err error) bool {
+	return checkError(err, codeNamespaceQuotaExceeded)
+}
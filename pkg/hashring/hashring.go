@@ -0,0 +1,157 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package hashring implements a consistent hash ring, which maps the keys to the nodes
+// so that only the keys of the node are remapped when a node is added or removed.
+package hashring
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultReplicas is the default number of the virtual nodes of each node on the ring.
+const DefaultReplicas = 160
+
+// HashRing is a thread-safe consistent hash ring.
+type HashRing struct {
+	replicas int
+
+	mu     sync.RWMutex
+	hashes []uint64
+	owners map[uint64]string
+	nodes  map[string]bool
+}
+
+// New creates a HashRing with the nodes, and each node has the replicas virtual nodes.
+// The DefaultReplicas will be used if replicas <= 0.
+func New(replicas int, nodes ...string) *HashRing {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &HashRing{
+		replicas: replicas,
+		owners:   make(map[uint64]string),
+		nodes:    make(map[string]bool),
+	}
+	r.Add(nodes...)
+	return r
+}
+
+// Add adds the nodes to the ring. The nodes which already exist will be ignored.
+func (r *HashRing) Add(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.add(nodes...)
+}
+
+// add adds the nodes to the ring, and it should be called with the lock held.
+func (r *HashRing) add(nodes ...string) {
+	for _, node := range nodes {
+		if r.nodes[node] {
+			continue
+		}
+		r.nodes[node] = true
+		for i := 0; i < r.replicas; i++ {
+			h := hash(strconv.Itoa(i) + "#" + node)
+			// the hash collision is resolved by the smaller node in order to be deterministic.
+			if owner, ok := r.owners[h]; ok {
+				if node < owner {
+					r.owners[h] = node
+				}
+				continue
+			}
+			r.owners[h] = node
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+}
+
+// Remove removes the nodes from the ring. The nodes which don't exist will be ignored.
+func (r *HashRing) Remove(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := false
+	for _, node := range nodes {
+		if r.nodes[node] {
+			delete(r.nodes, node)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+
+	// rebuild the ring to restore the virtual nodes shadowed by the removed nodes.
+	remained := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		remained = append(remained, node)
+	}
+	r.hashes = nil
+	r.owners = make(map[uint64]string)
+	r.nodes = make(map[string]bool)
+	r.add(remained...)
+}
+
+// Get returns the node which owns the key, and "" will be returned if the ring is empty.
+func (r *HashRing) Get(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := hash(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+// Has returns whether the node is on the ring.
+func (r *HashRing) Has(node string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.nodes[node]
+}
+
+// Nodes returns the sorted nodes on the ring.
+func (r *HashRing) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// hash returns the first 8 bytes of the md5 of the key, which spreads
+// the similar keys such as the virtual nodes evenly on the ring.
+func hash(key string) uint64 {
+	sum := md5.Sum([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
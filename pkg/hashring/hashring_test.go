@@ -0,0 +1,104 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hashring
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-check/check"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type HashRingSuite struct{}
+
+func init() {
+	check.Suite(&HashRingSuite{})
+}
+
+func keys(n int) []string {
+	var result []string
+	for i := 0; i < n; i++ {
+		result = append(result, fmt.Sprintf("key-%d", i))
+	}
+	return result
+}
+
+func (suite *HashRingSuite) TestEmpty(c *check.C) {
+	r := New(0)
+	c.Check(r.Get("key"), check.Equals, "")
+	c.Check(r.Nodes(), check.HasLen, 0)
+}
+
+func (suite *HashRingSuite) TestGet(c *check.C) {
+	r := New(0, "a", "b", "c")
+	c.Check(r.Nodes(), check.DeepEquals, []string{"a", "b", "c"})
+	c.Check(r.Has("a"), check.Equals, true)
+	c.Check(r.Has("d"), check.Equals, false)
+
+	counts := make(map[string]int)
+	for _, key := range keys(3000) {
+		owner := r.Get(key)
+		c.Check(r.Get(key), check.Equals, owner)
+		counts[owner]++
+	}
+	c.Check(counts, check.HasLen, 3)
+	for node, count := range counts {
+		c.Check(count > 600, check.Equals, true, check.Commentf("node %s owns %d keys", node, count))
+	}
+
+	// the order of adding the nodes doesn't matter.
+	other := New(0, "c", "a", "b")
+	for _, key := range keys(100) {
+		c.Check(other.Get(key), check.Equals, r.Get(key))
+	}
+}
+
+func (suite *HashRingSuite) TestAddAndRemove(c *check.C) {
+	r := New(0, "a", "b", "c")
+	before := make(map[string]string)
+	for _, key := range keys(1000) {
+		before[key] = r.Get(key)
+	}
+
+	// only the keys moved to the new node are remapped.
+	r.Add("d")
+	for key, owner := range before {
+		if now := r.Get(key); now != owner {
+			c.Check(now, check.Equals, "d")
+		}
+	}
+
+	// the keys are mapped to the original nodes after the node is removed.
+	r.Remove("d", "notExist")
+	for key, owner := range before {
+		c.Check(r.Get(key), check.Equals, owner)
+	}
+
+	// only the keys of the removed node are remapped.
+	r.Remove("a")
+	for key, owner := range before {
+		if owner != "a" {
+			c.Check(r.Get(key), check.Equals, owner)
+		} else {
+			c.Check(r.Get(key), check.Not(check.Equals), "a")
+		}
+	}
+}
@@ -85,7 +85,9 @@ func (lr *LimitReader) Read(p []byte) (n int, err error) {
 		if lr.md5sum != nil {
 			lr.md5sum.Write(p[:n])
 		}
-		lr.Limiter.AcquireBlocking(int64(n))
+		if lr.Limiter != nil {
+			lr.Limiter.AcquireBlocking(int64(n))
+		}
 	}
 	return n, e
 }
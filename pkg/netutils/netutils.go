@@ -21,12 +21,14 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/pkg/rate"
@@ -300,3 +302,24 @@ func CalculateTimeout(fileLength int64, minRate, defaultMinRate rate.Rate, reser
 
 	return time.Duration(fileLength/int64(minRate))*time.Second + reservedTime
 }
+
+// IsTimeout returns whether the err is caused by a network timeout.
+func IsTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// IsConnectionRefused returns whether the err is caused by
+// the target refusing the connection.
+func IsConnectionRefused(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	return err == syscall.ECONNREFUSED
+}
@@ -18,7 +18,12 @@ package netutils
 
 import (
 	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
 	"runtime"
+	"syscall"
 	"testing"
 	"time"
 
@@ -268,3 +273,25 @@ func (suite *NetUtilSuite) TestCalculateTimeout(c *check.C) {
 		c.Assert(result, check.DeepEquals, ca.expectedResult)
 	}
 }
+
+func (suite *NetUtilSuite) TestIsTimeoutAndIsConnectionRefused(c *check.C) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+	var cases = []struct {
+		err     error
+		timeout bool
+		refused bool
+	}{
+		{err: refused, timeout: false, refused: true},
+		{err: &url.Error{Op: "Get", URL: "http://a", Err: refused}, timeout: false, refused: true},
+		{err: timeout, timeout: true, refused: false},
+		{err: &url.Error{Op: "Get", URL: "http://a", Err: timeout}, timeout: true, refused: false},
+		{err: io.ErrUnexpectedEOF, timeout: false, refused: false},
+		{err: nil, timeout: false, refused: false},
+	}
+
+	for _, v := range cases {
+		c.Check(IsTimeout(v.err), check.Equals, v.timeout)
+		c.Check(IsConnectionRefused(v.err), check.Equals, v.refused)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimiter
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// WeightedFairLimiter shares the rate of a RateLimiter between multiple flows
+// in proportion to their weights.
+//
+// It's an implementation of self-clocked fair queueing: each request is stamped with a
+// virtual finish time computed by the size and the weight of its flow, and the requests
+// are granted the tokens in the order of their finish time.
+// So that a flow with higher weight gets a larger share of the rate when there is contention,
+// and a single flow can use the whole rate when there is not.
+type WeightedFairLimiter struct {
+	limiter *RateLimiter
+
+	mu          sync.Mutex
+	virtualTime float64
+	lastFinish  map[string]float64
+	waiters     waiterHeap
+	seq         uint64
+	serving     bool
+}
+
+// NewWeightedFairLimiter creates a WeightedFairLimiter instance based on the limiter.
+func NewWeightedFairLimiter(limiter *RateLimiter) *WeightedFairLimiter {
+	return &WeightedFairLimiter{
+		limiter:    limiter,
+		lastFinish: make(map[string]float64),
+	}
+}
+
+// AcquireBlocking acquires tokens for the flow with the weight. It will be blocking
+// until the turn of the request comes and the limiter has enough required number of tokens.
+func (wfl *WeightedFairLimiter) AcquireBlocking(flow string, weight int, token int64) int64 {
+	if token < 1 {
+		return token
+	}
+	if weight < 1 {
+		weight = 1
+	}
+
+	wfl.mu.Lock()
+	start := wfl.virtualTime
+	if last, ok := wfl.lastFinish[flow]; ok && last > start {
+		start = last
+	}
+	w := &waiter{
+		token:  token,
+		finish: start + float64(token)/float64(weight),
+		seq:    wfl.seq,
+		ready:  make(chan struct{}),
+	}
+	wfl.seq++
+	wfl.lastFinish[flow] = w.finish
+	heap.Push(&wfl.waiters, w)
+	if !wfl.serving {
+		wfl.serving = true
+		go wfl.serve()
+	}
+	wfl.mu.Unlock()
+
+	<-w.ready
+	return token
+}
+
+// SetRate sets rate of the underlying RateLimiter.
+func (wfl *WeightedFairLimiter) SetRate(rate int64) {
+	wfl.limiter.SetRate(rate)
+}
+
+// serve grants the tokens to the waiters one by one until there is no waiter.
+func (wfl *WeightedFairLimiter) serve() {
+	for {
+		wfl.mu.Lock()
+		if wfl.waiters.Len() == 0 {
+			// all the flows are idle, so their history can be forgotten.
+			wfl.serving = false
+			wfl.lastFinish = make(map[string]float64)
+			wfl.mu.Unlock()
+			return
+		}
+		w := heap.Pop(&wfl.waiters).(*waiter)
+		wfl.virtualTime = w.finish
+		wfl.mu.Unlock()
+
+		wfl.limiter.AcquireBlocking(w.token)
+		close(w.ready)
+	}
+}
+
+type waiter struct {
+	token  int64
+	finish float64
+	seq    uint64
+	ready  chan struct{}
+}
+
+// waiterHeap is a min-heap of waiters ordered by the finish time.
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].finish == h[j].finish {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].finish < h[j].finish
+}
+
+func (h waiterHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *waiterHeap) Push(x interface{}) { *h = append(*h, x.(*waiter)) }
+
+func (h *waiterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
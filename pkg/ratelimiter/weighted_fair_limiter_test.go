@@ -0,0 +1,84 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimiter
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/go-check/check"
+)
+
+func (suite *RateLimiterSuite) TestWeightedFairLimiter_Order(c *check.C) {
+	wfl := NewWeightedFairLimiter(NewRateLimiter(0, 2))
+
+	// pretend to be serving so that the requests below are queued.
+	wfl.mu.Lock()
+	wfl.serving = true
+	wfl.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		for flow, weight := range map[string]int{"background": 1, "critical": 4} {
+			wg.Add(1)
+			go func(flow string, weight int) {
+				defer wg.Done()
+				wfl.AcquireBlocking(flow, weight, 100)
+			}(flow, weight)
+		}
+	}
+	waitFor(func() bool { return wfl.waiters.Len() == 4 }, &wfl.mu)
+
+	wfl.mu.Lock()
+	var finishes []float64
+	var waiters []*waiter
+	for wfl.waiters.Len() > 0 {
+		w := heap.Pop(&wfl.waiters).(*waiter)
+		finishes = append(finishes, w.finish)
+		waiters = append(waiters, w)
+	}
+	wfl.mu.Unlock()
+	c.Assert(finishes, check.DeepEquals, []float64{25, 50, 100, 200})
+
+	for _, w := range waiters {
+		close(w.ready)
+	}
+	wg.Wait()
+}
+
+func (suite *RateLimiterSuite) TestWeightedFairLimiter_AcquireBlocking(c *check.C) {
+	wfl := NewWeightedFairLimiter(NewRateLimiter(0, 2))
+	c.Assert(wfl.AcquireBlocking("a", 0, 10), check.Equals, int64(10))
+	c.Assert(wfl.AcquireBlocking("b", 2, 0), check.Equals, int64(0))
+
+	// the history of flows should be forgotten when all of them are idle.
+	waitFor(func() bool { return !wfl.serving }, &wfl.mu)
+	c.Assert(wfl.lastFinish, check.HasLen, 0)
+}
+
+func waitFor(cond func() bool, mu *sync.Mutex) {
+	for {
+		mu.Lock()
+		ok := cond()
+		mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
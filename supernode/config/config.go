@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/pkg/dflog"
@@ -41,6 +42,9 @@ type Config struct {
 	*BaseProperties `yaml:"base"`
 	Plugins         map[PluginType][]*PluginProperties `yaml:"plugins"`
 	Storages        map[string]interface{}             `yaml:"storages"`
+
+	// reloadLock guards the reloadable properties which are changed by Apply at runtime.
+	reloadLock sync.RWMutex
 }
 
 // Load loads config properties from the giving file.
@@ -92,13 +96,17 @@ func NewBaseProperties() *BaseProperties {
 	return &BaseProperties{
 		ListenPort:              DefaultListenPort,
 		DownloadPort:            DefaultDownloadPort,
+		EnableFileServer:        true,
 		HomeDir:                 home,
 		SchedulerCorePoolSize:   DefaultSchedulerCorePoolSize,
 		DownloadPath:            filepath.Join(home, "repo", "download"),
 		PeerUpLimit:             DefaultPeerUpLimit,
 		PeerDownLimit:           DefaultPeerDownLimit,
 		EliminationLimit:        DefaultEliminationLimit,
+		ReputationHalfLife:      DefaultReputationHalfLife,
+		BlacklistTTL:            DefaultBlacklistTTL,
 		FailureCountLimit:       DefaultFailureCountLimit,
+		EndgameThreshold:        DefaultEndgameThreshold,
 		LinkLimit:               DefaultLinkLimit,
 		SystemReservedBandwidth: DefaultSystemReservedBandwidth,
 		MaxBandwidth:            DefaultMaxBandwidth,
@@ -114,6 +122,15 @@ func NewBaseProperties() *BaseProperties {
 		TaskExpireTime:          DefaultTaskExpireTime,
 		PeerGCDelay:             DefaultPeerGCDelay,
 		CleanRatio:              DefaultCleanRatio,
+		GCEvictionPolicy:        DefaultGCEvictionPolicy,
+		CDNWaitSpaceTimeout:     DefaultCDNWaitSpaceTimeout,
+		CDNWorkerLimit:          DefaultCDNWorkerLimit,
+		CDNOriginLimit:          DefaultCDNOriginLimit,
+		ProgressMemoryLimit:     DefaultProgressMemoryLimit,
+		ClusterCheckInterval:    DefaultClusterCheckInterval,
+		ReplicaCount:            DefaultReplicaCount,
+		HotTaskThreshold:        DefaultHotTaskThreshold,
+		DrainTimeout:            DefaultDrainTimeout,
 	}
 }
 
@@ -127,6 +144,11 @@ type BaseProperties struct {
 	// default: 8001
 	DownloadPort int `yaml:"downloadPort"`
 
+	// EnableFileServer sets whether supernode serves the downloaded files on DownloadPort by itself.
+	// Disable it only if the files are served by an external file server such as nginx.
+	// default: true
+	EnableFileServer bool `yaml:"enableFileServer"`
+
 	// HomeDir is working directory of supernode.
 	// default: /home/admin/supernode
 	HomeDir string `yaml:"homeDir"`
@@ -156,9 +178,22 @@ type BaseProperties struct {
 	// to pull pieces. If it runs into an issue when providing services for a peer, its self failure
 	// increases by 1. When the failure limit reaches EliminationLimit, the peer will isolate itself
 	// as a unhealthy state. Then this dfget will be no longer called by other peers.
+	// The failures decay over time with ReputationHalfLife, so that the peer will be called
+	// again after it recovers.
 	// default: 5
 	EliminationLimit int `yaml:"eliminationLimit"`
 
+	// ReputationHalfLife is the duration after which the weight of the history of a peer is halved.
+	// The reputation of a peer combines its success rate, latency and errors as a server,
+	// and the peers with better reputation will be preferred by the scheduler.
+	// default: 5m
+	ReputationHalfLife time.Duration `yaml:"reputationHalfLife"`
+
+	// BlacklistTTL is the duration that a peer stays in the blacklist of another peer
+	// after it failed to provide a piece for that peer. And zero means that it never expires.
+	// default: 5m
+	BlacklistTTL time.Duration `yaml:"blacklistTTL"`
+
 	// FailureCountLimit is the failure count limit set in supernode for dfget client.
 	// When a dfget client takes part in the peer network constructed by supernode,
 	// supernode will command the peer to start distribution task.
@@ -168,6 +203,18 @@ type BaseProperties struct {
 	// default: 5
 	FailureCountLimit int `yaml:"failureCountLimit"`
 
+	// EndgameThreshold is the count of remaining pieces when a dfget client enters the endgame.
+	// In the endgame, supernode will schedule the pieces being downloaded to another peer at the same time,
+	// so that a slow peer will not stall the whole download. And zero means that the endgame is disabled.
+	// default: 4
+	EndgameThreshold int `yaml:"endgameThreshold"`
+
+	// SuperUploadLimit is the limit of pieces that can be downloaded from supernode concurrently for all tasks.
+	// The upload slots will be shared by the tasks in proportion to the weight of their priority,
+	// and zero means that there is no limit.
+	// default: 0
+	SuperUploadLimit int `yaml:"superUploadLimit"`
+
 	// LinkLimit is set for supernode to limit every piece download network speed.
 	// default: 20 MB, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
 	LinkLimit rate.Rate `yaml:"linkLimit"`
@@ -228,12 +275,17 @@ type BaseProperties struct {
 
 	// YoungGCThreshold if the available disk space is more than YoungGCThreshold
 	// and there is no need to GC disk.
+	// It's also the high watermark, and the disk GC will be triggered immediately
+	// when the CDN writes push the available disk space below it.
 	//
 	// default: 100GB
 	YoungGCThreshold fileutils.Fsize `yaml:"youngGCThreshold"`
 
 	// FullGCThreshold if the available disk space is less than FullGCThreshold
 	// and the supernode should gc all task files which are not being used.
+	// It's also the low watermark, and the disk is considered critically full below it,
+	// so that the new CDN downloads will wait for CDNWaitSpaceTimeout and be refused
+	// if the disk space is still not released.
 	//
 	// default: 5GB
 	FullGCThreshold fileutils.Fsize `yaml:"fullGCThreshold"`
@@ -248,5 +300,202 @@ type BaseProperties struct {
 	// default: 1
 	CleanRatio int
 
+	// GCEvictionPolicy is the policy to choose the task files to be deleted when gc disk.
+	// The available policies are:
+	//   default: evicts the files which are not accessed regularly first.
+	//   lru: evicts the least recently used files first.
+	//   lfu: evicts the least frequently used files first.
+	//   size: evicts the large and stale files first.
+	//   ttl: evicts the files which are not accessed within the ttl of GCTTLRules.
+	//
+	// default: default
+	GCEvictionPolicy string `yaml:"gcEvictionPolicy"`
+
+	// GCTTLRules are the rules used by the ttl eviction policy, and the ttl of a task file
+	// is decided by the first rule whose pattern matches its URL.
+	// The files matching no rule will only be evicted when full gc.
+	GCTTLRules []*TTLRule `yaml:"gcTTLRules,omitempty"`
+
+	// CDNWaitSpaceTimeout is the max duration that a new CDN download waits for the
+	// disk space to be released when the disk is critically full.
+	// The CDN download will be refused immediately if it's 0.
+	//
+	// default: 1m
+	CDNWaitSpaceTimeout time.Duration `yaml:"cdnWaitSpaceTimeout"`
+
+	// CDNWorkerLimit is the max number of the concurrent CDN downloads from the source.
+	// The CDN downloads exceeding it are queued in order of the priority and the number of
+	// the waiting clients of the tasks, and the clients of the queued tasks will wait.
+	// And there is no limit if it's 0.
+	//
+	// default: 20
+	CDNWorkerLimit int `yaml:"cdnWorkerLimit"`
+
+	// CDNOriginLimit is the max number of the concurrent CDN downloads from the same origin host,
+	// which protects the origins from being overwhelmed. And there is no limit if it's 0.
+	//
+	// default: 0
+	CDNOriginLimit int `yaml:"cdnOriginLimit"`
+
+	// CDNOriginLimits overrides the CDNOriginLimit for the specified origin hosts,
+	// such as {"fragile.example.com": 2}.
+	CDNOriginLimits map[string]int `yaml:"cdnOriginLimits,omitempty"`
+
+	// CDNSiblings are the addresses of the sibling supernodes in the format of ip:port, and the port
+	// is the ListenPort of the sibling. When a task misses the local cache, the supernode queries the
+	// siblings for a completed cache of the task or the file with the same md5, and fetches the cached
+	// file from the first sibling which has it instead of downloading from the source. And it falls back
+	// to the source if none of them has the cache or the fetching fails.
+	CDNSiblings []string `yaml:"cdnSiblings,omitempty"`
+
+	// ReplicaCount is the number of the CDNSiblings which the hot tasks are replicated to.
+	// The replicas are advertised as extra super-peers which hold all the pieces of the task,
+	// so that the clients can download from them when the upload slots of supernode are busy.
+	// The siblings should list this supernode in their CDNSiblings too, so that they fetch
+	// the replicas from it instead of the source. And the replication is disabled if it's 0.
+	//
+	// default: 0
+	ReplicaCount int `yaml:"replicaCount"`
+
+	// HotTaskThreshold is the number of the demands of a task within a minute which makes
+	// the task hot, and a demand is either a registration of the task or a client waiting
+	// for the upload slots.
+	//
+	// default: 50
+	HotTaskThreshold int `yaml:"hotTaskThreshold"`
+
+	// ProgressMemoryLimit is the ceiling of the estimated memory used by the progress states
+	// of the tasks and clients. When it's exceeded, the progress states of the coldest
+	// completed tasks will be evicted by gc. And there is no limit if it's 0.
+	//
+	// default: 0
+	ProgressMemoryLimit fileutils.Fsize `yaml:"progressMemoryLimit"`
+
+	// ClusterMembers are the addresses of the supernodes which form a cluster, in the format of ip:port
+	// and the port is the ListenPort of the supernode. Each task is owned by one of the members
+	// through consistent hashing, and the registrations of the task to the other members are
+	// redirected to the owner. The supernode itself is always a member identified by AdvertiseIP
+	// and ListenPort. And the cluster is disabled if it's empty.
+	ClusterMembers []string `yaml:"clusterMembers,omitempty"`
+
+	// ClusterCheckInterval is the interval time to check the health of the cluster members.
+	// The member which fails to respond several times in a row is removed from the hash ring,
+	// and its tasks are owned by the others until it recovers.
+	//
+	// default: 10s
+	ClusterCheckInterval time.Duration `yaml:"clusterCheckInterval"`
+
+	// DrainTimeout is the max time to wait for the active downloads to finish when draining.
+	// The supernode rejects the new task registrations during draining, and exits once all the
+	// active downloads finish or the timeout passes.
+	//
+	// default: 10m
+	DrainTimeout time.Duration `yaml:"drainTimeout"`
+
+	// HealthOriginProbes are the URLs of the origins which are probed by the health check,
+	// and the supernode is degraded if any of them is unreachable.
+	// The origins are not probed if it's empty.
+	HealthOriginProbes []string `yaml:"healthOriginProbes,omitempty"`
+
+	// Webhooks are the HTTP endpoints which the events of the task lifecycle are posted to,
+	// such as the completion of the CDN download and all the dfget clients of a task.
+	Webhooks []*WebhookConfig `yaml:"webhooks,omitempty"`
+
+	// Namespaces isolate the tasks of the calling systems from each other, and each of them
+	// has its own cache quota, bandwidth share and task limit. The tasks which don't belong to
+	// any of them are in the namespace named default, which is unlimited unless it's configured.
+	Namespaces []*NamespaceConfig `yaml:"namespaces,omitempty"`
+
+	// OriginAllow restricts the origins which the supernode downloads the files from,
+	// and the URL of a task is rejected unless it matches all the non-empty lists of it.
+	// All the origins are allowed if it's nil.
+	OriginAllow *OriginRule `yaml:"originAllow,omitempty"`
+
+	// OriginDeny lists the origins which the supernode never downloads the files from,
+	// and the URL of a task is rejected if it matches any of the non-empty lists of it,
+	// such as the loopback addresses and the cloud metadata endpoints.
+	OriginDeny *OriginRule `yaml:"originDeny,omitempty"`
+
 	LogConfig dflog.LogConfig `yaml:"logConfig" json:"logConfig"`
 }
+
+// TTLRule specifies the ttl of the task files whose URL matches the pattern.
+type TTLRule struct {
+	// Pattern is a regular expression to match the URL of the task file.
+	Pattern string `yaml:"pattern"`
+
+	// TTL is the duration after the last access when the task file expires.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// WebhookConfig specifies an HTTP endpoint which the events are posted to.
+type WebhookConfig struct {
+	// URL is the address which the events are posted to in JSON.
+	URL string `yaml:"url"`
+
+	// Events are the types of the events posted to the URL, such as CDN_SUCCESS.
+	// All the events are posted if it's empty.
+	Events []string `yaml:"events,omitempty"`
+
+	// Secret is the key to sign the body of the request with HMAC-SHA256,
+	// and the signature is set in the header X-Dragonfly-Signature.
+	// The request is not signed if it's empty.
+	Secret string `yaml:"secret,omitempty"`
+
+	// Timeout is the timeout of each request.
+	//
+	// default: 5s
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// MaxRetries is the max times of retrying a failed request with exponential backoff.
+	//
+	// default: 3
+	MaxRetries int `yaml:"maxRetries,omitempty"`
+}
+
+// NamespaceConfig specifies a namespace of the tasks and its quotas.
+type NamespaceConfig struct {
+	// Name identifies the namespace, and it's also the label of the namespace metrics.
+	Name string `yaml:"name"`
+
+	// CallSystems are the callSystems of dfget whose tasks belong to the namespace.
+	CallSystems []string `yaml:"callSystems,omitempty"`
+
+	// Tokens are the auth tokens of the namespace. The registration carrying one of them in the
+	// header "Authorization: Bearer <token>" belongs to the namespace whatever its callSystem is.
+	Tokens []string `yaml:"tokens,omitempty"`
+
+	// CacheQuota is the max size of the files of the namespace cached by CDN. When it's exceeded,
+	// the unused files of the namespace are deleted by the disk gc even if there is enough disk space.
+	// There is no limit if it's 0.
+	CacheQuota fileutils.Fsize `yaml:"cacheQuota,omitempty"`
+
+	// BandwidthShare is the max percentage of the bandwidth of the file server, which is
+	// MaxBandwidth-SystemReservedBandwidth, used to upload the files of the namespace.
+	// There is no limit if it's 0.
+	BandwidthShare int `yaml:"bandwidthShare,omitempty"`
+
+	// MaxTasks is the max number of the tasks of the namespace at the same time,
+	// and the registrations of the new tasks beyond it are rejected.
+	// There is no limit if it's 0.
+	MaxTasks int `yaml:"maxTasks,omitempty"`
+}
+
+// OriginRule specifies the origins by the scheme, host, IP and port of their URLs.
+type OriginRule struct {
+	// Schemes are the schemes of the URLs, such as http and https.
+	Schemes []string `yaml:"schemes,omitempty"`
+
+	// Hosts are the patterns of the host names of the URLs, such as *.example.com,
+	// and * matches any sequence of the characters except the dot.
+	Hosts []string `yaml:"hosts,omitempty"`
+
+	// CIDRs are the networks of the IPs of the URLs, such as 169.254.0.0/16.
+	// They are checked against all the IPs the host name is resolved to,
+	// and against the address the supernode actually connects to unless it's the proxy of the request.
+	CIDRs []string `yaml:"cidrs,omitempty"`
+
+	// Ports are the ports of the URLs, and the default port of the scheme is used
+	// if the URL has no port.
+	Ports []int `yaml:"ports,omitempty"`
+}
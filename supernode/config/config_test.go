@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/go-check/check"
@@ -84,3 +85,97 @@ func (s *SupernodeConfigTestSuite) TestConfig_Load(c *check.C) {
 	p := &PluginProperties{Name: "local", Enabled: true, Config: "baseDir: /tmp/supernode/repo\n"}
 	c.Assert(conf.Plugins[StoragePlugin][0], check.DeepEquals, p)
 }
+
+func (s *SupernodeConfigTestSuite) TestConfig_DiffAndApply(c *check.C) {
+	conf := NewConfig()
+	conf.AdvertiseIP = "127.0.0.1"
+
+	n := NewConfig()
+	n.PeerUpLimit = conf.PeerUpLimit + 1
+	n.MaxBandwidth = conf.MaxBandwidth * 2
+	n.ListenPort = conf.ListenPort + 1
+	n.Plugins = map[PluginType][]*PluginProperties{StoragePlugin: {{Name: "local"}}}
+
+	reloadable, restartRequired := conf.Diff(n)
+	c.Assert(reloadable, check.DeepEquals, []string{"peerUpLimit", "maxBandwidth"})
+	c.Assert(restartRequired, check.DeepEquals, []string{"listenPort", "plugins"})
+
+	conf.Apply(n, reloadable)
+	c.Assert(conf.PeerUpLimit, check.Equals, n.PeerUpLimit)
+	c.Assert(conf.MaxBandwidth, check.Equals, n.MaxBandwidth)
+	c.Assert(conf.ListenPort, check.Not(check.Equals), n.ListenPort)
+	c.Assert(conf.AdvertiseIP, check.Equals, "127.0.0.1")
+
+	reloadable, restartRequired = conf.Diff(n)
+	c.Assert(reloadable, check.IsNil)
+	c.Assert(restartRequired, check.DeepEquals, []string{"listenPort", "plugins"})
+}
+
+func (s *SupernodeConfigTestSuite) TestConfig_ApplyConcurrently(c *check.C) {
+	conf := NewConfig()
+	n := NewConfig()
+	n.PeerDownLimit = conf.PeerDownLimit + 1
+	n.YoungGCThreshold = conf.YoungGCThreshold * 2
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			conf.Apply(n, []string{"peerDownLimit", "youngGCThreshold"})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		conf.GetPeerDownLimit()
+		conf.GetGCThresholds()
+	}
+	wg.Wait()
+
+	young, _ := conf.GetGCThresholds()
+	c.Assert(conf.GetPeerDownLimit(), check.Equals, n.PeerDownLimit)
+	c.Assert(young, check.Equals, n.YoungGCThreshold)
+}
+
+func (s *SupernodeConfigTestSuite) TestConfig_Namespaces(c *check.C) {
+	cfg := NewConfig()
+	cfg.Namespaces = []*NamespaceConfig{
+		{Name: "images", CallSystems: []string{"dfdaemon", "registry"}},
+		{Name: "datasets", CallSystems: []string{"spark"}, Tokens: []string{"secret"}, MaxTasks: 10},
+	}
+	c.Assert(cfg.CheckNamespaces(), check.IsNil)
+
+	var cases = []struct {
+		callSystem string
+		token      string
+		expected   string
+		isErr      bool
+	}{
+		{callSystem: "registry", expected: "images"},
+		{callSystem: "spark", expected: "datasets"},
+		{callSystem: "registry", token: "secret", expected: "datasets"},
+		{callSystem: "", expected: DefaultNamespace},
+		{callSystem: "unknown", expected: DefaultNamespace},
+		{callSystem: "registry", token: "unknown", isErr: true},
+	}
+	for _, tc := range cases {
+		name, err := cfg.GetNamespace(tc.callSystem, tc.token)
+		c.Assert(err != nil, check.Equals, tc.isErr, check.Commentf("%+v", tc))
+		c.Assert(name, check.Equals, tc.expected)
+	}
+
+	c.Assert(cfg.GetNamespaceConfig("datasets").MaxTasks, check.Equals, 10)
+	c.Assert(cfg.GetNamespaceConfig(DefaultNamespace), check.IsNil)
+
+	for _, namespaces := range [][]*NamespaceConfig{
+		{{Name: ""}},
+		{{Name: "a"}, {Name: "a"}},
+		{{Name: "a", BandwidthShare: 101}},
+		{{Name: "a", MaxTasks: -1}},
+		{{Name: "a", CallSystems: []string{"x"}}, {Name: "b", CallSystems: []string{"x"}}},
+		{{Name: "a", Tokens: []string{"t"}}, {Name: "b", Tokens: []string{"t"}}},
+		{{Name: "a", Tokens: []string{""}}},
+	} {
+		cfg.Namespaces = namespaces
+		c.Assert(cfg.CheckNamespaces(), check.NotNil)
+	}
+}
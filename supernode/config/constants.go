@@ -61,6 +61,10 @@ const (
 
 	// DefaultPeerDownLimit indicates the default limit of the download task count as a client.
 	DefaultPeerDownLimit = 4
+
+	// DefaultEndgameThreshold indicates the default count of remaining pieces
+	// when a client enters the endgame.
+	DefaultEndgameThreshold = 4
 )
 
 const (
@@ -109,6 +113,12 @@ const (
 
 	// DefaultPeerGCDelay is the delay time to execute the GC after the peer has reported the offline.
 	DefaultPeerGCDelay = 3 * time.Minute
+
+	// DefaultReputationHalfLife is the duration after which the weight of the history of a peer is halved.
+	DefaultReputationHalfLife = 5 * time.Minute
+
+	// DefaultBlacklistTTL is the duration that a peer stays in the blacklist of another peer.
+	DefaultBlacklistTTL = 5 * time.Minute
 )
 
 // Default config value for gc disk
@@ -122,6 +132,41 @@ const (
 	DefaultGCDiskInterval = 15 * time.Second
 
 	DefaultCleanRatio = 1
+
+	DefaultGCEvictionPolicy = "default"
+
+	DefaultCDNWaitSpaceTimeout = time.Minute
+
+	// DefaultCDNWorkerLimit is the default max number of the concurrent CDN downloads.
+	DefaultCDNWorkerLimit = 20
+
+	// DefaultCDNOriginLimit means that there is no limit of the concurrent CDN downloads per origin.
+	DefaultCDNOriginLimit = 0
+
+	// DefaultProgressMemoryLimit means that there is no limit of the memory used by the progress states.
+	DefaultProgressMemoryLimit = 0 * fileutils.B
+
+	// DefaultClusterCheckInterval is the default interval time to check the health of the cluster members.
+	DefaultClusterCheckInterval = 10 * time.Second
+
+	// DefaultReplicaCount means that the hot tasks are not replicated to the sibling supernodes.
+	DefaultReplicaCount = 0
+
+	// DefaultHotTaskThreshold is the default number of the demands of a task within a minute
+	// which makes the task hot.
+	DefaultHotTaskThreshold = 50
+
+	// DefaultDrainTimeout is the default max time to wait for the active downloads to finish when draining.
+	DefaultDrainTimeout = 10 * time.Minute
+
+	// DefaultWebhookTimeout is the default timeout of each request to the webhook.
+	DefaultWebhookTimeout = 5 * time.Second
+
+	// DefaultWebhookMaxRetries is the default max times of retrying a failed request to the webhook.
+	DefaultWebhookMaxRetries = 3
+
+	// DefaultNamespace is the namespace of the tasks which don't belong to any configured namespace.
+	DefaultNamespace = "default"
 )
 
 const (
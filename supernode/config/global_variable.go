@@ -20,4 +20,8 @@ var (
 	// DownloadHome is the parent directory where the downloaded files are stored
 	// which is a relative path.
 	DownloadHome = "download"
+
+	// HealthHome is the directory where the probe file is written to check
+	// whether the storage is writable, which is a relative path.
+	HealthHome = "health"
 )
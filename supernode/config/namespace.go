@@ -0,0 +1,102 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+
+	"github.com/pkg/errors"
+)
+
+// GetNamespace returns the name of the namespace which a registration belongs to.
+// The namespace is identified by the auth token first and then by the callSystem,
+// and it's DefaultNamespace if neither of them matches.
+// An error will be returned if the token doesn't belong to any namespace.
+func (c *Config) GetNamespace(callSystem, token string) (string, error) {
+	if !stringutils.IsEmptyStr(token) {
+		for _, ns := range c.Namespaces {
+			for _, t := range ns.Tokens {
+				if t == token {
+					return ns.Name, nil
+				}
+			}
+		}
+		return "", errors.Wrap(errortypes.ErrInvalidValue, "unknown namespace token")
+	}
+
+	for _, ns := range c.Namespaces {
+		for _, cs := range ns.CallSystems {
+			if cs == callSystem {
+				return ns.Name, nil
+			}
+		}
+	}
+	return DefaultNamespace, nil
+}
+
+// GetNamespaceConfig returns the config of the namespace with the name,
+// and nil will be returned if it's not configured.
+func (c *Config) GetNamespaceConfig(name string) *NamespaceConfig {
+	for _, ns := range c.Namespaces {
+		if ns.Name == name {
+			return ns
+		}
+	}
+	return nil
+}
+
+// CheckNamespaces checks that the namespaces are valid, and that a callSystem
+// or a token doesn't belong to more than one namespace.
+func (c *Config) CheckNamespaces() error {
+	names := make(map[string]bool)
+	callSystems := make(map[string]string)
+	tokens := make(map[string]string)
+	for _, ns := range c.Namespaces {
+		if stringutils.IsEmptyStr(ns.Name) {
+			return errors.Wrap(errortypes.ErrEmptyValue, "the name of namespace")
+		}
+		if names[ns.Name] {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "duplicate namespace %s", ns.Name)
+		}
+		names[ns.Name] = true
+
+		if ns.CacheQuota < 0 || ns.MaxTasks < 0 {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "the quotas of namespace %s are negative", ns.Name)
+		}
+		if ns.BandwidthShare < 0 || ns.BandwidthShare > 100 {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "the bandwidthShare of namespace %s is not in [0, 100]: %d", ns.Name, ns.BandwidthShare)
+		}
+
+		for _, cs := range ns.CallSystems {
+			if other, ok := callSystems[cs]; ok {
+				return errors.Wrapf(errortypes.ErrInvalidValue, "callSystem %s belongs to both namespace %s and %s", cs, other, ns.Name)
+			}
+			callSystems[cs] = ns.Name
+		}
+		for _, t := range ns.Tokens {
+			if stringutils.IsEmptyStr(t) {
+				return errors.Wrapf(errortypes.ErrEmptyValue, "the token of namespace %s", ns.Name)
+			}
+			if other, ok := tokens[t]; ok {
+				return errors.Wrapf(errortypes.ErrInvalidValue, "a token belongs to both namespace %s and %s", other, ns.Name)
+			}
+			tokens[t] = ns.Name
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
+	"github.com/dragonflyoss/Dragonfly/pkg/rate"
+)
+
+// reloadableProperties are the base properties which are read on each use or
+// applied by the supernode at runtime, so they can be changed without a restart.
+var reloadableProperties = map[string]bool{
+	"peerUpLimit":             true,
+	"peerDownLimit":           true,
+	"eliminationLimit":        true,
+	"failureCountLimit":       true,
+	"systemReservedBandwidth": true,
+	"maxBandwidth":            true,
+	"debug":                   true,
+	"failAccessInterval":      true,
+	"youngGCThreshold":        true,
+	"fullGCThreshold":         true,
+	"drainTimeout":            true,
+	"healthOriginProbes":      true,
+}
+
+// Diff compares c with the newly loaded config and returns the names of the changed
+// properties, which are split into the ones can be reloaded and the ones need a restart.
+// The names of the base properties are the keys in the yaml file,
+// and the changes of plugins and storages always need a restart.
+func (c *Config) Diff(n *Config) (reloadable, restartRequired []string) {
+	oldValue := reflect.ValueOf(c.BaseProperties).Elem()
+	newValue := reflect.ValueOf(n.BaseProperties).Elem()
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := propertyName(field)
+		// the advertiseIP is detected at startup if it's not specified.
+		if name == "advertiseIP" && n.AdvertiseIP == "" {
+			continue
+		}
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+		if reloadableProperties[name] {
+			reloadable = append(reloadable, name)
+		} else {
+			restartRequired = append(restartRequired, name)
+		}
+	}
+
+	if !reflect.DeepEqual(c.Plugins, n.Plugins) {
+		restartRequired = append(restartRequired, "plugins")
+	}
+	if !reflect.DeepEqual(c.Storages, n.Storages) {
+		restartRequired = append(restartRequired, "storages")
+	}
+	return reloadable, restartRequired
+}
+
+// Apply copies the values of the given base properties from the newly loaded config into c.
+// The names which are not the base properties will be ignored.
+func (c *Config) Apply(n *Config, names []string) {
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	oldValue := reflect.ValueOf(c.BaseProperties).Elem()
+	newValue := reflect.ValueOf(n.BaseProperties).Elem()
+	for _, name := range names {
+		for i := 0; i < oldValue.NumField(); i++ {
+			field := oldValue.Type().Field(i)
+			if field.PkgPath == "" && propertyName(field) == name {
+				oldValue.Field(i).Set(newValue.Field(i))
+				break
+			}
+		}
+	}
+}
+
+// The reloadable properties may be changed by Apply while the supernode is running,
+// so they should be read by the following methods instead of the fields at runtime.
+
+// GetPeerUpLimit returns the PeerUpLimit.
+func (c *Config) GetPeerUpLimit() int {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.PeerUpLimit
+}
+
+// GetPeerDownLimit returns the PeerDownLimit.
+func (c *Config) GetPeerDownLimit() int {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.PeerDownLimit
+}
+
+// GetEliminationLimit returns the EliminationLimit.
+func (c *Config) GetEliminationLimit() int {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.EliminationLimit
+}
+
+// GetFailureCountLimit returns the FailureCountLimit.
+func (c *Config) GetFailureCountLimit() int {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.FailureCountLimit
+}
+
+// GetUploadRate returns MaxBandwidth-SystemReservedBandwidth,
+// which is the max rate of the supernode uploading the files.
+func (c *Config) GetUploadRate() rate.Rate {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.MaxBandwidth - c.SystemReservedBandwidth
+}
+
+// GetDebug returns whether the debug mode is on.
+func (c *Config) GetDebug() bool {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.Debug
+}
+
+// GetFailAccessInterval returns the FailAccessInterval.
+func (c *Config) GetFailAccessInterval() time.Duration {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.FailAccessInterval
+}
+
+// GetGCThresholds returns the YoungGCThreshold and FullGCThreshold.
+func (c *Config) GetGCThresholds() (young, full fileutils.Fsize) {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.YoungGCThreshold, c.FullGCThreshold
+}
+
+// GetDrainTimeout returns the DrainTimeout.
+func (c *Config) GetDrainTimeout() time.Duration {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.DrainTimeout
+}
+
+// GetHealthOriginProbes returns the HealthOriginProbes.
+func (c *Config) GetHealthOriginProbes() []string {
+	c.reloadLock.RLock()
+	defer c.reloadLock.RUnlock()
+	return c.HealthOriginProbes
+}
+
+// propertyName returns the key of the field in the yaml file.
+func propertyName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -19,6 +19,9 @@ package daemon
 import (
 	"context"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
@@ -30,6 +33,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// shutdownTimeout is the max time to wait for the server to stop gracefully.
+const shutdownTimeout = 30 * time.Second
+
 // Daemon is a struct to identify main instance of supernode.
 type Daemon struct {
 	Name string
@@ -54,8 +60,9 @@ func New(cfg *config.Config, dfgetLogger *logrus.Logger) (*Daemon, error) {
 	}
 
 	return &Daemon{
-		config: cfg,
-		server: s,
+		config:        cfg,
+		ClusterMember: cfg.ClusterMembers,
+		server:        s,
 	}, nil
 }
 
@@ -79,11 +86,54 @@ func (d *Daemon) RegisterSuperNode() error {
 	return nil
 }
 
-// Run runs the daemon.
+// SetConfigLoader sets the loader used to reload the configuration
+// when a SIGHUP is captured or the reload API is called.
+func (d *Daemon) SetConfigLoader(loader server.ConfigLoader) {
+	d.server.SetConfigLoader(loader)
+}
+
+// Run runs the daemon until a stop signal is captured or the drain completes,
+// and then stops the server gracefully.
+// The configuration will be reloaded when a SIGHUP is captured,
+// and the server will start to drain when a SIGUSR1 is captured.
 func (d *Daemon) Run() error {
-	if err := d.server.Start(); err != nil {
-		logrus.Errorf("failed to start HTTP server: %v", err)
-		return err
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.server.Start()
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+	drain := make(chan os.Signal, 1)
+	signal.Notify(drain, syscall.SIGUSR1)
+	defer signal.Stop(drain)
+loop:
+	for {
+		select {
+		case err := <-errCh:
+			logrus.Errorf("failed to start HTTP server: %v", err)
+			return err
+		case <-reload:
+			logrus.Info("capture reload signal: hangup, will reload config...")
+			if _, err := d.server.ReloadConfig(context.Background()); err != nil {
+				logrus.Errorf("failed to reload config: %v", err)
+			}
+		case <-drain:
+			logrus.Info("capture drain signal: user defined signal 1, will drain...")
+			d.server.Drain(context.Background(), 0)
+		case <-d.server.Drained():
+			logrus.Info("drain completes, will shutdown...")
+			break loop
+		case s := <-quit:
+			logrus.Infof("capture stop signal: %s, will shutdown...", s)
+			break loop
+		}
 	}
-	return nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return d.server.Stop(ctx)
 }
@@ -23,12 +23,12 @@ import (
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/cdn/eviction"
 	"github.com/dragonflyoss/Dragonfly/supernode/store"
 
-	"github.com/emirpasic/gods/maps/treemap"
-	godsutils "github.com/emirpasic/gods/utils"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -36,29 +36,126 @@ import (
 // GetGCTaskIDs returns the taskIDs that should exec GC operations as a string slice.
 //
 // It should return nil when the free disk of cdn storage is lager than config.YoungGCThreshold.
-// It should return all taskIDs that are not running when the free disk of cdn storage is less than config.FullGCThreshold.
-func (cm *Manager) GetGCTaskIDs(ctx context.Context, taskMgr mgr.TaskMgr) ([]string, error) {
-	var gcTaskIDs []string
-
+// It should return all taskIDs that are not running when the free disk of cdn storage is less than config.FullGCThreshold,
+// and the fullGC will be true.
+// The taskIDs are sorted by the eviction policy specified by config.GCEvictionPolicy,
+// and the ones of the namespaces which exceed their cacheQuota come first.
+func (cm *Manager) GetGCTaskIDs(ctx context.Context, taskMgr mgr.TaskMgr) ([]string, bool, error) {
 	freeDisk, err := cm.cacheStore.GetAvailSpace(ctx, getHomeRawFunc())
 	if err != nil {
 		if store.IsKeyNotFound(err) {
-			return nil, nil
+			return nil, false, nil
 		}
-		return nil, errors.Wrapf(err, "failed to get avail space")
+		return nil, false, errors.Wrapf(err, "failed to get avail space")
 	}
-	if freeDisk > cm.cfg.YoungGCThreshold {
-		return nil, nil
+	youngGCThreshold, fullGCThreshold := cm.cfg.GetGCThresholds()
+	if freeDisk > youngGCThreshold {
+		return nil, false, nil
 	}
 
 	fullGC := false
-	if freeDisk <= cm.cfg.FullGCThreshold {
+	if freeDisk <= fullGCThreshold {
 		fullGC = true
 	}
-	logrus.Debugf("start to exec gc with fullGC: %t", fullGC)
+	logrus.Debugf("start to exec gc with fullGC: %t, policy: %s", fullGC, cm.evictionPolicy.Name())
+
+	usage, err := cm.walkCache(ctx, taskMgr, fullGC)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// the task files of the namespaces which exceed their cacheQuota are deleted
+	// before the others, so that they can't evict the files of the other namespaces.
+	var overQuotaTaskIDs, otherTaskIDs []string
+	namespaces := make(map[string]string, len(usage.candidates))
+	for _, candidate := range usage.candidates {
+		namespaces[candidate.TaskID] = candidate.Namespace
+	}
+	for _, taskID := range cm.evictionPolicy.Evict(usage.candidates, millisToTime(getCurrentTimeMillisFunc()), fullGC) {
+		if quota := cm.getCacheQuota(namespaces[taskID]); quota > 0 && usage.namespaceBytes[namespaces[taskID]] > quota {
+			overQuotaTaskIDs = append(overQuotaTaskIDs, taskID)
+			continue
+		}
+		otherTaskIDs = append(otherTaskIDs, taskID)
+	}
+
+	gcTaskIDs := append(usage.noMetaTaskIDs, append(overQuotaTaskIDs, otherTaskIDs...)...)
+	return gcTaskIDs, fullGC, nil
+}
+
+// GetQuotaGCTaskIDs returns the taskIDs that should be deleted to keep the cached files
+// of each namespace within its cacheQuota, and they are grouped by the namespace.
+//
+// It should return nil when no namespace has a cacheQuota.
+// The taskIDs of each namespace are sorted by the eviction policy specified by config.GCEvictionPolicy.
+func (cm *Manager) GetQuotaGCTaskIDs(ctx context.Context, taskMgr mgr.TaskMgr) (map[string][]string, error) {
+	hasQuota := false
+	for _, ns := range cm.cfg.Namespaces {
+		if ns.CacheQuota > 0 {
+			hasQuota = true
+			break
+		}
+	}
+	if !hasQuota {
+		return nil, nil
+	}
+
+	usage, err := cm.walkCache(ctx, taskMgr, false)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string][]*eviction.Candidate)
+	for _, candidate := range usage.candidates {
+		candidates[candidate.Namespace] = append(candidates[candidate.Namespace], candidate)
+	}
+
+	result := make(map[string][]string)
+	now := millisToTime(getCurrentTimeMillisFunc())
+	for namespace, used := range usage.namespaceBytes {
+		quota := cm.getCacheQuota(namespace)
+		if quota <= 0 || used <= quota {
+			continue
+		}
+
+		sizes := make(map[string]int64, len(candidates[namespace]))
+		for _, candidate := range candidates[namespace] {
+			sizes[candidate.TaskID] = candidate.Size
+		}
+		// all the candidates of the namespace are sorted with fullGC,
+		// and the ones at the beginning are deleted until the quota is satisfied.
+		for _, taskID := range cm.evictionPolicy.Evict(candidates[namespace], now, true) {
+			if used <= quota {
+				break
+			}
+			result[namespace] = append(result[namespace], taskID)
+			used -= sizes[taskID]
+		}
+		logrus.Infof("the cached files of namespace %s exceed the cacheQuota(%d), and %d of them will be deleted",
+			namespace, quota, len(result[namespace]))
+	}
+	return result, nil
+}
 
-	gapTasks := treemap.NewWith(godsutils.Int64Comparator)
-	intervalTasks := treemap.NewWith(godsutils.Int64Comparator)
+// cacheUsage is the result of walking the task files cached by CDN.
+type cacheUsage struct {
+	// candidates are the task files which are not being used.
+	candidates []*eviction.Candidate
+
+	// noMetaTaskIDs are the taskIDs whose metadata can't be read,
+	// and they will be deleted before the others when fullGC.
+	noMetaTaskIDs []string
+
+	// namespaceBytes is the size of the task files of each namespace,
+	// including the ones which are being used.
+	namespaceBytes map[string]int64
+}
+
+// walkCache walks the task files cached by CDN and returns the usage of them.
+func (cm *Manager) walkCache(ctx context.Context, taskMgr mgr.TaskMgr, fullGC bool) (*cacheUsage, error) {
+	usage := &cacheUsage{
+		namespaceBytes: make(map[string]int64),
+	}
 
 	// walkTaskIDs is used to avoid processing multiple times for the same taskID
 	// which is extracted from file name.
@@ -82,29 +179,27 @@ func (cm *Manager) GetGCTaskIDs(ctx context.Context, taskMgr mgr.TaskMgr) ([]str
 		walkTaskIDs[taskID] = true
 
 		// we should return directly when we success to get info which means it is being used
-		if _, err := taskMgr.Get(ctx, taskID); err == nil || !errortypes.IsDataNotFound(err) {
+		if task, err := taskMgr.Get(ctx, taskID); err == nil || !errortypes.IsDataNotFound(err) {
 			if err != nil {
 				logrus.Errorf("failed to get taskID(%s): %v", taskID, err)
+				return nil
 			}
+			size, _ := cm.GetFileSize(ctx, taskID)
+			usage.namespaceBytes[getNamespace(task.Namespace)] += size
 			return nil
 		}
 
-		// add taskID to gcTaskIDs slice directly when fullGC equals true.
-		if fullGC {
-			gcTaskIDs = append(gcTaskIDs, taskID)
-			return nil
-		}
-
-		metaData, err := cm.metaDataManager.readFileMetaData(ctx, taskID)
-		if err != nil || metaData == nil {
-			logrus.Debugf("failed to get metadata taskID(%s): %v", taskID, err)
+		candidate, err := cm.getCandidate(ctx, taskID)
+		if err != nil {
+			logrus.Debugf("failed to get gc candidate taskID(%s): %v", taskID, err)
 			// TODO: delete the file when failed to get metadata
+			if fullGC {
+				usage.noMetaTaskIDs = append(usage.noMetaTaskIDs, taskID)
+			}
 			return nil
 		}
-		// put taskID into gapTasks or intervalTasks which will sort by some rules
-		if err := cm.sortInert(ctx, gapTasks, intervalTasks, metaData); err != nil {
-			logrus.Errorf("failed to parse inert metaData(%+v): %v", metaData, err)
-		}
+		usage.candidates = append(usage.candidates, candidate)
+		usage.namespaceBytes[candidate.Namespace] += candidate.Size
 
 		return nil
 	}
@@ -117,57 +212,61 @@ func (cm *Manager) GetGCTaskIDs(ctx context.Context, taskMgr mgr.TaskMgr) ([]str
 		return nil, err
 	}
 
-	if !fullGC {
-		gcTaskIDs = append(gcTaskIDs, getGCTasks(gapTasks, intervalTasks)...)
+	for namespace, size := range usage.namespaceBytes {
+		cm.metrics.namespaceCacheBytes.WithLabelValues(namespace).Set(float64(size))
 	}
-
-	return gcTaskIDs, nil
+	return usage, nil
 }
 
-func (cm *Manager) sortInert(ctx context.Context, gapTasks, intervalTasks *treemap.Map, metaData *fileMetaData) error {
-	gap := getCurrentTimeMillisFunc() - metaData.AccessTime
-
-	if metaData.Interval > 0 &&
-		gap <= metaData.Interval+(int64(cm.cfg.IntervalThreshold.Seconds())*int64(time.Millisecond)) {
-		info, err := cm.cacheStore.Stat(ctx, getDownloadRaw(metaData.TaskID))
-		if err != nil {
-			return err
-		}
-
-		v, found := intervalTasks.Get(info.Size)
-		if !found {
-			v = make([]string, 0)
-		}
-		tasks := v.([]string)
-		tasks = append(tasks, metaData.TaskID)
-		intervalTasks.Put(info.Size, tasks)
-		return nil
+// getCacheQuota returns the cacheQuota of the namespace in bytes, and 0 means that there is no limit.
+func (cm *Manager) getCacheQuota(namespace string) int64 {
+	if ns := cm.cfg.GetNamespaceConfig(namespace); ns != nil {
+		return int64(ns.CacheQuota)
 	}
+	return 0
+}
 
-	v, found := gapTasks.Get(gap)
-	if !found {
-		v = make([]string, 0)
+// GetFileSize returns the size in bytes of the file downloaded by CDN with specified taskID.
+func (cm *Manager) GetFileSize(ctx context.Context, taskID string) (int64, error) {
+	info, err := cm.cacheStore.Stat(ctx, getDownloadRaw(taskID))
+	if err != nil {
+		return 0, err
 	}
-	tasks := v.([]string)
-	tasks = append(tasks, metaData.TaskID)
-	gapTasks.Put(gap, tasks)
-	return nil
+	return info.Size, nil
 }
 
-func getGCTasks(gapTasks, intervalTasks *treemap.Map) []string {
-	var gcTasks = make([]string, 0)
-
-	for _, v := range gapTasks.Values() {
-		if taskIDs, ok := v.([]string); ok {
-			gcTasks = append(gcTasks, taskIDs...)
-		}
+// getCandidate returns the eviction candidate of the taskID with its metadata.
+func (cm *Manager) getCandidate(ctx context.Context, taskID string) (*eviction.Candidate, error) {
+	metaData, err := cm.metaDataManager.readFileMetaData(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if metaData == nil {
+		return nil, errors.Wrapf(errortypes.ErrDataNotFound, "metadata of taskID(%s)", taskID)
 	}
 
-	for _, v := range intervalTasks.Values() {
-		if taskIDs, ok := v.([]string); ok {
-			gcTasks = append(gcTasks, taskIDs...)
-		}
+	size, _ := cm.GetFileSize(ctx, taskID)
+
+	return &eviction.Candidate{
+		TaskID:      taskID,
+		URL:         metaData.URL,
+		Namespace:   getNamespace(metaData.Namespace),
+		Size:        size,
+		AccessTime:  millisToTime(metaData.AccessTime),
+		AccessCount: metaData.AccessCount,
+		Interval:    time.Duration(metaData.Interval) * time.Millisecond,
+	}, nil
+}
+
+// getNamespace returns the namespace of the task file,
+// and the ones written before the namespaces are introduced belong to the default namespace.
+func getNamespace(namespace string) string {
+	if stringutils.IsEmptyStr(namespace) {
+		return config.DefaultNamespace
 	}
+	return namespace
+}
 
-	return gcTasks
+func millisToTime(millis int64) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond))
 }
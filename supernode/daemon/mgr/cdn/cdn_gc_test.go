@@ -0,0 +1,131 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/cdn/eviction"
+	"github.com/dragonflyoss/Dragonfly/supernode/store"
+
+	"github.com/go-check/check"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type CDNGCTestSuite struct {
+	workHome string
+	manager  *Manager
+}
+
+func init() {
+	check.Suite(&CDNGCTestSuite{})
+}
+
+// gcTaskMgr is a TaskMgr which only gets the tasks being used.
+type gcTaskMgr struct {
+	mgr.TaskMgr
+	tasks map[string]*types.TaskInfo
+}
+
+func (tm *gcTaskMgr) Get(ctx context.Context, taskID string) (*types.TaskInfo, error) {
+	if task, ok := tm.tasks[taskID]; ok {
+		return task, nil
+	}
+	return nil, errortypes.ErrDataNotFound
+}
+
+func (s *CDNGCTestSuite) SetUpSuite(c *check.C) {
+	s.workHome, _ = ioutil.TempDir("/tmp", "supernode-cdn-CDNGCTestSuite-")
+	fileStore, err := store.NewStore(store.LocalStorageDriver, store.NewLocalStorage, "baseDir: "+s.workHome)
+	c.Assert(err, check.IsNil)
+
+	cfg := config.NewConfig()
+	evictionPolicy, err := eviction.NewPolicy(cfg)
+	c.Assert(err, check.IsNil)
+	s.manager = &Manager{
+		cfg:             cfg,
+		cacheStore:      fileStore,
+		metaDataManager: newFileMetaDataManager(fileStore),
+		metrics:         newMetrics(prometheus.NewRegistry()),
+		evictionPolicy:  evictionPolicy,
+	}
+
+	now := time.Now()
+	for _, f := range []struct {
+		taskID     string
+		namespace  string
+		accessTime time.Time
+	}{
+		{"aaa1", "datasets", now.Add(-3 * time.Hour)},
+		{"aaa2", "datasets", now.Add(-time.Hour)},
+		{"aaa3", "datasets", now},
+		{"aaa4", "", now.Add(-5 * time.Hour)},
+	} {
+		err := fileStore.PutBytes(context.Background(), getDownloadRaw(f.taskID), make([]byte, 100))
+		c.Assert(err, check.IsNil)
+		err = s.manager.metaDataManager.writeFileMetaData(context.Background(), &fileMetaData{
+			TaskID:     f.taskID,
+			Namespace:  f.namespace,
+			AccessTime: f.accessTime.UnixNano() / int64(time.Millisecond),
+		})
+		c.Assert(err, check.IsNil)
+	}
+}
+
+func (s *CDNGCTestSuite) TearDownSuite(c *check.C) {
+	if s.workHome != "" {
+		if err := os.RemoveAll(s.workHome); err != nil {
+			c.Errorf("failed to remove %s: %v", s.workHome, err)
+		}
+	}
+}
+
+func (s *CDNGCTestSuite) TestGetQuotaGCTaskIDs(c *check.C) {
+	ctx := context.Background()
+	taskMgr := &gcTaskMgr{tasks: map[string]*types.TaskInfo{
+		"aaa3": {ID: "aaa3", Namespace: "datasets"},
+	}}
+
+	s.manager.cfg.Namespaces = nil
+	gcTaskIDs, err := s.manager.GetQuotaGCTaskIDs(ctx, taskMgr)
+	c.Assert(err, check.IsNil)
+	c.Check(gcTaskIDs, check.IsNil)
+
+	// the task file being used is counted but not deleted,
+	// and the ones of the other namespaces are not affected.
+	s.manager.cfg.Namespaces = []*config.NamespaceConfig{{Name: "datasets", CacheQuota: 200}}
+	gcTaskIDs, err = s.manager.GetQuotaGCTaskIDs(ctx, taskMgr)
+	c.Assert(err, check.IsNil)
+	c.Check(gcTaskIDs, check.DeepEquals, map[string][]string{"datasets": {"aaa2"}})
+
+	s.manager.cfg.Namespaces[0].CacheQuota = 100
+	gcTaskIDs, err = s.manager.GetQuotaGCTaskIDs(ctx, taskMgr)
+	c.Assert(err, check.IsNil)
+	c.Check(gcTaskIDs, check.DeepEquals, map[string][]string{"datasets": {"aaa2", "aaa1"}})
+
+	cacheBytes := s.manager.metrics.namespaceCacheBytes
+	c.Check(prom_testutil.ToFloat64(cacheBytes.WithLabelValues("datasets")), check.Equals, float64(300))
+	c.Check(prom_testutil.ToFloat64(cacheBytes.WithLabelValues(config.DefaultNamespace)), check.Equals, float64(100))
+}
@@ -33,6 +33,7 @@ import (
 //
 // If the returned error is nil, the Response will contain a non-nil
 // Body which the caller is expected to close.
+// And the download will be aborted once the ctx is done.
 func (cm *Manager) download(ctx context.Context, taskID, url string, headers map[string]string,
 	startPieceNum int, httpFileLength int64, pieceContSize int32) (*http.Response, error) {
 	var checkCode = http.StatusOK
@@ -51,5 +52,5 @@ func (cm *Manager) download(ctx context.Context, taskID, url string, headers map
 	}
 
 	logrus.Infof("start to download for taskId(%s) with fileUrl: %s header: %v checkCode: %d", taskID, url, headers, checkCode)
-	return cm.originClient.Download(url, headers, checkCode)
+	return cm.originClient.Download(ctx, url, headers, checkCode)
 }
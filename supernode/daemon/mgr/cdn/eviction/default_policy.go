@@ -0,0 +1,66 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eviction
+
+import (
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+)
+
+// DefaultPolicy is the name of the policy used when no policy is specified.
+const DefaultPolicy = "default"
+
+func init() {
+	Register(DefaultPolicy, func(cfg *config.Config) (Policy, error) {
+		return &defaultPolicy{intervalThreshold: cfg.IntervalThreshold}, nil
+	})
+}
+
+// defaultPolicy evicts the task files which are not accessed regularly before the others.
+//
+// A task file is considered to be accessed regularly when the time since its last access
+// is within its last access interval plus the intervalThreshold. The irregular ones are
+// evicted in ascending order of the time since the last access, and the regular ones
+// are evicted in ascending order of size.
+type defaultPolicy struct {
+	intervalThreshold time.Duration
+}
+
+func (p *defaultPolicy) Name() string {
+	return DefaultPolicy
+}
+
+func (p *defaultPolicy) Evict(candidates []*Candidate, now time.Time, fullGC bool) []string {
+	var gapTasks, intervalTasks []*Candidate
+	for _, c := range candidates {
+		gap := now.Sub(c.AccessTime)
+		if c.Interval > 0 && gap <= c.Interval+p.intervalThreshold {
+			intervalTasks = append(intervalTasks, c)
+			continue
+		}
+		gapTasks = append(gapTasks, c)
+	}
+
+	gapTasks = sortCandidates(gapTasks, func(a, b *Candidate) bool {
+		return now.Sub(a.AccessTime) < now.Sub(b.AccessTime)
+	})
+	intervalTasks = sortCandidates(intervalTasks, func(a, b *Candidate) bool {
+		return a.Size < b.Size
+	})
+	return append(taskIDs(gapTasks), taskIDs(intervalTasks)...)
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eviction
+
+import (
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+)
+
+// LFUPolicy is the name of the least frequently used policy.
+const LFUPolicy = "lfu"
+
+func init() {
+	Register(LFUPolicy, func(cfg *config.Config) (Policy, error) {
+		return &lfuPolicy{}, nil
+	})
+}
+
+// lfuPolicy evicts the least frequently used task files first,
+// and the least recently used one will be evicted first if they are used equally.
+// It fits the cache of a few huge files which are used by many peers.
+type lfuPolicy struct{}
+
+func (p *lfuPolicy) Name() string {
+	return LFUPolicy
+}
+
+func (p *lfuPolicy) Evict(candidates []*Candidate, now time.Time, fullGC bool) []string {
+	return taskIDs(sortCandidates(candidates, func(a, b *Candidate) bool {
+		if a.AccessCount != b.AccessCount {
+			return a.AccessCount < b.AccessCount
+		}
+		return a.AccessTime.Before(b.AccessTime)
+	}))
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eviction
+
+import (
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+)
+
+// LRUPolicy is the name of the least recently used policy.
+const LRUPolicy = "lru"
+
+func init() {
+	Register(LRUPolicy, func(cfg *config.Config) (Policy, error) {
+		return &lruPolicy{}, nil
+	})
+}
+
+// lruPolicy evicts the least recently used task files first.
+type lruPolicy struct{}
+
+func (p *lruPolicy) Name() string {
+	return LRUPolicy
+}
+
+func (p *lruPolicy) Evict(candidates []*Candidate, now time.Time, fullGC bool) []string {
+	return taskIDs(sortByAccessTime(candidates))
+}
+
+func sortByAccessTime(candidates []*Candidate) []*Candidate {
+	return sortCandidates(candidates, func(a, b *Candidate) bool {
+		return a.AccessTime.Before(b.AccessTime)
+	})
+}
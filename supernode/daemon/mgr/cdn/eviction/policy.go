@@ -0,0 +1,127 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package eviction provides the policies to choose the task files
+// to be deleted when supernode runs out of disk space.
+package eviction
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/pkg/errors"
+)
+
+// Candidate is a task file which is not being used and can be evicted.
+type Candidate struct {
+	// TaskID identifies the task file.
+	TaskID string
+
+	// URL is the source URL of the task file.
+	URL string
+
+	// Namespace is the namespace which the task file belongs to.
+	Namespace string
+
+	// Size is the size of the task file in bytes.
+	Size int64
+
+	// AccessTime is the last time when the task file was accessed.
+	AccessTime time.Time
+
+	// AccessCount is the number of times that the task file has been accessed.
+	AccessCount int64
+
+	// Interval is the interval between the last two accesses.
+	Interval time.Duration
+}
+
+// Policy decides which task files should be evicted and in what order.
+type Policy interface {
+	// Name returns the name of the policy.
+	Name() string
+
+	// Evict returns the taskIDs of the candidates to be evicted in order of priority,
+	// and the caller may evict only a part of them from the beginning.
+	//
+	// When fullGC is true, the disk is nearly full and all the candidates should be returned.
+	Evict(candidates []*Candidate, now time.Time, fullGC bool) []string
+}
+
+// Builder is a function that creates a Policy with the config of supernode.
+type Builder func(cfg *config.Config) (Policy, error)
+
+var (
+	builders     = make(map[string]Builder)
+	buildersLock sync.RWMutex
+)
+
+// Register registers a policy builder with the specified name.
+// All policies should call this function to register itself.
+func Register(name string, builder Builder) {
+	buildersLock.Lock()
+	defer buildersLock.Unlock()
+
+	builders[name] = builder
+}
+
+// NewPolicy creates the policy specified by cfg.GCEvictionPolicy,
+// and the DefaultPolicy will be used if it's empty.
+func NewPolicy(cfg *config.Config) (Policy, error) {
+	name := cfg.GCEvictionPolicy
+	if stringutils.IsEmptyStr(name) {
+		name = DefaultPolicy
+	}
+
+	buildersLock.RLock()
+	builder, ok := builders[name]
+	buildersLock.RUnlock()
+	if !ok {
+		return nil, errors.Wrapf(errortypes.ErrInvalidValue, "unknown gc eviction policy: %s", name)
+	}
+
+	return builder(cfg)
+}
+
+// sortCandidates sorts the candidates stably by less,
+// and the ties are broken by the taskID to make the order deterministic.
+func sortCandidates(candidates []*Candidate, less func(a, b *Candidate) bool) []*Candidate {
+	result := make([]*Candidate, len(candidates))
+	copy(result, candidates)
+	sort.SliceStable(result, func(i, j int) bool {
+		if less(result[i], result[j]) {
+			return true
+		}
+		if less(result[j], result[i]) {
+			return false
+		}
+		return result[i].TaskID < result[j].TaskID
+	})
+	return result
+}
+
+func taskIDs(candidates []*Candidate) []string {
+	result := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		result = append(result, c.TaskID)
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eviction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/go-check/check"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type EvictionTestSuite struct {
+	now        time.Time
+	candidates []*Candidate
+}
+
+func init() {
+	check.Suite(&EvictionTestSuite{})
+}
+
+func (s *EvictionTestSuite) SetUpTest(c *check.C) {
+	s.now = time.Unix(1000000, 0)
+	s.candidates = []*Candidate{
+		{
+			TaskID:      "a",
+			URL:         "http://registry.example.com/a",
+			Size:        100,
+			AccessTime:  s.now.Add(-10 * time.Minute),
+			AccessCount: 5,
+		},
+		{
+			TaskID:      "b",
+			URL:         "http://files.example.com/b",
+			Size:        1000,
+			AccessTime:  s.now.Add(-1 * time.Minute),
+			AccessCount: 1,
+		},
+		{
+			TaskID:      "c",
+			URL:         "http://registry.example.com/c",
+			Size:        10,
+			AccessTime:  s.now.Add(-30 * time.Minute),
+			AccessCount: 5,
+			Interval:    time.Hour,
+		},
+		{
+			TaskID:      "d",
+			URL:         "http://files.example.com/d",
+			Size:        500,
+			AccessTime:  s.now.Add(-1 * time.Minute),
+			AccessCount: 1,
+		},
+	}
+}
+
+func (s *EvictionTestSuite) newPolicy(c *check.C, name string, rules ...*config.TTLRule) Policy {
+	cfg := config.NewConfig()
+	cfg.GCEvictionPolicy = name
+	cfg.GCTTLRules = rules
+	p, err := NewPolicy(cfg)
+	c.Assert(err, check.IsNil)
+	return p
+}
+
+func (s *EvictionTestSuite) TestNewPolicy(c *check.C) {
+	cfg := config.NewConfig()
+	cfg.GCEvictionPolicy = ""
+	p, err := NewPolicy(cfg)
+	c.Assert(err, check.IsNil)
+	c.Check(p.Name(), check.Equals, DefaultPolicy)
+
+	cfg.GCEvictionPolicy = "unknown"
+	_, err = NewPolicy(cfg)
+	c.Check(errortypes.IsInvalidValue(err), check.Equals, true)
+
+	cfg.GCEvictionPolicy = TTLPolicy
+	cfg.GCTTLRules = []*config.TTLRule{{Pattern: "(", TTL: time.Hour}}
+	_, err = NewPolicy(cfg)
+	c.Check(errortypes.IsInvalidValue(err), check.Equals, true)
+
+	cfg.GCTTLRules = []*config.TTLRule{{Pattern: ".*", TTL: 0}}
+	_, err = NewPolicy(cfg)
+	c.Check(errortypes.IsInvalidValue(err), check.Equals, true)
+}
+
+func (s *EvictionTestSuite) TestDefaultPolicy(c *check.C) {
+	p := s.newPolicy(c, DefaultPolicy)
+	c.Check(p.Evict(s.candidates, s.now, false), check.DeepEquals, []string{"b", "d", "a", "c"})
+}
+
+func (s *EvictionTestSuite) TestLRUPolicy(c *check.C) {
+	p := s.newPolicy(c, LRUPolicy)
+	c.Check(p.Evict(s.candidates, s.now, false), check.DeepEquals, []string{"c", "a", "b", "d"})
+}
+
+func (s *EvictionTestSuite) TestLFUPolicy(c *check.C) {
+	p := s.newPolicy(c, LFUPolicy)
+	c.Check(p.Evict(s.candidates, s.now, false), check.DeepEquals, []string{"b", "d", "c", "a"})
+}
+
+func (s *EvictionTestSuite) TestSizePolicy(c *check.C) {
+	// a: 100*600, b: 1000*60, c: 10*1800, d: 500*60
+	p := s.newPolicy(c, SizePolicy)
+	c.Check(p.Evict(s.candidates, s.now, false), check.DeepEquals, []string{"a", "b", "d", "c"})
+}
+
+func (s *EvictionTestSuite) TestTTLPolicy(c *check.C) {
+	p := s.newPolicy(c, TTLPolicy,
+		&config.TTLRule{Pattern: `^http://registry\.example\.com/c`, TTL: time.Hour},
+		&config.TTLRule{Pattern: `^http://registry\.example\.com/`, TTL: 5 * time.Minute},
+		&config.TTLRule{Pattern: `/d$`, TTL: 30 * time.Second},
+	)
+	c.Check(p.Evict(s.candidates, s.now, false), check.DeepEquals, []string{"a", "d"})
+	c.Check(p.Evict(s.candidates, s.now, true), check.DeepEquals, []string{"a", "d", "c", "b"})
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eviction
+
+import (
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+)
+
+// SizePolicy is the name of the size-weighted policy.
+const SizePolicy = "size"
+
+func init() {
+	Register(SizePolicy, func(cfg *config.Config) (Policy, error) {
+		return &sizePolicy{}, nil
+	})
+}
+
+// sizePolicy evicts the task files in descending order of the size weighted by
+// the time since the last access, so that a large and stale file will be evicted first
+// to free more space with fewer files deleted.
+// It fits the cache of a large number of small files.
+type sizePolicy struct{}
+
+func (p *sizePolicy) Name() string {
+	return SizePolicy
+}
+
+func (p *sizePolicy) Evict(candidates []*Candidate, now time.Time, fullGC bool) []string {
+	weight := func(c *Candidate) float64 {
+		idle := now.Sub(c.AccessTime).Seconds()
+		if idle < 1 {
+			idle = 1
+		}
+		return float64(c.Size) * idle
+	}
+	return taskIDs(sortCandidates(candidates, func(a, b *Candidate) bool {
+		return weight(a) > weight(b)
+	}))
+}
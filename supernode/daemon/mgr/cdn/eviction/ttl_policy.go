@@ -0,0 +1,99 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eviction
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/pkg/errors"
+)
+
+// TTLPolicy is the name of the policy which evicts the task files by the TTL of their URL patterns.
+const TTLPolicy = "ttl"
+
+func init() {
+	Register(TTLPolicy, newTTLPolicy)
+}
+
+type ttlRule struct {
+	pattern *regexp.Regexp
+	ttl     time.Duration
+}
+
+// ttlPolicy evicts the task files which have not been accessed within the TTL of
+// the first rule whose pattern matches the URL, and the files matching no rule never expire.
+//
+// When fullGC is true, the unexpired files will be evicted after the expired ones
+// in order of least recently used.
+type ttlPolicy struct {
+	rules []*ttlRule
+}
+
+func newTTLPolicy(cfg *config.Config) (Policy, error) {
+	p := &ttlPolicy{}
+	for _, r := range cfg.GCTTLRules {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(errortypes.ErrInvalidValue, "pattern %s of gc ttl rule: %v", r.Pattern, err)
+		}
+		if r.TTL <= 0 {
+			return nil, errors.Wrapf(errortypes.ErrInvalidValue, "ttl %v of gc ttl rule for pattern %s", r.TTL, r.Pattern)
+		}
+		p.rules = append(p.rules, &ttlRule{pattern: pattern, ttl: r.TTL})
+	}
+	return p, nil
+}
+
+func (p *ttlPolicy) Name() string {
+	return TTLPolicy
+}
+
+func (p *ttlPolicy) Evict(candidates []*Candidate, now time.Time, fullGC bool) []string {
+	var expired, unexpired []*Candidate
+	overdue := make(map[string]time.Duration)
+	for _, c := range candidates {
+		ttl, ok := p.getTTL(c.URL)
+		if ok && now.Sub(c.AccessTime) > ttl {
+			overdue[c.TaskID] = now.Sub(c.AccessTime) - ttl
+			expired = append(expired, c)
+			continue
+		}
+		unexpired = append(unexpired, c)
+	}
+
+	// the files expired for the longest time will be evicted first.
+	expired = sortCandidates(expired, func(a, b *Candidate) bool {
+		return overdue[a.TaskID] > overdue[b.TaskID]
+	})
+	if !fullGC {
+		return taskIDs(expired)
+	}
+	return append(taskIDs(expired), taskIDs(sortByAccessTime(unexpired))...)
+}
+
+func (p *ttlPolicy) getTTL(url string) (time.Duration, bool) {
+	for _, r := range p.rules {
+		if r.pattern.MatchString(url) {
+			return r.ttl, true
+		}
+	}
+	return 0, false
+}
@@ -38,9 +38,11 @@ type fileMetaData struct {
 	PieceSize   int32  `json:"pieceSize"`
 	HTTPFileLen int64  `json:"httpFileLen"`
 	Identifier  string `json:"bizId"`
+	Namespace   string `json:"namespace,omitempty"`
 
 	AccessTime   int64  `json:"accessTime"`
 	Interval     int64  `json:"interval"`
+	AccessCount  int64  `json:"accessCount"`
 	FileLength   int64  `json:"fileLength"`
 	Md5          string `json:"md5"`
 	RealMd5      string `json:"realMd5"`
@@ -71,7 +73,9 @@ func (mm *fileMetaDataManager) writeFileMetaDataByTask(ctx context.Context, task
 		PieceSize:   task.PieceSize,
 		HTTPFileLen: task.HTTPFileLength,
 		Identifier:  task.Identifier,
+		Namespace:   task.Namespace,
 		AccessTime:  getCurrentTimeMillisFunc(),
+		AccessCount: 1,
 		FileLength:  task.FileLength,
 		Md5:         task.Md5,
 	}
@@ -129,6 +133,7 @@ func (mm *fileMetaDataManager) updateAccessTime(ctx context.Context, taskID stri
 	}
 
 	originMetaData.AccessTime = accessTime
+	originMetaData.AccessCount++
 
 	return mm.writeFileMetaData(ctx, originMetaData)
 }
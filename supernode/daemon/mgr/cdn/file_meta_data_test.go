@@ -96,6 +96,7 @@ func (s *CDNFileMetaDataTestSuite) TestWriteReadFileMetaData(c *check.C) {
 		PieceSize:   task.PieceSize,
 		HTTPFileLen: task.HTTPFileLength,
 		Identifier:  task.Identifier,
+		AccessCount: 1,
 	}
 
 	// write
@@ -120,6 +121,7 @@ func (s *CDNFileMetaDataTestSuite) TestWriteReadFileMetaData(c *check.C) {
 		PieceSize:    task.PieceSize,
 		HTTPFileLen:  task.HTTPFileLength,
 		Identifier:   task.Identifier,
+		AccessCount:  1,
 		LastModified: updatedFileMetaData.LastModified,
 		ETag:         updatedFileMetaData.ETag,
 	}
@@ -20,16 +20,20 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
+	"net/http"
 	"path"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/pkg/limitreader"
 	"github.com/dragonflyoss/Dragonfly/pkg/metricsutils"
 	"github.com/dragonflyoss/Dragonfly/pkg/netutils"
 	"github.com/dragonflyoss/Dragonfly/pkg/ratelimiter"
 	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/cdn/eviction"
 	"github.com/dragonflyoss/Dragonfly/supernode/httpclient"
 	"github.com/dragonflyoss/Dragonfly/supernode/store"
 	"github.com/dragonflyoss/Dragonfly/supernode/util"
@@ -52,6 +56,8 @@ type metrics struct {
 	cdnCacheHitCount     *prometheus.CounterVec
 	cdnDownloadCount     *prometheus.CounterVec
 	cdnDownloadFailCount *prometheus.CounterVec
+	cdnSiblingFetchCount *prometheus.CounterVec
+	namespaceCacheBytes  *prometheus.GaugeVec
 }
 
 func newMetrics(register prometheus.Registerer) *metrics {
@@ -64,6 +70,12 @@ func newMetrics(register prometheus.Registerer) *metrics {
 
 		cdnDownloadFailCount: metricsutils.NewCounter(config.SubsystemSupernode, "cdn_download_failed_total",
 			"Total failure times of cdn download", []string{}, register),
+
+		cdnSiblingFetchCount: metricsutils.NewCounter(config.SubsystemSupernode, "cdn_sibling_fetch_total",
+			"Total times of fetching the cache from the sibling supernodes by result", []string{"result"}, register),
+
+		namespaceCacheBytes: metricsutils.NewGauge(config.SubsystemSupernode, "namespace_cache_bytes",
+			"Current bytes of the files cached by cdn by namespace", []string{"namespace"}, register),
 	}
 }
 
@@ -71,7 +83,7 @@ func newMetrics(register prometheus.Registerer) *metrics {
 type Manager struct {
 	cfg             *config.Config
 	cacheStore      *store.Store
-	limiter         *ratelimiter.RateLimiter
+	limiter         *ratelimiter.WeightedFairLimiter
 	cdnLocker       *util.LockerPool
 	progressManager mgr.ProgressMgr
 
@@ -82,19 +94,28 @@ type Manager struct {
 	pieceMD5Manager *pieceMD5Mgr
 	writer          *superWriter
 	metrics         *metrics
+	evictionPolicy  eviction.Policy
+
+	// digests maps the md5 of the completed files to their taskIDs,
+	// which is used to look up the cache by the file md5 for the sibling supernodes.
+	digests *syncmap.SyncMap
 }
 
 // NewManager returns a new Manager.
 func NewManager(cfg *config.Config, cacheStore *store.Store, progressManager mgr.ProgressMgr,
 	originClient httpclient.OriginHTTPClient, register prometheus.Registerer) (*Manager, error) {
-	rateLimiter := ratelimiter.NewRateLimiter(ratelimiter.TransRate(int64(cfg.MaxBandwidth-cfg.SystemReservedBandwidth)), 2)
+	rateLimiter := ratelimiter.NewRateLimiter(ratelimiter.TransRate(int64(cfg.GetUploadRate())), 2)
 	metaDataManager := newFileMetaDataManager(cacheStore)
 	pieceMD5Manager := newpieceMD5Mgr()
 	cdnReporter := newReporter(cfg, cacheStore, progressManager, metaDataManager, pieceMD5Manager)
+	evictionPolicy, err := eviction.NewPolicy(cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create gc eviction policy")
+	}
 	return &Manager{
 		cfg:             cfg,
 		cacheStore:      cacheStore,
-		limiter:         rateLimiter,
+		limiter:         ratelimiter.NewWeightedFairLimiter(rateLimiter),
 		cdnLocker:       util.NewLockerPool(),
 		progressManager: progressManager,
 		metaDataManager: metaDataManager,
@@ -104,6 +125,8 @@ func NewManager(cfg *config.Config, cacheStore *store.Store, progressManager mgr
 		originClient:    originClient,
 		writer:          newSuperWriter(cacheStore, cdnReporter),
 		metrics:         newMetrics(register),
+		evictionPolicy:  evictionPolicy,
+		digests:         syncmap.NewSyncMap(),
 	}, nil
 }
 
@@ -129,9 +152,16 @@ func (cm *Manager) TriggerCDN(ctx context.Context, task *types.TaskInfo) (*types
 	if startPieceNum == -1 {
 		logrus.Infof("cache full hit for taskId:%s on local", task.ID)
 		cm.metrics.cdnCacheHitCount.WithLabelValues().Inc()
+		if updateTaskInfo != nil && !stringutils.IsEmptyStr(updateTaskInfo.RealMd5) {
+			cm.digests.Add(updateTaskInfo.RealMd5, task.ID)
+		}
 		return updateTaskInfo, nil
 	}
 
+	if err := cm.waitForSpace(ctx, task.ID); err != nil {
+		return getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusFAILED), err
+	}
+
 	if fileMD5 == nil {
 		fileMD5 = md5.New()
 	}
@@ -139,20 +169,39 @@ func (cm *Manager) TriggerCDN(ctx context.Context, task *types.TaskInfo) (*types
 	// get piece content size which not including the piece header and trailer
 	pieceContSize := task.PieceSize - config.PieceWrapSize
 
+	if startPieceNum == 0 && len(cm.cfg.CDNSiblings) > 0 {
+		if updateTaskInfo, ok := cm.triggerFromSiblings(ctx, task, httpFileLength, pieceContSize); ok {
+			return updateTaskInfo, nil
+		}
+		if ctx.Err() != nil {
+			cm.cleanCanceledCDN(task.ID)
+			return getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusFAILED), ctx.Err()
+		}
+	}
+
 	// start to download the source file
 	resp, err := cm.download(ctx, task.ID, task.RawURL, task.Headers, startPieceNum, httpFileLength, pieceContSize)
 	cm.metrics.cdnDownloadCount.WithLabelValues().Inc()
 	if err != nil {
 		cm.metrics.cdnDownloadFailCount.WithLabelValues().Inc()
+		if ctx.Err() != nil {
+			cm.cleanCanceledCDN(task.ID)
+		}
 		return getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusFAILED), err
 	}
 	defer resp.Body.Close()
 
 	cm.updateLastModifiedAndETag(ctx, task.ID, resp.Header.Get("Last-Modified"), resp.Header.Get("Etag"))
-	reader := limitreader.NewLimitReaderWithLimiterAndMD5Sum(resp.Body, cm.limiter, fileMD5)
+	// share the bandwidth of supernode with other tasks according to the priority of task.
+	body := newPriorityReader(resp.Body, cm.limiter, task.ID, mgr.GetPriorityWeight(task.Priority))
+	reader := limitreader.NewLimitReaderWithLimiterAndMD5Sum(body, nil, fileMD5)
 	downloadMetadata, err := cm.writer.startWriter(ctx, cm.cfg, reader, task, startPieceNum, httpFileLength, pieceContSize)
 	if err != nil {
 		logrus.Errorf("failed to write for task %s: %v", task.ID, err)
+		if ctx.Err() != nil {
+			cm.cleanCanceledCDN(task.ID)
+			return getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusFAILED), err
+		}
 		return nil, err
 	}
 
@@ -162,9 +211,101 @@ func (cm *Manager) TriggerCDN(ctx context.Context, task *types.TaskInfo) (*types
 		return getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusFAILED), err
 	}
 
+	cm.digests.Add(realMD5, task.ID)
+	return getUpdateTaskInfo(types.TaskInfoCdnStatusSUCCESS, realMD5, downloadMetadata.realFileLength), nil
+}
+
+// triggerFromSiblings fetches the file of the task from the sibling supernodes
+// which have a completed cache of it, and returns false if none of the siblings
+// has the cache or the fetching fails. The partial files are removed in the latter case
+// so that the task can be downloaded from the source again.
+func (cm *Manager) triggerFromSiblings(ctx context.Context, task *types.TaskInfo, httpFileLength int64, pieceContSize int32) (*types.TaskInfo, bool) {
+	cacheInfo, resp := cm.fetchFromSiblings(ctx, task)
+	if resp == nil {
+		cm.metrics.cdnSiblingFetchCount.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	updateTaskInfo, err := cm.writeFromSibling(ctx, task, cacheInfo, resp, httpFileLength, pieceContSize)
+	if err == nil {
+		cm.metrics.cdnSiblingFetchCount.WithLabelValues("success").Inc()
+		return updateTaskInfo, true
+	}
+
+	cm.metrics.cdnSiblingFetchCount.WithLabelValues("failed").Inc()
+	logrus.Errorf("failed to fetch taskID(%s) from the siblings and fall back to the source: %v", task.ID, err)
+	if ctx.Err() == nil {
+		if err := cm.pieceMD5Manager.removePieceMD5sByTaskID(task.ID); err != nil && !errortypes.IsDataNotFound(err) {
+			logrus.Errorf("failed to remove the piece md5s of taskID(%s): %v", task.ID, err)
+		}
+		if _, err := cm.detector.resetRepo(ctx, task); err != nil {
+			logrus.Errorf("failed to reset repo for taskID(%s): %v", task.ID, err)
+		}
+	}
+	return nil, false
+}
+
+// writeFromSibling writes the cached file fetched from a sibling supernode to the local storage.
+func (cm *Manager) writeFromSibling(ctx context.Context, task *types.TaskInfo, cacheInfo *types.CacheInfo,
+	resp *http.Response, httpFileLength int64, pieceContSize int32) (*types.TaskInfo, error) {
+	// keep the validators of the source file to check whether the cache expires later
+	if err := cm.metaDataManager.updateLastModifiedAndETag(ctx, task.ID, cacheInfo.LastModified, cacheInfo.ETag); err != nil {
+		return nil, err
+	}
+
+	body := newPriorityReader(newPieceReader(resp.Body, cacheInfo.PieceMd5s), cm.limiter, task.ID, mgr.GetPriorityWeight(task.Priority))
+	reader := limitreader.NewLimitReaderWithLimiterAndMD5Sum(body, nil, md5.New())
+	downloadMetadata, err := cm.writer.startWriter(ctx, cm.cfg, reader, task, 0, httpFileLength, pieceContSize)
+	if err != nil {
+		return nil, err
+	}
+
+	realMD5 := reader.Md5()
+	if realMD5 != cacheInfo.RealMd5 {
+		return nil, fmt.Errorf("file md5 not match expected: %s real: %s", cacheInfo.RealMd5, realMD5)
+	}
+	success, err := cm.handleCDNResult(ctx, task, realMD5, httpFileLength, downloadMetadata.realHTTPFileLength, downloadMetadata.realFileLength)
+	if err != nil {
+		return nil, err
+	}
+	if !success {
+		return nil, fmt.Errorf("file of the sibling does not match the task")
+	}
+
+	cm.digests.Add(realMD5, task.ID)
 	return getUpdateTaskInfo(types.TaskInfoCdnStatusSUCCESS, realMD5, downloadMetadata.realFileLength), nil
 }
 
+// waitForSpace queues the CDN download until the disk is not critically full,
+// and returns an error if the disk space is not released within CDNWaitSpaceTimeout
+// or the CDN download is canceled.
+func (cm *Manager) waitForSpace(ctx context.Context, taskID string) error {
+	if cm.cacheStore.GetSpaceLevel() != store.SpaceCritical {
+		return nil
+	}
+
+	logrus.Warnf("the disk is critically full and taskID(%s) waits for the space at most %v", taskID, cm.cfg.CDNWaitSpaceTimeout)
+	ctx, cancel := context.WithTimeout(ctx, cm.cfg.CDNWaitSpaceTimeout)
+	defer cancel()
+	if err := cm.cacheStore.WaitForSpace(ctx); err != nil {
+		return errors.Wrapf(errortypes.ErrDiskFull, "refuse to download taskID(%s)", taskID)
+	}
+	return nil
+}
+
+// cleanCanceledCDN removes the partial files and piece md5s of the canceled CDN download.
+func (cm *Manager) cleanCanceledCDN(taskID string) {
+	logrus.Infof("cdn download of taskID(%s) is canceled and start to clean the partial files", taskID)
+	cm.removeDigest(context.Background(), taskID)
+	if err := deleteTaskFiles(context.Background(), cm.cacheStore, taskID); err != nil {
+		logrus.Errorf("failed to delete the partial files of taskID(%s): %v", taskID, err)
+	}
+	if err := cm.pieceMD5Manager.removePieceMD5sByTaskID(taskID); err != nil && !errortypes.IsDataNotFound(err) {
+		logrus.Errorf("failed to remove the piece md5s of taskID(%s): %v", taskID, err)
+	}
+}
+
 // GetHTTPPath returns the http download path of taskID.
 // The returned path joined the DownloadRaw.Bucket and DownloadRaw.Key.
 func (cm *Manager) GetHTTPPath(ctx context.Context, taskID string) (string, error) {
@@ -229,6 +370,70 @@ func (cm *Manager) GetPieceMD5(ctx context.Context, taskID string, pieceNum int,
 	return "", nil
 }
 
+// GetCache returns the information of the completed cache with specified taskID,
+// or the one whose file md5 equals md5 if there is no such cache of taskID.
+func (cm *Manager) GetCache(ctx context.Context, taskID, md5 string) (*types.CacheInfo, error) {
+	if stringutils.IsEmptyStr(taskID) && stringutils.IsEmptyStr(md5) {
+		return nil, errors.Wrapf(errortypes.ErrEmptyValue, "taskId and md5")
+	}
+
+	if !stringutils.IsEmptyStr(taskID) {
+		info, err := cm.getCacheInfo(ctx, taskID)
+		if err == nil && (stringutils.IsEmptyStr(md5) || info.RealMd5 == md5) {
+			return info, nil
+		}
+		logrus.Debugf("failed to get the cache of taskID(%s) with md5(%s): %v", taskID, md5, err)
+	}
+
+	if !stringutils.IsEmptyStr(md5) {
+		if id, err := cm.digests.GetAsString(md5); err == nil && id != taskID {
+			info, err := cm.getCacheInfo(ctx, id)
+			if err == nil && info.RealMd5 == md5 {
+				return info, nil
+			}
+			cm.digests.Remove(md5)
+		}
+	}
+
+	return nil, errors.Wrapf(errortypes.ErrDataNotFound, "cache of taskID(%s) with md5(%s)", taskID, md5)
+}
+
+// getCacheInfo returns the information of the completed cache of taskID.
+func (cm *Manager) getCacheInfo(ctx context.Context, taskID string) (*types.CacheInfo, error) {
+	metaData, err := cm.metaDataManager.readFileMetaData(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !metaData.Finish || !metaData.Success {
+		return nil, errors.Wrapf(errortypes.ErrDataNotFound, "cache of taskID(%s) is not completed", taskID)
+	}
+
+	pieceMD5s, err := cm.metaDataManager.readPieceMD5s(ctx, taskID, metaData.RealMd5)
+	if err != nil {
+		return nil, err
+	}
+	if len(pieceMD5s) == 0 && metaData.FileLength > 0 {
+		return nil, errors.Wrapf(errortypes.ErrDataNotFound, "piece md5s of taskID(%s)", taskID)
+	}
+
+	downloadPath, err := cm.GetHTTPPath(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CacheInfo{
+		TaskID:       taskID,
+		FileLength:   metaData.FileLength,
+		RealMd5:      metaData.RealMd5,
+		LastModified: metaData.LastModified,
+		ETag:         metaData.ETag,
+		PieceSize:    metaData.PieceSize,
+		PieceMd5s:    pieceMD5s,
+		DownloadPort: int32(cm.cfg.DownloadPort),
+		DownloadPath: downloadPath,
+	}, nil
+}
+
 // CheckFile checks the file whether exists.
 func (cm *Manager) CheckFile(ctx context.Context, taskID string) bool {
 	if _, err := cm.cacheStore.Stat(ctx, getDownloadRaw(taskID)); err != nil {
@@ -244,9 +449,27 @@ func (cm *Manager) Delete(ctx context.Context, taskID string, force bool) error
 		return cm.pieceMD5Manager.removePieceMD5sByTaskID(taskID)
 	}
 
+	cm.removeDigest(ctx, taskID)
 	return deleteTaskFiles(ctx, cm.cacheStore, taskID)
 }
 
+// removeDigest removes the md5 of the file of taskID from the digests,
+// and it should be called before the files are deleted.
+func (cm *Manager) removeDigest(ctx context.Context, taskID string) {
+	metaData, err := cm.metaDataManager.readFileMetaData(ctx, taskID)
+	if err != nil || stringutils.IsEmptyStr(metaData.RealMd5) {
+		return
+	}
+	if id, err := cm.digests.GetAsString(metaData.RealMd5); err == nil && id == taskID {
+		cm.digests.Remove(metaData.RealMd5)
+	}
+}
+
+// SetRate sets the bandwidth in bytes per second shared by the downloads from the source.
+func (cm *Manager) SetRate(rate int64) {
+	cm.limiter.SetRate(ratelimiter.TransRate(rate))
+}
+
 func (cm *Manager) handleCDNResult(ctx context.Context, task *types.TaskInfo, realMd5 string, httpFileLength, realHTTPFileLength, realFileLength int64) (bool, error) {
 	var isSuccess = true
 	if !stringutils.IsEmptyStr(task.Md5) && task.Md5 != realMd5 {
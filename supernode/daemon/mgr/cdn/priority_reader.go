@@ -0,0 +1,49 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"io"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/ratelimiter"
+)
+
+// priorityReader reads the source with the bandwidth shared by the tasks
+// in proportion to the weight of their priority.
+type priorityReader struct {
+	src     io.Reader
+	limiter *ratelimiter.WeightedFairLimiter
+	taskID  string
+	weight  int
+}
+
+func newPriorityReader(src io.Reader, limiter *ratelimiter.WeightedFairLimiter, taskID string, weight int) *priorityReader {
+	return &priorityReader{
+		src:     src,
+		limiter: limiter,
+		taskID:  taskID,
+		weight:  weight,
+	}
+}
+
+func (pr *priorityReader) Read(p []byte) (n int, err error) {
+	n, err = pr.src.Read(p)
+	if n > 0 {
+		pr.limiter.AcquireBlocking(pr.taskID, pr.weight, int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/httpclient"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// fetchFromSiblings queries the sibling supernodes one by one for the completed cache of the task,
+// and returns the response of downloading the cached file from the first sibling which has it.
+//
+// The cache of a sibling is used only if its md5 equals the expected md5 of the task,
+// or it's not expired according to the source when the task has no expected md5.
+// And the returned response is nil if none of the siblings has an available cache.
+func (cm *Manager) fetchFromSiblings(ctx context.Context, task *types.TaskInfo) (*types.CacheInfo, *http.Response) {
+	for _, sibling := range cm.cfg.CDNSiblings {
+		info, err := httpclient.GetSiblingCache(sibling, task.ID, task.Md5)
+		if err != nil {
+			logrus.Debugf("taskID(%s) has no available cache on the sibling %s: %v", task.ID, sibling, err)
+			continue
+		}
+
+		if !stringutils.IsEmptyStr(task.Md5) {
+			if info.RealMd5 != task.Md5 {
+				logrus.Warnf("the cache of taskID(%s) on the sibling %s has md5 %s, expected: %s", task.ID, sibling, info.RealMd5, task.Md5)
+				continue
+			}
+		} else if expired, err := cm.originClient.IsExpired(task.RawURL, task.Headers, info.LastModified, info.ETag); err != nil || expired {
+			logrus.Infof("the cache of taskID(%s) on the sibling %s is expired: %t, err: %v", task.ID, sibling, expired, err)
+			continue
+		}
+
+		host, _, err := net.SplitHostPort(sibling)
+		if err != nil {
+			logrus.Errorf("invalid sibling address %s: %v", sibling, err)
+			continue
+		}
+		fileURL := fmt.Sprintf("http://%s%s", net.JoinHostPort(host, strconv.Itoa(int(info.DownloadPort))), info.DownloadPath)
+		resp, err := httpclient.DownloadSiblingFile(ctx, fileURL)
+		if err != nil {
+			logrus.Errorf("failed to download the cache of taskID(%s) from the sibling %s: %v", task.ID, sibling, err)
+			continue
+		}
+
+		logrus.Infof("start to fetch taskID(%s) from the sibling %s with fileUrl: %s", task.ID, sibling, fileURL)
+		return info, resp
+	}
+
+	return nil, nil
+}
+
+// pieceReader reads the pieces of a cached file fetched from a sibling supernode.
+// It strips the header and tailer of each piece, and returns the content of a piece
+// only after the md5 of the whole piece is verified against the piece md5s of the sibling.
+type pieceReader struct {
+	src       io.Reader
+	pieceMd5s []string
+	pieceNum  int
+	content   *bytes.Buffer
+}
+
+func newPieceReader(src io.Reader, pieceMd5s []string) *pieceReader {
+	return &pieceReader{
+		src:       src,
+		pieceMd5s: pieceMd5s,
+		content:   &bytes.Buffer{},
+	}
+}
+
+func (pr *pieceReader) Read(p []byte) (int, error) {
+	for pr.content.Len() == 0 {
+		if err := pr.readPiece(); err != nil {
+			return 0, err
+		}
+	}
+	return pr.content.Read(p)
+}
+
+// readPiece reads and verifies the next piece, and then fills the content with it.
+func (pr *pieceReader) readPiece() error {
+	header := make([]byte, config.PieceHeadSize)
+	if _, err := io.ReadFull(pr.src, header); err != nil {
+		if err == io.EOF && pr.pieceNum == len(pr.pieceMd5s) {
+			return io.EOF
+		}
+		return errors.Wrapf(err, "failed to read header of piece %d", pr.pieceNum)
+	}
+	if pr.pieceNum >= len(pr.pieceMd5s) {
+		return fmt.Errorf("unexpected piece %d, expected %d pieces", pr.pieceNum, len(pr.pieceMd5s))
+	}
+
+	pieceLen := getContentLengthByHeader(binary.BigEndian.Uint32(header))
+	pr.content.Reset()
+	if _, err := io.CopyN(pr.content, pr.src, int64(pieceLen)+1); err != nil {
+		return errors.Wrapf(err, "failed to read content of piece %d", pr.pieceNum)
+	}
+	if tailer := pr.content.Bytes()[pieceLen]; tailer != config.PieceTailChar {
+		return fmt.Errorf("unexpected tailer %v of piece %d", tailer, pr.pieceNum)
+	}
+
+	pieceMd5 := md5.New()
+	pieceMd5.Write(header)
+	pieceMd5.Write(pr.content.Bytes())
+	realMd5 := getPieceMd5Value(fileutils.GetMd5Sum(pieceMd5, nil), pieceLen+config.PieceWrapSize)
+	if realMd5 != pr.pieceMd5s[pr.pieceNum] {
+		return fmt.Errorf("piece %d md5 not match expected: %s real: %s", pr.pieceNum, pr.pieceMd5s[pr.pieceNum], realMd5)
+	}
+
+	pr.content.Truncate(int(pieceLen))
+	pr.pieceNum++
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
+	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/store"
+
+	"github.com/go-check/check"
+)
+
+type SiblingTestSuite struct {
+	workHome string
+	manager  *Manager
+}
+
+func init() {
+	check.Suite(&SiblingTestSuite{})
+}
+
+func (s *SiblingTestSuite) SetUpSuite(c *check.C) {
+	s.workHome, _ = ioutil.TempDir("/tmp", "supernode-cdn-SiblingTestSuite-")
+	fileStore, err := store.NewStore(store.LocalStorageDriver, store.NewLocalStorage, "baseDir: "+s.workHome)
+	c.Assert(err, check.IsNil)
+
+	cfg := config.NewConfig()
+	cfg.DownloadPort = 8001
+	s.manager = &Manager{
+		cfg:             cfg,
+		metaDataManager: newFileMetaDataManager(fileStore),
+		digests:         syncmap.NewSyncMap(),
+	}
+}
+
+func (s *SiblingTestSuite) TearDownSuite(c *check.C) {
+	if s.workHome != "" {
+		if err := os.RemoveAll(s.workHome); err != nil {
+			fmt.Printf("remove path: %s error", s.workHome)
+		}
+	}
+}
+
+// wrapPieces wraps the contents as the pieces stored by cdn and returns the piece md5s.
+func wrapPieces(contents ...string) ([]byte, []string) {
+	var buf bytes.Buffer
+	var pieceMd5s []string
+	for _, content := range contents {
+		piece := make([]byte, config.PieceHeadSize)
+		binary.BigEndian.PutUint32(piece, getPieceHeader(int32(len(content)), config.DefaultPieceSize))
+		piece = append(piece, content...)
+		piece = append(piece, config.PieceTailChar)
+
+		buf.Write(piece)
+		pieceMd5 := md5.New()
+		pieceMd5.Write(piece)
+		pieceMd5s = append(pieceMd5s, getPieceMd5Value(fileutils.GetMd5Sum(pieceMd5, nil), int32(len(piece))))
+	}
+	return buf.Bytes(), pieceMd5s
+}
+
+func (s *SiblingTestSuite) TestPieceReader(c *check.C) {
+	data, pieceMd5s := wrapPieces("hello", "", "world")
+
+	content, err := ioutil.ReadAll(newPieceReader(bytes.NewReader(data), pieceMd5s))
+	c.Assert(err, check.IsNil)
+	c.Check(string(content), check.Equals, "helloworld")
+
+	// the corrupted piece is never returned
+	corrupted := append([]byte{}, data...)
+	corrupted[config.PieceHeadSize] = 'j'
+	content, err = ioutil.ReadAll(newPieceReader(bytes.NewReader(corrupted), pieceMd5s))
+	c.Check(err, check.NotNil)
+	c.Check(string(content), check.Equals, "")
+
+	// missing pieces
+	content, err = ioutil.ReadAll(newPieceReader(bytes.NewReader(data[:len(data)-1]), pieceMd5s))
+	c.Check(err, check.NotNil)
+	c.Check(string(content), check.Equals, "hello")
+
+	// unexpected pieces
+	_, err = ioutil.ReadAll(newPieceReader(bytes.NewReader(data), pieceMd5s[:1]))
+	c.Check(err, check.NotNil)
+}
+
+func (s *SiblingTestSuite) TestGetCache(c *check.C) {
+	ctx := context.Background()
+	_, pieceMd5s := wrapPieces("hello")
+	metaData := &fileMetaData{
+		TaskID:     "completed",
+		PieceSize:  10,
+		FileLength: 10,
+		RealMd5:    "md5",
+		Finish:     true,
+		Success:    true,
+	}
+	c.Assert(s.manager.metaDataManager.writeFileMetaData(ctx, metaData), check.IsNil)
+	c.Assert(s.manager.metaDataManager.writePieceMD5s(ctx, metaData.TaskID, metaData.RealMd5, pieceMd5s), check.IsNil)
+	c.Assert(s.manager.metaDataManager.writeFileMetaData(ctx, &fileMetaData{TaskID: "running"}), check.IsNil)
+	s.manager.digests.Add(metaData.RealMd5, metaData.TaskID)
+
+	info, err := s.manager.GetCache(ctx, "completed", "")
+	c.Assert(err, check.IsNil)
+	c.Check(info, check.DeepEquals, &types.CacheInfo{
+		TaskID:       "completed",
+		FileLength:   10,
+		RealMd5:      "md5",
+		PieceSize:    10,
+		PieceMd5s:    pieceMd5s,
+		DownloadPort: 8001,
+		DownloadPath: "/download/com/completed",
+	})
+
+	// look up by the file md5
+	info, err = s.manager.GetCache(ctx, "running", "md5")
+	c.Assert(err, check.IsNil)
+	c.Check(info.TaskID, check.Equals, "completed")
+
+	_, err = s.manager.GetCache(ctx, "running", "")
+	c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
+	_, err = s.manager.GetCache(ctx, "completed", "unknown")
+	c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
+	_, err = s.manager.GetCache(ctx, "", "")
+	c.Check(errortypes.IsEmptyValue(err), check.Equals, true)
+}
+
+func (s *SiblingTestSuite) TestRemoveDigest(c *check.C) {
+	ctx := context.Background()
+	c.Assert(s.manager.metaDataManager.writeFileMetaData(ctx, &fileMetaData{
+		TaskID:  "deleted",
+		RealMd5: "md5-deleted",
+		Finish:  true,
+		Success: true,
+	}), check.IsNil)
+	s.manager.digests.Add("md5-deleted", "deleted")
+	s.manager.removeDigest(ctx, "deleted")
+	_, err := s.manager.digests.Get("md5-deleted")
+	c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
+
+	// the digest which maps to another task is kept.
+	s.manager.digests.Add("md5-deleted", "another")
+	s.manager.removeDigest(ctx, "deleted")
+	id, err := s.manager.digests.GetAsString("md5-deleted")
+	c.Check(err, check.IsNil)
+	c.Check(id, check.Equals, "another")
+}
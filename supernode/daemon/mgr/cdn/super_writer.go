@@ -56,6 +56,8 @@ func newSuperWriter(cdnStore *store.Store, cdnReporter *reporter) *superWriter {
 }
 
 // startWriter writes the stream data from the reader to the underlying storage.
+// It stops writing and returns the error of ctx once the ctx is done,
+// and all the pieces being written have been finished when it returns.
 func (cw *superWriter) startWriter(ctx context.Context, cfg *config.Config, reader io.Reader,
 	task *types.TaskInfo, startPieceNum int, httpFileLength int64, pieceContSize int32) (*downloadMetadata, error) {
 	// realFileLength is used to calculate the file Length dynamically
@@ -77,6 +79,12 @@ func (cw *superWriter) startWriter(ctx context.Context, cfg *config.Config, read
 	cw.writerPool(ctx, wg, routineCount, jobCh)
 
 	for {
+		if err := ctx.Err(); err != nil {
+			close(jobCh)
+			wg.Wait()
+			return nil, err
+		}
+
 		n, e := reader.Read(buf)
 		if n > 0 {
 			logrus.Debugf("success to read content with length: %d", n)
@@ -129,6 +137,10 @@ func (cw *superWriter) startWriter(ctx context.Context, cfg *config.Config, read
 		}
 		if e != nil {
 			close(jobCh)
+			wg.Wait()
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
 			return nil, e
 		}
 	}
@@ -79,6 +79,20 @@ func (s *SuperWriterTestSuite) TestStartWriter(c *check.C) {
 	checkFileSize(s.writer.cdnStore, task.ID, expectedSize, c)
 }
 
+func (s *SuperWriterTestSuite) TestStartWriterCanceled(c *check.C) {
+	var pieceContSize = int32(10)
+	task := &types.TaskInfo{
+		ID:        "canceledTaskID",
+		PieceSize: pieceContSize + config.PieceWrapSize,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := strings.NewReader("hello dragonfly")
+	_, err := s.writer.startWriter(ctx, nil, f, task, 0, int64(f.Len()), pieceContSize)
+	c.Check(err, check.Equals, context.Canceled)
+}
+
 func (s *SuperWriterTestSuite) TestWriteToFile(c *check.C) {
 	var pieceContSize = int32(15)
 	var pieceSize = pieceContSize + config.PieceWrapSize
@@ -57,6 +57,11 @@ func (cw *superWriter) writerPool(ctx context.Context, wg *sync.WaitGroup, n int
 		wg.Add(1)
 		go func(i int) {
 			for job := range jobCh {
+				// drop the left jobs once the download is canceled.
+				if ctx.Err() != nil {
+					continue
+				}
+
 				var pieceMd5 = md5.New()
 				if err := cw.writeToFile(ctx, job.pieceContent, job.taskID, job.pieceNum, job.pieceContentSize, job.pieceSize, pieceMd5); err != nil {
 					logrus.Errorf("failed to write taskID %s pieceNum %d file: %v", job.taskID, job.pieceNum, err)
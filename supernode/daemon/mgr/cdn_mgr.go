@@ -44,16 +44,31 @@ type CDNMgr interface {
 	// GetGCTaskIDs returns the taskIDs that should exec GC operations as a string slice.
 	//
 	// It should return nil when the free disk of cdn storage is lager than config.YoungGCThreshold.
-	// It should return all taskIDs that are not running when the free disk of cdn storage is less than config.FullGCThreshold.
-	GetGCTaskIDs(ctx context.Context, taskMgr TaskMgr) ([]string, error)
+	// It should return all taskIDs that are not running when the free disk of cdn storage is less than config.FullGCThreshold,
+	// and the fullGC will be true.
+	GetGCTaskIDs(ctx context.Context, taskMgr TaskMgr) (taskIDs []string, fullGC bool, err error)
+
+	// GetQuotaGCTaskIDs returns the taskIDs that should be deleted to keep the cached files
+	// of each namespace within its cacheQuota, and they are grouped by the namespace.
+	GetQuotaGCTaskIDs(ctx context.Context, taskMgr TaskMgr) (map[string][]string, error)
+
+	// GetFileSize returns the size in bytes of the file downloaded by CDN with specified taskID.
+	GetFileSize(ctx context.Context, taskID string) (int64, error)
 
 	// GetPieceMD5 gets the piece Md5 accorrding to the specified taskID and pieceNum.
 	GetPieceMD5(ctx context.Context, taskID string, pieceNum int, pieceRange, source string) (pieceMd5 string, err error)
 
+	// GetCache returns the information of the completed cache with specified taskID,
+	// or the one whose file md5 equals md5 if there is no such cache of taskID.
+	GetCache(ctx context.Context, taskID, md5 string) (*types.CacheInfo, error)
+
 	// CheckFile checks the file whether exists.
 	CheckFile(ctx context.Context, taskID string) bool
 
 	// Delete the cdn meta with specified taskID.
 	// The file on the disk will be deleted when the force is true.
 	Delete(ctx context.Context, taskID string, force bool) error
+
+	// SetRate sets the bandwidth in bytes per second shared by the downloads from the source.
+	SetRate(rate int64)
 }
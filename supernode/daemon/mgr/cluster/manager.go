@@ -0,0 +1,275 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/hashring"
+	"github.com/dragonflyoss/Dragonfly/pkg/httputils"
+	"github.com/dragonflyoss/Dragonfly/pkg/metricsutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+var _ mgr.ClusterMgr = &Manager{}
+
+const (
+	// maxCheckFailures is the number of the failed health checks in a row
+	// after which the member is treated as down.
+	maxCheckFailures = 3
+
+	// checkTimeout is the timeout of a health check.
+	checkTimeout = 3 * time.Second
+)
+
+type metrics struct {
+	members *prometheus.GaugeVec
+}
+
+func newMetrics(register prometheus.Registerer) *metrics {
+	return &metrics{
+		members: metricsutils.NewGauge(config.SubsystemSupernode, "cluster_members",
+			"Current number of the cluster members", []string{"status"}, register),
+	}
+}
+
+// member is the state of a member of the cluster.
+type member struct {
+	address       string
+	status        string
+	failures      int
+	lastCheckTime time.Time
+}
+
+// Manager is an implementation of the interface of ClusterMgr.
+type Manager struct {
+	cfg *config.Config
+
+	// self is the address of the supernode itself.
+	self string
+
+	// ring maps the tasks to the members which are UP.
+	ring *hashring.HashRing
+
+	// members are all the members of the cluster except the supernode itself.
+	members map[string]*member
+	lock    sync.RWMutex
+
+	// checkHealth checks whether the member is available.
+	checkHealth func(address string) error
+
+	metrics *metrics
+}
+
+// NewManager returns a new Manager.
+func NewManager(cfg *config.Config, register prometheus.Registerer) (*Manager, error) {
+	cm := &Manager{
+		cfg:         cfg,
+		self:        net.JoinHostPort(cfg.AdvertiseIP, strconv.Itoa(cfg.ListenPort)),
+		ring:        hashring.New(hashring.DefaultReplicas),
+		members:     make(map[string]*member),
+		checkHealth: ping,
+		metrics:     newMetrics(register),
+	}
+	cm.ring.Add(cm.self)
+	cm.SetMembers(context.Background(), cfg.ClusterMembers)
+	return cm, nil
+}
+
+// StartHealthCheck starts to check the health of the members by fixed delay.
+func (cm *Manager) StartHealthCheck(ctx context.Context) {
+	if cm.cfg.ClusterCheckInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(cm.cfg.ClusterCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cm.checkMembers(ctx)
+			}
+		}
+	}()
+}
+
+// GetOwner returns the address of the member which owns the task.
+func (cm *Manager) GetOwner(ctx context.Context, taskID string) (string, bool) {
+	owner := cm.ring.Get(taskID)
+	if owner == "" {
+		return cm.self, true
+	}
+	return owner, owner == cm.self
+}
+
+// SetMembers updates the members of the cluster.
+// The new members are treated as UP until they fail the health checks.
+func (cm *Manager) SetMembers(ctx context.Context, members []string) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	expected := make(map[string]bool)
+	for _, address := range members {
+		if address == cm.self || address == "" {
+			continue
+		}
+		expected[address] = true
+		if _, ok := cm.members[address]; !ok {
+			cm.members[address] = &member{
+				address: address,
+				status:  types.ClusterMemberStatusUP,
+			}
+			cm.ring.Add(address)
+			logrus.Infof("cluster member %s joins", address)
+		}
+	}
+
+	for address := range cm.members {
+		if !expected[address] {
+			delete(cm.members, address)
+			cm.ring.Remove(address)
+			logrus.Infof("cluster member %s leaves", address)
+		}
+	}
+	cm.updateMetrics()
+}
+
+// ListMembers returns all the members of the cluster, and the supernode itself comes first.
+func (cm *Manager) ListMembers(ctx context.Context) []*types.ClusterMember {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	result := []*types.ClusterMember{{
+		Address:       cm.self,
+		Self:          true,
+		Status:        types.ClusterMemberStatusUP,
+		LastCheckTime: strfmt.DateTime(time.Now()),
+	}}
+	for _, address := range cm.sortedAddresses() {
+		m := cm.members[address]
+		result = append(result, &types.ClusterMember{
+			Address:       m.address,
+			Status:        m.status,
+			LastCheckTime: strfmt.DateTime(m.lastCheckTime),
+		})
+	}
+	return result
+}
+
+// checkMembers checks the health of all the members concurrently,
+// and updates the ring according to the results.
+func (cm *Manager) checkMembers(ctx context.Context) {
+	cm.lock.RLock()
+	addresses := cm.sortedAddresses()
+	cm.lock.RUnlock()
+
+	results := make([]error, len(addresses))
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+			results[i] = cm.checkHealth(address)
+		}(i, address)
+	}
+	wg.Wait()
+
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	now := time.Now()
+	for i, address := range addresses {
+		m, ok := cm.members[address]
+		if !ok {
+			// the member has left during the check.
+			continue
+		}
+		m.lastCheckTime = now
+
+		if results[i] == nil {
+			m.failures = 0
+			if m.status == types.ClusterMemberStatusDOWN {
+				m.status = types.ClusterMemberStatusUP
+				cm.ring.Add(address)
+				logrus.Infof("cluster member %s is up", address)
+			}
+			continue
+		}
+
+		m.failures++
+		logrus.Warnf("failed to check the health of cluster member %s(%d/%d): %v",
+			address, m.failures, maxCheckFailures, results[i])
+		if m.status == types.ClusterMemberStatusUP && m.failures >= maxCheckFailures {
+			m.status = types.ClusterMemberStatusDOWN
+			cm.ring.Remove(address)
+			logrus.Warnf("cluster member %s is down", address)
+		}
+	}
+	cm.updateMetrics()
+}
+
+// sortedAddresses returns the sorted addresses of the members except the supernode itself,
+// and it should be called with the lock held.
+func (cm *Manager) sortedAddresses() []string {
+	addresses := make([]string, 0, len(cm.members))
+	for address := range cm.members {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// updateMetrics should be called with the lock held.
+func (cm *Manager) updateMetrics() {
+	up, down := 1, 0
+	for _, m := range cm.members {
+		if m.status == types.ClusterMemberStatusUP {
+			up++
+		} else {
+			down++
+		}
+	}
+	cm.metrics.members.WithLabelValues(types.ClusterMemberStatusUP).Set(float64(up))
+	cm.metrics.members.WithLabelValues(types.ClusterMemberStatusDOWN).Set(float64(down))
+}
+
+// ping checks the health of the member by its ping API.
+func ping(address string) error {
+	code, _, err := httputils.Get(fmt.Sprintf("http://%s/_ping", address), checkTimeout)
+	if err != nil {
+		return err
+	}
+	if !httputils.HTTPStatusOk(code) {
+		return fmt.Errorf("unexpected status code: %d", code)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/go-check/check"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type ClusterMgrTestSuite struct{}
+
+func init() {
+	check.Suite(&ClusterMgrTestSuite{})
+}
+
+func newTestManager(c *check.C, members ...string) *Manager {
+	cfg := config.NewConfig()
+	cfg.AdvertiseIP = "127.0.0.1"
+	cfg.ListenPort = 8002
+	cfg.ClusterMembers = members
+
+	cm, err := NewManager(cfg, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+	return cm
+}
+
+func taskIDs(n int) []string {
+	var result []string
+	for i := 0; i < n; i++ {
+		result = append(result, fmt.Sprintf("task-%d", i))
+	}
+	return result
+}
+
+func (s *ClusterMgrTestSuite) TestGetOwnerWithoutCluster(c *check.C) {
+	cm := newTestManager(c)
+	for _, taskID := range taskIDs(10) {
+		owner, isSelf := cm.GetOwner(context.Background(), taskID)
+		c.Check(owner, check.Equals, "127.0.0.1:8002")
+		c.Check(isSelf, check.Equals, true)
+	}
+}
+
+func (s *ClusterMgrTestSuite) TestGetOwner(c *check.C) {
+	members := []string{"127.0.0.1:8002", "127.0.0.2:8002", "127.0.0.3:8002"}
+	cm := newTestManager(c, members...)
+
+	owners := make(map[string]int)
+	for _, taskID := range taskIDs(300) {
+		owner, isSelf := cm.GetOwner(context.Background(), taskID)
+		c.Check(isSelf, check.Equals, owner == "127.0.0.1:8002")
+		owners[owner]++
+	}
+	c.Check(owners, check.HasLen, 3)
+
+	// all the members agree on the owners.
+	other := newTestManager(c, members...)
+	other.self = "127.0.0.2:8002"
+	for _, taskID := range taskIDs(300) {
+		owner, _ := cm.GetOwner(context.Background(), taskID)
+		otherOwner, _ := other.GetOwner(context.Background(), taskID)
+		c.Check(otherOwner, check.Equals, owner)
+	}
+}
+
+func (s *ClusterMgrTestSuite) TestSetMembers(c *check.C) {
+	cm := newTestManager(c, "127.0.0.2:8002", "127.0.0.3:8002")
+	before := make(map[string]string)
+	for _, taskID := range taskIDs(300) {
+		before[taskID], _ = cm.GetOwner(context.Background(), taskID)
+	}
+
+	// only the tasks of the left member change their owners.
+	cm.SetMembers(context.Background(), []string{"127.0.0.2:8002"})
+	for taskID, owner := range before {
+		now, _ := cm.GetOwner(context.Background(), taskID)
+		if owner != "127.0.0.3:8002" {
+			c.Check(now, check.Equals, owner)
+		} else {
+			c.Check(now, check.Not(check.Equals), owner)
+		}
+	}
+
+	members := cm.ListMembers(context.Background())
+	c.Assert(members, check.HasLen, 2)
+	c.Check(members[0].Address, check.Equals, "127.0.0.1:8002")
+	c.Check(members[0].Self, check.Equals, true)
+	c.Check(members[1].Address, check.Equals, "127.0.0.2:8002")
+	c.Check(members[1].Status, check.Equals, types.ClusterMemberStatusUP)
+}
+
+func (s *ClusterMgrTestSuite) TestCheckMembers(c *check.C) {
+	cm := newTestManager(c, "127.0.0.2:8002", "127.0.0.3:8002")
+	down := map[string]bool{"127.0.0.3:8002": true}
+	cm.checkHealth = func(address string) error {
+		if down[address] {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	}
+
+	// the member is still UP before it fails maxCheckFailures times in a row.
+	for i := 0; i < maxCheckFailures-1; i++ {
+		cm.checkMembers(context.Background())
+	}
+	c.Check(cm.ring.Has("127.0.0.3:8002"), check.Equals, true)
+
+	cm.checkMembers(context.Background())
+	c.Check(cm.ring.Has("127.0.0.3:8002"), check.Equals, false)
+	c.Check(cm.members["127.0.0.3:8002"].status, check.Equals, types.ClusterMemberStatusDOWN)
+	c.Check(prom_testutil.ToFloat64(cm.metrics.members.WithLabelValues(types.ClusterMemberStatusUP)), check.Equals, float64(2))
+	c.Check(prom_testutil.ToFloat64(cm.metrics.members.WithLabelValues(types.ClusterMemberStatusDOWN)), check.Equals, float64(1))
+	for _, taskID := range taskIDs(100) {
+		owner, _ := cm.GetOwner(context.Background(), taskID)
+		c.Check(owner, check.Not(check.Equals), "127.0.0.3:8002")
+	}
+
+	// the member owns the tasks again once it recovers.
+	delete(down, "127.0.0.3:8002")
+	cm.checkMembers(context.Background())
+	c.Check(cm.ring.Has("127.0.0.3:8002"), check.Equals, true)
+	c.Check(cm.members["127.0.0.3:8002"].status, check.Equals, types.ClusterMemberStatusUP)
+}
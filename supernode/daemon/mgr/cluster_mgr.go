@@ -0,0 +1,43 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mgr
+
+import (
+	"context"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+)
+
+// ClusterMgr as an interface defines all operations about the supernode cluster.
+// Each task is owned by one member of the cluster through consistent hashing,
+// so that a file is only cached by one supernode instead of all of them.
+type ClusterMgr interface {
+	// StartHealthCheck starts to check the health of the members with a new goroutine.
+	// The member which is down is removed from the hash ring until it recovers.
+	StartHealthCheck(ctx context.Context)
+
+	// GetOwner returns the address of the member which owns the task,
+	// and whether the owner is the supernode itself.
+	GetOwner(ctx context.Context, taskID string) (owner string, isSelf bool)
+
+	// SetMembers updates the members of the cluster.
+	// Only the tasks of the joined or left members change their owners.
+	SetMembers(ctx context.Context, members []string)
+
+	// ListMembers returns all the members of the cluster with their health status.
+	ListMembers(ctx context.Context) []*types.ClusterMember
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
@@ -66,15 +67,21 @@ type Manager struct {
 	dfgetTaskStore *dutil.Store
 	ptoc           *syncmap.SyncMap
 	metrics        *metrics
+	webhookMgr     mgr.WebhookMgr
+
+	// statusLock guards the Status of the dfgetTasks in the dfgetTaskStore, which is updated
+	// in place, so the dfgetTasks are returned to the callers as copies made under it.
+	statusLock sync.RWMutex
 }
 
 // NewManager returns a new Manager.
-func NewManager(cfg *config.Config, register prometheus.Registerer) (*Manager, error) {
+func NewManager(cfg *config.Config, webhookMgr mgr.WebhookMgr, register prometheus.Registerer) (*Manager, error) {
 	return &Manager{
 		cfg:            cfg,
 		dfgetTaskStore: dutil.NewStore(),
 		ptoc:           syncmap.NewSyncMap(),
 		metrics:        newMetrics(register),
+		webhookMgr:     webhookMgr,
 	}, nil
 }
 
@@ -117,7 +124,15 @@ func (dtm *Manager) Add(ctx context.Context, dfgetTask *types.DfGetTask) error {
 
 // Get a dfgetTask info with specified clientID and taskID.
 func (dtm *Manager) Get(ctx context.Context, clientID, taskID string) (dfgetTask *types.DfGetTask, err error) {
-	return dtm.getDfgetTask(clientID, taskID)
+	dfgetTask, err = dtm.getDfgetTask(clientID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtm.statusLock.RLock()
+	defer dtm.statusLock.RUnlock()
+	copied := *dfgetTask
+	return &copied, nil
 }
 
 // GetCIDByPeerIDAndTaskID returns cid with specified peerID and taskID.
@@ -182,8 +197,24 @@ func (dtm *Manager) GetCIDAndTaskIDsByPeerID(ctx context.Context, peerID string)
 }
 
 // List returns the list of dfgetTask.
+// The filter supports the keys "taskId", "status" and "callSystem",
+// and only the dfgetTasks matching all of them will be returned.
 func (dtm *Manager) List(ctx context.Context, filter map[string]string) (dfgetTaskList []*types.DfGetTask, err error) {
-	return nil, nil
+	dtm.statusLock.RLock()
+	defer dtm.statusLock.RUnlock()
+
+	for _, v := range dtm.dfgetTaskStore.List() {
+		dfgetTask, ok := v.(*types.DfGetTask)
+		if !ok {
+			return nil, errors.Wrapf(errortypes.ErrConvertFailed, "value: %v", v)
+		}
+		if !matchFilter(dfgetTask, filter) {
+			continue
+		}
+		copied := *dfgetTask
+		dfgetTaskList = append(dfgetTaskList, &copied)
+	}
+	return dfgetTaskList, nil
 }
 
 // Delete deletes a dfgetTask with clientID and taskID.
@@ -198,6 +229,9 @@ func (dtm *Manager) Delete(ctx context.Context, clientID, taskID string) error {
 		return err
 	}
 	dtm.ptoc.Delete(generatePeerKey(dfgetTask.PeerID, dfgetTask.TaskID))
+
+	dtm.statusLock.Lock()
+	defer dtm.statusLock.Unlock()
 	if !dtm.cfg.IsSuperCID(clientID) {
 		dtm.metrics.dfgetTasks.WithLabelValues(dfgetTask.CallSystem, dfgetTask.Status).Dec()
 	}
@@ -211,20 +245,81 @@ func (dtm *Manager) UpdateStatus(ctx context.Context, clientID, taskID, status s
 		return err
 	}
 
+	dtm.statusLock.Lock()
+	changed := dfgetTask.Status != types.DfGetTaskStatusSUCCESS && dfgetTask.Status != status
 	if dfgetTask.Status != types.DfGetTaskStatusSUCCESS {
 		dtm.metrics.dfgetTasks.WithLabelValues(dfgetTask.CallSystem, dfgetTask.Status).Dec()
 		dtm.metrics.dfgetTasks.WithLabelValues(dfgetTask.CallSystem, status).Inc()
 		dfgetTask.Status = status
 	}
+	updated := *dfgetTask
+	dtm.statusLock.Unlock()
 
 	// Add the total failed count.
-	if dfgetTask.Status == types.DfGetTaskStatusFAILED {
-		dtm.metrics.dfgetTasksFailCount.WithLabelValues(dfgetTask.CallSystem).Inc()
+	if updated.Status == types.DfGetTaskStatusFAILED {
+		dtm.metrics.dfgetTasksFailCount.WithLabelValues(updated.CallSystem).Inc()
 	}
 
+	if changed && !dtm.cfg.IsSuperCID(clientID) {
+		dtm.notifyStatus(ctx, &updated)
+	}
 	return nil
 }
 
+// notifyStatus notifies the webhooks when the dfgetTask succeeds or fails,
+// and when all the dfgetTasks of the task complete.
+func (dtm *Manager) notifyStatus(ctx context.Context, dfgetTask *types.DfGetTask) {
+	var event string
+	switch dfgetTask.Status {
+	case types.DfGetTaskStatusSUCCESS:
+		event = types.WebhookEventEventDFGETSUCCESS
+	case types.DfGetTaskStatusFAILED:
+		event = types.WebhookEventEventDFGETFAILED
+	default:
+		return
+	}
+	dtm.webhookMgr.Notify(ctx, &types.WebhookEvent{
+		Event:  event,
+		TaskID: dfgetTask.TaskID,
+		CID:    dfgetTask.CID,
+		Status: dfgetTask.Status,
+	})
+
+	succeeded, failed, completed := dtm.countCompletedClients(dfgetTask.TaskID)
+	if !completed {
+		return
+	}
+	dtm.webhookMgr.Notify(ctx, &types.WebhookEvent{
+		Event:            types.WebhookEventEventCLIENTSCOMPLETED,
+		TaskID:           dfgetTask.TaskID,
+		SucceededClients: succeeded,
+		FailedClients:    failed,
+	})
+}
+
+// countCompletedClients counts the succeeded and failed dfgetTasks of the task,
+// and completed is false if the task still has the active clients.
+func (dtm *Manager) countCompletedClients(taskID string) (succeeded, failed int64, completed bool) {
+	dtm.statusLock.RLock()
+	defer dtm.statusLock.RUnlock()
+
+	for _, v := range dtm.dfgetTaskStore.List() {
+		t, ok := v.(*types.DfGetTask)
+		if !ok || t.TaskID != taskID || dtm.cfg.IsSuperCID(t.CID) {
+			continue
+		}
+		switch t.Status {
+		case types.DfGetTaskStatusSUCCESS:
+			succeeded++
+		case types.DfGetTaskStatusFAILED:
+			failed++
+		default:
+			return 0, 0, false
+		}
+	}
+	return succeeded, failed, true
+}
+
 // getDfgetTask gets a DfGetTask from dfgetTaskStore with specified clientID and taskID.
 func (dtm *Manager) getDfgetTask(clientID, taskID string) (*types.DfGetTask, error) {
 	key, err := generateKey(clientID, taskID)
@@ -243,6 +338,27 @@ func (dtm *Manager) getDfgetTask(clientID, taskID string) (*types.DfGetTask, err
 	return nil, errors.Wrapf(errortypes.ErrConvertFailed, "clientID: %s, taskID: %s: %v", clientID, taskID, v)
 }
 
+// matchFilter returns whether the dfgetTask matches all the values of the filter.
+func matchFilter(dfgetTask *types.DfGetTask, filter map[string]string) bool {
+	for k, v := range filter {
+		var value string
+		switch k {
+		case "taskId":
+			value = dfgetTask.TaskID
+		case "status":
+			value = dfgetTask.Status
+		case "callSystem":
+			value = dfgetTask.CallSystem
+		default:
+			continue
+		}
+		if value != v {
+			return false
+		}
+	}
+	return true
+}
+
 // generateKey generates a key for a dfgetTask.
 func generateKey(cID, taskID string) (string, error) {
 	if stringutils.IsEmptyStr(cID) {
@@ -23,8 +23,10 @@ import (
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/mock"
 
 	"github.com/go-check/check"
+	"github.com/golang/mock/gomock"
 	"github.com/prometheus/client_golang/prometheus"
 	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
 )
@@ -38,16 +40,26 @@ func init() {
 }
 
 type DfgetTaskMgrTestSuite struct {
-	cfg *config.Config
+	cfg            *config.Config
+	mockCtl        *gomock.Controller
+	mockWebhookMgr *mock.MockWebhookMgr
 }
 
 func (s *DfgetTaskMgrTestSuite) SetUpSuite(c *check.C) {
 	s.cfg = config.NewConfig()
 	s.cfg.SetCIDPrefix("127.0.0.1")
+
+	s.mockCtl = gomock.NewController(c)
+	s.mockWebhookMgr = mock.NewMockWebhookMgr(s.mockCtl)
+	s.mockWebhookMgr.EXPECT().Notify(gomock.Any(), gomock.Any()).AnyTimes()
+}
+
+func (s *DfgetTaskMgrTestSuite) TearDownSuite(c *check.C) {
+	s.mockCtl.Finish()
 }
 
 func (s *DfgetTaskMgrTestSuite) TestDfgetTaskAdd(c *check.C) {
-	manager, _ := NewManager(s.cfg, prometheus.NewRegistry())
+	manager, _ := NewManager(s.cfg, s.mockWebhookMgr, prometheus.NewRegistry())
 	dfgetTasks := manager.metrics.dfgetTasks
 	dfgetTasksRegisterCount := manager.metrics.dfgetTasksRegisterCount
 
@@ -116,7 +128,7 @@ func (s *DfgetTaskMgrTestSuite) TestDfgetTaskAdd(c *check.C) {
 }
 
 func (s *DfgetTaskMgrTestSuite) TestDfgetTaskUpdate(c *check.C) {
-	manager, _ := NewManager(s.cfg, prometheus.NewRegistry())
+	manager, _ := NewManager(s.cfg, s.mockWebhookMgr, prometheus.NewRegistry())
 	dfgetTasksFailCount := manager.metrics.dfgetTasksFailCount
 
 	var testCases = []struct {
@@ -190,7 +202,7 @@ func (s *DfgetTaskMgrTestSuite) TestDfgetTaskUpdate(c *check.C) {
 }
 
 func (s *DfgetTaskMgrTestSuite) TestDfgetTaskDelete(c *check.C) {
-	manager, _ := NewManager(s.cfg, prometheus.NewRegistry())
+	manager, _ := NewManager(s.cfg, s.mockWebhookMgr, prometheus.NewRegistry())
 	dfgetTasks := manager.metrics.dfgetTasks
 
 	var testCases = []struct {
@@ -234,3 +246,110 @@ func (s *DfgetTaskMgrTestSuite) TestDfgetTaskDelete(c *check.C) {
 		c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
 	}
 }
+
+func (s *DfgetTaskMgrTestSuite) TestDfgetTaskList(c *check.C) {
+	manager, _ := NewManager(s.cfg, s.mockWebhookMgr, prometheus.NewRegistry())
+	for _, dfgetTask := range []*types.DfGetTask{
+		{CID: "foo", CallSystem: "foo", Path: "/peer/file/foo", TaskID: "test1", PeerID: "peer1"},
+		{CID: "bar", CallSystem: "bar", Path: "/peer/file/bar", TaskID: "test1", PeerID: "peer2",
+			Status: types.DfGetTaskStatusRUNNING},
+		{CID: "foo", CallSystem: "foo", Path: "/peer/file/foo", TaskID: "test2", PeerID: "peer1",
+			Status: types.DfGetTaskStatusRUNNING},
+	} {
+		c.Assert(manager.Add(context.Background(), dfgetTask), check.IsNil)
+	}
+
+	for _, tc := range []struct {
+		filter map[string]string
+		count  int
+	}{
+		{nil, 3},
+		{map[string]string{"taskId": "test1"}, 2},
+		{map[string]string{"status": types.DfGetTaskStatusRUNNING}, 2},
+		{map[string]string{"status": types.DfGetTaskStatusRUNNING, "callSystem": "foo"}, 1},
+		{map[string]string{"taskId": "test3"}, 0},
+	} {
+		dfgetTasks, err := manager.List(context.Background(), tc.filter)
+		c.Assert(err, check.IsNil)
+		c.Check(dfgetTasks, check.HasLen, tc.count)
+		for _, dfgetTask := range dfgetTasks {
+			c.Check(matchFilter(dfgetTask, tc.filter), check.Equals, true)
+		}
+	}
+}
+
+func (s *DfgetTaskMgrTestSuite) TestDfgetTaskListWhileUpdatingStatus(c *check.C) {
+	manager, _ := NewManager(s.cfg, s.mockWebhookMgr, prometheus.NewRegistry())
+	c.Assert(manager.Add(context.Background(), &types.DfGetTask{
+		CID: "foo", CallSystem: "foo", Path: "/peer/file/foo", TaskID: "test1", PeerID: "peer1",
+	}), check.IsNil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, status := range []string{types.DfGetTaskStatusRUNNING, types.DfGetTaskStatusFAILED, types.DfGetTaskStatusSUCCESS} {
+			manager.UpdateStatus(context.Background(), "foo", "test1", status)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		dfgetTasks, err := manager.List(context.Background(), nil)
+		c.Assert(err, check.IsNil)
+		c.Assert(dfgetTasks, check.HasLen, 1)
+		_ = dfgetTasks[0].Status
+	}
+	<-done
+
+	// the returned dfgetTasks are copies which don't change with the updates.
+	dfgetTask, err := manager.Get(context.Background(), "foo", "test1")
+	c.Assert(err, check.IsNil)
+	c.Check(dfgetTask.Status, check.Equals, types.DfGetTaskStatusSUCCESS)
+	dfgetTask.Status = types.DfGetTaskStatusFAILED
+	dfgetTask, err = manager.Get(context.Background(), "foo", "test1")
+	c.Assert(err, check.IsNil)
+	c.Check(dfgetTask.Status, check.Equals, types.DfGetTaskStatusSUCCESS)
+}
+
+func (s *DfgetTaskMgrTestSuite) TestDfgetTaskUpdateStatusNotifyWebhook(c *check.C) {
+	ctl := gomock.NewController(c)
+	defer ctl.Finish()
+	webhookMgr := mock.NewMockWebhookMgr(ctl)
+	manager, _ := NewManager(s.cfg, webhookMgr, prometheus.NewRegistry())
+
+	superCID := s.cfg.GetSuperCID("test1")
+	for _, dfgetTask := range []*types.DfGetTask{
+		{CID: "foo", Path: "/peer/file/foo", TaskID: "test1", PeerID: "peer1"},
+		{CID: "bar", Path: "/peer/file/bar", TaskID: "test1", PeerID: "peer2"},
+		{CID: superCID, Path: "/peer/file/super", TaskID: "test1", PeerID: "super"},
+		{CID: "foo", Path: "/peer/file/foo", TaskID: "test2", PeerID: "peer1"},
+	} {
+		c.Assert(manager.Add(context.Background(), dfgetTask), check.IsNil)
+	}
+
+	gomock.InOrder(
+		webhookMgr.EXPECT().Notify(gomock.Any(), &types.WebhookEvent{
+			Event:  types.WebhookEventEventDFGETSUCCESS,
+			TaskID: "test1",
+			CID:    "foo",
+			Status: types.DfGetTaskStatusSUCCESS,
+		}),
+		webhookMgr.EXPECT().Notify(gomock.Any(), &types.WebhookEvent{
+			Event:  types.WebhookEventEventDFGETFAILED,
+			TaskID: "test1",
+			CID:    "bar",
+			Status: types.DfGetTaskStatusFAILED,
+		}),
+		webhookMgr.EXPECT().Notify(gomock.Any(), &types.WebhookEvent{
+			Event:            types.WebhookEventEventCLIENTSCOMPLETED,
+			TaskID:           "test1",
+			SucceededClients: 1,
+			FailedClients:    1,
+		}),
+	)
+
+	// the dfgetTask of supernode is neither notified nor counted
+	c.Assert(manager.UpdateStatus(context.Background(), superCID, "test1", types.DfGetTaskStatusRUNNING), check.IsNil)
+	c.Assert(manager.UpdateStatus(context.Background(), "foo", "test1", types.DfGetTaskStatusSUCCESS), check.IsNil)
+	// the webhooks are only notified when the status changes
+	c.Assert(manager.UpdateStatus(context.Background(), "foo", "test1", types.DfGetTaskStatusSUCCESS), check.IsNil)
+	c.Assert(manager.UpdateStatus(context.Background(), "bar", "test1", types.DfGetTaskStatusFAILED), check.IsNil)
+}
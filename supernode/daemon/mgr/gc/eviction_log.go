@@ -0,0 +1,92 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/sirupsen/logrus"
+)
+
+// evictionLogSize is the max number of the eviction records kept in memory.
+const evictionLogSize = 1024
+
+// evictionLog keeps the most recent eviction records in a ring buffer.
+type evictionLog struct {
+	sync.RWMutex
+
+	records []*types.EvictionRecord
+
+	// next is the index where the next record will be put.
+	next int
+}
+
+func newEvictionLog(size int) *evictionLog {
+	return &evictionLog{
+		records: make([]*types.EvictionRecord, 0, size),
+	}
+}
+
+// add records that the candidate has been garbage collected at the specified time,
+// and returns the record.
+func (el *evictionLog) add(candidate *types.GCCandidate, now time.Time) *types.EvictionRecord {
+	record := &types.EvictionRecord{
+		ID:         candidate.ID,
+		Type:       candidate.Type,
+		Reason:     candidate.Reason,
+		Detail:     candidate.Detail,
+		FreedBytes: candidate.ReclaimableBytes,
+		Time:       strfmt.DateTime(now),
+	}
+	logrus.Infof("gc: evict %s(%s) reason(%s) freedBytes(%d): %s",
+		record.Type, record.ID, record.Reason, record.FreedBytes, record.Detail)
+
+	el.Lock()
+	defer el.Unlock()
+
+	if len(el.records) < cap(el.records) {
+		el.records = append(el.records, record)
+	} else {
+		el.records[el.next] = record
+	}
+	el.next = (el.next + 1) % cap(el.records)
+	return record
+}
+
+// list returns the records filtered by id from the latest to the oldest,
+// and the id is ignored if it's empty and the limit <= 0 means no limit.
+func (el *evictionLog) list(id string, limit int) []*types.EvictionRecord {
+	el.RLock()
+	defer el.RUnlock()
+
+	result := make([]*types.EvictionRecord, 0)
+	for i := 1; i <= len(el.records); i++ {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		record := el.records[(el.next-i+len(el.records))%len(el.records)]
+		if id != "" && record.ID != id {
+			continue
+		}
+		result = append(result, record)
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+
+	"github.com/go-check/check"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type EvictionLogTestSuite struct{}
+
+func init() {
+	check.Suite(&EvictionLogTestSuite{})
+}
+
+func (s *EvictionLogTestSuite) TestEvictionLog(c *check.C) {
+	el := newEvictionLog(3)
+	c.Check(el.list("", 0), check.HasLen, 0)
+
+	now := time.Now()
+	for _, id := range []string{"a", "b", "a", "c"} {
+		el.add(&types.GCCandidate{
+			ID:               id,
+			Type:             types.GCCandidateTypeTask,
+			Reason:           types.GCCandidateReasonDISKYOUNGGC,
+			ReclaimableBytes: 10,
+		}, now)
+	}
+
+	var cases = []struct {
+		id       string
+		limit    int
+		expected []string
+	}{
+		{id: "", limit: 0, expected: []string{"c", "a", "b"}},
+		{id: "", limit: 2, expected: []string{"c", "a"}},
+		{id: "a", limit: 0, expected: []string{"a"}},
+		{id: "d", limit: 0, expected: []string{}},
+	}
+	for _, tc := range cases {
+		records := el.list(tc.id, tc.limit)
+		ids := make([]string, 0)
+		for _, r := range records {
+			c.Check(r.FreedBytes, check.Equals, int64(10))
+			ids = append(ids, r.ID)
+		}
+		c.Check(ids, check.DeepEquals, tc.expected)
+	}
+}
@@ -18,15 +18,20 @@ package gc
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
 	"github.com/dragonflyoss/Dragonfly/supernode/util"
 
 	"github.com/sirupsen/logrus"
 )
 
 func (gcm *Manager) gcDisk(ctx context.Context) {
-	gcTaskIDs, err := gcm.cdnMgr.GetGCTaskIDs(ctx, gcm.taskMgr)
+	gcm.gcNamespaceQuota(ctx)
+
+	gcTaskIDs, fullGC, err := gcm.cdnMgr.GetGCTaskIDs(ctx, gcm.taskMgr)
 	if err != nil {
 		logrus.Errorf("gc disk: failed to get gc tasks: %v", err)
 		return
@@ -37,12 +42,32 @@ func (gcm *Manager) gcDisk(ctx context.Context) {
 	}
 
 	logrus.Debugf("gc disk: success to get gcTaskIDs(%d)", len(gcTaskIDs))
-	gcm.deleteTaskDisk(ctx, gcTaskIDs)
+	gcm.deleteTaskDisk(ctx, gcTaskIDs, fullGC)
+}
+
+// gcNamespaceQuota deletes the task files of the namespaces which exceed their cacheQuota,
+// and it's done before the gc by the available disk space.
+func (gcm *Manager) gcNamespaceQuota(ctx context.Context) {
+	gcTaskIDs, err := gcm.cdnMgr.GetQuotaGCTaskIDs(ctx, gcm.taskMgr)
+	if err != nil {
+		logrus.Errorf("gc disk: failed to get the gc tasks of namespaces: %v", err)
+		return
+	}
+
+	for namespace, taskIDs := range gcTaskIDs {
+		count := 0
+		for _, taskID := range taskIDs {
+			if gcm.deleteTaskFile(ctx, gcm.newQuotaGCCandidate(ctx, taskID, namespace)) {
+				count++
+			}
+		}
+		gcm.metrics.gcNamespaceDisksCount.WithLabelValues(namespace).Add(float64(count))
+		logrus.Infof("gc disk: success to gc task count(%d) of namespace %s", count, namespace)
+	}
 }
 
-func (gcm *Manager) deleteTaskDisk(ctx context.Context, gcTaskIDs []string) {
-	// NOTE: We only gc a certain percentage of tasks which calculated by the config.CleanRatio.
-	gcLen := (len(gcTaskIDs)*gcm.cfg.CleanRatio + 9) / 10
+func (gcm *Manager) deleteTaskDisk(ctx context.Context, gcTaskIDs []string, fullGC bool) {
+	gcLen := gcm.getDiskGCLen(len(gcTaskIDs))
 
 	count := 0
 	for _, taskID := range gcTaskIDs {
@@ -50,27 +75,145 @@ func (gcm *Manager) deleteTaskDisk(ctx context.Context, gcTaskIDs []string) {
 			break
 		}
 
-		util.GetLock(taskID, false)
+		if gcm.deleteTaskFile(ctx, gcm.newDiskGCCandidate(ctx, taskID, fullGC)) {
+			count++
+		}
+	}
+	gcm.metrics.gcDisksCount.WithLabelValues().Add(float64(count))
+	gcm.metrics.lastGCDisksTime.WithLabelValues().SetToCurrentTime()
 
-		// try to ensure the taskID is not using again
-		if _, err := gcm.taskMgr.Get(ctx, taskID); err == nil || !errortypes.IsDataNotFound(err) {
-			if err != nil {
-				logrus.Errorf("gc disk: failed to get taskID(%s): %v", taskID, err)
+	logrus.Debugf("gc disk: success to gc task count(%d), remainder count(%d)", count, len(gcTaskIDs)-count)
+}
+
+// deleteTaskFile deletes the files of the candidate task if it's not being used again,
+// and returns whether the files are deleted.
+func (gcm *Manager) deleteTaskFile(ctx context.Context, candidate *types.GCCandidate) bool {
+	taskID := candidate.ID
+	util.GetLock(taskID, false)
+	defer util.ReleaseLock(taskID, false)
+
+	if gcm.isTaskInUse(ctx, taskID) {
+		return false
+	}
+
+	if err := gcm.cdnMgr.Delete(ctx, taskID, true); err != nil {
+		logrus.Errorf("gc disk: failed to delete disk files with taskID(%s): %v", taskID, err)
+		return false
+	}
+	gcm.recordEviction(ctx, candidate)
+	return true
+}
+
+// isTaskInUse returns whether the taskID is being used again,
+// and the files of it shouldn't be deleted.
+func (gcm *Manager) isTaskInUse(ctx context.Context, taskID string) bool {
+	if _, err := gcm.taskMgr.Get(ctx, taskID); err == nil || !errortypes.IsDataNotFound(err) {
+		if err != nil {
+			logrus.Errorf("gc disk: failed to get taskID(%s): %v", taskID, err)
+		}
+		return true
+	}
+	return false
+}
+
+// canDeleteTaskFile returns whether the files of the taskID would be deleted by deleteTaskFile.
+func (gcm *Manager) canDeleteTaskFile(ctx context.Context, taskID string) bool {
+	util.GetLock(taskID, true)
+	defer util.ReleaseLock(taskID, true)
+
+	return !gcm.isTaskInUse(ctx, taskID)
+}
+
+// getDiskGCTasks returns the gc candidates of the tasks whose files would be deleted by disk gc.
+func (gcm *Manager) getDiskGCTasks(ctx context.Context) ([]*types.GCCandidate, error) {
+	quotaTaskIDs, err := gcm.cdnMgr.GetQuotaGCTaskIDs(ctx, gcm.taskMgr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the gc tasks of namespaces: %v", err)
+	}
+	gcTaskIDs, fullGC, err := gcm.cdnMgr.GetGCTaskIDs(ctx, gcm.taskMgr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gc tasks: %v", err)
+	}
+
+	// skip the tasks in use as gcNamespaceQuota and deleteTaskDisk do.
+	var candidates []*types.GCCandidate
+	quotaGC := make(map[string]bool)
+	for namespace, taskIDs := range quotaTaskIDs {
+		for _, taskID := range taskIDs {
+			if gcm.canDeleteTaskFile(ctx, taskID) {
+				candidates = append(candidates, gcm.newQuotaGCCandidate(ctx, taskID, namespace))
+				quotaGC[taskID] = true
 			}
-			util.ReleaseLock(taskID, false)
-			continue
 		}
+	}
 
-		if err := gcm.cdnMgr.Delete(ctx, taskID, true); err != nil {
-			logrus.Errorf("gc disk: failed to delete disk files with taskID(%s): %v", taskID, err)
-			util.ReleaseLock(taskID, false)
-			continue
+	// the tasks deleted by gcNamespaceQuota are not listed when deleteTaskDisk runs.
+	diskTaskIDs := make([]string, 0, len(gcTaskIDs))
+	for _, taskID := range gcTaskIDs {
+		if !quotaGC[taskID] {
+			diskTaskIDs = append(diskTaskIDs, taskID)
 		}
-		util.ReleaseLock(taskID, false)
-		count++
 	}
-	gcm.metrics.gcDisksCount.WithLabelValues().Add(float64(count))
-	gcm.metrics.lastGCDisksTime.WithLabelValues().SetToCurrentTime()
+	gcLen := gcm.getDiskGCLen(len(diskTaskIDs))
+	count := 0
+	for _, taskID := range diskTaskIDs {
+		if count >= gcLen {
+			break
+		}
+		if gcm.canDeleteTaskFile(ctx, taskID) {
+			candidates = append(candidates, gcm.newDiskGCCandidate(ctx, taskID, fullGC))
+			count++
+		}
+	}
+	return candidates, nil
+}
 
-	logrus.Debugf("gc disk: success to gc task count(%d), remainder count(%d)", count, len(gcTaskIDs)-count)
+// getDiskGCLen returns the number of tasks to be deleted in a disk gc pass.
+//
+// NOTE: We only gc a certain percentage of tasks which calculated by the config.CleanRatio.
+func (gcm *Manager) getDiskGCLen(total int) int {
+	return (total*gcm.cfg.CleanRatio + 9) / 10
+}
+
+func (gcm *Manager) newDiskGCCandidate(ctx context.Context, taskID string, fullGC bool) *types.GCCandidate {
+	size, err := gcm.cdnMgr.GetFileSize(ctx, taskID)
+	if err != nil {
+		logrus.Debugf("gc disk: failed to get file size taskID(%s): %v", taskID, err)
+	}
+
+	youngGCThreshold, fullGCThreshold := gcm.cfg.GetGCThresholds()
+	candidate := &types.GCCandidate{
+		ID:               taskID,
+		Type:             types.GCCandidateTypeTask,
+		Reason:           types.GCCandidateReasonDISKYOUNGGC,
+		ReclaimableBytes: size,
+		Detail: fmt.Sprintf("the available disk space is less than the youngGCThreshold(%s), "+
+			"and the task is selected by the %s eviction policy", fileutils.FsizeToString(youngGCThreshold), gcm.cfg.GCEvictionPolicy),
+	}
+	if fullGC {
+		candidate.Reason = types.GCCandidateReasonDISKFULLGC
+		candidate.Detail = fmt.Sprintf("the available disk space is less than the fullGCThreshold(%s), "+
+			"and the task is selected by the %s eviction policy", fileutils.FsizeToString(fullGCThreshold), gcm.cfg.GCEvictionPolicy)
+	}
+	return candidate
+}
+
+func (gcm *Manager) newQuotaGCCandidate(ctx context.Context, taskID, namespace string) *types.GCCandidate {
+	size, err := gcm.cdnMgr.GetFileSize(ctx, taskID)
+	if err != nil {
+		logrus.Debugf("gc disk: failed to get file size taskID(%s): %v", taskID, err)
+	}
+
+	var quota fileutils.Fsize
+	if ns := gcm.cfg.GetNamespaceConfig(namespace); ns != nil {
+		quota = ns.CacheQuota
+	}
+	return &types.GCCandidate{
+		ID:               taskID,
+		Type:             types.GCCandidateTypeTask,
+		Reason:           types.GCCandidateReasonNAMESPACEQUOTA,
+		ReclaimableBytes: size,
+		Detail: fmt.Sprintf("the cached files of namespace %s exceed its cacheQuota(%s), "+
+			"and the task is selected by the %s eviction policy", namespace, fileutils.FsizeToString(quota), gcm.cfg.GCEvictionPolicy),
+	}
 }
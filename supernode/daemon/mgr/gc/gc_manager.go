@@ -18,11 +18,16 @@ package gc
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
 	"github.com/dragonflyoss/Dragonfly/pkg/metricsutils"
+	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+	"github.com/dragonflyoss/Dragonfly/supernode/store"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
@@ -30,11 +35,23 @@ import (
 
 var _ mgr.GCMgr = &Manager{}
 
+// gcStallFactor is the number of the intervals after which a gc loop is treated stalled if it has not run.
+const gcStallFactor = 3
+
+// the names of the gc loops.
+const (
+	gcTasksLoop = "tasks"
+	gcPeersLoop = "peers"
+	gcDiskLoop  = "disk"
+)
+
 type metrics struct {
 	gcTasksCount    *prometheus.CounterVec
 	gcPeersCount    *prometheus.CounterVec
 	gcDisksCount    *prometheus.CounterVec
 	lastGCDisksTime *prometheus.GaugeVec
+
+	gcNamespaceDisksCount *prometheus.CounterVec
 }
 
 func newMetrics(register prometheus.Registerer) *metrics {
@@ -50,6 +67,10 @@ func newMetrics(register prometheus.Registerer) *metrics {
 
 		lastGCDisksTime: metricsutils.NewGauge(config.SubsystemSupernode, "last_gc_disks_timestamp_seconds",
 			"Timestamp of the last disk gc", []string{}, register),
+
+		gcNamespaceDisksCount: metricsutils.NewCounter(config.SubsystemSupernode, "gc_namespace_disks_total",
+			"Total number of the task data in disks garbage collected because the namespace exceeds its cacheQuota",
+			[]string{"namespace"}, register),
 	}
 }
 
@@ -63,12 +84,25 @@ type Manager struct {
 	dfgetTaskMgr mgr.DfgetTaskMgr
 	progressMgr  mgr.ProgressMgr
 	cdnMgr       mgr.CDNMgr
+	webhookMgr   mgr.WebhookMgr
 	metrics      *metrics
+
+	// cacheStore is the store of CDN files, which emits the events
+	// to trigger the disk gc when the available space is low.
+	cacheStore *store.Store
+
+	// evictionLog records the tasks and peers which have been garbage collected.
+	evictionLog *evictionLog
+
+	// heartbeats records the time when each gc loop runs last time,
+	// or the time when it's expected to run first.
+	heartbeats *syncmap.SyncMap
 }
 
 // NewManager returns a new Manager.
 func NewManager(cfg *config.Config, taskMgr mgr.TaskMgr, peerMgr mgr.PeerMgr, dfgetTaskMgr mgr.DfgetTaskMgr,
-	progressMgr mgr.ProgressMgr, cdnMgr mgr.CDNMgr, register prometheus.Registerer) (*Manager, error) {
+	progressMgr mgr.ProgressMgr, cdnMgr mgr.CDNMgr, webhookMgr mgr.WebhookMgr, cacheStore *store.Store,
+	register prometheus.Registerer) (*Manager, error) {
 	return &Manager{
 		cfg:          cfg,
 		taskMgr:      taskMgr,
@@ -76,23 +110,34 @@ func NewManager(cfg *config.Config, taskMgr mgr.TaskMgr, peerMgr mgr.PeerMgr, df
 		dfgetTaskMgr: dfgetTaskMgr,
 		progressMgr:  progressMgr,
 		cdnMgr:       cdnMgr,
+		webhookMgr:   webhookMgr,
 		metrics:      newMetrics(register),
+		cacheStore:   cacheStore,
+		evictionLog:  newEvictionLog(evictionLogSize),
+		heartbeats:   syncmap.NewSyncMap(),
 	}, nil
 }
 
 // StartGC starts to do the gc jobs.
 func (gcm *Manager) StartGC(ctx context.Context) {
 	logrus.Debugf("start the gc job")
+	firstRunTime := time.Now().Add(gcm.cfg.GCInitialDelay)
+	for _, loop := range []string{gcTasksLoop, gcPeersLoop, gcDiskLoop} {
+		gcm.heartbeats.Add(loop, firstRunTime)
+	}
 
 	// start a goroutine to gc the tasks
 	go func() {
 		// delay to execute GC after gcm.initialDelay
 		time.Sleep(gcm.cfg.GCInitialDelay)
 
-		// execute the GC by fixed delay
+		// execute the GC by fixed delay,
+		// and evict the progress states of the tasks if they take too much memory.
 		ticker := time.NewTicker(gcm.cfg.GCMetaInterval)
 		for range ticker.C {
 			gcm.gcTasks(ctx)
+			gcm.gcProgress(ctx)
+			gcm.heartbeats.Add(gcTasksLoop, time.Now())
 		}
 	}()
 
@@ -105,28 +150,138 @@ func (gcm *Manager) StartGC(ctx context.Context) {
 		ticker := time.NewTicker(gcm.cfg.GCMetaInterval)
 		for range ticker.C {
 			gcm.gcPeers(ctx)
+			gcm.heartbeats.Add(gcPeersLoop, time.Now())
 		}
 	}()
 
 	// start a goroutine to gc the disks
 	go func() {
+		var spaceEvents <-chan store.SpaceEvent
+		if gcm.cacheStore != nil {
+			spaceEvents = gcm.cacheStore.SubscribeSpaceEvents()
+		}
+
 		// delay to execute GC after gcm.initialDelay
 		time.Sleep(gcm.cfg.GCInitialDelay)
 
-		// execute the GC by fixed delay
+		// execute the GC by fixed delay,
+		// and execute it immediately when the available space is below the watermarks.
 		ticker := time.NewTicker(gcm.cfg.GCDiskInterval)
-		for range ticker.C {
+		for {
+			select {
+			case <-ticker.C:
+			case event := <-spaceEvents:
+				if event.Level == store.SpaceNormal {
+					continue
+				}
+				logrus.Infof("gc disk: triggered by the %s available space(%s)",
+					event.Level, fileutils.FsizeToString(event.AvailSpace))
+			}
 			gcm.gcDisk(ctx)
+			gcm.heartbeats.Add(gcDiskLoop, time.Now())
 		}
 	}()
 }
 
+// CheckHealth checks whether the gc loops are alive,
+// and it's unhealthy if any of them has not run for several intervals.
+func (gcm *Manager) CheckHealth(ctx context.Context) *types.ComponentHealth {
+	health := &types.ComponentHealth{
+		Name:    "gc",
+		Status:  types.ComponentHealthStatusHealthy,
+		Details: make(map[string]string),
+	}
+	for _, loop := range []struct {
+		name     string
+		interval time.Duration
+	}{
+		{gcTasksLoop, gcm.cfg.GCMetaInterval},
+		{gcPeersLoop, gcm.cfg.GCMetaInterval},
+		{gcDiskLoop, gcm.cfg.GCDiskInterval},
+	} {
+		lastRunTime, err := gcm.heartbeats.GetAsTime(loop.name)
+		if err != nil {
+			health.Status = types.ComponentHealthStatusUnhealthy
+			health.Message = "the gc loops are not started"
+			return health
+		}
+		health.Details[loop.name+"LastRunTime"] = lastRunTime.Format(time.RFC3339)
+		if since := time.Since(lastRunTime); since > gcStallFactor*loop.interval {
+			health.Status = types.ComponentHealthStatusUnhealthy
+			health.Message = fmt.Sprintf("the gc loop of %s has not run for %v", loop.name, since.Truncate(time.Second))
+		}
+	}
+	return health
+}
+
 // GCTask is used to do the gc job with specified taskID.
 func (gcm *Manager) GCTask(ctx context.Context, taskID string, full bool) {
+	candidate := &types.GCCandidate{
+		ID:     taskID,
+		Type:   types.GCCandidateTypeTask,
+		Reason: types.GCCandidateReasonTASKREQUESTED,
+		Detail: fmt.Sprintf("the task is deleted by request with full(%t)", full),
+	}
+	if full {
+		candidate.ReclaimableBytes, _ = gcm.cdnMgr.GetFileSize(ctx, taskID)
+	}
+
 	gcm.gcTask(ctx, taskID, full)
+	gcm.recordEviction(ctx, candidate)
+}
+
+// recordEviction records that the candidate has been garbage collected
+// and notifies the webhooks.
+func (gcm *Manager) recordEviction(ctx context.Context, candidate *types.GCCandidate) {
+	record := gcm.evictionLog.add(candidate, time.Now())
+
+	event := &types.WebhookEvent{
+		Event:    types.WebhookEventEventEVICTED,
+		Eviction: record,
+	}
+	if record.Type == types.EvictionRecordTypeTask {
+		event.TaskID = record.ID
+	}
+	gcm.webhookMgr.Notify(ctx, event)
 }
 
 // GCPeer is used to do the gc job when a peer offline.
 func (gcm *Manager) GCPeer(ctx context.Context, peerID string) {
 	gcm.gcPeer(ctx, peerID)
 }
+
+// DryRun runs the gc selection without deleting anything and
+// returns the tasks and peers which would be garbage collected by the next gc pass.
+func (gcm *Manager) DryRun(ctx context.Context) (*types.GCDryRunResult, error) {
+	taskCandidates, _, err := gcm.getExpiredTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	peerCandidates, _ := gcm.getGCPeers(ctx)
+	diskCandidates, err := gcm.getDiskGCTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	progressCandidates, err := gcm.getProgressGCTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.GCDryRunResult{
+		Candidates: make([]*types.GCCandidate, 0,
+			len(taskCandidates)+len(peerCandidates)+len(diskCandidates)+len(progressCandidates)),
+	}
+	result.Candidates = append(result.Candidates, taskCandidates...)
+	result.Candidates = append(result.Candidates, peerCandidates...)
+	result.Candidates = append(result.Candidates, diskCandidates...)
+	result.Candidates = append(result.Candidates, progressCandidates...)
+	for _, candidate := range result.Candidates {
+		result.ReclaimableBytes += candidate.ReclaimableBytes
+	}
+	return result, nil
+}
+
+// ListEvictionRecords returns the most recent records of garbage collection and the latest one comes first.
+func (gcm *Manager) ListEvictionRecords(ctx context.Context, id string, limit int) ([]*types.EvictionRecord, error) {
+	return gcm.evictionLog.list(id, limit), nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gc
+
+import (
+	"context"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/mock"
+
+	"github.com/go-check/check"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type GCManagerTestSuite struct{}
+
+func init() {
+	check.Suite(&GCManagerTestSuite{})
+}
+
+func (s *GCManagerTestSuite) TestCheckHealth(c *check.C) {
+	cfg := config.NewConfig()
+	gcm, err := NewManager(cfg, nil, nil, nil, nil, nil, nil, nil, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+
+	health := gcm.CheckHealth(context.Background())
+	c.Check(health.Status, check.Equals, types.ComponentHealthStatusUnhealthy)
+	c.Check(health.Message, check.Equals, "the gc loops are not started")
+
+	now := time.Now()
+	for _, loop := range []string{gcTasksLoop, gcPeersLoop, gcDiskLoop} {
+		gcm.heartbeats.Add(loop, now)
+	}
+	health = gcm.CheckHealth(context.Background())
+	c.Check(health.Status, check.Equals, types.ComponentHealthStatusHealthy)
+	c.Check(health.Details, check.HasLen, 3)
+
+	gcm.heartbeats.Add(gcDiskLoop, now.Add(-gcStallFactor*cfg.GCDiskInterval-time.Second))
+	health = gcm.CheckHealth(context.Background())
+	c.Check(health.Status, check.Equals, types.ComponentHealthStatusUnhealthy)
+	c.Check(health.Message, check.Matches, "the gc loop of disk has not run for .*")
+}
+
+// gcTaskMgr is a TaskMgr which only gets the tasks being used.
+type gcTaskMgr struct {
+	mgr.TaskMgr
+	tasks map[string]*types.TaskInfo
+}
+
+func (tm *gcTaskMgr) Get(ctx context.Context, taskID string) (*types.TaskInfo, error) {
+	if task, ok := tm.tasks[taskID]; ok {
+		return task, nil
+	}
+	return nil, errortypes.ErrDataNotFound
+}
+
+func (s *GCManagerTestSuite) TestGCNamespaceQuota(c *check.C) {
+	ctx := context.Background()
+	mockCtl := gomock.NewController(c)
+	defer mockCtl.Finish()
+	mockCDNMgr := mock.NewMockCDNMgr(mockCtl)
+	mockWebhookMgr := mock.NewMockWebhookMgr(mockCtl)
+	mockWebhookMgr.EXPECT().Notify(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := config.NewConfig()
+	cfg.Namespaces = []*config.NamespaceConfig{{Name: "datasets", CacheQuota: 100}}
+	taskMgr := &gcTaskMgr{tasks: map[string]*types.TaskInfo{"used": {ID: "used"}}}
+	gcm, err := NewManager(cfg, taskMgr, nil, nil, nil, mockCDNMgr, mockWebhookMgr, nil, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+
+	mockCDNMgr.EXPECT().GetQuotaGCTaskIDs(gomock.Any(), taskMgr).
+		Return(map[string][]string{"datasets": {"evicted", "used"}}, nil)
+	mockCDNMgr.EXPECT().GetFileSize(gomock.Any(), gomock.Any()).Return(int64(60), nil).AnyTimes()
+	mockCDNMgr.EXPECT().Delete(gomock.Any(), "evicted", true).Return(nil)
+	gcm.gcNamespaceQuota(ctx)
+
+	records, err := gcm.ListEvictionRecords(ctx, "", 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(records, check.HasLen, 1)
+	c.Check(records[0].ID, check.Equals, "evicted")
+	c.Check(records[0].Reason, check.Equals, types.EvictionRecordReasonNAMESPACEQUOTA)
+	c.Check(records[0].FreedBytes, check.Equals, int64(60))
+	c.Check(records[0].Detail, check.Matches, "the cached files of namespace datasets exceed its cacheQuota.*")
+}
+
+func (s *GCManagerTestSuite) TestGetDiskGCTasks(c *check.C) {
+	ctx := context.Background()
+	mockCtl := gomock.NewController(c)
+	defer mockCtl.Finish()
+	mockCDNMgr := mock.NewMockCDNMgr(mockCtl)
+
+	cfg := config.NewConfig()
+	cfg.CleanRatio = 3
+	taskMgr := &gcTaskMgr{tasks: map[string]*types.TaskInfo{"used": {ID: "used"}, "quotaUsed": {ID: "quotaUsed"}}}
+	gcm, err := NewManager(cfg, taskMgr, nil, nil, nil, mockCDNMgr, nil, nil, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+
+	mockCDNMgr.EXPECT().GetQuotaGCTaskIDs(gomock.Any(), taskMgr).
+		Return(map[string][]string{"datasets": {"quota", "quotaUsed"}}, nil)
+	mockCDNMgr.EXPECT().GetGCTaskIDs(gomock.Any(), taskMgr).
+		Return([]string{"quota", "used", "a", "b", "c"}, false, nil)
+	mockCDNMgr.EXPECT().GetFileSize(gomock.Any(), gomock.Any()).Return(int64(60), nil).AnyTimes()
+
+	// the tasks in use are skipped and the following ones are selected instead,
+	// and the task deleted by the namespace quota isn't counted in the disk gc.
+	candidates, err := gcm.getDiskGCTasks(ctx)
+	c.Assert(err, check.IsNil)
+	var ids []string
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.ID)
+	}
+	c.Check(ids, check.DeepEquals, []string{"quota", "a", "b"})
+	c.Check(candidates[0].Reason, check.Equals, types.GCCandidateReasonNAMESPACEQUOTA)
+	c.Check(candidates[1].Reason, check.Equals, types.GCCandidateReasonDISKYOUNGGC)
+}
@@ -18,9 +18,11 @@ package gc
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/timeutils"
 	"github.com/dragonflyoss/Dragonfly/supernode/util"
 
@@ -36,6 +38,32 @@ const (
 func (gcm *Manager) gcPeers(ctx context.Context) {
 	var gcPeerCount int
 	startTime := time.Now()
+	candidates, totalPeerNums := gcm.getGCPeers(ctx)
+
+	for _, candidate := range candidates {
+		gcm.gcPeer(ctx, candidate.ID)
+		gcm.recordEviction(ctx, candidate)
+
+		// the peers whose state is lost are not counted as the offline ones.
+		if candidate.Reason == types.GCCandidateReasonPEEROFFLINE {
+			gcPeerCount++
+		}
+	}
+
+	// slow GC detected, report it with a log warning
+	if timeDuring := time.Since(startTime); timeDuring > gcPeersTimeout {
+		logrus.Warnf("gc peers:%d cost:%.3f", gcPeerCount, timeDuring.Seconds())
+	}
+
+	gcm.metrics.gcPeersCount.WithLabelValues().Add(float64(gcPeerCount))
+
+	logrus.Infof("gc peers: success to gc peer count(%d), remainder count(%d)", gcPeerCount, totalPeerNums-gcPeerCount)
+}
+
+// getGCPeers returns the gc candidates of the peers which are offline
+// and the total number of the peers.
+func (gcm *Manager) getGCPeers(ctx context.Context) ([]*types.GCCandidate, int) {
+	var candidates []*types.GCCandidate
 	peerIDs := gcm.peerMgr.GetAllPeerIDs(ctx)
 
 	for _, peerID := range peerIDs {
@@ -45,27 +73,29 @@ func (gcm *Manager) gcPeers(ctx context.Context) {
 		peerState, err := gcm.progressMgr.GetPeerStateByPeerID(ctx, peerID)
 		if err != nil {
 			logrus.Warnf("gc peers: failed to get peerState peerID(%s): %v", peerID, err)
-			gcm.gcPeer(ctx, peerID)
+			candidates = append(candidates, &types.GCCandidate{
+				ID:     peerID,
+				Type:   types.GCCandidateTypePeer,
+				Reason: types.GCCandidateReasonPEERNOTFOUND,
+				Detail: fmt.Sprintf("failed to get the state of the peer: %v", err),
+			})
 			continue
 		}
 
-		if peerState.ServiceDownTime == 0 ||
-			timeutils.GetCurrentTimeMillis()-peerState.ServiceDownTime < int64(gcm.cfg.PeerGCDelay/time.Millisecond) {
+		offline := timeutils.GetCurrentTimeMillis() - peerState.ServiceDownTime
+		if peerState.ServiceDownTime == 0 || offline < int64(gcm.cfg.PeerGCDelay/time.Millisecond) {
 			continue
 		}
 
-		gcm.gcPeer(ctx, peerID)
-		gcPeerCount++
+		candidates = append(candidates, &types.GCCandidate{
+			ID:     peerID,
+			Type:   types.GCCandidateTypePeer,
+			Reason: types.GCCandidateReasonPEEROFFLINE,
+			Detail: fmt.Sprintf("the peer has been offline for %v which exceeds the peerGCDelay(%v)",
+				time.Duration(offline)*time.Millisecond, gcm.cfg.PeerGCDelay),
+		})
 	}
-
-	// slow GC detected, report it with a log warning
-	if timeDuring := time.Since(startTime); timeDuring > gcPeersTimeout {
-		logrus.Warnf("gc peers:%d cost:%.3f", gcPeerCount, timeDuring.Seconds())
-	}
-
-	gcm.metrics.gcPeersCount.WithLabelValues().Add(float64(gcPeerCount))
-
-	logrus.Infof("gc peers: success to gc peer count(%d), remainder count(%d)", gcPeerCount, len(peerIDs)-gcPeerCount)
+	return candidates, len(peerIDs)
 }
 
 func (gcm *Manager) gcPeer(ctx context.Context, peerID string) {
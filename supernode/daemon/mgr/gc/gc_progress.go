@@ -0,0 +1,100 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (gcm *Manager) gcProgress(ctx context.Context) {
+	candidates, err := gcm.getProgressGCTasks(ctx)
+	if err != nil {
+		logrus.Errorf("gc progress: %v", err)
+		return
+	}
+
+	for _, candidate := range candidates {
+		gcm.gcTask(ctx, candidate.ID, false)
+		gcm.recordEviction(ctx, candidate)
+	}
+	gcm.metrics.gcTasksCount.WithLabelValues().Add(float64(len(candidates)))
+
+	if len(candidates) > 0 {
+		logrus.Infof("gc progress: success to gc task count(%d)", len(candidates))
+	}
+}
+
+// getProgressGCTasks returns the gc candidates of the coldest completed tasks
+// whose progress states should be evicted to keep the memory under progressMemoryLimit.
+// It also refreshes the metrics of the progress states even if there is no limit.
+func (gcm *Manager) getProgressGCTasks(ctx context.Context) ([]*types.GCCandidate, error) {
+	usage, err := gcm.progressMgr.GetMemoryUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory usage of progress: %v", err)
+	}
+
+	limit := int64(gcm.cfg.ProgressMemoryLimit)
+	if limit <= 0 || usage.TotalBytes <= limit {
+		return nil, nil
+	}
+
+	var candidates []*types.GCCandidate
+	exceeded := usage.TotalBytes - limit
+	for _, task := range usage.CompletedTasks {
+		if exceeded <= 0 {
+			break
+		}
+
+		// the task whose cdn is still running should be kept,
+		// and the orphan progress states of the deleted task can be evicted directly.
+		taskInfo, err := gcm.taskMgr.Get(ctx, task.TaskID)
+		if err != nil && !errortypes.IsDataNotFound(err) {
+			logrus.Errorf("gc progress: failed to get taskID(%s): %v", task.TaskID, err)
+			continue
+		}
+		if taskInfo != nil && !isCDNFinished(taskInfo.CdnStatus) {
+			continue
+		}
+
+		candidates = append(candidates, &types.GCCandidate{
+			ID:     task.TaskID,
+			Type:   types.GCCandidateTypeTask,
+			Reason: types.GCCandidateReasonPROGRESSMEMORYLIMIT,
+			Detail: fmt.Sprintf("the progress states take %s which exceeds the progressMemoryLimit(%s), "+
+				"and the task taking %s is completed and has not been accessed for %v",
+				fileutils.FsizeToString(fileutils.Fsize(usage.TotalBytes)),
+				fileutils.FsizeToString(gcm.cfg.ProgressMemoryLimit),
+				fileutils.FsizeToString(fileutils.Fsize(task.Bytes)),
+				time.Since(task.AccessTime).Truncate(time.Second)),
+		})
+		exceeded -= task.Bytes
+	}
+	return candidates, nil
+}
+
+func isCDNFinished(cdnStatus string) bool {
+	return cdnStatus == types.TaskInfoCdnStatusSUCCESS || cdnStatus == types.TaskInfoCdnStatusFAILED ||
+		cdnStatus == types.TaskInfoCdnStatusSOURCEERROR
+}
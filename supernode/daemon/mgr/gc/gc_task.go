@@ -18,9 +18,12 @@ package gc
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/supernode/util"
 
 	"github.com/sirupsen/logrus"
@@ -30,49 +33,81 @@ const (
 	// gcTasksTimeout specifies the timeout for tasks gc.
 	// If the actual execution time exceeds this threshold, a warning will be thrown.
 	gcTasksTimeout = 2.0 * time.Second
+
+	// cancelCDNTimeout specifies the max time to wait for the in-flight CDN download
+	// of the task to exit before the task is garbage collected.
+	cancelCDNTimeout = 30 * time.Second
 )
 
 func (gcm *Manager) gcTasks(ctx context.Context) {
 	var removedTaskCount int
 	startTime := time.Now()
 
+	candidates, totalTaskNums, err := gcm.getExpiredTasks(ctx)
+	if err != nil {
+		logrus.Errorf("gc tasks: %v", err)
+		return
+	}
+
+	for _, candidate := range candidates {
+		gcm.gcTask(ctx, candidate.ID, false)
+		gcm.recordEviction(ctx, candidate)
+		removedTaskCount++
+	}
+
+	// slow GC detected, report it with a log warning
+	if timeDuring := time.Since(startTime); timeDuring > gcTasksTimeout {
+		logrus.Warnf("gc tasks:%d cost:%.3f", removedTaskCount, timeDuring.Seconds())
+	}
+
+	gcm.metrics.gcTasksCount.WithLabelValues().Add(float64(removedTaskCount))
+
+	logrus.Infof("gc tasks: success to full gc task count(%d), remainder count(%d)", removedTaskCount, totalTaskNums-removedTaskCount)
+}
+
+// getExpiredTasks returns the gc candidates of the tasks which are expired
+// and the total number of the tasks.
+func (gcm *Manager) getExpiredTasks(ctx context.Context) ([]*types.GCCandidate, int, error) {
 	// get all taskIDs and the corresponding accessTime
 	taskAccessMap, err := gcm.taskMgr.GetAccessTime(ctx)
 	if err != nil {
-		logrus.Errorf("gc tasks: failed to get task accessTime map for GC: %v", err)
-		return
+		return nil, 0, fmt.Errorf("failed to get task accessTime map for GC: %v", err)
 	}
 
 	// range all tasks and determine whether they are expired
+	var candidates []*types.GCCandidate
 	taskIDs := taskAccessMap.ListKeyAsStringSlice()
-	totalTaskNums := len(taskIDs)
 	for _, taskID := range taskIDs {
 		atime, err := taskAccessMap.GetAsTime(taskID)
 		if err != nil {
 			logrus.Errorf("gc tasks: failed to get access time taskID(%s): %v", taskID, err)
 			continue
 		}
-		if time.Since(atime) < gcm.cfg.TaskExpireTime {
+		idle := time.Since(atime)
+		if idle < gcm.cfg.TaskExpireTime {
 			continue
 		}
 
-		gcm.gcTask(ctx, taskID, false)
-		removedTaskCount++
-	}
-
-	// slow GC detected, report it with a log warning
-	if timeDuring := time.Since(startTime); timeDuring > gcTasksTimeout {
-		logrus.Warnf("gc tasks:%d cost:%.3f", removedTaskCount, timeDuring.Seconds())
+		// NOTE: the files of an expired task are kept on the disk
+		// and they will be deleted by disk gc when the disk is not enough.
+		candidates = append(candidates, &types.GCCandidate{
+			ID:     taskID,
+			Type:   types.GCCandidateTypeTask,
+			Reason: types.GCCandidateReasonTASKEXPIRED,
+			Detail: fmt.Sprintf("the task has not been accessed for %v which exceeds the taskExpireTime(%v), "+
+				"and the cdn files are kept for disk gc", idle.Truncate(time.Second), gcm.cfg.TaskExpireTime),
+		})
 	}
-
-	gcm.metrics.gcTasksCount.WithLabelValues().Add(float64(removedTaskCount))
-
-	logrus.Infof("gc tasks: success to full gc task count(%d), remainder count(%d)", removedTaskCount, totalTaskNums-removedTaskCount)
+	return candidates, len(taskIDs), nil
 }
 
 func (gcm *Manager) gcTask(ctx context.Context, taskID string, full bool) {
 	logrus.Infof("gc task: start to deal with task: %s", taskID)
 
+	// abort the in-flight CDN download at first,
+	// otherwise it will keep pulling from the source and writing the files of the deleted task.
+	gcm.cancelCDNByTaskID(ctx, taskID)
+
 	util.GetLock(taskID, false)
 	defer util.ReleaseLock(taskID, false)
 
@@ -109,6 +144,14 @@ func (gcm *Manager) gcCIDsByTaskID(ctx context.Context, taskID string) {
 	}
 }
 
+func (gcm *Manager) cancelCDNByTaskID(ctx context.Context, taskID string) {
+	ctx, cancel := context.WithTimeout(ctx, cancelCDNTimeout)
+	defer cancel()
+	if err := gcm.taskMgr.CancelCDN(ctx, taskID); err != nil && !errortypes.IsDataNotFound(err) {
+		logrus.Errorf("gc task: failed to cancel cdn download taskID(%s): %v", taskID, err)
+	}
+}
+
 func (gcm *Manager) gcCDNByTaskID(ctx context.Context, taskID string, full bool) {
 	if err := gcm.cdnMgr.Delete(ctx, taskID, full); err != nil {
 		logrus.Errorf("gc task: failed to gc cdn meta taskID(%s) full(%t): %v", taskID, full, err)
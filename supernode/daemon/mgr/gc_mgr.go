@@ -18,6 +18,8 @@ package mgr
 
 import (
 	"context"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
 )
 
 // GCMgr as an interface defines all operations about gc operation.
@@ -31,4 +33,15 @@ type GCMgr interface {
 
 	// GCPeer is used to do the gc peer job when a peer offline.
 	GCPeer(ctx context.Context, peerID string)
+
+	// DryRun runs the gc selection without deleting anything and
+	// returns the tasks and peers which would be garbage collected by the next gc pass.
+	DryRun(ctx context.Context) (*types.GCDryRunResult, error)
+
+	// ListEvictionRecords returns the most recent records of garbage collection and the latest one comes first.
+	// The records will be filtered by the id if it's not empty, and the limit <= 0 means no limit.
+	ListEvictionRecords(ctx context.Context, id string, limit int) ([]*types.EvictionRecord, error)
+
+	// CheckHealth checks whether the gc loops are alive.
+	CheckHealth(ctx context.Context) *types.ComponentHealth
 }
@@ -83,12 +83,13 @@ func (mr *MockCDNMgrMockRecorder) GetStatus(ctx, taskID interface{}) *gomock.Cal
 }
 
 // GetGCTaskIDs mocks base method
-func (m *MockCDNMgr) GetGCTaskIDs(ctx context.Context, taskMgr mgr.TaskMgr) ([]string, error) {
+func (m *MockCDNMgr) GetGCTaskIDs(ctx context.Context, taskMgr mgr.TaskMgr) ([]string, bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetGCTaskIDs", ctx, taskMgr)
 	ret0, _ := ret[0].([]string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetGCTaskIDs indicates an expected call of GetGCTaskIDs
@@ -97,6 +98,36 @@ func (mr *MockCDNMgrMockRecorder) GetGCTaskIDs(ctx, taskMgr interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGCTaskIDs", reflect.TypeOf((*MockCDNMgr)(nil).GetGCTaskIDs), ctx, taskMgr)
 }
 
+// GetQuotaGCTaskIDs mocks base method
+func (m *MockCDNMgr) GetQuotaGCTaskIDs(ctx context.Context, taskMgr mgr.TaskMgr) (map[string][]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuotaGCTaskIDs", ctx, taskMgr)
+	ret0, _ := ret[0].(map[string][]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuotaGCTaskIDs indicates an expected call of GetQuotaGCTaskIDs
+func (mr *MockCDNMgrMockRecorder) GetQuotaGCTaskIDs(ctx, taskMgr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuotaGCTaskIDs", reflect.TypeOf((*MockCDNMgr)(nil).GetQuotaGCTaskIDs), ctx, taskMgr)
+}
+
+// GetFileSize mocks base method
+func (m *MockCDNMgr) GetFileSize(ctx context.Context, taskID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileSize", ctx, taskID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileSize indicates an expected call of GetFileSize
+func (mr *MockCDNMgrMockRecorder) GetFileSize(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileSize", reflect.TypeOf((*MockCDNMgr)(nil).GetFileSize), ctx, taskID)
+}
+
 // GetPieceMD5 mocks base method
 func (m *MockCDNMgr) GetPieceMD5(ctx context.Context, taskID string, pieceNum int, pieceRange, source string) (string, error) {
 	m.ctrl.T.Helper()
@@ -112,6 +143,21 @@ func (mr *MockCDNMgrMockRecorder) GetPieceMD5(ctx, taskID, pieceNum, pieceRange,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPieceMD5", reflect.TypeOf((*MockCDNMgr)(nil).GetPieceMD5), ctx, taskID, pieceNum, pieceRange, source)
 }
 
+// GetCache mocks base method
+func (m *MockCDNMgr) GetCache(ctx context.Context, taskID, md5 string) (*types.CacheInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCache", ctx, taskID, md5)
+	ret0, _ := ret[0].(*types.CacheInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCache indicates an expected call of GetCache
+func (mr *MockCDNMgrMockRecorder) GetCache(ctx, taskID, md5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCache", reflect.TypeOf((*MockCDNMgr)(nil).GetCache), ctx, taskID, md5)
+}
+
 // CheckFile mocks base method
 func (m *MockCDNMgr) CheckFile(ctx context.Context, taskID string) bool {
 	m.ctrl.T.Helper()
@@ -139,3 +185,15 @@ func (mr *MockCDNMgrMockRecorder) Delete(ctx, taskID, force interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCDNMgr)(nil).Delete), ctx, taskID, force)
 }
+
+// SetRate mocks base method
+func (m *MockCDNMgr) SetRate(rate int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRate", rate)
+}
+
+// SetRate indicates an expected call of SetRate
+func (mr *MockCDNMgrMockRecorder) SetRate(rate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRate", reflect.TypeOf((*MockCDNMgr)(nil).SetRate), rate)
+}
@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 
@@ -95,6 +96,21 @@ func (mr *MockProgressMgrMockRecorder) GetPieceProgressByCID(ctx, taskID, client
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPieceProgressByCID", reflect.TypeOf((*MockProgressMgr)(nil).GetPieceProgressByCID), ctx, taskID, clientID, filter)
 }
 
+// GetCDNPieceProgress mocks base method
+func (m *MockProgressMgr) GetCDNPieceProgress(ctx context.Context, taskID string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCDNPieceProgress", ctx, taskID)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCDNPieceProgress indicates an expected call of GetCDNPieceProgress
+func (mr *MockProgressMgrMockRecorder) GetCDNPieceProgress(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCDNPieceProgress", reflect.TypeOf((*MockProgressMgr)(nil).GetCDNPieceProgress), ctx, taskID)
+}
+
 // GetPeerIDsByPieceNum mocks base method
 func (m *MockProgressMgr) GetPeerIDsByPieceNum(ctx context.Context, taskID string, pieceNum int) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +126,21 @@ func (mr *MockProgressMgrMockRecorder) GetPeerIDsByPieceNum(ctx, taskID, pieceNu
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeerIDsByPieceNum", reflect.TypeOf((*MockProgressMgr)(nil).GetPeerIDsByPieceNum), ctx, taskID, pieceNum)
 }
 
+// GetRunningPieceSources mocks base method
+func (m *MockProgressMgr) GetRunningPieceSources(ctx context.Context, clientID string, pieceNum int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRunningPieceSources", ctx, clientID, pieceNum)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRunningPieceSources indicates an expected call of GetRunningPieceSources
+func (mr *MockProgressMgrMockRecorder) GetRunningPieceSources(ctx, clientID, pieceNum interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRunningPieceSources", reflect.TypeOf((*MockProgressMgr)(nil).GetRunningPieceSources), ctx, clientID, pieceNum)
+}
+
 // DeletePeerIDByPieceNum mocks base method
 func (m *MockProgressMgr) DeletePeerIDByPieceNum(ctx context.Context, taskID string, pieceNum int, peerID string) error {
 	m.ctrl.T.Helper()
@@ -124,6 +155,35 @@ func (mr *MockProgressMgrMockRecorder) DeletePeerIDByPieceNum(ctx, taskID, piece
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePeerIDByPieceNum", reflect.TypeOf((*MockProgressMgr)(nil).DeletePeerIDByPieceNum), ctx, taskID, pieceNum, peerID)
 }
 
+// UpdatePeerThroughput mocks base method
+func (m *MockProgressMgr) UpdatePeerThroughput(ctx context.Context, peerID string, pieceSize int64, cost time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePeerThroughput", ctx, peerID, pieceSize, cost)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePeerThroughput indicates an expected call of UpdatePeerThroughput
+func (mr *MockProgressMgrMockRecorder) UpdatePeerThroughput(ctx, peerID, pieceSize, cost interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePeerThroughput", reflect.TypeOf((*MockProgressMgr)(nil).UpdatePeerThroughput), ctx, peerID, pieceSize, cost)
+}
+
+// UpdatePeerServiceError mocks base method
+func (m *MockProgressMgr) UpdatePeerServiceError(ctx context.Context, peerID string, weight float64) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePeerServiceError", ctx, peerID, weight)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePeerServiceError indicates an expected call of UpdatePeerServiceError
+func (mr *MockProgressMgrMockRecorder) UpdatePeerServiceError(ctx, peerID, weight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePeerServiceError", reflect.TypeOf((*MockProgressMgr)(nil).UpdatePeerServiceError), ctx, peerID, weight)
+}
+
 // GetPeerStateByPeerID mocks base method
 func (m *MockProgressMgr) GetPeerStateByPeerID(ctx context.Context, peerID string) (*mgr.PeerState, error) {
 	m.ctrl.T.Helper()
@@ -168,6 +228,21 @@ func (mr *MockProgressMgrMockRecorder) GetPeersByTaskID(ctx, taskID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeersByTaskID", reflect.TypeOf((*MockProgressMgr)(nil).GetPeersByTaskID), ctx, taskID)
 }
 
+// GetClientsByTaskID mocks base method
+func (m *MockProgressMgr) GetClientsByTaskID(ctx context.Context, taskID string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClientsByTaskID", ctx, taskID)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClientsByTaskID indicates an expected call of GetClientsByTaskID
+func (mr *MockProgressMgrMockRecorder) GetClientsByTaskID(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClientsByTaskID", reflect.TypeOf((*MockProgressMgr)(nil).GetClientsByTaskID), ctx, taskID)
+}
+
 // GetBlackInfoByPeerID mocks base method
 func (m *MockProgressMgr) GetBlackInfoByPeerID(ctx context.Context, peerID string) (*syncmap.SyncMap, error) {
 	m.ctrl.T.Helper()
@@ -183,6 +258,20 @@ func (mr *MockProgressMgrMockRecorder) GetBlackInfoByPeerID(ctx, peerID interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlackInfoByPeerID", reflect.TypeOf((*MockProgressMgr)(nil).GetBlackInfoByPeerID), ctx, peerID)
 }
 
+// IsInBlackList mocks base method
+func (m *MockProgressMgr) IsInBlackList(ctx context.Context, srcPID string, dstPID string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsInBlackList", ctx, srcPID, dstPID)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsInBlackList indicates an expected call of IsInBlackList
+func (mr *MockProgressMgrMockRecorder) IsInBlackList(ctx, srcPID, dstPID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsInBlackList", reflect.TypeOf((*MockProgressMgr)(nil).IsInBlackList), ctx, srcPID, dstPID)
+}
+
 // UpdateSuperLoad mocks base method
 func (m *MockProgressMgr) UpdateSuperLoad(ctx context.Context, taskID string, delta, limit int32) (bool, error) {
 	m.ctrl.T.Helper()
@@ -198,6 +287,20 @@ func (mr *MockProgressMgrMockRecorder) UpdateSuperLoad(ctx, taskID, delta, limit
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSuperLoad", reflect.TypeOf((*MockProgressMgr)(nil).UpdateSuperLoad), ctx, taskID, delta, limit)
 }
 
+// SetSuperLoadWeight mocks base method
+func (m *MockProgressMgr) SetSuperLoadWeight(ctx context.Context, taskID string, weight int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSuperLoadWeight", ctx, taskID, weight)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSuperLoadWeight indicates an expected call of SetSuperLoadWeight
+func (mr *MockProgressMgrMockRecorder) SetSuperLoadWeight(ctx, taskID, weight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSuperLoadWeight", reflect.TypeOf((*MockProgressMgr)(nil).SetSuperLoadWeight), ctx, taskID, weight)
+}
+
 // DeleteTaskID mocks base method
 func (m *MockProgressMgr) DeleteTaskID(ctx context.Context, taskID string, pieceTotal int) error {
 	m.ctrl.T.Helper()
@@ -212,6 +315,20 @@ func (mr *MockProgressMgrMockRecorder) DeleteTaskID(ctx, taskID, pieceTotal inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskID", reflect.TypeOf((*MockProgressMgr)(nil).DeleteTaskID), ctx, taskID, pieceTotal)
 }
 
+// CompactCID mocks base method
+func (m *MockProgressMgr) CompactCID(ctx context.Context, clientID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompactCID", ctx, clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompactCID indicates an expected call of CompactCID
+func (mr *MockProgressMgrMockRecorder) CompactCID(ctx, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompactCID", reflect.TypeOf((*MockProgressMgr)(nil).CompactCID), ctx, clientID)
+}
+
 // DeleteCID mocks base method
 func (m *MockProgressMgr) DeleteCID(ctx context.Context, clientID string) error {
 	m.ctrl.T.Helper()
@@ -239,3 +356,18 @@ func (mr *MockProgressMgrMockRecorder) DeletePeerID(ctx, peerID interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePeerID", reflect.TypeOf((*MockProgressMgr)(nil).DeletePeerID), ctx, peerID)
 }
+
+// GetMemoryUsage mocks base method
+func (m *MockProgressMgr) GetMemoryUsage(ctx context.Context) (*mgr.ProgressMemoryUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemoryUsage", ctx)
+	ret0, _ := ret[0].(*mgr.ProgressMemoryUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemoryUsage indicates an expected call of GetMemoryUsage
+func (mr *MockProgressMgrMockRecorder) GetMemoryUsage(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemoryUsage", reflect.TypeOf((*MockProgressMgr)(nil).GetMemoryUsage), ctx)
+}
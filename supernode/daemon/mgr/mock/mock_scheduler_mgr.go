@@ -37,16 +37,16 @@ func (m *MockSchedulerMgr) EXPECT() *MockSchedulerMgrMockRecorder {
 }
 
 // Schedule mocks base method
-func (m *MockSchedulerMgr) Schedule(ctx context.Context, taskID, clientID, peerID string) ([]*mgr.PieceResult, error) {
+func (m *MockSchedulerMgr) Schedule(ctx context.Context, taskID, clientID, peerID string, pieceTotal int) ([]*mgr.PieceResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Schedule", ctx, taskID, clientID, peerID)
+	ret := m.ctrl.Call(m, "Schedule", ctx, taskID, clientID, peerID, pieceTotal)
 	ret0, _ := ret[0].([]*mgr.PieceResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Schedule indicates an expected call of Schedule
-func (mr *MockSchedulerMgrMockRecorder) Schedule(ctx, taskID, clientID, peerID interface{}) *gomock.Call {
+func (mr *MockSchedulerMgrMockRecorder) Schedule(ctx, taskID, clientID, peerID, pieceTotal interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockSchedulerMgr)(nil).Schedule), ctx, taskID, clientID, peerID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockSchedulerMgr)(nil).Schedule), ctx, taskID, clientID, peerID, pieceTotal)
 }
@@ -18,6 +18,7 @@ package mgr
 
 import (
 	"context"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 )
@@ -31,4 +32,14 @@ type PieceErrorMgr interface {
 	// it failed to download a piece from supernode.
 	// And the supernode should handle the piece Error and do some repair operations.
 	HandlePieceError(ctx context.Context, pieceErrorRequest *types.PieceErrorRequest) error
+
+	// ListTopPieceErrors returns the number of the piece errors of errorType reported within
+	// the window, which are grouped by groupBy and sorted by the number in descending order.
+	// The errors of all types will be counted if errorType is empty,
+	// and all the groups will be returned if limit is not positive.
+	ListTopPieceErrors(ctx context.Context, errorType, groupBy string, window time.Duration,
+		limit int) ([]*types.PieceErrorStat, error)
+
+	// CheckHealth checks whether the workers handling the piece errors are alive.
+	CheckHealth(ctx context.Context) *types.ComponentHealth
 }
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
 
 	"github.com/sirupsen/logrus"
@@ -36,7 +37,8 @@ func init() {
 	Register(types.PieceErrorRequestErrorTypeFILENOTEXIST, NewFileNotExistHandler)
 }
 
-func NewFileNotExistHandler(gcManager mgr.GCMgr, cdnManager mgr.CDNMgr) (Handler, error) {
+func NewFileNotExistHandler(cfg *config.Config, gcManager mgr.GCMgr, cdnManager mgr.CDNMgr,
+	progressManager mgr.ProgressMgr) (Handler, error) {
 	return &FileNotExistHandler{
 		gcManager:  gcManager,
 		cdnManager: cdnManager,
@@ -19,15 +19,19 @@ package pieceerror
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/metricsutils"
 	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -43,7 +47,8 @@ const (
 // handlerStore stores all registered handler.
 var handlerStore = syncmap.NewSyncMap()
 
-type handlerInitFunc func(gcManager mgr.GCMgr, cdnManager mgr.CDNMgr) (handler Handler, err error)
+type handlerInitFunc func(cfg *config.Config, gcManager mgr.GCMgr, cdnManager mgr.CDNMgr,
+	progressManager mgr.ProgressMgr) (handler Handler, err error)
 
 func Register(errType string, initer handlerInitFunc) {
 	handlerStore.Add(errType, initer)
@@ -54,25 +59,50 @@ type Handler interface {
 	Handle(ctx context.Context, pieceErrorRequest *types.PieceErrorRequest) error
 }
 
+type metrics struct {
+	pieceErrors *prometheus.CounterVec
+}
+
+func newMetrics(register prometheus.Registerer) *metrics {
+	return &metrics{
+		// the errors are only counted by the type, and the details of the peers, the tasks
+		// and the clients are kept by the errorStats, because the series of them would
+		// grow with every peer and task which ever reported errors.
+		pieceErrors: metricsutils.NewCounter(config.SubsystemSupernode, "piece_errors_total",
+			"Total times of piece errors reported by dfget", []string{"type"}, register),
+	}
+}
+
 type Manager struct {
 	cfg      *config.Config
 	handlers map[string]Handler
 
-	gcManager  mgr.GCMgr
-	cdnManager mgr.CDNMgr
+	gcManager       mgr.GCMgr
+	cdnManager      mgr.CDNMgr
+	progressManager mgr.ProgressMgr
+
+	metrics *metrics
+	stats   *errorStats
 
 	// error handler
 	pieceErrChan       chan *types.PieceErrorRequest
 	errorHandlingStore *syncmap.SyncMap
 	handledStore       *syncmap.SyncMap
+
+	// workers is the number of the alive goroutines handling the piece errors.
+	workers int32
 }
 
-func NewManager(cfg *config.Config, gcManager mgr.GCMgr, cdnManager mgr.CDNMgr) (*Manager, error) {
+func NewManager(cfg *config.Config, gcManager mgr.GCMgr, cdnManager mgr.CDNMgr,
+	progressManager mgr.ProgressMgr, register prometheus.Registerer) (*Manager, error) {
 	return &Manager{
 		cfg:                cfg,
 		handlers:           make(map[string]Handler),
 		gcManager:          gcManager,
 		cdnManager:         cdnManager,
+		progressManager:    progressManager,
+		metrics:            newMetrics(register),
+		stats:              newErrorStats(),
 		pieceErrChan:       make(chan *types.PieceErrorRequest, ErrHandlerChanSize),
 		errorHandlingStore: syncmap.NewSyncMap(),
 		handledStore:       syncmap.NewSyncMap(),
@@ -83,24 +113,30 @@ func NewManager(cfg *config.Config, gcManager mgr.GCMgr, cdnManager mgr.CDNMgr)
 // it failed to download a piece from supernode.
 // And the supernode should handle the piece Error and do some repair operations.
 func (em *Manager) HandlePieceError(ctx context.Context, pieceErrorRequest *types.PieceErrorRequest) error {
-	// ignore the error that isn't caused by downloading from supernode
-	if !em.cfg.IsSuperPID(pieceErrorRequest.DstPid) {
+	// count all the reported errors before filtering them
+	em.metrics.pieceErrors.WithLabelValues(pieceErrorRequest.ErrorType).Inc()
+	em.stats.add(pieceErrorRequest, time.Now())
+
+	// ignore the error of the file that isn't caused by downloading from supernode,
+	// and the error of the service that is caused by downloading from supernode.
+	if em.cfg.IsSuperPID(pieceErrorRequest.DstPid) == isPeerServiceError(pieceErrorRequest.ErrorType) {
 		return nil
 	}
 
 	// if the error is handling, we should ignore it.
-	_, err := em.errorHandlingStore.Get(pieceErrorRequest.TaskID)
+	key := getHandlingKey(pieceErrorRequest)
+	_, err := em.errorHandlingStore.Get(key)
 	if err == nil {
 		return nil
 	}
 	if !errortypes.IsDataNotFound(err) {
-		logrus.Errorf("failed to get taskID(%s) from errorHandlingStore: %v", pieceErrorRequest.TaskID, err)
+		logrus.Errorf("failed to get key(%s) from errorHandlingStore: %v", key, err)
 		return err
 	}
 
 	select {
 	case em.pieceErrChan <- pieceErrorRequest:
-		em.errorHandlingStore.Add(pieceErrorRequest.TaskID, true)
+		em.errorHandlingStore.Add(key, true)
 		return nil
 	default:
 		logrus.Warnf("drop piece error request: %+v", pieceErrorRequest)
@@ -108,6 +144,21 @@ func (em *Manager) HandlePieceError(ctx context.Context, pieceErrorRequest *type
 	}
 }
 
+// ListTopPieceErrors returns the number of the piece errors of errorType reported within
+// the window, which are grouped by groupBy and sorted by the number in descending order.
+func (em *Manager) ListTopPieceErrors(ctx context.Context, errorType, groupBy string,
+	window time.Duration, limit int) ([]*types.PieceErrorStat, error) {
+	groupByFunc, ok := groupByFuncs[groupBy]
+	if !ok {
+		return nil, errors.Wrapf(errortypes.ErrInvalidValue, "groupBy: %s", groupBy)
+	}
+	if window <= 0 || window > statsRetention {
+		return nil, errors.Wrapf(errortypes.ErrInvalidValue, "window: %v should be in (0, %v]", window, statsRetention)
+	}
+
+	return em.stats.top(errorType, groupByFunc, window, limit, time.Now()), nil
+}
+
 // StartHandleError starts a goroutine to handle the piece error.
 func (em *Manager) StartHandleError(ctx context.Context) {
 	em.initHandlers()
@@ -124,6 +175,29 @@ func (em *Manager) StartHandleError(ctx context.Context) {
 	}()
 }
 
+// CheckHealth checks whether the workers handling the piece errors are alive,
+// and it's degraded if the queue of the piece errors is full.
+func (em *Manager) CheckHealth(ctx context.Context) *types.ComponentHealth {
+	workers := atomic.LoadInt32(&em.workers)
+	queued := len(em.pieceErrChan)
+	health := &types.ComponentHealth{
+		Name:   "pieceErrorWorkers",
+		Status: types.ComponentHealthStatusHealthy,
+		Details: map[string]string{
+			"workers": strconv.Itoa(int(workers)),
+			"queued":  strconv.Itoa(queued),
+		},
+	}
+	if workers <= 0 {
+		health.Status = types.ComponentHealthStatusUnhealthy
+		health.Message = "no worker is handling the piece errors"
+	} else if queued >= cap(em.pieceErrChan) {
+		health.Status = types.ComponentHealthStatusDegraded
+		health.Message = fmt.Sprintf("%d piece errors are being processed already", queued)
+	}
+	return health
+}
+
 func (em *Manager) initHandlers() {
 	rangeFunc := func(key, value interface{}) bool {
 		initFunc, ok := value.(handlerInitFunc)
@@ -136,7 +210,7 @@ func (em *Manager) initHandlers() {
 			return true
 		}
 
-		handler, err := initFunc(em.gcManager, em.cdnManager)
+		handler, err := initFunc(em.cfg, em.gcManager, em.cdnManager, em.progressManager)
 		if err != nil {
 			logrus.Errorf("failed to init handler type %s: %v", errType, err)
 			return true
@@ -159,9 +233,9 @@ func (em *Manager) deleteHandling(ctx context.Context) {
 			return true
 		}
 
-		if taskID, ok := key.(string); ok {
-			em.errorHandlingStore.Delete(taskID)
-			em.handledStore.Delete(taskID)
+		if handlingKey, ok := key.(string); ok {
+			em.errorHandlingStore.Delete(handlingKey)
+			em.handledStore.Delete(handlingKey)
 		}
 		return true
 	}
@@ -170,7 +244,9 @@ func (em *Manager) deleteHandling(ctx context.Context) {
 }
 func (em *Manager) startHandleErrorPool(ctx context.Context) {
 	for i := 0; i < HandleErrorPool; i++ {
+		atomic.AddInt32(&em.workers, 1)
 		go func() {
+			defer atomic.AddInt32(&em.workers, -1)
 			for per := range em.pieceErrChan {
 				if err := em.handleError(ctx, per); err != nil {
 					logrus.Errorf("failed to handle error %+v:%v", per, err)
@@ -181,9 +257,9 @@ func (em *Manager) startHandleErrorPool(ctx context.Context) {
 }
 
 func (em *Manager) handleError(ctx context.Context, pieceError *types.PieceErrorRequest) error {
-	// add taskID to handledStore regardless of the result of handler
+	// add the key to handledStore regardless of the result of handler
 	defer func() {
-		em.handledStore.Add(pieceError.TaskID, time.Now())
+		em.handledStore.Add(getHandlingKey(pieceError), time.Now())
 	}()
 
 	handler, err := em.getHandler(ctx, pieceError.ErrorType)
@@ -201,3 +277,14 @@ func (em *Manager) getHandler(ctx context.Context, errType string) (Handler, err
 
 	return nil, fmt.Errorf("unregistered error handler")
 }
+
+// getHandlingKey returns the key to deduplicate the piece errors being handled.
+// The errors of the file are deduplicated by the task, while the errors of the
+// service are deduplicated by each pair of the source and target peers,
+// so that the repeated failures reported by different peers will all be counted.
+func getHandlingKey(pieceErrorRequest *types.PieceErrorRequest) string {
+	if isPeerServiceError(pieceErrorRequest.ErrorType) {
+		return fmt.Sprintf("%s:%s:%s", pieceErrorRequest.ErrorType, pieceErrorRequest.SrcCid, pieceErrorRequest.DstPid)
+	}
+	return pieceErrorRequest.TaskID
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
 	"github.com/dragonflyoss/Dragonfly/supernode/util"
 
@@ -37,7 +38,8 @@ func init() {
 	Register(types.PieceErrorRequestErrorTypeFILEMD5NOTMATCH, NewFileMd5NotMatchHandler)
 }
 
-func NewFileMd5NotMatchHandler(gcManager mgr.GCMgr, cdnManager mgr.CDNMgr) (Handler, error) {
+func NewFileMd5NotMatchHandler(cfg *config.Config, gcManager mgr.GCMgr, cdnManager mgr.CDNMgr,
+	progressManager mgr.ProgressMgr) (Handler, error) {
 	return &FileMd5NotMatchHandler{
 		gcManager:  gcManager,
 		cdnManager: cdnManager,
@@ -0,0 +1,105 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pieceerror
+
+import (
+	"context"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var _ Handler = &PeerServiceErrorHandler{}
+
+// peerServiceErrorWeights maps the error types caused by the service of the peer
+// to the weight of the penalty on the reputation of the peer,
+// including the penalty of the failed piece which is reported along with the error.
+var peerServiceErrorWeights = map[string]float64{
+	// the peer server is likely to be offline when refusing the connection.
+	types.PieceErrorRequestErrorTypeCONNECTIONREFUSED: 3.0,
+	types.PieceErrorRequestErrorTypeTIMEOUT:           2.0,
+	types.PieceErrorRequestErrorTypeTRUNCATEDBODY:     1.0,
+}
+
+// PeerServiceErrorHandler penalizes the target peer that failed to serve the piece,
+// and marks it service down after repeated failures.
+type PeerServiceErrorHandler struct {
+	cfg             *config.Config
+	progressManager mgr.ProgressMgr
+}
+
+func init() {
+	for errType := range peerServiceErrorWeights {
+		Register(errType, NewPeerServiceErrorHandler)
+	}
+}
+
+func NewPeerServiceErrorHandler(cfg *config.Config, gcManager mgr.GCMgr, cdnManager mgr.CDNMgr,
+	progressManager mgr.ProgressMgr) (Handler, error) {
+	return &PeerServiceErrorHandler{
+		cfg:             cfg,
+		progressManager: progressManager,
+	}, nil
+}
+
+func (pseh *PeerServiceErrorHandler) Handle(ctx context.Context, pieceErrorRequest *types.PieceErrorRequest) error {
+	dstPID := pieceErrorRequest.DstPid
+	if stringutils.IsEmptyStr(dstPID) {
+		return errors.Wrap(errortypes.ErrEmptyValue, "dstPid")
+	}
+
+	weight, ok := peerServiceErrorWeights[pieceErrorRequest.ErrorType]
+	if !ok {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "errorType: %s", pieceErrorRequest.ErrorType)
+	}
+
+	recentErrors, err := pseh.progressManager.UpdatePeerServiceError(ctx, dstPID, weight)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update service error of peer(%s)", dstPID)
+	}
+	logrus.Warnf("peer(%s) failed to serve taskID(%s) pieceRange(%s) to client(%s) with %s, recent service errors: %.2f",
+		dstPID, pieceErrorRequest.TaskID, pieceErrorRequest.Range, pieceErrorRequest.SrcCid,
+		pieceErrorRequest.ErrorType, recentErrors)
+
+	eliminationLimit := pseh.cfg.GetEliminationLimit()
+	if eliminationLimit <= 0 || recentErrors < float64(eliminationLimit) {
+		return nil
+	}
+
+	peerState, err := pseh.progressManager.GetPeerStateByPeerID(ctx, dstPID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get peer state of peer(%s)", dstPID)
+	}
+	if peerState.ServiceDownTime > 0 {
+		return nil
+	}
+
+	logrus.Warnf("peer(%s) reaches the elimination limit %d, mark it service down", dstPID, eliminationLimit)
+	return pseh.progressManager.UpdatePeerServiceDown(ctx, dstPID)
+}
+
+// isPeerServiceError returns whether the errType is caused by the service of the peer.
+func isPeerServiceError(errType string) bool {
+	_, ok := peerServiceErrorWeights[errType]
+	return ok
+}
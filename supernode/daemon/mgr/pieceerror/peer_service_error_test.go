@@ -0,0 +1,143 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pieceerror
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/mock"
+
+	"github.com/go-check/check"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+func init() {
+	check.Suite(&PeerServiceErrorTestSuite{})
+}
+
+type PeerServiceErrorTestSuite struct {
+	mockCtl         *gomock.Controller
+	mockProgressMgr *mock.MockProgressMgr
+
+	cfg     *config.Config
+	handler Handler
+}
+
+func (s *PeerServiceErrorTestSuite) SetUpTest(c *check.C) {
+	s.mockCtl = gomock.NewController(c)
+	s.mockProgressMgr = mock.NewMockProgressMgr(s.mockCtl)
+
+	s.cfg = config.NewConfig()
+	s.cfg.SetSuperPID("superPid")
+	s.cfg.EliminationLimit = 5
+	s.handler, _ = NewPeerServiceErrorHandler(s.cfg, nil, nil, s.mockProgressMgr)
+}
+
+func (s *PeerServiceErrorTestSuite) TearDownTest(c *check.C) {
+	s.mockCtl.Finish()
+}
+
+func (s *PeerServiceErrorTestSuite) TestHandleBelowLimit(c *check.C) {
+	s.mockProgressMgr.EXPECT().UpdatePeerServiceError(gomock.Any(), "dstPid", 2.0).Return(2.0, nil)
+
+	err := s.handler.Handle(context.Background(), &types.PieceErrorRequest{
+		DstPid:    "dstPid",
+		ErrorType: types.PieceErrorRequestErrorTypeTIMEOUT,
+	})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *PeerServiceErrorTestSuite) TestHandleReachLimit(c *check.C) {
+	gomock.InOrder(
+		s.mockProgressMgr.EXPECT().UpdatePeerServiceError(gomock.Any(), "dstPid", 3.0).Return(5.5, nil),
+		s.mockProgressMgr.EXPECT().GetPeerStateByPeerID(gomock.Any(), "dstPid").Return(&mgr.PeerState{}, nil),
+		s.mockProgressMgr.EXPECT().UpdatePeerServiceDown(gomock.Any(), "dstPid").Return(nil),
+	)
+
+	err := s.handler.Handle(context.Background(), &types.PieceErrorRequest{
+		DstPid:    "dstPid",
+		ErrorType: types.PieceErrorRequestErrorTypeCONNECTIONREFUSED,
+	})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *PeerServiceErrorTestSuite) TestHandleAlreadyServiceDown(c *check.C) {
+	s.mockProgressMgr.EXPECT().UpdatePeerServiceError(gomock.Any(), "dstPid", 1.0).Return(6.0, nil)
+	s.mockProgressMgr.EXPECT().GetPeerStateByPeerID(gomock.Any(), "dstPid").Return(&mgr.PeerState{ServiceDownTime: 1}, nil)
+
+	err := s.handler.Handle(context.Background(), &types.PieceErrorRequest{
+		DstPid:    "dstPid",
+		ErrorType: types.PieceErrorRequestErrorTypeTRUNCATEDBODY,
+	})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *PeerServiceErrorTestSuite) TestHandleEmptyDstPid(c *check.C) {
+	err := s.handler.Handle(context.Background(), &types.PieceErrorRequest{
+		ErrorType: types.PieceErrorRequestErrorTypeTIMEOUT,
+	})
+	c.Assert(err, check.NotNil)
+}
+
+func (s *PeerServiceErrorTestSuite) TestHandlePieceErrorFilter(c *check.C) {
+	manager, _ := NewManager(s.cfg, nil, nil, s.mockProgressMgr, prometheus.NewRegistry())
+	cases := []struct {
+		dstPid    string
+		errorType string
+		accepted  bool
+	}{
+		{"superPid", types.PieceErrorRequestErrorTypeFILENOTEXIST, true},
+		{"dstPid", types.PieceErrorRequestErrorTypeFILENOTEXIST, false},
+		{"superPid", types.PieceErrorRequestErrorTypeTIMEOUT, false},
+		{"dstPid", types.PieceErrorRequestErrorTypeTIMEOUT, true},
+	}
+
+	for _, v := range cases {
+		err := manager.HandlePieceError(context.Background(), &types.PieceErrorRequest{
+			TaskID:    "taskID",
+			SrcCid:    "srcCid",
+			DstPid:    v.dstPid,
+			ErrorType: v.errorType,
+		})
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(len(manager.pieceErrChan), check.Equals, 2)
+
+	// the service errors reported by the different peers should not be deduplicated
+	manager.HandlePieceError(context.Background(), &types.PieceErrorRequest{
+		TaskID:    "taskID",
+		SrcCid:    "srcCid2",
+		DstPid:    "dstPid",
+		ErrorType: types.PieceErrorRequestErrorTypeTIMEOUT,
+	})
+	manager.HandlePieceError(context.Background(), &types.PieceErrorRequest{
+		TaskID:    "taskID",
+		SrcCid:    "srcCid2",
+		DstPid:    "dstPid",
+		ErrorType: types.PieceErrorRequestErrorTypeTIMEOUT,
+	})
+	c.Assert(len(manager.pieceErrChan), check.Equals, 3)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pieceerror
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+)
+
+const (
+	// statsBucketDuration is the time granularity of the piece error stats.
+	statsBucketDuration = time.Minute
+
+	// statsRetention is how long the piece error stats will be kept.
+	statsRetention = 24 * time.Hour
+)
+
+// The dimensions that the piece errors can be grouped by.
+const (
+	GroupByErrorType = "errorType"
+	GroupBySrcCid    = "srcCid"
+	GroupByDstPid    = "dstPid"
+	GroupByTaskID    = "taskId"
+)
+
+// statsKey identifies the piece errors with the same error type, source, destination and task.
+type statsKey struct {
+	errorType string
+	srcCid    string
+	dstPid    string
+	taskID    string
+}
+
+var groupByFuncs = map[string]func(key statsKey) string{
+	GroupByErrorType: func(key statsKey) string { return key.errorType },
+	GroupBySrcCid:    func(key statsKey) string { return key.srcCid },
+	GroupByDstPid:    func(key statsKey) string { return key.dstPid },
+	GroupByTaskID:    func(key statsKey) string { return key.taskID },
+}
+
+// statsBucket counts the piece errors reported within statsBucketDuration from start.
+type statsBucket struct {
+	start  time.Time
+	counts map[statsKey]int64
+}
+
+// errorStats aggregates the piece errors in a ring of buckets,
+// and the bucket older than statsRetention will be reused.
+type errorStats struct {
+	sync.Mutex
+	buckets []*statsBucket
+}
+
+func newErrorStats() *errorStats {
+	return &errorStats{
+		buckets: make([]*statsBucket, int(statsRetention/statsBucketDuration)),
+	}
+}
+
+// add counts the pieceErrorRequest reported at now.
+func (es *errorStats) add(pieceErrorRequest *types.PieceErrorRequest, now time.Time) {
+	start := now.Truncate(statsBucketDuration)
+	idx := int(start.UnixNano()/int64(statsBucketDuration)) % len(es.buckets)
+	key := statsKey{
+		errorType: pieceErrorRequest.ErrorType,
+		srcCid:    pieceErrorRequest.SrcCid,
+		dstPid:    pieceErrorRequest.DstPid,
+		taskID:    pieceErrorRequest.TaskID,
+	}
+
+	es.Lock()
+	defer es.Unlock()
+
+	bucket := es.buckets[idx]
+	if bucket == nil || !bucket.start.Equal(start) {
+		bucket = &statsBucket{
+			start:  start,
+			counts: make(map[statsKey]int64),
+		}
+		es.buckets[idx] = bucket
+	}
+	bucket.counts[key]++
+}
+
+// top returns the number of the piece errors of errorType reported within the window before now,
+// which are grouped by groupBy and sorted by the number in descending order.
+// The errors of all types will be counted if errorType is empty,
+// and all the groups will be returned if limit is not positive.
+func (es *errorStats) top(errorType string, groupBy func(key statsKey) string,
+	window time.Duration, limit int, now time.Time) []*types.PieceErrorStat {
+	since := now.Add(-window)
+	counts := make(map[string]int64)
+
+	es.Lock()
+	for _, bucket := range es.buckets {
+		if bucket == nil || !bucket.start.Add(statsBucketDuration).After(since) || bucket.start.After(now) {
+			continue
+		}
+		for key, count := range bucket.counts {
+			if errorType != "" && key.errorType != errorType {
+				continue
+			}
+			counts[groupBy(key)] += count
+		}
+	}
+	es.Unlock()
+
+	result := make([]*types.PieceErrorStat, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, &types.PieceErrorStat{
+			Key:   key,
+			Count: count,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
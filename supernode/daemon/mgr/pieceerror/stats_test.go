@@ -0,0 +1,107 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pieceerror
+
+import (
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+
+	"github.com/go-check/check"
+)
+
+func init() {
+	check.Suite(&ErrorStatsTestSuite{})
+}
+
+type ErrorStatsTestSuite struct{}
+
+func (s *ErrorStatsTestSuite) TestTop(c *check.C) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	stats := newErrorStats()
+	add := func(errorType, dstPid string, ago time.Duration, times int) {
+		for i := 0; i < times; i++ {
+			stats.add(&types.PieceErrorRequest{
+				ErrorType: errorType,
+				SrcCid:    "srcCid",
+				DstPid:    dstPid,
+				TaskID:    "taskID",
+			}, now.Add(-ago))
+		}
+	}
+	add(types.PieceErrorRequestErrorTypeFILEMD5NOTMATCH, "peerA", time.Minute, 3)
+	add(types.PieceErrorRequestErrorTypeFILEMD5NOTMATCH, "peerB", 10*time.Minute, 5)
+	add(types.PieceErrorRequestErrorTypeFILEMD5NOTMATCH, "peerC", 2*time.Hour, 10)
+	add(types.PieceErrorRequestErrorTypeTIMEOUT, "peerA", time.Minute, 4)
+
+	var cases = []struct {
+		errorType string
+		groupBy   string
+		window    time.Duration
+		limit     int
+		expected  []*types.PieceErrorStat
+	}{
+		{
+			errorType: types.PieceErrorRequestErrorTypeFILEMD5NOTMATCH,
+			groupBy:   GroupByDstPid,
+			window:    time.Hour,
+			expected:  []*types.PieceErrorStat{{Key: "peerB", Count: 5}, {Key: "peerA", Count: 3}},
+		},
+		{
+			errorType: types.PieceErrorRequestErrorTypeFILEMD5NOTMATCH,
+			groupBy:   GroupByDstPid,
+			window:    statsRetention,
+			limit:     2,
+			expected:  []*types.PieceErrorStat{{Key: "peerC", Count: 10}, {Key: "peerB", Count: 5}},
+		},
+		{
+			groupBy:  GroupByDstPid,
+			window:   5 * time.Minute,
+			expected: []*types.PieceErrorStat{{Key: "peerA", Count: 7}},
+		},
+		{
+			groupBy: GroupByErrorType,
+			window:  time.Hour,
+			expected: []*types.PieceErrorStat{
+				{Key: types.PieceErrorRequestErrorTypeFILEMD5NOTMATCH, Count: 8},
+				{Key: types.PieceErrorRequestErrorTypeTIMEOUT, Count: 4},
+			},
+		},
+		{
+			groupBy:  GroupByTaskID,
+			window:   time.Hour,
+			expected: []*types.PieceErrorStat{{Key: "taskID", Count: 12}},
+		},
+	}
+
+	for _, v := range cases {
+		result := stats.top(v.errorType, groupByFuncs[v.groupBy], v.window, v.limit, now)
+		c.Check(result, check.DeepEquals, v.expected)
+	}
+}
+
+func (s *ErrorStatsTestSuite) TestReuseBucket(c *check.C) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	stats := newErrorStats()
+	request := &types.PieceErrorRequest{ErrorType: types.PieceErrorRequestErrorTypeTIMEOUT, DstPid: "peerA"}
+
+	stats.add(request, now)
+	stats.add(request, now.Add(statsRetention))
+
+	result := stats.top("", groupByFuncs[GroupByDstPid], statsRetention, 0, now.Add(statsRetention))
+	c.Check(result, check.DeepEquals, []*types.PieceErrorStat{{Key: "peerA", Count: 1}})
+}
@@ -19,6 +19,9 @@ package progress
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
@@ -29,6 +32,7 @@ import (
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"github.com/willf/bitset"
 )
@@ -44,6 +48,10 @@ const (
 	// PieceAvailable means that the piece has neither been downloaded successfully
 	// nor being downloaded and supernode has downloaded it successfully.
 	PieceAvailable = "available"
+
+	// PieceFailed means that the piece has been failed to download
+	// and has not been downloaded successfully yet.
+	PieceFailed = "failed"
 )
 
 var _ mgr.ProgressMgr = &Manager{}
@@ -75,23 +83,43 @@ type Manager struct {
 	// key:taskID string, value:superLoadState *superLoadState
 	superLoad *stateSyncMap
 
-	cfg *config.Config
+	// superLoadLock makes the check and update of superLoad atomic for all tasks.
+	superLoadLock sync.Mutex
+
+	// now returns the current time, which can be replaced by a virtual clock.
+	now func() time.Time
+
+	cfg     *config.Config
+	metrics *metrics
 }
 
 // NewManager returns a new Manager.
-func NewManager(cfg *config.Config) (*Manager, error) {
-	manager := &Manager{
+func NewManager(cfg *config.Config, register prometheus.Registerer) (*Manager, error) {
+	manager, err := NewManagerWithClock(cfg, time.Now, register)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.startMonitorSuperLoad()
+	return manager, nil
+}
+
+// NewManagerWithClock returns a new Manager which uses now to get the current time.
+//
+// The superload will not be renewed in the background periodically,
+// and the caller should call RenewSuperLoad by itself according to the clock.
+func NewManagerWithClock(cfg *config.Config, now func() time.Time, register prometheus.Registerer) (*Manager, error) {
+	return &Manager{
 		cfg:             cfg,
+		metrics:         newMetrics(register),
+		now:             now,
 		superProgress:   newStateSyncMap(),
 		clientProgress:  newStateSyncMap(),
 		peerProgress:    newStateSyncMap(),
 		pieceProgress:   newStateSyncMap(),
 		clientBlackInfo: syncmap.NewSyncMap(),
 		superLoad:       newStateSyncMap(),
-	}
-
-	manager.startMonitorSuperLoad()
-	return manager, nil
+	}, nil
 }
 
 // InitProgress inits the correlation information between peers and pieces, etc.
@@ -109,11 +137,11 @@ func (pm *Manager) InitProgress(ctx context.Context, taskID, peerID, clientID st
 
 	// init cdn node if the clientID represents a supernode.
 	if pm.cfg.IsSuperCID(clientID) {
-		return pm.superProgress.add(taskID, newSuperState())
+		return pm.superProgress.add(taskID, newSuperState(pm.now()))
 	}
 
 	// init peer node if the clientID represents a ordinary peer node.
-	if err := pm.clientProgress.add(clientID, newClientState()); err != nil {
+	if err := pm.clientProgress.add(clientID, newClientState(taskID, peerID)); err != nil {
 		return err
 	}
 	defer func() {
@@ -140,6 +168,8 @@ func (pm *Manager) UpdateProgress(ctx context.Context, taskID, srcCID, srcPID, d
 		return errors.Wrapf(errortypes.ErrEmptyValue, "srcPID for taskID:%s", taskID)
 	}
 
+	pm.touchTask(taskID)
+
 	// Step1: update the PieceProgress
 	// Add one more peer for this piece when the srcPID successfully downloads the piece.
 	if pieceStatus == config.PieceSUCCESS {
@@ -153,7 +183,7 @@ func (pm *Manager) UpdateProgress(ctx context.Context, taskID, srcCID, srcPID, d
 	}
 
 	// Step2: update the clientProgress and superProgress
-	result, err := pm.updateClientProgress(taskID, srcCID, dstPID, pieceNum, pieceStatus)
+	result, released, err := pm.updateClientProgress(taskID, srcCID, dstPID, pieceNum, pieceStatus)
 	if err != nil {
 		logrus.Errorf("failed to update ClientProgress taskID(%s) srcCID(%s) dstPID(%s) pieceNum(%d) pieceStatus(%d): %v",
 			taskID, srcCID, dstPID, pieceNum, pieceStatus, err)
@@ -161,6 +191,7 @@ func (pm *Manager) UpdateProgress(ctx context.Context, taskID, srcCID, srcPID, d
 	}
 	logrus.Debugf("success to update ClientProgress taskID(%s) srcCID(%s) dstPID(%s) pieceNum(%d) pieceStatus(%d) with result: %t",
 		taskID, srcCID, dstPID, pieceNum, pieceStatus, result)
+	pm.releaseProducerLoad(taskID, released, pieceNum)
 	// It means that it's already successful and
 	// there is no need to perform subsequent updates
 	// when err==nil and result ==false.
@@ -188,7 +219,8 @@ func (pm *Manager) UpdateClientProgress(ctx context.Context, taskID, srcCID, dst
 		return errors.Wrapf(errortypes.ErrEmptyValue, "srcCID for taskID:%s", taskID)
 	}
 
-	result, err := pm.updateClientProgress(taskID, srcCID, dstPID, pieceNum, pieceStatus)
+	pm.touchTask(taskID)
+	result, released, err := pm.updateClientProgress(taskID, srcCID, dstPID, pieceNum, pieceStatus)
 	if err != nil {
 		logrus.Errorf("failed to update ClientProgress taskID(%s) srcCID(%s) dstPID(%s) pieceNum(%d) pieceStatus(%d): %v",
 			taskID, srcCID, dstPID, pieceNum, pieceStatus, err)
@@ -196,13 +228,14 @@ func (pm *Manager) UpdateClientProgress(ctx context.Context, taskID, srcCID, dst
 	}
 	logrus.Debugf("success to update ClientProgress taskID(%s) srcCID(%s) dstPID(%s) pieceNum(%d) pieceStatus(%d) with result: %t",
 		taskID, srcCID, dstPID, pieceNum, pieceStatus, result)
+	pm.releaseProducerLoad(taskID, released, pieceNum)
 
 	return nil
 }
 
 // GetPieceProgressByCID gets all pieces with specified clientID.
 //
-// And the pieceStatus should be one of the `PieceRunning`,`PieceSuccess` and `PieceAvailable`.
+// And the pieceStatus should be one of the `PieceRunning`,`PieceSuccess`,`PieceFailed` and `PieceAvailable`.
 // If not, the `PieceAvailable` will be as the default value.
 func (pm *Manager) GetPieceProgressByCID(ctx context.Context, taskID, clientID, pieceStatus string) (pieceNums []int, err error) {
 	cs, err := pm.clientProgress.getAsClientState(clientID)
@@ -216,13 +249,23 @@ func (pm *Manager) GetPieceProgressByCID(ctx context.Context, taskID, clientID,
 		return runningPieces, nil
 	}
 
+	// get failed pieces
+	if pieceStatus == PieceFailed {
+		return getFailedPieces(cs.pieceBitSet.Clone()), nil
+	}
+
 	// get bitset
 	ss, err := pm.superProgress.getAsSuperState(taskID)
 	if err != nil {
 		return nil, err
 	}
-	clientBitset := cs.pieceBitSet.Clone()
+	ss.touch(pm.now())
 	cdnBitset := ss.pieceBitSet.Clone()
+	clientBitset := cs.pieceBitSet.Clone()
+	if cs.finished {
+		// the finished client has all the pieces downloaded by supernode.
+		clientBitset = cdnBitset.Clone()
+	}
 
 	// get successful pieces
 	if pieceStatus == PieceSuccess {
@@ -233,6 +276,17 @@ func (pm *Manager) GetPieceProgressByCID(ctx context.Context, taskID, clientID,
 	return getAvailablePieces(clientBitset, cdnBitset, runningPieces)
 }
 
+// GetCDNPieceProgress gets the pieces which have been downloaded by CDN successfully.
+func (pm *Manager) GetCDNPieceProgress(ctx context.Context, taskID string) (pieceNums []int, err error) {
+	ss, err := pm.superProgress.getAsSuperState(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	cdnBitset := ss.pieceBitSet.Clone()
+	return getSuccessfulPieces(cdnBitset, cdnBitset.Clone())
+}
+
 // GetPeerIDsByPieceNum gets all peerIDs with specified taskID and pieceNum.
 // It will return nil when no peers are available.
 func (pm *Manager) GetPeerIDsByPieceNum(ctx context.Context, taskID string, pieceNum int) (peerIDs []string, err error) {
@@ -248,6 +302,16 @@ func (pm *Manager) GetPeerIDsByPieceNum(ctx context.Context, taskID string, piec
 	return ps.getAvailablePeers(), nil
 }
 
+// GetRunningPieceSources gets all the peerIDs that the running piece is being downloaded from.
+func (pm *Manager) GetRunningPieceSources(ctx context.Context, clientID string, pieceNum int) (dstPIDs []string, err error) {
+	cs, err := pm.clientProgress.getAsClientState(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return getRunningPieceSources(cs, pieceNum)
+}
+
 // GetPeerStateByPeerID gets peer state with specified peerID.
 func (pm *Manager) GetPeerStateByPeerID(ctx context.Context, peerID string) (*mgr.PeerState, error) {
 	peerState, err := pm.peerProgress.getAsPeerState(peerID)
@@ -255,15 +319,63 @@ func (pm *Manager) GetPeerStateByPeerID(ctx context.Context, peerID string) (*mg
 		return nil, err
 	}
 
+	reputation, serviceErrors := peerState.reputation.get(pm.now(), pm.getReputationHalfLife())
 	return &mgr.PeerState{
-		PeerID:            peerID,
-		ServiceDownTime:   peerState.serviceDownTime,
-		ClientErrorCount:  peerState.clientErrorCount,
-		ServiceErrorCount: peerState.serviceErrorCount,
-		ProducerLoad:      peerState.producerLoad,
+		PeerID:              peerID,
+		ServiceDownTime:     peerState.serviceDownTime,
+		ClientErrorCount:    peerState.clientErrorCount,
+		ServiceErrorCount:   peerState.serviceErrorCount,
+		RecentServiceErrors: serviceErrors,
+		ProducerLoad:        peerState.producerLoad,
+		Throughput:          peerState.throughput.get(),
+		Reputation:          reputation,
 	}, nil
 }
 
+// UpdatePeerThroughput updates the estimated upload throughput of the peerID.
+func (pm *Manager) UpdatePeerThroughput(ctx context.Context, peerID string, pieceSize int64, cost time.Duration) error {
+	if stringutils.IsEmptyStr(peerID) {
+		return errors.Wrap(errortypes.ErrEmptyValue, "peerID")
+	}
+	if pieceSize <= 0 || cost <= 0 {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "pieceSize: %d, cost: %v", pieceSize, cost)
+	}
+
+	peerState, err := pm.peerProgress.getAsPeerState(peerID)
+	if err != nil {
+		return err
+	}
+
+	peerState.throughput.update(pieceSize, cost, pm.now())
+	peerState.reputation.addLatency(cost, pm.now(), pm.getReputationHalfLife())
+	return nil
+}
+
+// UpdatePeerServiceError penalizes the peerID with an error of the weight.
+// The failed piece has been penalized with pieceFailedWeight when it was reported,
+// so only the rest of the weight is added here to penalize a failure once.
+func (pm *Manager) UpdatePeerServiceError(ctx context.Context, peerID string, weight float64) (float64, error) {
+	if stringutils.IsEmptyStr(peerID) {
+		return 0, errors.Wrap(errortypes.ErrEmptyValue, "peerID")
+	}
+	if weight <= 0 {
+		return 0, errors.Wrapf(errortypes.ErrInvalidValue, "weight: %v", weight)
+	}
+
+	peerState, err := pm.peerProgress.getAsPeerState(peerID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := pm.now()
+	halfLife := pm.getReputationHalfLife()
+	if extra := weight - pieceFailedWeight; extra > 0 {
+		peerState.reputation.addFailure(extra, now, halfLife)
+	}
+	_, recentErrors := peerState.reputation.get(now, halfLife)
+	return recentErrors, nil
+}
+
 // UpdatePeerServiceDown does update operation when a peer server offline.
 func (pm *Manager) UpdatePeerServiceDown(ctx context.Context, peerID string) (err error) {
 	peerState, err := pm.peerProgress.getAsPeerState(peerID)
@@ -276,17 +388,64 @@ func (pm *Manager) UpdatePeerServiceDown(ctx context.Context, peerID string) (er
 	}
 
 	peerState.serviceDownTime = timeutils.GetCurrentTimeMillis()
+	peerState.reputation.addFailure(serviceDownWeight, pm.now(), pm.getReputationHalfLife())
 	return nil
 }
 
 // GetPeersByTaskID gets all peers info with specified taskID.
+// The clients of the task are ranged and only the IDs of the peers are filled.
 func (pm *Manager) GetPeersByTaskID(ctx context.Context, taskID string) (peersInfo []*types.PeerInfo, err error) {
-	return nil, nil
+	if stringutils.IsEmptyStr(taskID) {
+		return nil, errors.Wrap(errortypes.ErrEmptyValue, "taskID")
+	}
+
+	peerIDs := make(map[string]bool)
+	pm.clientProgress.Range(func(key, value interface{}) bool {
+		cs, ok := value.(*clientState)
+		if !ok || cs.taskID != taskID || peerIDs[cs.peerID] {
+			return true
+		}
+		peerIDs[cs.peerID] = true
+		peersInfo = append(peersInfo, &types.PeerInfo{ID: cs.peerID})
+		return true
+	})
+
+	sort.Slice(peersInfo, func(i, j int) bool {
+		return peersInfo[i].ID < peersInfo[j].ID
+	})
+	return peersInfo, nil
+}
+
+// GetClientsByTaskID gets the peerIDs of all clients with specified taskID keyed by the clientID.
+func (pm *Manager) GetClientsByTaskID(ctx context.Context, taskID string) (clients map[string]string, err error) {
+	if stringutils.IsEmptyStr(taskID) {
+		return nil, errors.Wrap(errortypes.ErrEmptyValue, "taskID")
+	}
+
+	clients = make(map[string]string)
+	pm.clientProgress.Range(func(key, value interface{}) bool {
+		clientID, ok := key.(string)
+		if !ok {
+			return true
+		}
+		cs, ok := value.(*clientState)
+		if !ok || cs.taskID != taskID {
+			return true
+		}
+		clients[clientID] = cs.peerID
+		return true
+	})
+	return clients, nil
 }
 
 // GetBlackInfoByPeerID gets black info with specified peerID.
 func (pm *Manager) GetBlackInfoByPeerID(ctx context.Context, peerID string) (dstPIDMap *syncmap.SyncMap, err error) {
-	return pm.clientBlackInfo.GetAsMap(peerID)
+	return pm.getBlackInfo(peerID)
+}
+
+// IsInBlackList returns whether dstPID is in the blacklist of srcPID.
+func (pm *Manager) IsInBlackList(ctx context.Context, srcPID, dstPID string) bool {
+	return pm.isInBlackList(srcPID, dstPID)
 }
 
 // getSuccessfulPieces gets pieces that the piece has been downloaded successful.
@@ -302,6 +461,18 @@ func getSuccessfulPieces(clientBitset, cdnBitset *bitset.BitSet) ([]int, error)
 	return successPieces, nil
 }
 
+// getFailedPieces gets pieces that the piece has been failed to download.
+func getFailedPieces(clientBitset *bitset.BitSet) []int {
+	failedPieces := make([]int, 0)
+	for i, e := clientBitset.NextSet(0); e; i, e = clientBitset.NextSet(i + 1) {
+		if getPieceStatusByIndex(i) == config.PieceFAILED {
+			failedPieces = append(failedPieces, getPieceNumByIndex(i))
+		}
+	}
+
+	return failedPieces
+}
+
 // getAvailablePieces gets pieces that has neither been downloaded successfully
 // nor being downloaded and supernode has downloaded it successfully.
 func getAvailablePieces(clientBitset, cdnBitset *bitset.BitSet, runningPieceNums []int) ([]int, error) {
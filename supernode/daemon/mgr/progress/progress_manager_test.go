@@ -100,3 +100,27 @@ func (s *ProgressManagerTestSuite) TestGetAvailablePieces(c *check.C) {
 		c.Check(result, check.DeepEquals, v.expected)
 	}
 }
+
+func (s *ProgressManagerTestSuite) TestGetFailedPieces(c *check.C) {
+	var cases = []struct {
+		clientBitset *bitset.BitSet
+		expected     []int
+	}{
+		{
+			clientBitset: bitset.New(16),
+			expected:     []int{},
+		},
+		{
+			clientBitset: bitset.New(24).Set(1).Set(10).Set(16),
+			expected:     []int{1},
+		},
+		{
+			clientBitset: bitset.New(24).Set(2).Set(9).Set(18),
+			expected:     []int{0, 2},
+		},
+	}
+
+	for _, v := range cases {
+		c.Check(getFailedPieces(v.clientBitset), check.DeepEquals, v.expected)
+	}
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/metricsutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/willf/bitset"
+)
+
+// The estimated bytes of the fixed overhead of the progress states,
+// which don't need to be accurate but should be proportional to the real usage.
+const (
+	// mapEntryBytes is the overhead of an entry in a sync.Map except the key.
+	mapEntryBytes = 64
+
+	superStateBytes  = 32
+	clientStateBytes = 3*mapEntryBytes + 64
+	peerStateBytes   = 256
+	pieceStateBytes  = mapEntryBytes + 16
+)
+
+// The kinds of the progress states which are used as the label of metrics.
+const (
+	superStateKind  = "super"
+	clientStateKind = "client"
+	peerStateKind   = "peer"
+	pieceStateKind  = "piece"
+)
+
+type metrics struct {
+	states     *prometheus.GaugeVec
+	stateBytes *prometheus.GaugeVec
+}
+
+func newMetrics(register prometheus.Registerer) *metrics {
+	return &metrics{
+		states: metricsutils.NewGauge(config.SubsystemSupernode, "progress_states",
+			"Current number of the progress states", []string{"state"}, register),
+
+		stateBytes: metricsutils.NewGauge(config.SubsystemSupernode, "progress_state_bytes",
+			"Estimated memory used by the progress states in bytes", []string{"state"}, register),
+	}
+}
+
+// stateUsage accumulates the number and the estimated bytes of a kind of progress states.
+type stateUsage struct {
+	count int
+	bytes int64
+}
+
+func (su *stateUsage) add(bytes int64) {
+	su.count++
+	su.bytes += bytes
+}
+
+// taskUsage accumulates the usage of the progress states of a task.
+type taskUsage struct {
+	mgr.TaskProgressUsage
+
+	// hasSuperState means that the super progress of the task still exists,
+	// and the others are the orphan states of the deleted task.
+	hasSuperState bool
+
+	// unfinished is the number of the clients which are still downloading.
+	unfinished int
+}
+
+// CompactCID compacts the client progress of the clientID which has finished downloading.
+func (pm *Manager) CompactCID(ctx context.Context, clientID string) error {
+	cs, err := pm.clientProgress.getAsClientState(clientID)
+	if err != nil {
+		return err
+	}
+	if cs.finished {
+		return nil
+	}
+
+	// replace the state instead of modifying it,
+	// because it may be being read by others without lock.
+	return pm.clientProgress.add(clientID, newFinishedClientState(cs.taskID, cs.peerID))
+}
+
+// GetMemoryUsage returns the estimated memory usage of the progress states,
+// and updates the metrics of them at the same time.
+func (pm *Manager) GetMemoryUsage(ctx context.Context) (*mgr.ProgressMemoryUsage, error) {
+	usages := map[string]*stateUsage{
+		superStateKind:  {},
+		clientStateKind: {},
+		peerStateKind:   {},
+		pieceStateKind:  {},
+	}
+	tasks := make(map[string]*taskUsage)
+	getTask := func(taskID string) *taskUsage {
+		task, ok := tasks[taskID]
+		if !ok {
+			task = &taskUsage{TaskProgressUsage: mgr.TaskProgressUsage{TaskID: taskID}}
+			tasks[taskID] = task
+		}
+		return task
+	}
+
+	pm.superProgress.Range(func(key, value interface{}) bool {
+		taskID, _ := key.(string)
+		ss, ok := value.(*superState)
+		if !ok {
+			return true
+		}
+		bytes := mapEntryBytes + int64(len(taskID)) + superStateBytes + bitsetBytes(ss.pieceBitSet)
+		usages[superStateKind].add(bytes)
+
+		task := getTask(taskID)
+		task.Bytes += bytes
+		task.AccessTime = ss.getAccessTime()
+		task.hasSuperState = true
+		return true
+	})
+
+	pm.clientProgress.Range(func(key, value interface{}) bool {
+		clientID, _ := key.(string)
+		cs, ok := value.(*clientState)
+		if !ok {
+			return true
+		}
+		bytes := mapEntryBytes + int64(len(clientID)) + clientStateBytes + bitsetBytes(cs.pieceBitSet) +
+			mapEntryBytes*int64(countSyncMap(cs.runningPiece.Map)+countSyncMap(cs.duplicatePiece.Map))
+		usages[clientStateKind].add(bytes)
+
+		task := getTask(cs.taskID)
+		task.Bytes += bytes
+		if !cs.finished {
+			task.unfinished++
+		}
+		return true
+	})
+
+	pm.pieceProgress.Range(func(key, value interface{}) bool {
+		pieceKey, _ := key.(string)
+		ps, ok := value.(*pieceState)
+		if !ok {
+			return true
+		}
+		bytes := mapEntryBytes + int64(len(pieceKey)) + pieceStateBytes +
+			mapEntryBytes*int64(countSyncMap(ps.pieceContainer.Map))
+		usages[pieceStateKind].add(bytes)
+
+		// the key of pieceProgress is pieceNum@taskID
+		if idx := strings.IndexByte(pieceKey, '@'); idx >= 0 {
+			getTask(pieceKey[idx+1:]).Bytes += bytes
+		}
+		return true
+	})
+
+	pm.peerProgress.Range(func(key, value interface{}) bool {
+		peerID, _ := key.(string)
+		usages[peerStateKind].add(mapEntryBytes + int64(len(peerID)) + peerStateBytes)
+		return true
+	})
+
+	result := &mgr.ProgressMemoryUsage{}
+	for kind, usage := range usages {
+		pm.metrics.states.WithLabelValues(kind).Set(float64(usage.count))
+		pm.metrics.stateBytes.WithLabelValues(kind).Set(float64(usage.bytes))
+		result.TotalBytes += usage.bytes
+	}
+
+	for _, task := range tasks {
+		if !task.hasSuperState || task.unfinished > 0 {
+			continue
+		}
+		usage := task.TaskProgressUsage
+		result.CompletedTasks = append(result.CompletedTasks, &usage)
+	}
+	sort.Slice(result.CompletedTasks, func(i, j int) bool {
+		ti, tj := result.CompletedTasks[i], result.CompletedTasks[j]
+		if !ti.AccessTime.Equal(tj.AccessTime) {
+			return ti.AccessTime.Before(tj.AccessTime)
+		}
+		return ti.TaskID < tj.TaskID
+	})
+
+	logrus.Debugf("progress memory usage: total bytes(%d), completed tasks(%d)",
+		result.TotalBytes, len(result.CompletedTasks))
+	return result, nil
+}
+
+// touchTask updates the access time of the progress of taskID.
+func (pm *Manager) touchTask(taskID string) {
+	ss, err := pm.superProgress.getAsSuperState(taskID)
+	if err != nil {
+		return
+	}
+	ss.touch(pm.now())
+}
+
+func bitsetBytes(bs *bitset.BitSet) int64 {
+	if bs == nil {
+		return 0
+	}
+	return int64(bs.BinaryStorageSize())
+}
+
+func countSyncMap(m interface {
+	Range(f func(key, value interface{}) bool)
+}) int {
+	count := 0
+	m.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"context"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/go-check/check"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	check.Suite(&ProgressMemoryTestSuite{})
+}
+
+type ProgressMemoryTestSuite struct {
+	now     time.Time
+	manager *Manager
+}
+
+func (s *ProgressMemoryTestSuite) SetUpTest(c *check.C) {
+	cfg := config.NewConfig()
+	cfg.SetCIDPrefix("127.0.0.1")
+	cfg.SetSuperPID("superPID")
+
+	s.now = time.Unix(1000, 0)
+	s.manager, _ = NewManagerWithClock(cfg, func() time.Time { return s.now }, prometheus.NewRegistry())
+}
+
+func (s *ProgressMemoryTestSuite) initTask(c *check.C, taskID string, pieceTotal int, clientIDs ...string) {
+	ctx := context.Background()
+	superCID := s.manager.cfg.GetSuperCID(taskID)
+	c.Assert(s.manager.InitProgress(ctx, taskID, "superPID", superCID), check.IsNil)
+	for i := 0; i < pieceTotal; i++ {
+		c.Assert(s.manager.UpdateProgress(ctx, taskID, superCID, "superPID", "", i, config.PieceSUCCESS), check.IsNil)
+	}
+
+	for _, clientID := range clientIDs {
+		peerID := "peer-" + clientID
+		c.Assert(s.manager.InitProgress(ctx, taskID, peerID, clientID), check.IsNil)
+		for i := 0; i < pieceTotal; i++ {
+			c.Assert(s.manager.UpdateProgress(ctx, taskID, clientID, peerID, "superPID", i, config.PieceSUCCESS), check.IsNil)
+		}
+	}
+}
+
+func (s *ProgressMemoryTestSuite) TestGetMemoryUsage(c *check.C) {
+	ctx := context.Background()
+	s.initTask(c, "task1", 16, "client1", "client2")
+	s.now = s.now.Add(time.Minute)
+	s.initTask(c, "task2", 16, "client3")
+
+	usage, err := s.manager.GetMemoryUsage(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(usage.TotalBytes > 0, check.Equals, true)
+	c.Check(usage.CompletedTasks, check.HasLen, 0)
+
+	// the task is completed when all of its clients have finished.
+	c.Assert(s.manager.CompactCID(ctx, "client3"), check.IsNil)
+	c.Assert(s.manager.CompactCID(ctx, "client1"), check.IsNil)
+	usage, err = s.manager.GetMemoryUsage(ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(usage.CompletedTasks, check.HasLen, 1)
+	c.Check(usage.CompletedTasks[0].TaskID, check.Equals, "task2")
+
+	c.Assert(s.manager.CompactCID(ctx, "client2"), check.IsNil)
+	compacted, err := s.manager.GetMemoryUsage(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(compacted.TotalBytes < usage.TotalBytes, check.Equals, true)
+	c.Assert(compacted.CompletedTasks, check.HasLen, 2)
+
+	// the coldest task comes first.
+	c.Check(compacted.CompletedTasks[0].TaskID, check.Equals, "task1")
+	c.Check(compacted.CompletedTasks[0].AccessTime, check.Equals, time.Unix(1000, 0))
+	c.Check(compacted.CompletedTasks[1].TaskID, check.Equals, "task2")
+	c.Check(compacted.CompletedTasks[0].Bytes > 0, check.Equals, true)
+}
+
+func (s *ProgressMemoryTestSuite) TestCompactCID(c *check.C) {
+	ctx := context.Background()
+	s.initTask(c, "task1", 4, "client1")
+
+	c.Assert(s.manager.CompactCID(ctx, "client1"), check.IsNil)
+	cs, err := s.manager.clientProgress.getAsClientState("client1")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.finished, check.Equals, true)
+	c.Check(cs.taskID, check.Equals, "task1")
+	c.Check(cs.pieceBitSet.Count(), check.Equals, uint(0))
+
+	// the finished client is considered to have all the pieces.
+	pieces, err := s.manager.GetPieceProgressByCID(ctx, "task1", "client1", PieceSuccess)
+	c.Assert(err, check.IsNil)
+	c.Check(pieces, check.DeepEquals, []int{0, 1, 2, 3})
+
+	// the reports after finished are ignored.
+	err = s.manager.UpdateClientProgress(ctx, "task1", "client1", "superPID", 0, config.PieceRUNNING)
+	c.Check(err, check.IsNil)
+	cs, err = s.manager.clientProgress.getAsClientState("client1")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.runningPiece.ListKeyAsIntSlice(), check.HasLen, 0)
+
+	c.Check(s.manager.CompactCID(ctx, "client1"), check.IsNil)
+	c.Check(s.manager.CompactCID(ctx, "foo"), check.NotNil)
+}
+
+func (s *ProgressMemoryTestSuite) TestGetPeersByTaskID(c *check.C) {
+	ctx := context.Background()
+	s.initTask(c, "task1", 4, "client2", "client1")
+	s.initTask(c, "task2", 4, "client3")
+
+	peers, err := s.manager.GetPeersByTaskID(ctx, "task1")
+	c.Assert(err, check.IsNil)
+	c.Assert(peers, check.HasLen, 2)
+	c.Check(peers[0].ID, check.Equals, "peer-client1")
+	c.Check(peers[1].ID, check.Equals, "peer-client2")
+
+	// the compacted clients are still listed.
+	c.Assert(s.manager.CompactCID(ctx, "client1"), check.IsNil)
+	peers, err = s.manager.GetPeersByTaskID(ctx, "task1")
+	c.Assert(err, check.IsNil)
+	c.Check(peers, check.HasLen, 2)
+
+	peers, err = s.manager.GetPeersByTaskID(ctx, "foo")
+	c.Assert(err, check.IsNil)
+	c.Check(peers, check.HasLen, 0)
+}
+
+func (s *ProgressMemoryTestSuite) TestGetCDNPieceProgress(c *check.C) {
+	ctx := context.Background()
+	s.initTask(c, "task1", 3)
+	superCID := s.manager.cfg.GetSuperCID("task1")
+	c.Assert(s.manager.UpdateProgress(ctx, "task1", superCID, "superPID", "", 3, config.PieceRUNNING), check.IsNil)
+
+	pieces, err := s.manager.GetCDNPieceProgress(ctx, "task1")
+	c.Assert(err, check.IsNil)
+	c.Check(pieces, check.DeepEquals, []int{0, 1, 2})
+
+	_, err = s.manager.GetCDNPieceProgress(ctx, "foo")
+	c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
+}
+
+func (s *ProgressMemoryTestSuite) TestUpdatePeerServiceError(c *check.C) {
+	ctx := context.Background()
+	s.initTask(c, "task1", 1, "client1")
+	c.Assert(s.manager.InitProgress(ctx, "task1", "peer-client2", "client2"), check.IsNil)
+
+	// the failed piece and the service error are the same failure.
+	c.Assert(s.manager.UpdateProgress(ctx, "task1", "client2", "peer-client2", "peer-client1", 0, config.PieceFAILED), check.IsNil)
+	recentErrors, err := s.manager.UpdatePeerServiceError(ctx, "peer-client1", 3.0)
+	c.Assert(err, check.IsNil)
+	c.Check(recentErrors, check.Equals, 3.0)
+
+	recentErrors, err = s.manager.UpdatePeerServiceError(ctx, "peer-client1", 1.0)
+	c.Assert(err, check.IsNil)
+	c.Check(recentErrors, check.Equals, 3.0)
+}
+
+func (s *ProgressMemoryTestSuite) TestGetClientsByTaskID(c *check.C) {
+	ctx := context.Background()
+	s.initTask(c, "task1", 4, "client1", "client2")
+	s.initTask(c, "task2", 4, "client3")
+	// peer-client1 downloads task1 again with another client.
+	c.Assert(s.manager.InitProgress(ctx, "task1", "peer-client1", "client4"), check.IsNil)
+
+	clients, err := s.manager.GetClientsByTaskID(ctx, "task1")
+	c.Assert(err, check.IsNil)
+	c.Check(clients, check.DeepEquals, map[string]string{
+		"client1": "peer-client1",
+		"client2": "peer-client2",
+		"client4": "peer-client1",
+	})
+
+	clients, err = s.manager.GetClientsByTaskID(ctx, "foo")
+	c.Assert(err, check.IsNil)
+	c.Check(clients, check.HasLen, 0)
+}
@@ -17,10 +17,12 @@
 package progress
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/pkg/atomiccount"
 	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
 
 	"github.com/willf/bitset"
 )
@@ -29,9 +31,23 @@ type superState struct {
 	// pieceBitSet maintains the piece bitSet of CID
 	// which means that the status of each pieces of the task corresponding to taskID on the supernode.
 	pieceBitSet *bitset.BitSet
+
+	// accessTime is the last time in nanoseconds when the progress of the task was accessed.
+	// It should be accessed atomically.
+	accessTime int64
 }
 
 type clientState struct {
+	// taskID is the task which the client is downloading.
+	taskID string
+
+	// peerID is the peer which the client belongs to.
+	peerID string
+
+	// finished means that the client has finished downloading and the state has been compacted,
+	// so that the pieceBitSet and runningPiece are released and all pieces are considered successful.
+	finished bool
+
 	// pieceBitSet maintains the piece bitSet of CID
 	// which means that the status of each pieces of the task on the peer corresponding to cid.
 	pieceBitSet *bitset.BitSet
@@ -39,6 +55,11 @@ type clientState struct {
 	// runningPiece maintains the pieces currently being downloaded from dstCID to srcCID.
 	// key:pieceNum,value:dstPID
 	runningPiece *syncmap.SyncMap
+
+	// duplicatePiece maintains the running pieces which are also being downloaded
+	// from another dstPID in the endgame.
+	// key:pieceNum,value:dstPID
+	duplicatePiece *syncmap.SyncMap
 }
 
 type peerState struct {
@@ -61,6 +82,13 @@ type peerState struct {
 
 	// serviceDownTime the down time of the peer service.
 	serviceDownTime int64
+
+	// throughput maintains the estimated upload throughput of the peer
+	// which is measured by the pieces downloaded from it.
+	throughput *throughputState
+
+	// reputation maintains the decaying reputation of the peer as a server.
+	reputation *reputationState
 }
 
 type superLoadState struct {
@@ -69,32 +97,87 @@ type superLoadState struct {
 
 	// loadModTime will record the time when the load be modified.
 	loadModTime time.Time
+
+	// weight is the weight of the task priority to share the upload slots of supernode.
+	weight *atomiccount.AtomicInt
+}
+
+// blackState maintains the failures of a dstPID in the blacklist of a srcPID.
+type blackState struct {
+	// count is the number of times that srcPID failed to download from dstPID.
+	count *atomiccount.AtomicInt
+
+	// updateTime is the UnixNano of the last failure, and the dstPID will be removed
+	// from the blacklist when it has been expired.
+	updateTime int64
 }
 
-func newSuperState() *superState {
+func newSuperState(now time.Time) *superState {
 	return &superState{
 		pieceBitSet: &bitset.BitSet{},
+		accessTime:  now.UnixNano(),
 	}
 }
 
-func newClientState() *clientState {
+// touch updates the access time of the super state.
+func (ss *superState) touch(now time.Time) {
+	atomic.StoreInt64(&ss.accessTime, now.UnixNano())
+}
+
+// getAccessTime returns the last access time of the super state.
+func (ss *superState) getAccessTime() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&ss.accessTime))
+}
+
+func newClientState(taskID, peerID string) *clientState {
 	return &clientState{
-		pieceBitSet:  &bitset.BitSet{},
-		runningPiece: syncmap.NewSyncMap(),
+		taskID:         taskID,
+		peerID:         peerID,
+		pieceBitSet:    &bitset.BitSet{},
+		runningPiece:   syncmap.NewSyncMap(),
+		duplicatePiece: syncmap.NewSyncMap(),
 	}
 }
 
+// newFinishedClientState returns a compacted clientState of the client which has finished downloading.
+func newFinishedClientState(taskID, peerID string) *clientState {
+	cs := newClientState(taskID, peerID)
+	cs.finished = true
+	return cs
+}
+
 func newPeerState() *peerState {
 	return &peerState{
 		producerLoad:      atomiccount.NewAtomicInt(0),
 		clientErrorCount:  atomiccount.NewAtomicInt(0),
 		serviceErrorCount: atomiccount.NewAtomicInt(0),
+		throughput:        newThroughputState(),
+		reputation:        newReputationState(),
 	}
 }
 
-func newSuperLoadState() *superLoadState {
+func newSuperLoadState(now time.Time) *superLoadState {
 	return &superLoadState{
 		loadValue:   atomiccount.NewAtomicInt(0),
-		loadModTime: time.Now(),
+		loadModTime: now,
+		weight:      atomiccount.NewAtomicInt(int32(mgr.GetPriorityWeight(""))),
+	}
+}
+
+func newBlackState(now time.Time) *blackState {
+	return &blackState{
+		count:      atomiccount.NewAtomicInt(1),
+		updateTime: now.UnixNano(),
 	}
 }
+
+// touch updates the time of the last failure.
+func (bs *blackState) touch(now time.Time) {
+	atomic.StoreInt64(&bs.updateTime, now.UnixNano())
+}
+
+// expired returns whether the last failure is earlier than ttl ago,
+// and it never expires if ttl isn't positive.
+func (bs *blackState) expired(now time.Time, ttl time.Duration) bool {
+	return ttl > 0 && now.Sub(time.Unix(0, atomic.LoadInt64(&bs.updateTime))) > ttl
+}
@@ -17,8 +17,10 @@
 package progress
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/pkg/atomiccount"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
@@ -66,47 +68,113 @@ func (pm *Manager) updatePieceProgress(taskID, srcPID string, pieceNum int) erro
 
 // updateClientProgress updates the client progress when clientID is not a supernode,
 // otherwise update the super progress.
-func (pm *Manager) updateClientProgress(taskID, srcCID, dstPID string, pieceNum, pieceStatus int) (bool, error) {
+//
+// The released are the other dstPIDs which no longer need to provide the piece for srcCID
+// because srcCID has downloaded it from dstPID successfully.
+func (pm *Manager) updateClientProgress(taskID, srcCID, dstPID string, pieceNum, pieceStatus int) (updated bool, released []string, err error) {
 	// update piece bitSet
 	if pm.cfg.IsSuperCID(srcCID) {
 		ss, err := pm.superProgress.getAsSuperState(taskID)
 		if err != nil {
-			return false, err
+			return false, nil, err
 		}
-		return updatePieceBitSet(ss.pieceBitSet, pieceNum, pieceStatus), nil
+		return updatePieceBitSet(ss.pieceBitSet, pieceNum, pieceStatus), nil, nil
 	}
 
 	cs, err := pm.clientProgress.getAsClientState(srcCID)
 	if err != nil {
-		return false, err
+		return false, nil, err
+	}
+
+	// It's already successful when the client has finished.
+	if cs.finished {
+		return false, nil, nil
 	}
 
 	// update running piece
-	err = updateRunningPiece(cs.runningPiece, srcCID, dstPID, pieceNum, pieceStatus)
+	released, err = updateRunningPiece(cs, dstPID, pieceNum, pieceStatus)
 	if err != nil {
-		return false, err
+		return false, nil, err
 	}
 
-	return updatePieceBitSet(cs.pieceBitSet, pieceNum, pieceStatus), nil
+	return updatePieceBitSet(cs.pieceBitSet, pieceNum, pieceStatus), released, nil
 }
 
 // updateRunningPiece updates the relationship between the running piece and srcCID and dstPID,
 // which means the info that records the pieces being downloaded from dstPID to srcCID.
-func updateRunningPiece(dstPIDMap *syncmap.SyncMap, srcCID, dstPID string, pieceNum, pieceStatus int) error {
+//
+// A running piece can be downloaded from another dstPID at the same time in the endgame.
+// When one of them succeeds, the others will be returned as released.
+func updateRunningPiece(cs *clientState, dstPID string, pieceNum, pieceStatus int) (released []string, err error) {
 	pieceNumString := strconv.Itoa(pieceNum)
-	if pieceStatus == config.PieceRUNNING && !stringutils.IsEmptyStr(dstPID) {
-		return dstPIDMap.Add(pieceNumString, dstPID)
+	runningPID, err := cs.runningPiece.GetAsString(pieceNumString)
+	if err != nil && !errortypes.IsDataNotFound(err) {
+		return nil, err
+	}
+	duplicatePID, err := cs.duplicatePiece.GetAsString(pieceNumString)
+	if err != nil && !errortypes.IsDataNotFound(err) {
+		return nil, err
 	}
 
-	if _, err := dstPIDMap.Get(pieceNumString); err != nil {
-		if errortypes.IsDataNotFound(err) {
-			return nil
+	if pieceStatus == config.PieceRUNNING {
+		if stringutils.IsEmptyStr(dstPID) {
+			return nil, nil
 		}
-		return err
+		if !stringutils.IsEmptyStr(runningPID) && runningPID != dstPID {
+			return nil, cs.duplicatePiece.Add(pieceNumString, dstPID)
+		}
+		return nil, cs.runningPiece.Add(pieceNumString, dstPID)
 	}
-	dstPIDMap.Remove(pieceNumString)
 
-	return nil
+	if isSuccessStatus(pieceStatus) {
+		for _, pid := range []string{runningPID, duplicatePID} {
+			if !stringutils.IsEmptyStr(pid) && pid != dstPID {
+				released = append(released, pid)
+			}
+		}
+		cs.runningPiece.Remove(pieceNumString)
+		cs.duplicatePiece.Remove(pieceNumString)
+		return released, nil
+	}
+
+	// only the dstPID stops providing the piece when it fails,
+	// and the duplicate one will take over if exists.
+	// The failure of the other dstPIDs is ignored, such as a late report of an earlier attempt,
+	// otherwise the piece would be scheduled to more than maxPieceSources.
+	if duplicatePID == dstPID {
+		cs.duplicatePiece.Remove(pieceNumString)
+		return nil, nil
+	}
+	if runningPID != dstPID {
+		return nil, nil
+	}
+	cs.runningPiece.Remove(pieceNumString)
+	if !stringutils.IsEmptyStr(duplicatePID) {
+		cs.duplicatePiece.Remove(pieceNumString)
+		return nil, cs.runningPiece.Add(pieceNumString, duplicatePID)
+	}
+	return nil, nil
+}
+
+// getRunningPieceSources returns all the dstPIDs that the running piece is being downloaded from.
+func getRunningPieceSources(cs *clientState, pieceNum int) ([]string, error) {
+	var result []string
+	pieceNumString := strconv.Itoa(pieceNum)
+	for _, m := range []*syncmap.SyncMap{cs.runningPiece, cs.duplicatePiece} {
+		pid, err := m.GetAsString(pieceNumString)
+		if err != nil {
+			if errortypes.IsDataNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		result = append(result, pid)
+	}
+	return result, nil
+}
+
+func isSuccessStatus(pieceStatus int) bool {
+	return pieceStatus == config.PieceSUCCESS || pieceStatus == config.PieceSEMISUC
 }
 
 // updatePieceBitSet adds a new piece for srcCID when it successfully downloads the piece.
@@ -137,7 +205,8 @@ func (pm *Manager) updatePeerProgress(taskID, srcPID, dstPID string, pieceNum, p
 
 	// update producerLoad of dstPID
 	if !stringutils.IsEmptyStr(dstPID) {
-		dstPeerState, err := pm.peerProgress.getAsPeerState(dstPID)
+		var err error
+		dstPeerState, err = pm.peerProgress.getAsPeerState(dstPID)
 		if err != nil && !errortypes.IsDataNotFound(err) {
 			return err
 		}
@@ -161,12 +230,18 @@ func (pm *Manager) updatePeerProgress(taskID, srcPID, dstPID string, pieceNum, p
 	// update ClientErrorInfo/serviceErrorInfo
 	if pieceStatus == config.PieceSUCCESS || pieceStatus == config.PieceSEMISUC {
 		processPeerSucInfo(srcPeerState, dstPeerState)
+		if dstPeerState != nil {
+			dstPeerState.reputation.addSuccess(pm.now(), pm.getReputationHalfLife())
+		}
 	}
 	if pieceStatus == config.PieceFAILED {
 		if err := pm.updateBlackInfo(srcPID, dstPID); err != nil {
 			return err
 		}
 		processPeerFailInfo(srcPeerState, dstPeerState)
+		if dstPeerState != nil {
+			dstPeerState.reputation.addFailure(pieceFailedWeight, pm.now(), pm.getReputationHalfLife())
+		}
 	}
 	return nil
 }
@@ -185,18 +260,85 @@ func (pm *Manager) updateBlackInfo(srcPID, dstPID string) error {
 		}
 	}
 
-	v, err := blackList.GetAsAtomicInt(dstPID)
+	v, err := blackList.Get(dstPID)
 	if err == nil {
-		v.Add(1)
-		return nil
+		if bs, ok := v.(*blackState); ok {
+			bs.count.Add(1)
+			bs.touch(pm.now())
+			return nil
+		}
+		return errors.Wrapf(errortypes.ErrConvertFailed, "dstPID %s: %v", dstPID, v)
 	}
 	if errortypes.IsDataNotFound(err) {
-		return blackList.Add(dstPID, atomiccount.NewAtomicInt(1))
+		return blackList.Add(dstPID, newBlackState(pm.now()))
 	}
 
 	return err
 }
 
+// getBlackInfo returns the unexpired dstPIDs in the blacklist of srcPID with their failure counts,
+// and the expired ones will be removed.
+func (pm *Manager) getBlackInfo(srcPID string) (*syncmap.SyncMap, error) {
+	blackList, err := pm.clientBlackInfo.GetAsMap(srcPID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := pm.getBlacklistTTL()
+	now := pm.now()
+	result := syncmap.NewSyncMap()
+	blackList.Range(func(key, value interface{}) bool {
+		bs, ok := value.(*blackState)
+		if !ok {
+			return true
+		}
+		if bs.expired(now, ttl) {
+			blackList.Delete(key)
+			return true
+		}
+		result.Store(key, bs.count)
+		return true
+	})
+	return result, nil
+}
+
+// isInBlackList returns whether dstPID is in the unexpired blacklist of srcPID,
+// and the expired one will be removed.
+func (pm *Manager) isInBlackList(srcPID, dstPID string) bool {
+	blackList, err := pm.clientBlackInfo.GetAsMap(srcPID)
+	if err != nil {
+		return false
+	}
+
+	v, ok := blackList.Load(dstPID)
+	if !ok {
+		return false
+	}
+	bs, ok := v.(*blackState)
+	if !ok {
+		return false
+	}
+	if bs.expired(pm.now(), pm.getBlacklistTTL()) {
+		blackList.Delete(dstPID)
+		return false
+	}
+	return true
+}
+
+func (pm *Manager) getBlacklistTTL() time.Duration {
+	if pm.cfg == nil || pm.cfg.BaseProperties == nil {
+		return config.DefaultBlacklistTTL
+	}
+	return pm.cfg.BlacklistTTL
+}
+
+func (pm *Manager) getReputationHalfLife() time.Duration {
+	if pm.cfg == nil || pm.cfg.BaseProperties == nil {
+		return config.DefaultReputationHalfLife
+	}
+	return pm.cfg.ReputationHalfLife
+}
+
 // processPeerSucInfo sets the count of errors to 0
 // when srcCID successfully downloads a piece from dstPID.
 func processPeerSucInfo(srcPeerState, dstPeerState *peerState) {
@@ -233,6 +375,25 @@ func processPeerFailInfo(srcPeerState, dstPeerState *peerState) {
 	}
 }
 
+// releaseProducerLoad decreases the load of the peers which no longer
+// need to provide the piece.
+func (pm *Manager) releaseProducerLoad(taskID string, peerIDs []string, pieceNum int) {
+	for _, peerID := range peerIDs {
+		if pm.cfg.IsSuperPID(peerID) {
+			if _, err := pm.UpdateSuperLoad(context.Background(), taskID, -1, -1); err != nil {
+				logrus.Warnf("failed to release superLoad taskID(%s): %v", taskID, err)
+			}
+			continue
+		}
+
+		peerState, err := pm.peerProgress.getAsPeerState(peerID)
+		if err != nil || peerState.producerLoad == nil {
+			continue
+		}
+		updateProducerLoad(peerState.producerLoad, taskID, peerID, pieceNum, config.PieceSUCCESS)
+	}
+}
+
 // updateProducerLoad updates the load of the clientID.
 // TODO: avoid multiple calls
 func updateProducerLoad(load *atomiccount.AtomicInt, taskID, peerID string, pieceNum, pieceStatus int) {
@@ -17,10 +17,14 @@
 package progress
 
 import (
+	"context"
+	"time"
+
 	"github.com/dragonflyoss/Dragonfly/pkg/atomiccount"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
 
 	"github.com/go-check/check"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/willf/bitset"
 )
 
@@ -88,7 +92,7 @@ func (s *ProgressUtilTestSuite) TestUpdatePieceBitset(c *check.C) {
 }
 
 func (s *ProgressUtilTestSuite) TestUpdateBlackInfo(c *check.C) {
-	pm, _ := NewManager(nil)
+	pm, _ := NewManager(nil, prometheus.NewRegistry())
 
 	updateAndCheckBlackInfo(pm, "src0", "dst0", 1, c)
 
@@ -102,9 +106,92 @@ func (s *ProgressUtilTestSuite) TestUpdateBlackInfo(c *check.C) {
 func updateAndCheckBlackInfo(pm *Manager, srcPID, dstPID string, expected int32, c *check.C) {
 	err := pm.updateBlackInfo(srcPID, dstPID)
 	c.Check(err, check.IsNil)
-	dstPIDMap, err := pm.clientBlackInfo.GetAsMap(srcPID)
+	dstPIDMap, err := pm.GetBlackInfoByPeerID(context.Background(), srcPID)
 	c.Check(err, check.IsNil)
 	count, err := dstPIDMap.GetAsAtomicInt(dstPID)
 	c.Check(err, check.IsNil)
 	c.Check(count.Get(), check.Equals, atomiccount.NewAtomicInt(expected).Get())
 }
+
+func (s *ProgressUtilTestSuite) TestBlackInfoExpired(c *check.C) {
+	now := time.Unix(0, 0)
+	cfg := &config.Config{BaseProperties: &config.BaseProperties{BlacklistTTL: time.Minute}}
+	pm, _ := NewManagerWithClock(cfg, func() time.Time { return now }, prometheus.NewRegistry())
+
+	c.Check(pm.updateBlackInfo("src0", "dst0"), check.IsNil)
+	now = now.Add(30 * time.Second)
+	c.Check(pm.updateBlackInfo("src0", "dst1"), check.IsNil)
+
+	// dst0 expires while dst1 is still in the blacklist.
+	now = now.Add(40 * time.Second)
+	dstPIDMap, err := pm.GetBlackInfoByPeerID(context.Background(), "src0")
+	c.Assert(err, check.IsNil)
+	c.Check(dstPIDMap.ListKeyAsStringSlice(), check.DeepEquals, []string{"dst1"})
+
+	// a new failure renews the entry.
+	c.Check(pm.updateBlackInfo("src0", "dst1"), check.IsNil)
+	now = now.Add(40 * time.Second)
+	dstPIDMap, err = pm.GetBlackInfoByPeerID(context.Background(), "src0")
+	c.Assert(err, check.IsNil)
+	count, err := dstPIDMap.GetAsAtomicInt("dst1")
+	c.Assert(err, check.IsNil)
+	c.Check(count.Get(), check.Equals, int32(2))
+}
+
+func (s *ProgressUtilTestSuite) TestIsInBlackList(c *check.C) {
+	now := time.Unix(0, 0)
+	cfg := &config.Config{BaseProperties: &config.BaseProperties{BlacklistTTL: time.Minute}}
+	pm, _ := NewManagerWithClock(cfg, func() time.Time { return now }, prometheus.NewRegistry())
+
+	c.Check(pm.IsInBlackList(context.Background(), "src0", "dst0"), check.Equals, false)
+	c.Check(pm.updateBlackInfo("src0", "dst0"), check.IsNil)
+	c.Check(pm.IsInBlackList(context.Background(), "src0", "dst0"), check.Equals, true)
+	c.Check(pm.IsInBlackList(context.Background(), "src0", "dst1"), check.Equals, false)
+	c.Check(pm.IsInBlackList(context.Background(), "src1", "dst0"), check.Equals, false)
+
+	// the expired one is removed.
+	now = now.Add(2 * time.Minute)
+	c.Check(pm.IsInBlackList(context.Background(), "src0", "dst0"), check.Equals, false)
+	dstPIDMap, err := pm.GetBlackInfoByPeerID(context.Background(), "src0")
+	c.Assert(err, check.IsNil)
+	c.Check(dstPIDMap.ListKeyAsStringSlice(), check.HasLen, 0)
+}
+
+func (s *ProgressUtilTestSuite) TestUpdateRunningPiece(c *check.C) {
+	cs := newClientState("taskID", "peerID")
+
+	// schedule the piece to dst0 and then to dst1 in the endgame
+	_, err := updateRunningPiece(cs, "dst0", 1, config.PieceRUNNING)
+	c.Check(err, check.IsNil)
+	_, err = updateRunningPiece(cs, "dst1", 1, config.PieceRUNNING)
+	c.Check(err, check.IsNil)
+	sources, err := getRunningPieceSources(cs, 1)
+	c.Check(err, check.IsNil)
+	c.Check(sources, check.DeepEquals, []string{"dst0", "dst1"})
+
+	// the late failure of an earlier attempt from dst2 doesn't stop the running ones
+	released, err := updateRunningPiece(cs, "dst2", 1, config.PieceFAILED)
+	c.Check(err, check.IsNil)
+	c.Check(released, check.IsNil)
+	sources, err = getRunningPieceSources(cs, 1)
+	c.Check(err, check.IsNil)
+	c.Check(sources, check.DeepEquals, []string{"dst0", "dst1"})
+
+	// dst0 fails and dst1 takes over
+	released, err = updateRunningPiece(cs, "dst0", 1, config.PieceFAILED)
+	c.Check(err, check.IsNil)
+	c.Check(released, check.IsNil)
+	sources, err = getRunningPieceSources(cs, 1)
+	c.Check(err, check.IsNil)
+	c.Check(sources, check.DeepEquals, []string{"dst1"})
+
+	// schedule the piece to dst2 and dst1 succeeds
+	_, err = updateRunningPiece(cs, "dst2", 1, config.PieceRUNNING)
+	c.Check(err, check.IsNil)
+	released, err = updateRunningPiece(cs, "dst1", 1, config.PieceSUCCESS)
+	c.Check(err, check.IsNil)
+	c.Check(released, check.DeepEquals, []string{"dst2"})
+	sources, err = getRunningPieceSources(cs, 1)
+	c.Check(err, check.IsNil)
+	c.Check(sources, check.IsNil)
+}
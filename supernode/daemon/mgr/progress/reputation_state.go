@@ -0,0 +1,135 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// pieceFailedWeight is the weight of an error that a piece failed to be downloaded from the peer.
+	pieceFailedWeight = 1.0
+
+	// serviceDownWeight is the weight of an error that the peer went offline.
+	serviceDownWeight = 3.0
+
+	// latencySampleWeight is the weight of a new latency sample
+	// when the previous estimate was updated just now.
+	latencySampleWeight = 0.3
+
+	// reputationLatencyScale is the latency at which the reputation is halved.
+	reputationLatencyScale = time.Second
+)
+
+// reputationState maintains the reputation of a peer as a server.
+//
+// The successes and the weighted errors decay exponentially over time,
+// so that the peer can recover from a period of failures.
+type reputationState struct {
+	sync.Mutex
+
+	// successes is the decayed count of pieces downloaded from the peer successfully.
+	successes float64
+
+	// failures is the decayed count of errors weighted by their kinds.
+	failures float64
+
+	// latency is the estimated time in seconds to download a piece from the peer.
+	latency float64
+
+	// updateTime is the time when the state be updated.
+	updateTime time.Time
+}
+
+func newReputationState() *reputationState {
+	return &reputationState{}
+}
+
+// addSuccess records a piece downloaded from the peer successfully.
+func (rs *reputationState) addSuccess(now time.Time, halfLife time.Duration) {
+	rs.Lock()
+	defer rs.Unlock()
+
+	rs.decay(now, halfLife)
+	rs.successes++
+}
+
+// addFailure records an error of the peer with the weight of its kind.
+func (rs *reputationState) addFailure(weight float64, now time.Time, halfLife time.Duration) {
+	rs.Lock()
+	defer rs.Unlock()
+
+	rs.decay(now, halfLife)
+	rs.failures += weight
+}
+
+// addLatency adds a sample of the time to download a piece from the peer.
+func (rs *reputationState) addLatency(latency time.Duration, now time.Time, halfLife time.Duration) {
+	if latency <= 0 {
+		return
+	}
+
+	rs.Lock()
+	defer rs.Unlock()
+
+	sample := latency.Seconds()
+	if rs.latency <= 0 {
+		rs.decay(now, halfLife)
+		rs.latency = sample
+		return
+	}
+	weight := (1 - latencySampleWeight) * decayFactor(now.Sub(rs.updateTime), halfLife)
+	rs.decay(now, halfLife)
+	rs.latency = weight*rs.latency + (1-weight)*sample
+}
+
+// get returns the score in (0, 1] and the decayed failures of the peer at now.
+//
+// The score is the success rate smoothed by a successful prior,
+// and it will be discounted by the latency.
+func (rs *reputationState) get(now time.Time, halfLife time.Duration) (score, failures float64) {
+	rs.Lock()
+	defer rs.Unlock()
+
+	factor := decayFactor(now.Sub(rs.updateTime), halfLife)
+	successes := rs.successes * factor
+	failures = rs.failures * factor
+
+	score = (successes + 1) / (successes + failures + 1)
+	if rs.latency > 0 {
+		score *= reputationLatencyScale.Seconds() / (reputationLatencyScale.Seconds() + rs.latency)
+	}
+	return score, failures
+}
+
+// decay applies the decay since the last update, and it should be called with the lock held.
+func (rs *reputationState) decay(now time.Time, halfLife time.Duration) {
+	factor := decayFactor(now.Sub(rs.updateTime), halfLife)
+	rs.successes *= factor
+	rs.failures *= factor
+	rs.updateTime = now
+}
+
+// decayFactor returns the factor that a value decays by after elapsed.
+func decayFactor(elapsed, halfLife time.Duration) float64 {
+	if elapsed <= 0 || halfLife <= 0 {
+		return 1
+	}
+	return math.Exp2(-elapsed.Seconds() / halfLife.Seconds())
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"time"
+
+	"github.com/go-check/check"
+)
+
+func init() {
+	check.Suite(&ReputationStateTestSuite{})
+}
+
+type ReputationStateTestSuite struct {
+}
+
+func (s *ReputationStateTestSuite) TestGet(c *check.C) {
+	now := time.Unix(0, 0)
+	halfLife := time.Minute
+	rs := newReputationState()
+
+	// a new peer is considered reliable.
+	score, failures := rs.get(now, halfLife)
+	c.Check(score, check.Equals, 1.0)
+	c.Check(failures, check.Equals, 0.0)
+
+	rs.addSuccess(now, halfLife)
+	rs.addFailure(pieceFailedWeight, now, halfLife)
+	rs.addFailure(pieceFailedWeight, now, halfLife)
+	score, failures = rs.get(now, halfLife)
+	c.Check(score, check.Equals, 0.5)
+	c.Check(failures, check.Equals, 2.0)
+
+	// the history decays over time, and the score recovers.
+	now = now.Add(halfLife)
+	score, failures = rs.get(now, halfLife)
+	c.Check(score, check.Equals, 1.5/2.5)
+	c.Check(failures, check.Equals, 1.0)
+
+	// the score is discounted by the latency.
+	rs.addLatency(reputationLatencyScale, now, halfLife)
+	score, _ = rs.get(now, halfLife)
+	c.Check(score, check.Equals, 1.5/2.5/2)
+}
+
+func (s *ReputationStateTestSuite) TestAddLatency(c *check.C) {
+	now := time.Unix(0, 0)
+	halfLife := time.Minute
+	rs := newReputationState()
+
+	rs.addLatency(0, now, halfLife)
+	c.Check(rs.latency, check.Equals, 0.0)
+
+	rs.addLatency(time.Second, now, halfLife)
+	c.Check(rs.latency, check.Equals, 1.0)
+
+	rs.addLatency(2*time.Second, now, halfLife)
+	c.Check(rs.latency, check.Equals, 0.7*1+0.3*2)
+
+	// the previous estimate is replaced by the new sample after a long time.
+	rs.addLatency(3*time.Second, now.Add(100*halfLife), halfLife)
+	c.Check(rs.latency > 2.99, check.Equals, true)
+}
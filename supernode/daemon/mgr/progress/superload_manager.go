@@ -21,6 +21,9 @@ import (
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/pkg/errors"
 )
 
 const (
@@ -35,22 +38,110 @@ const (
 // The updated will be `false` if failed to do update operation.
 //
 // It's considered as a failure when then superload is greater than limit after adding delta.
+// And the limit will be scaled by the weight of the task priority, a task with normal priority
+// takes the limit as it is.
+//
+// When the SuperUploadLimit is set, the upload slots of supernode will be shared by all tasks
+// that are downloading from supernode in proportion to their weights.
 func (pm *Manager) UpdateSuperLoad(ctx context.Context, taskID string, delta, limit int32) (updated bool, err error) {
-	v, _ := pm.superLoad.LoadOrStore(taskID, newSuperLoadState())
-	loadState, ok := v.(*superLoadState)
-	if !ok {
-		return false, errortypes.ErrConvertFailed
+	loadState, err := pm.getSuperLoadState(taskID)
+	if err != nil {
+		return false, err
 	}
 
-	if loadState.loadValue.Add(delta) > limit && limit > 0 {
-		loadState.loadValue.Add(-delta)
+	if delta <= 0 {
+		loadState.loadValue.Add(delta)
+		loadState.loadModTime = pm.now()
+		return true, nil
+	}
+
+	pm.superLoadLock.Lock()
+	defer pm.superLoadLock.Unlock()
+
+	weight := loadState.weight.Get()
+	load := loadState.loadValue.Get() + delta
+	if limit > 0 && load > scaleByWeight(limit, weight) {
 		return false, nil
 	}
-	loadState.loadModTime = time.Now()
+
+	if globalLimit := pm.getSuperUploadLimit(); globalLimit > 0 {
+		totalLoad, totalWeight := pm.sumSuperLoad(taskID)
+		totalLoad += load
+		totalWeight += weight
+		if totalLoad > globalLimit {
+			return false, nil
+		}
+		// the share of the task will be at least one slot to avoid starving.
+		share := (globalLimit*weight + totalWeight - 1) / totalWeight
+		if load > share {
+			return false, nil
+		}
+	}
+
+	loadState.loadValue.Add(delta)
+	loadState.loadModTime = pm.now()
 
 	return true, nil
 }
 
+// SetSuperLoadWeight sets the weight of taskID to share the upload slots of supernode with other tasks.
+func (pm *Manager) SetSuperLoadWeight(ctx context.Context, taskID string, weight int32) error {
+	if weight <= 0 {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "weight: %d", weight)
+	}
+
+	loadState, err := pm.getSuperLoadState(taskID)
+	if err != nil {
+		return err
+	}
+	loadState.weight.Set(weight)
+
+	return nil
+}
+
+func (pm *Manager) getSuperLoadState(taskID string) (*superLoadState, error) {
+	v, _ := pm.superLoad.LoadOrStore(taskID, newSuperLoadState(pm.now()))
+	loadState, ok := v.(*superLoadState)
+	if !ok {
+		return nil, errortypes.ErrConvertFailed
+	}
+	return loadState, nil
+}
+
+func (pm *Manager) getSuperUploadLimit() int32 {
+	if pm.cfg == nil {
+		return 0
+	}
+	return int32(pm.cfg.SuperUploadLimit)
+}
+
+// sumSuperLoad returns the total superload and the total weight of
+// the tasks downloading from supernode except the specified taskID.
+func (pm *Manager) sumSuperLoad(exceptTaskID string) (totalLoad, totalWeight int32) {
+	pm.superLoad.Range(func(key, value interface{}) bool {
+		if key == exceptTaskID {
+			return true
+		}
+		if v, ok := value.(*superLoadState); ok {
+			if load := v.loadValue.Get(); load > 0 {
+				totalLoad += load
+				totalWeight += v.weight.Get()
+			}
+		}
+		return true
+	})
+	return
+}
+
+// scaleByWeight scales the limit by the weight relative to the normal priority.
+func scaleByWeight(limit, weight int32) int32 {
+	scaled := limit * weight / int32(mgr.GetPriorityWeight(""))
+	if scaled < 1 {
+		return 1
+	}
+	return scaled
+}
+
 // startMonitorSuperLoad starts a new goroutine to check the superload periodically and
 // reset the superload to zero if there is no update for a long time for one task to
 // avoid being occupied when supernode doesn't receive the message from peers that downloading piece from supernode for a variety of reasons.
@@ -59,15 +150,18 @@ func (pm *Manager) startMonitorSuperLoad() {
 	go func() {
 		ticker := time.NewTicker(renewInterval)
 		for range ticker.C {
-			pm.renewSuperLoad()
+			pm.RenewSuperLoad()
 		}
 	}()
 }
 
-func (pm *Manager) renewSuperLoad() {
+// RenewSuperLoad resets the superload to zero for the tasks
+// which have no update for a long time.
+func (pm *Manager) RenewSuperLoad() {
+	now := pm.now()
 	rangeFunc := func(key, value interface{}) bool {
 		if v, ok := value.(*superLoadState); ok {
-			if time.Since(v.loadModTime) > renewDelayTime {
+			if now.Sub(v.loadModTime) > renewDelayTime {
 				v.loadValue.Set(0)
 			}
 		}
@@ -0,0 +1,89 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"context"
+
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/go-check/check"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	check.Suite(&SuperLoadManagerTestSuite{})
+}
+
+type SuperLoadManagerTestSuite struct {
+}
+
+func (s *SuperLoadManagerTestSuite) TestUpdateSuperLoadWithWeight(c *check.C) {
+	pm, _ := NewManager(nil, prometheus.NewRegistry())
+	ctx := context.Background()
+
+	c.Assert(pm.SetSuperLoadWeight(ctx, "critical", 4), check.IsNil)
+	c.Assert(pm.SetSuperLoadWeight(ctx, "background", 1), check.IsNil)
+	c.Assert(pm.SetSuperLoadWeight(ctx, "background", 0), check.NotNil)
+
+	// the limit of a critical task is doubled and the one of a background task is halved.
+	c.Assert(acquireSuperLoad(pm, "critical", 4), check.Equals, 8)
+	c.Assert(acquireSuperLoad(pm, "background", 4), check.Equals, 2)
+	c.Assert(acquireSuperLoad(pm, "normal", 4), check.Equals, 4)
+
+	updated, err := pm.UpdateSuperLoad(ctx, "background", -1, -1)
+	c.Assert(err, check.IsNil)
+	c.Assert(updated, check.Equals, true)
+	c.Assert(acquireSuperLoad(pm, "background", 4), check.Equals, 1)
+}
+
+func (s *SuperLoadManagerTestSuite) TestUpdateSuperLoadWithGlobalLimit(c *check.C) {
+	pm, _ := NewManager(&config.Config{BaseProperties: &config.BaseProperties{SuperUploadLimit: 10}},
+		prometheus.NewRegistry())
+	ctx := context.Background()
+
+	c.Assert(pm.SetSuperLoadWeight(ctx, "critical", 4), check.IsNil)
+	c.Assert(pm.SetSuperLoadWeight(ctx, "background", 1), check.IsNil)
+
+	// a single task can use all the upload slots.
+	c.Assert(acquireSuperLoad(pm, "background", 0), check.Equals, 10)
+	pm.superLoad.remove("background")
+	c.Assert(pm.SetSuperLoadWeight(ctx, "background", 1), check.IsNil)
+
+	// the upload slots are shared in proportion to the weights when tasks compete.
+	c.Assert(acquireSuperLoad(pm, "background", 0), check.Equals, 10)
+	for i := 0; i < 8; i++ {
+		_, err := pm.UpdateSuperLoad(ctx, "background", -1, -1)
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(acquireSuperLoad(pm, "critical", 0), check.Equals, 8)
+	c.Assert(acquireSuperLoad(pm, "background", 0), check.Equals, 0)
+}
+
+// acquireSuperLoad increases the superload of taskID one by one until it fails,
+// and returns the count of succeeded.
+func acquireSuperLoad(pm *Manager, taskID string, limit int32) int {
+	count := 0
+	for i := 0; i < 100; i++ {
+		updated, err := pm.UpdateSuperLoad(context.Background(), taskID, 1, limit)
+		if err != nil || !updated {
+			break
+		}
+		count++
+	}
+	return count
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// throughputSampleWeight is the weight of a new sample
+	// when the previous estimate was updated just now.
+	throughputSampleWeight = 0.3
+
+	// throughputHalfLife is the duration after which the weight of
+	// the previous estimate is halved.
+	throughputHalfLife = time.Minute
+)
+
+// throughputState maintains a decaying estimate of the upload throughput of a peer.
+//
+// The estimate is an exponentially weighted moving average of the measured samples,
+// and the weight of the previous estimate decays with the time since it was updated.
+// So that a stale estimate will be replaced quickly by the newly measured samples.
+type throughputState struct {
+	sync.RWMutex
+
+	// value is the estimated throughput in bytes per second.
+	value float64
+
+	// updateTime is the time when the value be updated.
+	updateTime time.Time
+}
+
+func newThroughputState() *throughputState {
+	return &throughputState{}
+}
+
+// update adds a sample of size bytes which took cost to be transferred.
+func (ts *throughputState) update(size int64, cost time.Duration, now time.Time) {
+	if size <= 0 || cost <= 0 {
+		return
+	}
+	sample := float64(size) / cost.Seconds()
+
+	ts.Lock()
+	defer ts.Unlock()
+
+	if ts.value <= 0 {
+		ts.value = sample
+		ts.updateTime = now
+		return
+	}
+
+	elapsed := now.Sub(ts.updateTime)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	weight := (1 - throughputSampleWeight) * math.Exp2(-elapsed.Seconds()/throughputHalfLife.Seconds())
+	ts.value = weight*ts.value + (1-weight)*sample
+	ts.updateTime = now
+}
+
+// get returns the estimated throughput in bytes per second.
+func (ts *throughputState) get() float64 {
+	ts.RLock()
+	defer ts.RUnlock()
+	return ts.value
+}
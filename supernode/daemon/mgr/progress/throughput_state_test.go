@@ -0,0 +1,56 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"time"
+
+	"github.com/go-check/check"
+)
+
+func init() {
+	check.Suite(&ThroughputStateTestSuite{})
+}
+
+type ThroughputStateTestSuite struct {
+}
+
+func (s *ThroughputStateTestSuite) TestUpdate(c *check.C) {
+	now := time.Now()
+	ts := newThroughputState()
+	c.Check(ts.get(), check.Equals, float64(0))
+
+	// invalid samples should be ignored
+	ts.update(0, time.Second, now)
+	ts.update(1000, 0, now)
+	c.Check(ts.get(), check.Equals, float64(0))
+
+	// the first sample is used as the estimate directly
+	ts.update(1000, time.Second, now)
+	c.Check(ts.get(), check.Equals, float64(1000))
+
+	// a new sample updated immediately only takes throughputSampleWeight
+	ts.update(2000, time.Second, now)
+	c.Check(ts.get(), check.Equals, 1000*(1-throughputSampleWeight)+2000*throughputSampleWeight)
+
+	// the weight of the previous estimate decays with time
+	ts = newThroughputState()
+	ts.update(1000, time.Second, now)
+	ts.update(2000, time.Second, now.Add(10*throughputHalfLife))
+	c.Check(ts.get() > 1990, check.Equals, true)
+	c.Check(ts.get() < 2000, check.Equals, true)
+}
@@ -18,6 +18,7 @@ package mgr
 
 import (
 	"context"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/atomiccount"
@@ -38,8 +39,43 @@ type PeerState struct {
 	// ServiceErrorCount maintains the number of times that the other peer nodes failed to downloaded from the PeerID.
 	ServiceErrorCount *atomiccount.AtomicInt
 
+	// RecentServiceErrors is the count of errors as a server which are weighted by their kinds
+	// and decay over time.
+	RecentServiceErrors float64
+
 	// ServiceDownTime the down time of the peer service.
 	ServiceDownTime int64
+
+	// Throughput is the estimated upload throughput of the peer in bytes per second.
+	// And zero means that there is no measurement about the peer yet.
+	Throughput float64
+
+	// Reputation is the score of the peer as a server in (0, 1], which combines
+	// the success rate, latency and errors of the peer and decays over time.
+	// And zero means that the reputation is unknown.
+	Reputation float64
+}
+
+// ProgressMemoryUsage is the estimated memory usage of the progress states.
+type ProgressMemoryUsage struct {
+	// TotalBytes is the estimated bytes of all the progress states.
+	TotalBytes int64
+
+	// CompletedTasks are the tasks whose clients have all finished downloading,
+	// and the least recently accessed one comes first.
+	CompletedTasks []*TaskProgressUsage
+}
+
+// TaskProgressUsage is the estimated memory usage of the progress states of a task.
+type TaskProgressUsage struct {
+	// TaskID identifies a task uniquely.
+	TaskID string
+
+	// Bytes is the estimated bytes of the progress states of the task and its clients.
+	Bytes int64
+
+	// AccessTime is the last time when the progress of the task was accessed.
+	AccessTime time.Time
 }
 
 // ProgressMgr is responsible for maintaining the correspondence between peer and pieces.
@@ -60,13 +96,30 @@ type ProgressMgr interface {
 	// The filter parameter depends on the specific implementation.
 	GetPieceProgressByCID(ctx context.Context, taskID, clientID, filter string) (pieceNums []int, err error)
 
+	// GetCDNPieceProgress gets the pieces which have been downloaded by CDN successfully.
+	GetCDNPieceProgress(ctx context.Context, taskID string) (pieceNums []int, err error)
+
 	// GetPeerIDsByPieceNum gets all peerIDs with specified taskID and pieceNum.
 	GetPeerIDsByPieceNum(ctx context.Context, taskID string, pieceNum int) (peerIDs []string, err error)
 
+	// GetRunningPieceSources gets all the peerIDs that the running piece with specified clientID
+	// is being downloaded from. There may be more than one sources in the endgame.
+	GetRunningPieceSources(ctx context.Context, clientID string, pieceNum int) (dstPIDs []string, err error)
+
 	// DeletePeerIDByPieceNum deletes the peerID which means that
 	// the peer no longer provides the service for the pieceNum of taskID.
 	DeletePeerIDByPieceNum(ctx context.Context, taskID string, pieceNum int, peerID string) error
 
+	// UpdatePeerThroughput updates the estimated upload throughput of the peerID
+	// with a piece of pieceSize bytes which took cost to be downloaded from it.
+	UpdatePeerThroughput(ctx context.Context, peerID string, pieceSize int64, cost time.Duration) error
+
+	// UpdatePeerServiceError penalizes the peerID with an error of the weight
+	// that others failed to download pieces from it because of its service,
+	// and returns the recent service errors of the peer after decaying.
+	// The weight includes the penalty of the failed piece reported by UpdateProgress.
+	UpdatePeerServiceError(ctx context.Context, peerID string, weight float64) (recentErrors float64, err error)
+
 	// GetPeerStateByPeerID gets peer state with specified peerID.
 	GetPeerStateByPeerID(ctx context.Context, peerID string) (peerState *PeerState, err error)
 
@@ -79,20 +132,38 @@ type ProgressMgr interface {
 	// GetPeersByTaskID gets all peers info with specified taskID.
 	GetPeersByTaskID(ctx context.Context, taskID string) (peersInfo []*types.PeerInfo, err error)
 
+	// GetClientsByTaskID gets the peerIDs of all clients downloading the taskID keyed by the clientID,
+	// and a peer which downloads the task repeatedly has a client for each download.
+	GetClientsByTaskID(ctx context.Context, taskID string) (clients map[string]string, err error)
+
 	// GetBlackInfoByPeerID gets black info with specified peerID.
 	GetBlackInfoByPeerID(ctx context.Context, peerID string) (dstPIDMap *syncmap.SyncMap, err error)
 
+	// IsInBlackList returns whether dstPID is in the blacklist of srcPID,
+	// which means that srcPID failed to download from dstPID recently.
+	IsInBlackList(ctx context.Context, srcPID, dstPID string) bool
+
 	// UpdateSuperLoad updates the superLoad with delta.
 	//
 	// The value will be rolled back if it exceeds the limit after updated and returns false.
 	UpdateSuperLoad(ctx context.Context, taskID string, delta, limit int32) (updated bool, err error)
 
+	// SetSuperLoadWeight sets the weight of taskID to share the upload slots of supernode with other tasks.
+	SetSuperLoadWeight(ctx context.Context, taskID string, weight int32) error
+
 	// DeleteTaskID deletes the super progress with specified taskID.
 	DeleteTaskID(ctx context.Context, taskID string, pieceTotal int) (err error)
 
+	// CompactCID compacts the client progress of the clientID which has finished downloading,
+	// and the piece bitSet and running pieces of it will be released.
+	CompactCID(ctx context.Context, clientID string) (err error)
+
 	// DeleteCID deletes the super progress with specified clientID.
 	DeleteCID(ctx context.Context, clientID string) (err error)
 
 	// DeletePeerID deletes the peerState by PeerID.
 	DeletePeerID(ctx context.Context, peerID string) (err error)
+
+	// GetMemoryUsage returns the estimated memory usage of the progress states.
+	GetMemoryUsage(ctx context.Context) (usage *ProgressMemoryUsage, err error)
 }
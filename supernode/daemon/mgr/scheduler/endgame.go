@@ -0,0 +1,136 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scheduler
+
+import (
+	"context"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPieceSources is the max count of peers that a piece can be downloaded from at the same time.
+const maxPieceSources = 2
+
+// isEndgame returns whether the client has only a few pieces left.
+// The left pieces include the ones which the CDN hasn't downloaded yet besides the running
+// and available ones, so a client never enters the endgame while the CDN is far from complete.
+func (sm *Manager) isEndgame(ctx context.Context, taskID, clientID string, pieceTotal int, pieceAvailable, pieceRunning []int) bool {
+	if sm.cfg.EndgameThreshold <= 0 || pieceTotal <= 0 || len(pieceRunning) == 0 {
+		return false
+	}
+	if len(pieceAvailable)+len(pieceRunning) > sm.cfg.EndgameThreshold {
+		return false
+	}
+
+	pieceSuccess, err := sm.progressMgr.GetPieceProgressByCID(ctx, taskID, clientID, "success")
+	if err != nil {
+		logrus.Warnf("scheduler: failed to get success pieces for taskID(%s) clientID(%s): %v", taskID, clientID, err)
+		return false
+	}
+	return pieceTotal-len(pieceSuccess) <= sm.cfg.EndgameThreshold
+}
+
+// scheduleEndgame schedules the available pieces as usual,
+// and then schedules the running pieces to another peer.
+// The client will cancel the other requests once a copy of the piece arrives.
+func (sm *Manager) scheduleEndgame(ctx context.Context, taskID, clientID, srcPID string, pieceAvailable, pieceRunning []int) ([]*mgr.PieceResult, error) {
+	pieceResults := make([]*mgr.PieceResult, 0)
+	if len(pieceAvailable) > 0 && len(pieceRunning) < sm.cfg.GetPeerDownLimit() {
+		pieceNums, err := sm.sort(ctx, pieceAvailable, pieceRunning, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if pieceResults, err = sm.getPieceResults(ctx, taskID, clientID, srcPID, pieceNums, len(pieceRunning)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pieceNum := range pieceRunning {
+		dstPID := sm.getDuplicatePID(ctx, taskID, clientID, srcPID, pieceNum)
+		if dstPID == "" {
+			continue
+		}
+
+		pieceResults = append(pieceResults, &mgr.PieceResult{
+			TaskID:   taskID,
+			PieceNum: pieceNum,
+			DstPID:   dstPID,
+		})
+	}
+
+	if len(pieceResults) == 0 {
+		return nil, errors.Wrapf(errortypes.ErrPeerWait, "taskID(%s) clientID(%s)", taskID, clientID)
+	}
+	return pieceResults, nil
+}
+
+// getDuplicatePID returns another available dstPID for the running piece
+// and it returns "" if there is no such peer.
+func (sm *Manager) getDuplicatePID(ctx context.Context, taskID, clientID, srcPID string, pieceNum int) string {
+	sources, err := sm.progressMgr.GetRunningPieceSources(ctx, clientID, pieceNum)
+	if err != nil {
+		logrus.Warnf("scheduler: failed to get running sources for pieceNum(%d) taskID(%s) clientID(%s): %v", pieceNum, taskID, clientID, err)
+		return ""
+	}
+	if len(sources) == 0 || len(sources) >= maxPieceSources {
+		return ""
+	}
+
+	peerIDs, err := sm.progressMgr.GetPeerIDsByPieceNum(ctx, taskID, pieceNum)
+	if err != nil {
+		logrus.Warnf("scheduler: failed to get peerIDs for pieceNum(%d) taskID(%s): %v", pieceNum, taskID, err)
+		return ""
+	}
+	candidates := make([]string, 0, len(peerIDs))
+	for _, peerID := range peerIDs {
+		if peerID != srcPID && !containsString(sources, peerID) {
+			candidates = append(candidates, peerID)
+		}
+	}
+
+	dstPID := sm.tryGetPID(ctx, taskID, pieceNum, srcPID, candidates)
+	if containsString(sources, dstPID) {
+		return ""
+	}
+
+	if sm.cfg.IsSuperPID(dstPID) {
+		updated, err := sm.progressMgr.UpdateSuperLoad(ctx, taskID, 1, int32(sm.cfg.GetPeerDownLimit()))
+		if err != nil || !updated {
+			return ""
+		}
+	}
+
+	if err := sm.progressMgr.UpdateClientProgress(ctx, taskID, clientID, dstPID, pieceNum, config.PieceRUNNING); err != nil {
+		logrus.Warnf("scheduler: failed to update client progress running for pieceNum(%d) taskID(%s) clientID(%s) dstPID(%s)", pieceNum, taskID, clientID, dstPID)
+		return ""
+	}
+	return dstPID
+}
+
+func containsString(slice []string, s string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -20,10 +20,10 @@ import (
 	"context"
 	"math/rand"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
-	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
 
@@ -31,36 +31,43 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 var _ mgr.SchedulerMgr = &Manager{}
 
 // Manager is an implement of the interface of SchedulerMgr.
 type Manager struct {
 	cfg         *config.Config
 	progressMgr mgr.ProgressMgr
+
+	// random makes the random choices of scheduler,
+	// and it should be used with randLock held.
+	random   *rand.Rand
+	randLock sync.Mutex
 }
 
 // NewManager returns a new Manager.
 func NewManager(cfg *config.Config, progressMgr mgr.ProgressMgr) (*Manager, error) {
+	return NewManagerWithSeed(cfg, progressMgr, time.Now().UnixNano())
+}
+
+// NewManagerWithSeed returns a new Manager whose random choices are determined by the seed.
+// It's useful to reproduce the scheduling results, such as simulation.
+func NewManagerWithSeed(cfg *config.Config, progressMgr mgr.ProgressMgr, seed int64) (*Manager, error) {
 	return &Manager{
 		cfg:         cfg,
 		progressMgr: progressMgr,
+		random:      rand.New(rand.NewSource(seed)),
 	}, nil
 }
 
 // Schedule gets scheduler result with specified taskID, clientID and peerID through some rules.
-func (sm *Manager) Schedule(ctx context.Context, taskID, clientID, peerID string) ([]*mgr.PieceResult, error) {
+func (sm *Manager) Schedule(ctx context.Context, taskID, clientID, peerID string, pieceTotal int) ([]*mgr.PieceResult, error) {
 	// get available pieces
 	pieceAvailable, err := sm.progressMgr.GetPieceProgressByCID(ctx, taskID, clientID, "available")
 	if err != nil {
 		return nil, err
 	}
-	if len(pieceAvailable) == 0 {
-		return nil, errors.Wrapf(errortypes.ErrPeerWait, "taskID(%s) clientID(%s)", taskID, clientID)
-	}
+	// sort the pieces to make the scheduling result independent of the order they are stored.
+	sort.Ints(pieceAvailable)
 	logrus.Debugf("scheduler get available pieces %v for taskID(%s) clientID(%s)", pieceAvailable, taskID, clientID)
 
 	// get running pieces
@@ -68,9 +75,21 @@ func (sm *Manager) Schedule(ctx context.Context, taskID, clientID, peerID string
 	if err != nil {
 		return nil, err
 	}
+	sort.Ints(pieceRunning)
 	logrus.Debugf("scheduler get running pieces %v for taskID(%s) clientID(%s)", pieceRunning, taskID, clientID)
+
+	// In the endgame, the running pieces will be scheduled to another peer at the same time.
+	if sm.isEndgame(ctx, taskID, clientID, pieceTotal, pieceAvailable, pieceRunning) {
+		logrus.Debugf("scheduler: taskID(%s) clientID(%s) enters the endgame with available pieces %v and running pieces %v",
+			taskID, clientID, pieceAvailable, pieceRunning)
+		return sm.scheduleEndgame(ctx, taskID, clientID, peerID, pieceAvailable, pieceRunning)
+	}
+
+	if len(pieceAvailable) == 0 {
+		return nil, errors.Wrapf(errortypes.ErrPeerWait, "taskID(%s) clientID(%s)", taskID, clientID)
+	}
 	runningCount := len(pieceRunning)
-	if runningCount >= sm.cfg.PeerDownLimit {
+	if runningCount >= sm.cfg.GetPeerDownLimit() {
 		return nil, errors.Wrapf(errortypes.PeerContinue, "taskID: %s,clientID: %s", taskID, clientID)
 	}
 
@@ -131,7 +150,7 @@ func (sm *Manager) sortExecutor(ctx context.Context, pieceNums []int, centerNum
 
 		// randomly choose whether to exchange when the distance to center value is equal
 		if abs(pieceNums[i]-centerNum) == abs(pieceNums[j]-centerNum) {
-			randNum := rand.Intn(2)
+			randNum := sm.intn(2)
 			if randNum == 0 {
 				return true
 			}
@@ -147,9 +166,9 @@ func (sm *Manager) getPieceResults(ctx context.Context, taskID, clientID, srcPID
 	if err != nil {
 		return nil, err
 	}
-	if srcPeerState.ClientErrorCount.Get() > int32(sm.cfg.FailureCountLimit) {
+	if srcPeerState.ClientErrorCount.Get() > int32(sm.cfg.GetFailureCountLimit()) {
 		logrus.Warnf("scheduler: peerID: %s got errors for %d times which reaches error limit: %d for taskID(%s)",
-			srcPID, srcPeerState.ClientErrorCount.Get(), sm.cfg.FailureCountLimit, taskID)
+			srcPID, srcPeerState.ClientErrorCount.Get(), sm.cfg.GetFailureCountLimit(), taskID)
 		useSupernode = true
 	}
 
@@ -174,7 +193,7 @@ func (sm *Manager) getPieceResults(ctx context.Context, taskID, clientID, srcPID
 
 		// We limit the number of simultaneous connections that supernode can accept for each task.
 		if sm.cfg.IsSuperPID(dstPID) {
-			updated, err := sm.progressMgr.UpdateSuperLoad(ctx, taskID, 1, int32(sm.cfg.PeerDownLimit))
+			updated, err := sm.progressMgr.UpdateSuperLoad(ctx, taskID, 1, int32(sm.cfg.GetPeerDownLimit()))
 			if err != nil {
 				logrus.Warnf("failed to update super load taskID(%s) clientID(%s): %v", taskID, clientID, err)
 				continue
@@ -196,7 +215,7 @@ func (sm *Manager) getPieceResults(ctx context.Context, taskID, clientID, srcPID
 		})
 
 		runningCount++
-		if runningCount >= sm.cfg.PeerDownLimit {
+		if runningCount >= sm.cfg.GetPeerDownLimit() {
 			break
 		}
 	}
@@ -205,6 +224,9 @@ func (sm *Manager) getPieceResults(ctx context.Context, taskID, clientID, srcPID
 }
 
 // tryGetPID returns an available dstPID from ps.pieceContainer.
+//
+// The available peers will be tried in ascending order of the expected time
+// to transfer a piece, which is estimated by their measured throughput, ProducerLoad and Reputation.
 func (sm *Manager) tryGetPID(ctx context.Context, taskID string, pieceNum int, srcPID string, peerIDs []string) (dstPID string) {
 	defer func() {
 		if dstPID == "" {
@@ -212,6 +234,10 @@ func (sm *Manager) tryGetPID(ctx context.Context, taskID string, pieceNum int, s
 		}
 	}()
 
+	// the peers with the same expected cost will be tried in random order.
+	peerIDs = sm.shuffle(peerIDs)
+
+	candidates := make([]*mgr.PeerState, 0, len(peerIDs))
 	for i := 0; i < len(peerIDs); i++ {
 		// if failed to get peerState, and then it should not be needed.
 		peerState, err := sm.progressMgr.GetPeerStateByPeerID(ctx, peerIDs[i])
@@ -228,49 +254,106 @@ func (sm *Manager) tryGetPID(ctx context.Context, taskID string, pieceNum int, s
 			continue
 		}
 
-		// if service has failed for EliminationLimit times, and then it should not be needed.
-		if peerState.ServiceErrorCount != nil {
-			serviceErrorCount := peerState.ServiceErrorCount.Get()
-			if int(serviceErrorCount) >= sm.cfg.EliminationLimit {
-				logrus.Warnf("scheduler: the peer(%s) has been eliminated to because of too many errors(%d) occurred as a peer server", peerIDs[i], serviceErrorCount)
-				sm.deletePeerIDByPieceNum(ctx, taskID, pieceNum, peerIDs[i])
-				continue
-			}
+		// if service has failed for EliminationLimit times recently, it should not be needed for now.
+		// And it will be needed again after the errors decay.
+		if peerState.RecentServiceErrors >= float64(sm.cfg.GetEliminationLimit()) {
+			logrus.Warnf("scheduler: the peer(%s) has been skipped because of too many recent errors(%.2f) occurred as a peer server",
+				peerIDs[i], peerState.RecentServiceErrors)
+			continue
 		}
 
 		// if the v is in the blackList, try the next one.
-		blackInfo, err := sm.progressMgr.GetBlackInfoByPeerID(ctx, srcPID)
-		if err != nil && !errortypes.IsDataNotFound(err) {
-			logrus.Warnf("scheduler: failed to get blackInfo for peerID %s: %v", peerIDs[i], err)
-			continue
-		}
-		if blackInfo != nil && isExistInMap(blackInfo, peerIDs[i]) {
+		if sm.progressMgr.IsInBlackList(ctx, srcPID, peerIDs[i]) {
 			continue
 		}
 
 		if peerState.ProducerLoad != nil {
-			if peerState.ProducerLoad.Add(1) <= int32(sm.cfg.PeerUpLimit) {
-				return peerIDs[i]
-			}
-			peerState.ProducerLoad.Add(-1)
+			peerState.PeerID = peerIDs[i]
+			candidates = append(candidates, peerState)
 		}
 	}
+
+	sortByExpectedCost(candidates)
+	for _, peerState := range candidates {
+		if peerState.ProducerLoad.Add(1) <= int32(sm.cfg.GetPeerUpLimit()) {
+			return peerState.PeerID
+		}
+		peerState.ProducerLoad.Add(-1)
+	}
 	return
 }
 
+// sortByExpectedCost sorts the peers by the expected time to transfer a piece from them.
+//
+// A peer serves its ProducerLoad downloads concurrently, so the expected time of
+// a new download is proportional to (ProducerLoad + 1) / Throughput.
+// The peers that have not been measured are considered as fast as the average
+// of the measured ones, so that they still get a chance to be measured.
+// And the expected time is scaled by the reciprocal of the Reputation,
+// which makes the unreliable peers less preferred rather than unavailable.
+func sortByExpectedCost(peerStates []*mgr.PeerState) {
+	if len(peerStates) < 2 {
+		return
+	}
+
+	var total float64
+	var measured int
+	for _, ps := range peerStates {
+		if ps.Throughput > 0 {
+			total += ps.Throughput
+			measured++
+		}
+	}
+	average := 1.0
+	if measured > 0 {
+		average = total / float64(measured)
+	}
+
+	expectedCost := make(map[string]float64, len(peerStates))
+	for _, ps := range peerStates {
+		throughput := ps.Throughput
+		if throughput <= 0 {
+			throughput = average
+		}
+		reputation := ps.Reputation
+		if reputation <= 0 {
+			reputation = 1
+		}
+		expectedCost[ps.PeerID] = float64(ps.ProducerLoad.Get()+1) / throughput / reputation
+	}
+
+	sort.SliceStable(peerStates, func(i, j int) bool {
+		return expectedCost[peerStates[i].PeerID] < expectedCost[peerStates[j].PeerID]
+	})
+}
+
 func (sm *Manager) deletePeerIDByPieceNum(ctx context.Context, taskID string, pieceNum int, peerID string) {
 	if err := sm.progressMgr.DeletePeerIDByPieceNum(ctx, taskID, pieceNum, peerID); err != nil {
 		logrus.Warnf("scheduler: failed to delete the peerID %s for pieceNum %d of taskID: %s: %v", peerID, pieceNum, taskID, err)
 	}
 }
 
-// isExistInMap returns whether the key exists in the mmap.
-func isExistInMap(mmap *syncmap.SyncMap, key string) bool {
-	if mmap == nil {
-		return false
-	}
-	_, err := mmap.Get(key)
-	return err == nil
+// intn returns a random number in [0,n).
+func (sm *Manager) intn(n int) int {
+	sm.randLock.Lock()
+	defer sm.randLock.Unlock()
+	return sm.random.Intn(n)
+}
+
+// shuffle returns a copy of the peerIDs in random order.
+// The peerIDs are sorted before being shuffled to make the result
+// independent of their original order.
+func (sm *Manager) shuffle(peerIDs []string) []string {
+	result := make([]string, len(peerIDs))
+	copy(result, peerIDs)
+	sort.Strings(result)
+
+	sm.randLock.Lock()
+	defer sm.randLock.Unlock()
+	sm.random.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result
 }
 
 // get the center value of the piece num being downloaded
@@ -22,8 +22,9 @@ import (
 	"reflect"
 	"testing"
 
-	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
+	"github.com/dragonflyoss/Dragonfly/pkg/atomiccount"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/mock"
 
 	"github.com/go-check/check"
@@ -122,14 +123,126 @@ func (s *SchedulerMgrTestSuite) TestGetCenterNum(c *check.C) {
 	}
 }
 
-func (s *SchedulerMgrTestSuite) TestIsExistInMap(c *check.C) {
-	mmap := syncmap.NewSyncMap()
-	mmap.Add("a", "value")
-	mmap.Add("b", "value")
-	mmap.Add("c", "value")
+func (s *SchedulerMgrTestSuite) TestIsEndgame(c *check.C) {
+	var cases = []struct {
+		pieceTotal     int
+		pieceSuccess   []int
+		pieceAvailable []int
+		pieceRunning   []int
+		expected       bool
+	}{
+		{
+			pieceTotal:     10,
+			pieceSuccess:   []int{6, 7, 8, 9, 10},
+			pieceAvailable: []int{1, 2, 3},
+			pieceRunning:   []int{4, 5},
+			expected:       false,
+		},
+		{
+			pieceTotal:     10,
+			pieceSuccess:   []int{3, 6, 7, 8, 9, 10},
+			pieceAvailable: []int{1, 2},
+			pieceRunning:   []int{4, 5},
+			expected:       true,
+		},
+		{
+			pieceTotal:     10,
+			pieceSuccess:   []int{2, 3, 4, 5, 6, 7, 8, 9, 10},
+			pieceAvailable: []int{1},
+			pieceRunning:   []int{},
+			expected:       false,
+		},
+		{
+			// the CDN is still downloading the pieces which the client hasn't got.
+			pieceTotal:     100,
+			pieceSuccess:   []int{3},
+			pieceAvailable: []int{1},
+			pieceRunning:   []int{2},
+			expected:       false,
+		},
+		{
+			// the pieceTotal of the task is unknown.
+			pieceTotal:     -1,
+			pieceSuccess:   []int{3},
+			pieceAvailable: []int{1},
+			pieceRunning:   []int{2},
+			expected:       false,
+		},
+	}
+
+	for i, v := range cases {
+		clientID := fmt.Sprintf("clientID%d", i)
+		s.mockProgressMgr.EXPECT().GetPieceProgressByCID(gomock.Any(), "taskID", clientID, "success").Return(v.pieceSuccess, nil).MaxTimes(1)
+		c.Check(s.manager.isEndgame(context.Background(), "taskID", clientID, v.pieceTotal, v.pieceAvailable, v.pieceRunning),
+			check.Equals, v.expected, check.Commentf("%+v", v))
+	}
+}
+
+func (s *SchedulerMgrTestSuite) TestSortByExpectedCost(c *check.C) {
+	newPeerState := func(peerID string, load int32, throughput float64) *mgr.PeerState {
+		return &mgr.PeerState{
+			PeerID:       peerID,
+			ProducerLoad: atomiccount.NewAtomicInt(load),
+			Throughput:   throughput,
+		}
+	}
+	getPeerIDs := func(peerStates []*mgr.PeerState) []string {
+		var result []string
+		for _, ps := range peerStates {
+			result = append(result, ps.PeerID)
+		}
+		return result
+	}
+
+	var cases = []struct {
+		peerStates []*mgr.PeerState
+		expected   []string
+	}{
+		{
+			// keep the origin order when no peer has been measured
+			peerStates: []*mgr.PeerState{newPeerState("a", 0, 0), newPeerState("b", 0, 0)},
+			expected:   []string{"a", "b"},
+		},
+		{
+			peerStates: []*mgr.PeerState{newPeerState("a", 0, 100), newPeerState("b", 0, 1000)},
+			expected:   []string{"b", "a"},
+		},
+		{
+			// the faster peer is slower than others when it is heavily loaded
+			peerStates: []*mgr.PeerState{newPeerState("a", 0, 100), newPeerState("b", 19, 1000)},
+			expected:   []string{"a", "b"},
+		},
+		{
+			// the unmeasured peer is considered as fast as the average
+			peerStates: []*mgr.PeerState{newPeerState("a", 0, 100), newPeerState("b", 0, 0), newPeerState("c", 0, 1000)},
+			expected:   []string{"c", "b", "a"},
+		},
+		{
+			// the peer with bad reputation is less preferred
+			peerStates: []*mgr.PeerState{withReputation(newPeerState("a", 0, 1000), 0.1), newPeerState("b", 0, 500)},
+			expected:   []string{"b", "a"},
+		},
+		{
+			// but it is still preferred when the others are much slower
+			peerStates: []*mgr.PeerState{withReputation(newPeerState("a", 0, 1000), 0.5), newPeerState("b", 0, 100)},
+			expected:   []string{"a", "b"},
+		},
+		{
+			// the reputation works when no peer has been measured
+			peerStates: []*mgr.PeerState{withReputation(newPeerState("a", 0, 0), 0.5), newPeerState("b", 0, 0)},
+			expected:   []string{"b", "a"},
+		},
+	}
+
+	for _, v := range cases {
+		sortByExpectedCost(v.peerStates)
+		c.Check(getPeerIDs(v.peerStates), check.DeepEquals, v.expected)
+	}
+}
 
-	c.Check(isExistInMap(mmap, "a"), check.Equals, true)
-	c.Check(isExistInMap(mmap, "d"), check.Equals, false)
+func withReputation(ps *mgr.PeerState, reputation float64) *mgr.PeerState {
+	ps.Reputation = reputation
+	return ps
 }
 
 func (s *SchedulerMgrTestSuite) BenchmarkGetPieceCountMap(c *check.C) {
@@ -29,6 +29,7 @@ type PieceResult struct {
 
 // SchedulerMgr is responsible for calculating scheduling results according to certain rules.
 type SchedulerMgr interface {
-	// Schedule gets scheduler result with specified taskID, clientID and peerID through some rules.
-	Schedule(ctx context.Context, taskID, clientID, peerID string) ([]*PieceResult, error)
+	// Schedule gets scheduler result with specified taskID, clientID and peerID through some rules,
+	// and pieceTotal is the number of the pieces of the task which is not positive if it's unknown yet.
+	Schedule(ctx context.Context, taskID, clientID, peerID string, pieceTotal int) ([]*PieceResult, error)
 }
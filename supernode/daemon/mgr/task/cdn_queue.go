@@ -0,0 +1,257 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package task
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	cdnStateQueued  = "queued"
+	cdnStateRunning = "running"
+)
+
+// cdnJob is a CDN download of a task which is queued or running.
+type cdnJob struct {
+	task     *types.TaskInfo
+	download *cdnDownload
+
+	// origin is the host of the source which the task is downloaded from.
+	origin string
+
+	// weight is the priority weight of the task.
+	weight int
+
+	// waitingClients is the number of the clients which are waiting for the task.
+	waitingClients int
+
+	// seq is the order in which the job is queued.
+	seq uint64
+}
+
+func newCDNJob(task *types.TaskInfo, download *cdnDownload) *cdnJob {
+	var origin string
+	if u, err := url.Parse(task.RawURL); err == nil {
+		origin = u.Host
+	}
+
+	return &cdnJob{
+		task:           task,
+		download:       download,
+		origin:         origin,
+		weight:         mgr.GetPriorityWeight(task.Priority),
+		waitingClients: 1,
+	}
+}
+
+// before returns whether the job j should be started before the job o.
+// The job with higher priority comes first, and then the one with more waiting clients,
+// and then the one queued earlier.
+func (j *cdnJob) before(o *cdnJob) bool {
+	if j.weight != o.weight {
+		return j.weight > o.weight
+	}
+	if j.waitingClients != o.waitingClients {
+		return j.waitingClients > o.waitingClients
+	}
+	return j.seq < o.seq
+}
+
+// cdnQueue bounds the number of the concurrent CDN downloads in total and per origin,
+// and the CDN downloads exceeding the limits are queued until there are free workers.
+type cdnQueue struct {
+	sync.Mutex
+
+	// workerLimit is the max number of the concurrent CDN downloads, and 0 means no limit.
+	workerLimit int
+
+	// originLimit is the default max number of the concurrent CDN downloads per origin,
+	// and 0 means no limit.
+	originLimit int
+
+	// originLimits are the max numbers of the concurrent CDN downloads of the specified origins.
+	originLimits map[string]int
+
+	// start starts the CDN download of the job which is admitted.
+	start func(job *cdnJob)
+
+	pending        []*cdnJob
+	running        int
+	originsRunning map[string]int
+	seq            uint64
+
+	gauge *prometheus.GaugeVec
+}
+
+func newCDNQueue(cfg *config.Config, start func(job *cdnJob), gauge *prometheus.GaugeVec) *cdnQueue {
+	return &cdnQueue{
+		workerLimit:    cfg.CDNWorkerLimit,
+		originLimit:    cfg.CDNOriginLimit,
+		originLimits:   cfg.CDNOriginLimits,
+		start:          start,
+		originsRunning: make(map[string]int),
+		gauge:          gauge,
+	}
+}
+
+// push queues the job and starts the jobs which can be admitted.
+func (q *cdnQueue) push(job *cdnJob) {
+	q.Lock()
+	q.seq++
+	job.seq = q.seq
+	q.pending = append(q.pending, job)
+	admitted := q.admit()
+	q.Unlock()
+
+	q.startAll(admitted)
+}
+
+// done releases the worker of the finished job and starts the jobs which can be admitted.
+func (q *cdnQueue) done(job *cdnJob) {
+	q.Lock()
+	q.running--
+	q.originsRunning[job.origin]--
+	if q.originsRunning[job.origin] <= 0 {
+		delete(q.originsRunning, job.origin)
+	}
+	admitted := q.admit()
+	q.Unlock()
+
+	q.startAll(admitted)
+}
+
+// addWaitingClient adds a waiting client to the queued job of the task,
+// so that the job will be admitted earlier.
+func (q *cdnQueue) addWaitingClient(taskID string) {
+	q.Lock()
+	defer q.Unlock()
+
+	for _, job := range q.pending {
+		if job.task.ID == taskID {
+			job.waitingClients++
+			return
+		}
+	}
+}
+
+// upgrade raises the weight of the queued job of the task to the weight
+// if the priority of the task has been upgraded, and the job will be admitted earlier.
+func (q *cdnQueue) upgrade(taskID string, weight int) {
+	q.Lock()
+	defer q.Unlock()
+
+	for _, job := range q.pending {
+		if job.task.ID == taskID {
+			if weight > job.weight {
+				job.weight = weight
+			}
+			return
+		}
+	}
+}
+
+// remove removes the queued job of the task and returns it.
+// And nil will be returned if the job is not queued.
+func (q *cdnQueue) remove(taskID string) *cdnJob {
+	q.Lock()
+	defer q.Unlock()
+
+	for i, job := range q.pending {
+		if job.task.ID == taskID {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.updateGauge()
+			return job
+		}
+	}
+	return nil
+}
+
+// removeAll removes all the queued jobs and returns them.
+func (q *cdnQueue) removeAll() []*cdnJob {
+	q.Lock()
+	defer q.Unlock()
+
+	jobs := q.pending
+	q.pending = nil
+	q.updateGauge()
+	return jobs
+}
+
+// depth returns the number of the running and pending CDN downloads.
+func (q *cdnQueue) depth() (running, pending int) {
+	q.Lock()
+	defer q.Unlock()
+	return q.running, len(q.pending)
+}
+
+// admit pops the jobs which can be started within the limits in order.
+// It should be called with the lock held.
+func (q *cdnQueue) admit() []*cdnJob {
+	var admitted []*cdnJob
+	for q.workerLimit <= 0 || q.running < q.workerLimit {
+		next := -1
+		for i, job := range q.pending {
+			if q.isOriginFull(job.origin) {
+				continue
+			}
+			if next == -1 || job.before(q.pending[next]) {
+				next = i
+			}
+		}
+		if next == -1 {
+			break
+		}
+
+		job := q.pending[next]
+		q.pending = append(q.pending[:next], q.pending[next+1:]...)
+		q.running++
+		q.originsRunning[job.origin]++
+		admitted = append(admitted, job)
+	}
+
+	q.updateGauge()
+	return admitted
+}
+
+func (q *cdnQueue) isOriginFull(origin string) bool {
+	limit := q.originLimit
+	if l, ok := q.originLimits[origin]; ok {
+		limit = l
+	}
+	return limit > 0 && q.originsRunning[origin] >= limit
+}
+
+func (q *cdnQueue) startAll(jobs []*cdnJob) {
+	for _, job := range jobs {
+		q.start(job)
+	}
+}
+
+func (q *cdnQueue) updateGauge() {
+	if q.gauge == nil {
+		return
+	}
+	q.gauge.WithLabelValues(cdnStateQueued).Set(float64(len(q.pending)))
+	q.gauge.WithLabelValues(cdnStateRunning).Set(float64(q.running))
+}
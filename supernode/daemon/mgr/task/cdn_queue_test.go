@@ -0,0 +1,153 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package task
+
+import (
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/go-check/check"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func init() {
+	check.Suite(&CDNQueueTestSuite{})
+}
+
+type CDNQueueTestSuite struct {
+	started []string
+}
+
+func (s *CDNQueueTestSuite) SetUpTest(c *check.C) {
+	s.started = nil
+}
+
+func (s *CDNQueueTestSuite) newQueue(workerLimit, originLimit int, originLimits map[string]int) *cdnQueue {
+	cfg := config.NewConfig()
+	cfg.CDNWorkerLimit = workerLimit
+	cfg.CDNOriginLimit = originLimit
+	cfg.CDNOriginLimits = originLimits
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "cdn_downloads"}, []string{"state"})
+	return newCDNQueue(cfg, func(job *cdnJob) {
+		s.started = append(s.started, job.task.ID)
+	}, gauge)
+}
+
+func newTestCDNJob(taskID, rawURL, priority string) *cdnJob {
+	return newCDNJob(&types.TaskInfo{
+		ID:       taskID,
+		RawURL:   rawURL,
+		Priority: priority,
+	}, newCDNDownload())
+}
+
+func (s *CDNQueueTestSuite) TestWorkerLimit(c *check.C) {
+	q := s.newQueue(2, 0, nil)
+	jobs := []*cdnJob{
+		newTestCDNJob("a", "http://a.com/a", ""),
+		newTestCDNJob("b", "http://a.com/b", ""),
+		newTestCDNJob("c", "http://a.com/c", ""),
+	}
+	for _, job := range jobs {
+		q.push(job)
+	}
+	c.Check(s.started, check.DeepEquals, []string{"a", "b"})
+	c.Check(prom_testutil.ToFloat64(q.gauge.WithLabelValues(cdnStateQueued)), check.Equals, float64(1))
+	c.Check(prom_testutil.ToFloat64(q.gauge.WithLabelValues(cdnStateRunning)), check.Equals, float64(2))
+
+	q.done(jobs[0])
+	c.Check(s.started, check.DeepEquals, []string{"a", "b", "c"})
+	c.Check(prom_testutil.ToFloat64(q.gauge.WithLabelValues(cdnStateQueued)), check.Equals, float64(0))
+	c.Check(prom_testutil.ToFloat64(q.gauge.WithLabelValues(cdnStateRunning)), check.Equals, float64(2))
+}
+
+func (s *CDNQueueTestSuite) TestOrder(c *check.C) {
+	q := s.newQueue(1, 0, nil)
+	jobs := map[string]*cdnJob{
+		"running": newTestCDNJob("running", "http://a.com/running", ""),
+		"a":       newTestCDNJob("a", "http://a.com/a", ""),
+		"b":       newTestCDNJob("b", "http://a.com/b", ""),
+		"c":       newTestCDNJob("c", "http://a.com/c", types.TaskInfoPriorityCritical),
+		"d":       newTestCDNJob("d", "http://a.com/d", ""),
+		"e":       newTestCDNJob("e", "http://a.com/e", types.TaskInfoPriorityBackground),
+	}
+	for _, id := range []string{"running", "e", "a", "b", "c", "d"} {
+		q.push(jobs[id])
+	}
+	q.addWaitingClient("b")
+
+	for i := 0; i < len(jobs); i++ {
+		q.done(jobs[s.started[len(s.started)-1]])
+	}
+	c.Check(s.started, check.DeepEquals, []string{"running", "c", "b", "a", "d", "e"})
+}
+
+func (s *CDNQueueTestSuite) TestUpgrade(c *check.C) {
+	q := s.newQueue(1, 0, nil)
+	jobs := map[string]*cdnJob{
+		"running": newTestCDNJob("running", "http://a.com/running", ""),
+		"a":       newTestCDNJob("a", "http://a.com/a", ""),
+		"b":       newTestCDNJob("b", "http://a.com/b", types.TaskInfoPriorityBackground),
+		"c":       newTestCDNJob("c", "http://a.com/c", types.TaskInfoPriorityBackground),
+	}
+	for _, id := range []string{"running", "a", "b", "c"} {
+		q.push(jobs[id])
+	}
+	// the queued job of c is upgraded to critical by a registration,
+	// and the weight of a job is never downgraded.
+	q.upgrade("c", mgr.GetPriorityWeight(types.TaskInfoPriorityCritical))
+	q.upgrade("a", mgr.GetPriorityWeight(types.TaskInfoPriorityBackground))
+
+	for i := 0; i < len(jobs); i++ {
+		q.done(jobs[s.started[len(s.started)-1]])
+	}
+	c.Check(s.started, check.DeepEquals, []string{"running", "c", "a", "b"})
+}
+
+func (s *CDNQueueTestSuite) TestOriginLimits(c *check.C) {
+	q := s.newQueue(0, 1, map[string]int{"b.com": 2})
+	a1 := newTestCDNJob("a1", "http://a.com/1", "")
+	q.push(a1)
+	q.push(newTestCDNJob("a2", "http://a.com/2", ""))
+	q.push(newTestCDNJob("b1", "http://b.com/1", ""))
+	q.push(newTestCDNJob("b2", "http://b.com/2", ""))
+	q.push(newTestCDNJob("b3", "http://b.com/3", ""))
+	c.Check(s.started, check.DeepEquals, []string{"a1", "b1", "b2"})
+
+	q.done(a1)
+	c.Check(s.started, check.DeepEquals, []string{"a1", "b1", "b2", "a2"})
+}
+
+func (s *CDNQueueTestSuite) TestRemove(c *check.C) {
+	q := s.newQueue(1, 0, nil)
+	running := newTestCDNJob("running", "http://a.com/running", "")
+	q.push(running)
+	q.push(newTestCDNJob("a", "http://a.com/a", ""))
+	q.push(newTestCDNJob("b", "http://a.com/b", ""))
+
+	c.Check(q.remove("running"), check.IsNil)
+	job := q.remove("a")
+	c.Assert(job, check.NotNil)
+	c.Check(job.task.ID, check.Equals, "a")
+
+	q.done(running)
+	c.Check(s.started, check.DeepEquals, []string{"running", "b"})
+	c.Check(q.removeAll(), check.HasLen, 0)
+}
@@ -18,11 +18,14 @@ package task
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/pkg/metricsutils"
+	"github.com/dragonflyoss/Dragonfly/pkg/netutils"
 	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
 	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
@@ -48,6 +51,10 @@ type metrics struct {
 	triggerCdnCount              *prometheus.CounterVec
 	triggerCdnFailCount          *prometheus.CounterVec
 	scheduleDurationMilliSeconds *prometheus.HistogramVec
+	cdnDownloads                 *prometheus.GaugeVec
+	replicasCount                *prometheus.CounterVec
+	namespaceTasks               *prometheus.GaugeVec
+	namespaceRejectedCount       *prometheus.CounterVec
 }
 
 func newMetrics(register prometheus.Registerer) *metrics {
@@ -67,6 +74,18 @@ func newMetrics(register prometheus.Registerer) *metrics {
 		scheduleDurationMilliSeconds: metricsutils.NewHistogram(config.SubsystemSupernode, "schedule_duration_milliseconds",
 			"Duration for task scheduling in milliseconds", []string{"peer"},
 			prometheus.ExponentialBuckets(0.02, 2, 6), register),
+
+		cdnDownloads: metricsutils.NewGauge(config.SubsystemSupernode, "cdn_downloads",
+			"Current number of the queued and running cdn downloads", []string{"state"}, register),
+
+		replicasCount: metricsutils.NewCounter(config.SubsystemSupernode, "task_replicas_total",
+			"Total times of replicating the hot tasks to the sibling supernodes by result", []string{"result"}, register),
+
+		namespaceTasks: metricsutils.NewGauge(config.SubsystemSupernode, "namespace_tasks",
+			"Current number of tasks by namespace", []string{"namespace"}, register),
+
+		namespaceRejectedCount: metricsutils.NewCounter(config.SubsystemSupernode, "namespace_rejected_tasks_total",
+			"Total times of rejecting the new tasks because the namespace reaches its maxTasks", []string{"namespace"}, register),
 	}
 }
 
@@ -75,25 +94,46 @@ type Manager struct {
 	cfg          *config.Config
 	metrics      *metrics
 	originClient httpclient.OriginHTTPClient
+	// originPolicy rejects the registrations of the tasks whose origins are not allowed.
+	originPolicy *httpclient.OriginPolicy
 
 	// store object
 	taskStore               *dutil.Store
 	accessTimeMap           *syncmap.SyncMap
 	taskURLUnReachableStore *syncmap.SyncMap
 
+	// cdnDownloads maintains the queued and in-flight CDN downloads.
+	// key:taskID string, value:*cdnDownload
+	cdnDownloads *syncmap.SyncMap
+	cdnQueue     *cdnQueue
+
+	// hotTasks detects the hot tasks which should be replicated to the sibling supernodes.
+	hotTasks *hotTaskDetector
+	// replicaPeers maps the siblings which hold the replicas to their peerIDs.
+	replicaPeers *syncmap.SyncMap
+
+	// namespaces counts the tasks of each namespace.
+	namespaces *namespaceCounter
+
 	// mgr object
 	peerMgr      mgr.PeerMgr
 	dfgetTaskMgr mgr.DfgetTaskMgr
 	progressMgr  mgr.ProgressMgr
 	cdnMgr       mgr.CDNMgr
 	schedulerMgr mgr.SchedulerMgr
+	webhookMgr   mgr.WebhookMgr
 }
 
 // NewManager returns a new Manager Object.
 func NewManager(cfg *config.Config, peerMgr mgr.PeerMgr, dfgetTaskMgr mgr.DfgetTaskMgr,
-	progressMgr mgr.ProgressMgr, cdnMgr mgr.CDNMgr, schedulerMgr mgr.SchedulerMgr,
+	progressMgr mgr.ProgressMgr, cdnMgr mgr.CDNMgr, schedulerMgr mgr.SchedulerMgr, webhookMgr mgr.WebhookMgr,
 	originClient httpclient.OriginHTTPClient, register prometheus.Registerer) (*Manager, error) {
-	return &Manager{
+	originPolicy, err := httpclient.NewOriginPolicy(cfg.OriginAllow, cfg.OriginDeny)
+	if err != nil {
+		return nil, err
+	}
+
+	tm := &Manager{
 		cfg:                     cfg,
 		taskStore:               dutil.NewStore(),
 		peerMgr:                 peerMgr,
@@ -101,11 +141,19 @@ func NewManager(cfg *config.Config, peerMgr mgr.PeerMgr, dfgetTaskMgr mgr.DfgetT
 		progressMgr:             progressMgr,
 		cdnMgr:                  cdnMgr,
 		schedulerMgr:            schedulerMgr,
+		webhookMgr:              webhookMgr,
 		accessTimeMap:           syncmap.NewSyncMap(),
 		taskURLUnReachableStore: syncmap.NewSyncMap(),
+		cdnDownloads:            syncmap.NewSyncMap(),
+		hotTasks:                newHotTaskDetector(cfg.HotTaskThreshold, hotTaskWindow),
+		replicaPeers:            syncmap.NewSyncMap(),
 		originClient:            originClient,
+		originPolicy:            originPolicy,
 		metrics:                 newMetrics(register),
-	}, nil
+	}
+	tm.cdnQueue = newCDNQueue(cfg, tm.startCDN, tm.metrics.cdnDownloads)
+	tm.namespaces = newNamespaceCounter(tm.metrics.namespaceTasks, tm.metrics.namespaceRejectedCount)
+	return tm, nil
 }
 
 // Register will not only register a task.
@@ -114,9 +162,12 @@ func (tm *Manager) Register(ctx context.Context, req *types.TaskCreateRequest) (
 	if err := validateParams(req); err != nil {
 		return nil, err
 	}
+	if err := tm.originPolicy.Check(req.RawURL); err != nil {
+		return nil, err
+	}
 
 	// Step2: add a new Task or update the exist task
-	failAccessInterval := tm.cfg.FailAccessInterval
+	failAccessInterval := tm.cfg.GetFailAccessInterval()
 	task, err := tm.addOrUpdateTask(ctx, req, failAccessInterval)
 	if err != nil {
 		logrus.Infof("failed to add or update task with req %+v: %v", req, err)
@@ -158,10 +209,51 @@ func (tm *Manager) Register(ctx context.Context, req *types.TaskCreateRequest) (
 	logrus.Debugf("success to init progress for taskID: %s peerID: %s cID: %s", task.ID, req.PeerID, req.CID)
 	// TODO: defer rollback init Progress
 
+	// share the upload slots of supernode according to the priority of task.
+	if err := tm.progressMgr.SetSuperLoadWeight(ctx, task.ID, int32(mgr.GetPriorityWeight(task.Priority))); err != nil {
+		logrus.Warnf("failed to set superLoad weight for taskID(%s): %v", task.ID, err)
+	}
+
 	// Step5: trigger CDN
 	if err := tm.triggerCdnSyncAction(ctx, task); err != nil {
 		return nil, errors.Wrapf(errortypes.ErrSystemError, "failed to trigger cdn: %v", err)
 	}
+	tm.recordDemand(task)
+
+	return &types.TaskCreateResponse{
+		ID:         task.ID,
+		FileLength: task.HTTPFileLength,
+		PieceSize:  task.PieceSize,
+	}, nil
+}
+
+// Replicate creates a task from the request of a sibling supernode and triggers
+// its CDN download without any client, so that the sibling can advertise this
+// supernode as a replica of the task when it's completed.
+func (tm *Manager) Replicate(ctx context.Context, req *types.TaskCreateRequest) (*types.TaskCreateResponse, error) {
+	if !netutils.IsValidURL(req.RawURL) {
+		return nil, errors.Wrapf(errortypes.ErrInvalidValue, "raw url: %s", req.RawURL)
+	}
+	if err := tm.originPolicy.Check(req.RawURL); err != nil {
+		return nil, err
+	}
+
+	task, err := tm.addOrUpdateTask(ctx, req, tm.cfg.GetFailAccessInterval())
+	if err != nil {
+		return nil, err
+	}
+
+	util.GetLock(task.ID, true)
+	defer util.ReleaseLock(task.ID, true)
+
+	if err := tm.accessTimeMap.Add(task.ID, time.Now()); err != nil {
+		logrus.Warnf("failed to update accessTime for taskID(%s): %v", task.ID, err)
+	}
+
+	if err := tm.triggerCdnSyncAction(ctx, task); err != nil {
+		return nil, errors.Wrapf(errortypes.ErrSystemError, "failed to trigger cdn: %v", err)
+	}
+	logrus.Infof("success to trigger the replica of taskID(%s)", task.ID)
 
 	return &types.TaskCreateResponse{
 		ID:         task.ID,
@@ -181,9 +273,28 @@ func (tm *Manager) GetAccessTime(ctx context.Context) (*syncmap.SyncMap, error)
 }
 
 // List returns a list of tasks with filter.
-// TODO: implement it.
+// The tasks are the copies which are read under the locks of the tasks,
+// so that they won't be changed while the caller reads them.
 func (tm *Manager) List(ctx context.Context, filter map[string]string) ([]*types.TaskInfo, error) {
-	return nil, nil
+	cdnStatus := filter["cdnStatus"]
+
+	var tasks []*types.TaskInfo
+	for _, v := range tm.taskStore.List() {
+		task, ok := v.(*types.TaskInfo)
+		if !ok {
+			return nil, errors.Wrapf(errortypes.ErrConvertFailed, "value: %v", v)
+		}
+
+		util.GetLock(task.ID, true)
+		copied := *task
+		util.ReleaseLock(task.ID, true)
+
+		if cdnStatus != "" && copied.CdnStatus != cdnStatus {
+			continue
+		}
+		tasks = append(tasks, &copied)
+	}
+	return tasks, nil
 }
 
 // CheckTaskStatus checks the task status.
@@ -206,9 +317,13 @@ func (tm *Manager) CheckTaskStatus(ctx context.Context, taskID string) (bool, er
 
 // Delete deletes a task.
 func (tm *Manager) Delete(ctx context.Context, taskID string) error {
+	if task, err := tm.getTask(taskID); err == nil {
+		tm.namespaces.release(task.Namespace)
+	}
 	tm.accessTimeMap.Delete(taskID)
 	tm.taskURLUnReachableStore.Delete(taskID)
 	tm.taskStore.Delete(taskID)
+	tm.hotTasks.remove(taskID)
 	return nil
 }
 
@@ -295,6 +410,121 @@ func (tm *Manager) UpdatePieceStatus(ctx context.Context, taskID, pieceRange str
 		return errors.Wrapf(errortypes.ErrInvalidValue, "result: %s", pieceUpdateRequest.PieceStatus)
 	}
 
+	// update the throughput of the uploader with the cost of the piece downloaded successfully,
+	// and it will be used by scheduler to prefer the faster peers.
+	if pieceStatus == config.PieceSUCCESS && pieceUpdateRequest.Cost > 0 &&
+		!tm.cfg.IsSuperPID(pieceUpdateRequest.DstPID) {
+		if err := tm.progressMgr.UpdatePeerThroughput(ctx, pieceUpdateRequest.DstPID, util.CalculatePieceSize(pieceRange),
+			time.Duration(pieceUpdateRequest.Cost)*time.Millisecond); err != nil {
+			logrus.Warnf("failed to update throughput of peer(%s) taskID(%s): %v", pieceUpdateRequest.DstPID, taskID, err)
+		}
+	}
+
 	return tm.progressMgr.UpdateProgress(ctx, taskID, pieceUpdateRequest.ClientID,
 		srcDfgetTask.PeerID, pieceUpdateRequest.DstPID, pieceNum, pieceStatus)
 }
+
+// GetPieceHolders gets the peers which hold or are downloading each piece of the task
+// and the piece bitmaps of each client.
+func (tm *Manager) GetPieceHolders(ctx context.Context, taskID, encoding string) (*types.TaskPieceHolders, error) {
+	task, err := tm.getTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	pieceTotal := int(task.PieceTotal)
+	if pieceTotal < 0 {
+		pieceTotal = 0
+	}
+	if stringutils.IsEmptyStr(encoding) {
+		encoding = types.TaskPieceHoldersEncodingBitmap
+		if pieceTotal > rlePieceThreshold {
+			encoding = types.TaskPieceHoldersEncodingRle
+		}
+	}
+	if encoding != types.TaskPieceHoldersEncodingBitmap && encoding != types.TaskPieceHoldersEncodingRle {
+		return nil, errors.Wrapf(errortypes.ErrInvalidValue, "encoding: %s", encoding)
+	}
+
+	clients, downloaders, err := tm.getClientPieceBitmaps(ctx, taskID, pieceTotal, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	pieces := make([]*types.PieceHolders, 0)
+	for i := 0; i < pieceTotal; i++ {
+		holders, err := tm.progressMgr.GetPeerIDsByPieceNum(ctx, taskID, i)
+		if err != nil && !errortypes.IsDataNotFound(err) {
+			return nil, err
+		}
+		pieces = appendPieceHolders(pieces, i, holders, downloaders[i], encoding == types.TaskPieceHoldersEncodingRle)
+	}
+
+	return &types.TaskPieceHolders{
+		TaskID:     taskID,
+		PieceTotal: int32(pieceTotal),
+		Encoding:   encoding,
+		Pieces:     pieces,
+		Clients:    clients,
+	}, nil
+}
+
+// CancelCDN cancels the queued or in-flight CDN download of the task and waits for
+// the partial files to be cleaned up.
+func (tm *Manager) CancelCDN(ctx context.Context, taskID string) error {
+	v, err := tm.cdnDownloads.Get(taskID)
+	if err != nil {
+		return errors.Wrapf(err, "no cdn download in flight for taskID(%s)", taskID)
+	}
+
+	// the queued download is finished directly without being started.
+	if job := tm.cdnQueue.remove(taskID); job != nil {
+		job.download.cancel()
+		tm.finishCDN(job, nil)
+	}
+	return v.(*cdnDownload).cancelAndWait(ctx)
+}
+
+// CancelAllCDN cancels all the queued and in-flight CDN downloads and waits for
+// the partial files to be cleaned up.
+func (tm *Manager) CancelAllCDN(ctx context.Context) error {
+	for _, job := range tm.cdnQueue.removeAll() {
+		job.download.cancel()
+		tm.finishCDN(job, nil)
+	}
+
+	var downloads []*cdnDownload
+	tm.cdnDownloads.Range(func(key, value interface{}) bool {
+		downloads = append(downloads, value.(*cdnDownload))
+		return true
+	})
+
+	for _, d := range downloads {
+		d.cancel()
+	}
+	for _, d := range downloads {
+		if err := d.cancelAndWait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckHealth checks the depth of the CDN download queue,
+// and it's degraded if the pending downloads are more than the workers.
+func (tm *Manager) CheckHealth(ctx context.Context) *types.ComponentHealth {
+	running, pending := tm.cdnQueue.depth()
+	health := &types.ComponentHealth{
+		Name:   "cdnQueue",
+		Status: types.ComponentHealthStatusHealthy,
+		Details: map[string]string{
+			"running": strconv.Itoa(running),
+			"pending": strconv.Itoa(pending),
+		},
+	}
+	if tm.cfg.CDNWorkerLimit > 0 && pending >= tm.cfg.CDNWorkerLimit {
+		health.Status = types.ComponentHealthStatusDegraded
+		health.Message = fmt.Sprintf("%d CDN downloads are queued for %d workers", pending, tm.cfg.CDNWorkerLimit)
+	}
+	return health
+}
@@ -18,7 +18,9 @@ package task
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
@@ -48,6 +50,7 @@ type TaskMgrTestSuite struct {
 	mockPeerMgr      *mock.MockPeerMgr
 	mockProgressMgr  *mock.MockProgressMgr
 	mockSchedulerMgr *mock.MockSchedulerMgr
+	mockWebhookMgr   *mock.MockWebhookMgr
 	mockOriginClient *cMock.MockOriginHTTPClient
 
 	taskManager *Manager
@@ -61,15 +64,18 @@ func (s *TaskMgrTestSuite) SetUpSuite(c *check.C) {
 	s.mockDfgetTaskMgr = mock.NewMockDfgetTaskMgr(s.mockCtl)
 	s.mockProgressMgr = mock.NewMockProgressMgr(s.mockCtl)
 	s.mockSchedulerMgr = mock.NewMockSchedulerMgr(s.mockCtl)
+	s.mockWebhookMgr = mock.NewMockWebhookMgr(s.mockCtl)
 	s.mockOriginClient = cMock.NewMockOriginHTTPClient(s.mockCtl)
 
 	s.mockCDNMgr.EXPECT().TriggerCDN(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
 	s.mockDfgetTaskMgr.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	s.mockProgressMgr.EXPECT().InitProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	s.mockProgressMgr.EXPECT().SetSuperLoadWeight(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	s.mockWebhookMgr.EXPECT().Notify(gomock.Any(), gomock.Any()).AnyTimes()
 	s.mockOriginClient.EXPECT().GetContentLength(gomock.Any(), gomock.Any()).Return(int64(1000), 200, nil)
 	cfg := config.NewConfig()
 	s.taskManager, _ = NewManager(cfg, s.mockPeerMgr, s.mockDfgetTaskMgr,
-		s.mockProgressMgr, s.mockCDNMgr, s.mockSchedulerMgr, s.mockOriginClient, prometheus.NewRegistry())
+		s.mockProgressMgr, s.mockCDNMgr, s.mockSchedulerMgr, s.mockWebhookMgr, s.mockOriginClient, prometheus.NewRegistry())
 }
 
 func (s *TaskMgrTestSuite) TearDownSuite(c *check.C) {
@@ -166,3 +172,140 @@ func (s *TaskMgrTestSuite) TestUpdateTaskInfo(c *check.C) {
 	c.Check(task.CdnStatus, check.Equals, types.TaskInfoCdnStatusSUCCESS)
 	c.Check(task.FileLength, check.Equals, int64(2000))
 }
+
+func (s *TaskMgrTestSuite) TestListTasks(c *check.C) {
+	s.taskManager.taskStore = dutil.NewStore()
+	for _, task := range []*types.TaskInfo{
+		{ID: "foo", CdnStatus: types.TaskInfoCdnStatusSUCCESS},
+		{ID: "bar", CdnStatus: types.TaskInfoCdnStatusRUNNING},
+		{ID: "baz", CdnStatus: types.TaskInfoCdnStatusSUCCESS},
+	} {
+		s.taskManager.taskStore.Put(task.ID, task)
+	}
+
+	tasks, err := s.taskManager.List(context.Background(), nil)
+	c.Check(err, check.IsNil)
+	c.Check(tasks, check.HasLen, 3)
+
+	tasks, err = s.taskManager.List(context.Background(), map[string]string{"cdnStatus": types.TaskInfoCdnStatusSUCCESS})
+	c.Check(err, check.IsNil)
+	c.Check(tasks, check.HasLen, 2)
+	for _, task := range tasks {
+		c.Check(task.CdnStatus, check.Equals, types.TaskInfoCdnStatusSUCCESS)
+	}
+}
+
+func (s *TaskMgrTestSuite) TestRegisterWithNamespace(c *check.C) {
+	cfg := config.NewConfig()
+	cfg.Namespaces = []*config.NamespaceConfig{{Name: "datasets", MaxTasks: 1}}
+	tm, err := NewManager(cfg, s.mockPeerMgr, s.mockDfgetTaskMgr, s.mockProgressMgr, s.mockCDNMgr,
+		s.mockSchedulerMgr, s.mockWebhookMgr, s.mockOriginClient, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+	s.mockOriginClient.EXPECT().GetContentLength(gomock.Any(), gomock.Any()).Return(int64(1000), 200, nil).Times(3)
+
+	newRequest := func(rawURL, namespace string) *types.TaskCreateRequest {
+		return &types.TaskCreateRequest{
+			CID:       "cid",
+			Path:      "/peer/file/foo",
+			RawURL:    rawURL,
+			PeerID:    "fooPeerID",
+			Namespace: namespace,
+		}
+	}
+
+	resp, err := tm.Register(context.Background(), newRequest("http://aa.bb.com/1", "datasets"))
+	c.Assert(err, check.IsNil)
+	task, err := tm.Get(context.Background(), resp.ID)
+	c.Assert(err, check.IsNil)
+	c.Check(task.Namespace, check.Equals, "datasets")
+
+	// the new task beyond the maxTasks of the namespace is rejected,
+	// but the registrations of the existing task are accepted.
+	_, err = tm.Register(context.Background(), newRequest("http://aa.bb.com/2", "datasets"))
+	c.Check(errortypes.IsNamespaceQuotaExceeded(err), check.Equals, true)
+	_, err = tm.Register(context.Background(), newRequest("http://aa.bb.com/1", "datasets"))
+	c.Check(err, check.IsNil)
+	c.Check(int(prom_testutil.ToFloat64(tm.metrics.namespaceRejectedCount.WithLabelValues("datasets"))), check.Equals, 1)
+
+	// the task of the namespace which is not configured belongs to the default namespace.
+	resp2, err := tm.Register(context.Background(), newRequest("http://aa.bb.com/2", "unknown"))
+	c.Assert(err, check.IsNil)
+	task, err = tm.Get(context.Background(), resp2.ID)
+	c.Assert(err, check.IsNil)
+	c.Check(task.Namespace, check.Equals, config.DefaultNamespace)
+
+	c.Assert(tm.Delete(context.Background(), resp.ID), check.IsNil)
+	_, err = tm.Register(context.Background(), newRequest("http://aa.bb.com/3", "datasets"))
+	c.Check(err, check.IsNil)
+	c.Check(int(prom_testutil.ToFloat64(tm.metrics.namespaceTasks.WithLabelValues("datasets"))), check.Equals, 1)
+}
+
+func (s *TaskMgrTestSuite) TestRegisterWithOriginPolicy(c *check.C) {
+	cfg := config.NewConfig()
+	cfg.OriginAllow = &config.OriginRule{Hosts: []string{"*.bb.com", "169.254.169.254"}}
+	cfg.OriginDeny = &config.OriginRule{CIDRs: []string{"169.254.0.0/16"}}
+	tm, err := NewManager(cfg, s.mockPeerMgr, s.mockDfgetTaskMgr, s.mockProgressMgr, s.mockCDNMgr,
+		s.mockSchedulerMgr, s.mockWebhookMgr, s.mockOriginClient, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+
+	// the origins which are not allowed are rejected before requesting them.
+	for _, rawURL := range []string{"http://aa.cc.com/foo", "http://169.254.169.254/latest/meta-data"} {
+		_, err = tm.Register(context.Background(), &types.TaskCreateRequest{
+			CID:    "cid",
+			Path:   "/peer/file/foo",
+			RawURL: rawURL,
+			PeerID: "fooPeerID",
+		})
+		c.Check(errortypes.IsInvalidValue(err), check.Equals, true)
+	}
+
+	cfg.OriginDeny = &config.OriginRule{CIDRs: []string{"169.254.0.0"}}
+	_, err = NewManager(cfg, s.mockPeerMgr, s.mockDfgetTaskMgr, s.mockProgressMgr, s.mockCDNMgr,
+		s.mockSchedulerMgr, s.mockWebhookMgr, s.mockOriginClient, prometheus.NewRegistry())
+	c.Check(errortypes.IsInvalidValue(err), check.Equals, true)
+}
+
+func (s *TaskMgrTestSuite) TestAddTaskConcurrentlyWithNamespace(c *check.C) {
+	cfg := config.NewConfig()
+	cfg.Namespaces = []*config.NamespaceConfig{{Name: "datasets", MaxTasks: 1}}
+	mockCtl := gomock.NewController(c)
+	defer mockCtl.Finish()
+	mockOriginClient := cMock.NewMockOriginHTTPClient(mockCtl)
+	tm, err := NewManager(cfg, s.mockPeerMgr, s.mockDfgetTaskMgr, s.mockProgressMgr, s.mockCDNMgr,
+		s.mockSchedulerMgr, s.mockWebhookMgr, mockOriginClient, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+	// the slow origin lets the concurrent registrations overlap.
+	mockOriginClient.EXPECT().GetContentLength(gomock.Any(), gomock.Any()).Do(func(string, map[string]string) {
+		time.Sleep(20 * time.Millisecond)
+	}).Return(int64(1000), 200, nil).Times(2)
+
+	newRequest := func(rawURL string) *types.TaskCreateRequest {
+		return &types.TaskCreateRequest{
+			CID:       "cid",
+			Path:      "/peer/file/foo",
+			RawURL:    rawURL,
+			PeerID:    "fooPeerID",
+			Namespace: "datasets",
+		}
+	}
+
+	// the burst of the first registrations of a task counts it in the namespace only once.
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = tm.addOrUpdateTask(context.Background(), newRequest("http://aa.bb.com/1"), cfg.FailAccessInterval)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		c.Assert(err, check.IsNil)
+	}
+	c.Check(int(prom_testutil.ToFloat64(tm.metrics.namespaceTasks.WithLabelValues("datasets"))), check.Equals, 1)
+
+	c.Assert(tm.Delete(context.Background(), generateTaskID("http://aa.bb.com/1", "", "")), check.IsNil)
+	_, err = tm.addOrUpdateTask(context.Background(), newRequest("http://aa.bb.com/2"), cfg.FailAccessInterval)
+	c.Check(err, check.IsNil)
+}
@@ -20,6 +20,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
@@ -36,16 +39,19 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// rlePieceThreshold is the number of pieces above which the piece holders
+// are run-length encoded by default.
+const rlePieceThreshold = 256
+
 // addOrUpdateTask adds a new task or update the exist task to taskStore.
 func (tm *Manager) addOrUpdateTask(ctx context.Context, req *types.TaskCreateRequest, failAccessInterval time.Duration) (*types.TaskInfo, error) {
-	taskURL := req.TaskURL
-	if stringutils.IsEmptyStr(req.TaskURL) {
-		taskURL = netutils.FilterURLParam(req.RawURL, req.Filter)
-	}
+	taskURL := getTaskURL(req)
 	taskID := generateTaskID(taskURL, req.Md5, req.Identifier)
 
-	util.GetLock(taskID, true)
-	defer util.ReleaseLock(taskID, true)
+	// the write lock makes the creation of the task exclusive, otherwise the concurrent
+	// first registrations of the task all count it in the namespace and overwrite each other.
+	util.GetLock(taskID, false)
+	defer util.ReleaseLock(taskID, false)
 
 	if key, err := tm.taskURLUnReachableStore.Get(taskID); err == nil {
 		if unReachableStartTime, ok := key.(time.Time); ok &&
@@ -56,6 +62,14 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, req *types.TaskCreateReq
 		tm.taskURLUnReachableStore.Delete(taskID)
 	}
 
+	// the task whose namespace is not configured belongs to the default namespace.
+	namespace := req.Namespace
+	nsConfig := tm.cfg.GetNamespaceConfig(namespace)
+	if nsConfig == nil {
+		namespace = config.DefaultNamespace
+		nsConfig = tm.cfg.GetNamespaceConfig(namespace)
+	}
+
 	// using the existing task if it already exists corresponding to taskID
 	var task *types.TaskInfo
 	newTask := &types.TaskInfo{
@@ -67,6 +81,11 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, req *types.TaskCreateReq
 		TaskURL:    taskURL,
 		CdnStatus:  types.TaskInfoCdnStatusWAITING,
 		PieceTotal: -1,
+		Priority:   req.Priority,
+		Namespace:  namespace,
+	}
+	if stringutils.IsEmptyStr(newTask.Priority) {
+		newTask.Priority = types.TaskInfoPriorityNormal
 	}
 
 	if v, err := tm.taskStore.Get(taskID); err == nil {
@@ -74,7 +93,25 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, req *types.TaskCreateReq
 		if !equalsTask(task, newTask) {
 			return nil, errors.Wrapf(errortypes.ErrTaskIDDuplicate, "%s", taskID)
 		}
+
+		// the task shared by multiple requests takes the highest priority of them.
+		// NOTE: the upgraded priority applies to the upload slots of supernode, the CDN download
+		// which is queued and the ones triggered later, but not to the CDN download which is running,
+		// because the weight is captured by its priorityReader when the download starts.
+		if mgr.GetPriorityWeight(newTask.Priority) > mgr.GetPriorityWeight(task.Priority) {
+			logrus.Infof("upgrade the priority of taskID(%s) from %s to %s", taskID, task.Priority, newTask.Priority)
+			task.Priority = newTask.Priority
+		}
 	} else {
+		// the task is accounted to the namespace which registers it first.
+		var maxTasks int
+		if nsConfig != nil {
+			maxTasks = nsConfig.MaxTasks
+		}
+		if !tm.namespaces.acquire(namespace, maxTasks) {
+			return nil, errors.Wrapf(errortypes.ErrNamespaceQuotaExceeded,
+				"namespace %s has reached the maxTasks(%d)", namespace, maxTasks)
+		}
 		task = newTask
 	}
 
@@ -89,9 +126,11 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, req *types.TaskCreateReq
 
 		if errortypes.IsURLNotReachable(err) {
 			tm.taskURLUnReachableStore.Add(taskID, time.Now())
+			tm.releaseNewTask(task, newTask)
 			return nil, err
 		}
 		if errortypes.IsAuthenticationRequired(err) {
+			tm.releaseNewTask(task, newTask)
 			return nil, err
 		}
 	}
@@ -114,6 +153,13 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, req *types.TaskCreateReq
 	return task, nil
 }
 
+// releaseNewTask removes the task from its namespace if it's the new one which is not stored.
+func (tm *Manager) releaseNewTask(task, newTask *types.TaskInfo) {
+	if task == newTask {
+		tm.namespaces.release(task.Namespace)
+	}
+}
+
 // getTask returns the taskInfo according to the specified taskID.
 func (tm *Manager) getTask(taskID string) (*types.TaskInfo, error) {
 	if stringutils.IsEmptyStr(taskID) {
@@ -161,7 +207,10 @@ func (tm *Manager) updateTask(taskID string, updateTaskInfo *types.TaskInfo) err
 		// the origin CDNStatus both not equals success
 		tm.metrics.tasks.WithLabelValues(task.CdnStatus).Dec()
 		tm.metrics.tasks.WithLabelValues(updateTaskInfo.CdnStatus).Inc()
-		task.CdnStatus = updateTaskInfo.CdnStatus
+		if task.CdnStatus != updateTaskInfo.CdnStatus {
+			task.CdnStatus = updateTaskInfo.CdnStatus
+			tm.notifyCDNStatus(task)
+		}
 		return nil
 	}
 
@@ -185,10 +234,31 @@ func (tm *Manager) updateTask(taskID string, updateTaskInfo *types.TaskInfo) err
 	tm.metrics.tasks.WithLabelValues(task.CdnStatus).Dec()
 	tm.metrics.tasks.WithLabelValues(updateTaskInfo.CdnStatus).Inc()
 	task.CdnStatus = updateTaskInfo.CdnStatus
+	tm.notifyCDNStatus(task)
 
 	return nil
 }
 
+// notifyCDNStatus notifies the webhooks when the CDN download of the task succeeds or fails.
+func (tm *Manager) notifyCDNStatus(task *types.TaskInfo) {
+	var event string
+	switch task.CdnStatus {
+	case types.TaskInfoCdnStatusSUCCESS:
+		event = types.WebhookEventEventCDNSUCCESS
+	case types.TaskInfoCdnStatusFAILED, types.TaskInfoCdnStatusSOURCEERROR:
+		event = types.WebhookEventEventCDNFAILED
+	default:
+		return
+	}
+
+	tm.webhookMgr.Notify(context.Background(), &types.WebhookEvent{
+		Event:  event,
+		TaskID: task.ID,
+		Status: task.CdnStatus,
+		URL:    task.RawURL,
+	})
+}
+
 func (tm *Manager) addDfgetTask(ctx context.Context, req *types.TaskCreateRequest, task *types.TaskInfo) (*types.DfGetTask, error) {
 	dfgetTask := &types.DfGetTask{
 		CID:         req.CID,
@@ -210,6 +280,14 @@ func (tm *Manager) addDfgetTask(ctx context.Context, req *types.TaskCreateReques
 }
 
 func (tm *Manager) triggerCdnSyncAction(ctx context.Context, task *types.TaskInfo) error {
+	// the CDN download of the task has been queued or is running.
+	if _, err := tm.cdnDownloads.Get(task.ID); err == nil {
+		tm.cdnQueue.addWaitingClient(task.ID)
+		tm.cdnQueue.upgrade(task.ID, mgr.GetPriorityWeight(task.Priority))
+		logrus.Infof("CDN(%s) is queued or running for taskID: %s", task.CdnStatus, task.ID)
+		return nil
+	}
+
 	if !isFrozen(task.CdnStatus) {
 		logrus.Infof("CDN(%s) is running or has been downloaded successfully for taskID: %s", task.CdnStatus, task.ID)
 		return nil
@@ -223,24 +301,67 @@ func (tm *Manager) triggerCdnSyncAction(ctx context.Context, task *types.TaskInf
 		logrus.Infof("success to init cdn node or taskID %s", task.ID)
 	}
 
+	// the clients will wait until the CDN download is admitted by the queue.
 	if err := tm.updateTask(task.ID, &types.TaskInfo{
-		CdnStatus: types.TaskInfoCdnStatusRUNNING,
+		CdnStatus: types.TaskInfoCdnStatusWAITING,
 	}); err != nil {
 		return err
 	}
 
+	// NOTE: the ctx can't be used by the CDN download, because it will be done
+	// once the request of registering the task is finished.
+	download := newCDNDownload()
+	tm.cdnDownloads.Add(task.ID, download)
+	tm.cdnQueue.push(newCDNJob(task, download))
+	logrus.Infof("success to queue cdn trigger for taskID: %s", task.ID)
+	return nil
+}
+
+// startCDN starts the CDN download of the job admitted by the cdnQueue.
+//
+// NOTE: it may be called with the lock of the task held by triggerCdnSyncAction,
+// so the task is updated with its lock in the new goroutine.
+func (tm *Manager) startCDN(job *cdnJob) {
+	task := job.task
 	go func() {
-		updateTaskInfo, err := tm.cdnMgr.TriggerCDN(ctx, task)
+		defer tm.cdnQueue.done(job)
+
+		util.GetLock(task.ID, false)
+		if err := tm.updateTask(task.ID, &types.TaskInfo{
+			CdnStatus: types.TaskInfoCdnStatusRUNNING,
+		}); err != nil {
+			logrus.Warnf("failed to update cdn status to running for taskID(%s): %v", task.ID, err)
+		}
+		util.ReleaseLock(task.ID, false)
+
+		updateTaskInfo, err := tm.cdnMgr.TriggerCDN(job.download.ctx, task)
 		tm.metrics.triggerCdnCount.WithLabelValues().Inc()
 		if err != nil {
 			tm.metrics.triggerCdnFailCount.WithLabelValues().Inc()
 			logrus.Errorf("taskID(%s) trigger cdn get error: %v", task.ID, err)
 		}
-		tm.updateTask(task.ID, updateTaskInfo)
-		logrus.Infof("success to update task cdn %+v", updateTaskInfo)
+		tm.finishCDN(job, updateTaskInfo)
 	}()
 	logrus.Infof("success to start cdn trigger for taskID: %s", task.ID)
-	return nil
+}
+
+// finishCDN releases the CDN download of the job and updates the task with updateTaskInfo.
+// The task will be FAILED if the CDN download has been canceled.
+func (tm *Manager) finishCDN(job *cdnJob, updateTaskInfo *types.TaskInfo) {
+	task := job.task
+	// remove the download before updating the task status,
+	// so that it won't remove the next download of the task.
+	tm.cdnDownloads.Remove(task.ID)
+	close(job.download.done)
+
+	if job.download.ctx.Err() != nil {
+		logrus.Infof("cdn download of taskID(%s) has been canceled", task.ID)
+		updateTaskInfo = &types.TaskInfo{CdnStatus: types.TaskInfoCdnStatusFAILED}
+	}
+	util.GetLock(task.ID, false)
+	tm.updateTask(task.ID, updateTaskInfo)
+	util.ReleaseLock(task.ID, false)
+	logrus.Infof("success to update task cdn %+v", updateTaskInfo)
 }
 
 func (tm *Manager) initCdnNode(ctx context.Context, task *types.TaskInfo) error {
@@ -327,6 +448,10 @@ func (tm *Manager) parseAvailablePeers(ctx context.Context, clientID string, tas
 			logrus.Errorf("failed to update dfget task status with "+
 				"taskID(%s) clientID(%s) status(%s): %v", task.ID, clientID, types.DfGetTaskStatusSUCCESS, err)
 		}
+		// release the piece bitset of the finished client which is no longer needed.
+		if err := tm.progressMgr.CompactCID(ctx, clientID); err != nil {
+			logrus.Warnf("failed to compact progress of taskID(%s) clientID(%s): %v", task.ID, clientID, err)
+		}
 		finishInfo := make(map[string]interface{})
 		finishInfo["md5"] = task.RealMd5
 		finishInfo["fileLength"] = task.FileLength
@@ -338,7 +463,7 @@ func (tm *Manager) parseAvailablePeers(ctx context.Context, clientID string, tas
 	// get scheduler pieceResult
 	logrus.Debugf("start scheduler for taskID: %s clientID: %s", task.ID, clientID)
 	startTime := time.Now()
-	pieceResult, err := tm.schedulerMgr.Schedule(ctx, task.ID, clientID, dfgetTask.PeerID)
+	pieceResult, err := tm.schedulerMgr.Schedule(ctx, task.ID, clientID, dfgetTask.PeerID, int(task.PieceTotal))
 	if err != nil {
 		return false, nil, err
 	}
@@ -346,6 +471,8 @@ func (tm *Manager) parseAvailablePeers(ctx context.Context, clientID string, tas
 	logrus.Debugf("get scheduler result length(%d) with taskID(%s) and clientID(%s)", len(pieceResult), task.ID, clientID)
 
 	if len(pieceResult) == 0 {
+		// the client waits for the upload slots which are all busy.
+		tm.recordDemand(task)
 		return false, nil, errortypes.ErrPeerWait
 	}
 	var pieceInfos []*types.PieceInfo
@@ -475,9 +602,28 @@ func validateParams(req *types.TaskCreateRequest) error {
 		return errors.Wrapf(errortypes.ErrEmptyValue, "peerID")
 	}
 
+	if !stringutils.IsEmptyStr(req.Priority) {
+		if _, ok := mgr.PriorityWeightMap[req.Priority]; !ok {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "priority: %s", req.Priority)
+		}
+	}
+
 	return nil
 }
 
+// GenerateTaskID returns the taskID of the task which will be registered by the req.
+func GenerateTaskID(req *types.TaskCreateRequest) string {
+	return generateTaskID(getTaskURL(req), req.Md5, req.Identifier)
+}
+
+// getTaskURL returns the taskURL of the req, which is generated from the rawURL if it's empty.
+func getTaskURL(req *types.TaskCreateRequest) string {
+	if stringutils.IsEmptyStr(req.TaskURL) {
+		return netutils.FilterURLParam(req.RawURL, req.Filter)
+	}
+	return req.TaskURL
+}
+
 // generateTaskID generates taskID with taskURL,md5 and identifier
 // and returns the SHA-256 checksum of the data.
 func generateTaskID(taskURL, md5, identifier string) string {
@@ -543,3 +689,172 @@ func (tm *Manager) getHTTPFileLength(taskID, url string, headers map[string]stri
 
 	return fileLength, nil
 }
+
+// getClientPieceBitmaps gets the piece bitmaps of each client downloading the task,
+// and the peers which are downloading each piece.
+// The clients which have been garbage collected in the meantime are skipped.
+func (tm *Manager) getClientPieceBitmaps(ctx context.Context, taskID string, pieceTotal int, encoding string) ([]*types.ClientPieceBitmap, [][]string, error) {
+	peerIDs, err := tm.progressMgr.GetClientsByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientIDs := make([]string, 0, len(peerIDs))
+	for clientID := range peerIDs {
+		clientIDs = append(clientIDs, clientID)
+	}
+	sort.Strings(clientIDs)
+
+	clients := make([]*types.ClientPieceBitmap, 0, len(clientIDs))
+	downloaders := make([][]string, pieceTotal)
+	for _, clientID := range clientIDs {
+		peerID := peerIDs[clientID]
+		bitmaps, running, err := tm.getClientPieceBitmap(ctx, taskID, clientID, pieceTotal, encoding)
+		if errortypes.IsDataNotFound(err) {
+			logrus.Debugf("skip the client(%s) of taskID(%s) which has been gc: %v", clientID, taskID, err)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, pieceNum := range running {
+			if pieceNum >= 0 && pieceNum < pieceTotal {
+				downloaders[pieceNum] = append(downloaders[pieceNum], peerID)
+			}
+		}
+		clients = append(clients, &types.ClientPieceBitmap{
+			Cid:     clientID,
+			PeerID:  peerID,
+			Success: bitmaps[0],
+			Running: bitmaps[1],
+			Failed:  bitmaps[2],
+		})
+	}
+
+	return clients, downloaders, nil
+}
+
+// getClientPieceBitmap gets the success, running and failed piece bitmaps of the clientID,
+// and the running pieces of it.
+func (tm *Manager) getClientPieceBitmap(ctx context.Context, taskID, clientID string, pieceTotal int, encoding string) (bitmaps [3]string, running []int, err error) {
+	for i, pieceStatus := range []string{"success", "running", "failed"} {
+		pieceNums, err := tm.progressMgr.GetPieceProgressByCID(ctx, taskID, clientID, pieceStatus)
+		if err != nil {
+			return bitmaps, nil, err
+		}
+		bitmaps[i] = encodePieceBitmap(pieceNums, pieceTotal, encoding)
+		if pieceStatus == "running" {
+			running = pieceNums
+		}
+	}
+	return bitmaps, running, nil
+}
+
+// encodePieceBitmap encodes the pieceNums as a bitmap of pieceTotal pieces
+// with the encoding of bitmap or rle.
+func encodePieceBitmap(pieceNums []int, pieceTotal int, encoding string) string {
+	bitmap := make([]bool, pieceTotal)
+	for _, pieceNum := range pieceNums {
+		if pieceNum >= 0 && pieceNum < pieceTotal {
+			bitmap[pieceNum] = true
+		}
+	}
+
+	if encoding != types.TaskPieceHoldersEncodingRle {
+		var sb strings.Builder
+		for _, set := range bitmap {
+			if set {
+				sb.WriteByte('1')
+			} else {
+				sb.WriteByte('0')
+			}
+		}
+		return sb.String()
+	}
+
+	// the runs start with the unset pieces.
+	var runs []string
+	set, length := false, 0
+	for _, v := range bitmap {
+		if v != set {
+			runs = append(runs, strconv.Itoa(length))
+			set, length = v, 0
+		}
+		length++
+	}
+	if length > 0 {
+		runs = append(runs, strconv.Itoa(length))
+	}
+	return strings.Join(runs, ",")
+}
+
+// appendPieceHolders appends the holders and downloaders of the pieceNum to pieces.
+// And the piece will be merged into the last item if merge is true and they have the same peers.
+func appendPieceHolders(pieces []*types.PieceHolders, pieceNum int, holders, downloaders []string, merge bool) []*types.PieceHolders {
+	holders = sortedPeerIDs(holders)
+	downloaders = sortedPeerIDs(downloaders)
+
+	if merge && len(pieces) > 0 {
+		last := pieces[len(pieces)-1]
+		if int(last.EndPieceNum) == pieceNum-1 &&
+			equalsPeerIDs(last.Holders, holders) && equalsPeerIDs(last.Downloaders, downloaders) {
+			last.EndPieceNum = int32(pieceNum)
+			return pieces
+		}
+	}
+
+	return append(pieces, &types.PieceHolders{
+		StartPieceNum: int32(pieceNum),
+		EndPieceNum:   int32(pieceNum),
+		Holders:       holders,
+		Downloaders:   downloaders,
+	})
+}
+
+func sortedPeerIDs(peerIDs []string) []string {
+	result := make([]string, len(peerIDs))
+	copy(result, peerIDs)
+	sort.Strings(result)
+	return result
+}
+
+func equalsPeerIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cdnDownload is an in-flight CDN download which can be canceled.
+type cdnDownload struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// done will be closed once the CDN download exits.
+	done chan struct{}
+}
+
+func newCDNDownload() *cdnDownload {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &cdnDownload{
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// cancelAndWait cancels the CDN download and waits for it to exit until the ctx is done.
+func (d *cdnDownload) cancelAndWait(ctx context.Context) error {
+	d.cancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
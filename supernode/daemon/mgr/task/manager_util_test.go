@@ -18,8 +18,10 @@ package task
 
 import (
 	"context"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/mock"
 	cMock "github.com/dragonflyoss/Dragonfly/supernode/httpclient/mock"
@@ -41,6 +43,7 @@ type TaskUtilTestSuite struct {
 	mockPeerMgr      *mock.MockPeerMgr
 	mockProgressMgr  *mock.MockProgressMgr
 	mockSchedulerMgr *mock.MockSchedulerMgr
+	mockWebhookMgr   *mock.MockWebhookMgr
 	mockOriginClient *cMock.MockOriginHTTPClient
 
 	taskManager *Manager
@@ -54,9 +57,11 @@ func (s *TaskUtilTestSuite) SetUpSuite(c *check.C) {
 	s.mockDfgetTaskMgr = mock.NewMockDfgetTaskMgr(s.mockCtl)
 	s.mockProgressMgr = mock.NewMockProgressMgr(s.mockCtl)
 	s.mockSchedulerMgr = mock.NewMockSchedulerMgr(s.mockCtl)
+	s.mockWebhookMgr = mock.NewMockWebhookMgr(s.mockCtl)
+	s.mockWebhookMgr.EXPECT().Notify(gomock.Any(), gomock.Any()).AnyTimes()
 	s.mockOriginClient = cMock.NewMockOriginHTTPClient(s.mockCtl)
 	s.taskManager, _ = NewManager(config.NewConfig(), s.mockPeerMgr, s.mockDfgetTaskMgr,
-		s.mockProgressMgr, s.mockCDNMgr, s.mockSchedulerMgr, s.mockOriginClient, prometheus.NewRegistry())
+		s.mockProgressMgr, s.mockCDNMgr, s.mockSchedulerMgr, s.mockWebhookMgr, s.mockOriginClient, prometheus.NewRegistry())
 
 	s.mockOriginClient.EXPECT().GetContentLength(gomock.Any(), gomock.Any()).Return(int64(1000), 200, nil)
 }
@@ -128,6 +133,25 @@ func (s *TaskUtilTestSuite) TestEqualsTask(c *check.C) {
 	}
 }
 
+func (s *TaskUtilTestSuite) TestValidateParams(c *check.C) {
+	req := &types.TaskCreateRequest{
+		RawURL: "http://a.b.com/foo",
+		Path:   "/peer/file/foo",
+		CID:    "cid",
+		PeerID: "peerID",
+	}
+	c.Check(validateParams(req), check.IsNil)
+
+	for _, priority := range []string{types.TaskCreateRequestPriorityCritical,
+		types.TaskCreateRequestPriorityNormal, types.TaskCreateRequestPriorityBackground} {
+		req.Priority = priority
+		c.Check(validateParams(req), check.IsNil)
+	}
+
+	req.Priority = "urgent"
+	c.Check(errortypes.IsInvalidValue(validateParams(req)), check.Equals, true)
+}
+
 func (s *TaskUtilTestSuite) TestTriggerCdnSyncAction(c *check.C) {
 	var err error
 	totalCounter := s.taskManager.metrics.triggerCdnCount
@@ -181,3 +205,183 @@ func (s *TaskUtilTestSuite) TestTriggerCdnSyncAction(c *check.C) {
 		}
 	}
 }
+
+func (s *TaskUtilTestSuite) TestUpdateTaskNotifyWebhook(c *check.C) {
+	ctl := gomock.NewController(c)
+	defer ctl.Finish()
+	webhookMgr := mock.NewMockWebhookMgr(ctl)
+	tm, _ := NewManager(config.NewConfig(), nil, nil, nil, nil, nil, webhookMgr, nil, prometheus.NewRegistry())
+
+	success := &types.TaskInfo{ID: "success", RawURL: "http://a.b.com/success",
+		PieceSize: 4, CdnStatus: types.TaskInfoCdnStatusRUNNING}
+	failed := &types.TaskInfo{ID: "failed", RawURL: "http://a.b.com/failed",
+		PieceSize: 4, CdnStatus: types.TaskInfoCdnStatusRUNNING}
+	tm.taskStore.Put(success.ID, success)
+	tm.taskStore.Put(failed.ID, failed)
+
+	webhookMgr.EXPECT().Notify(gomock.Any(), &types.WebhookEvent{
+		Event:  types.WebhookEventEventCDNSUCCESS,
+		TaskID: success.ID,
+		Status: types.TaskInfoCdnStatusSUCCESS,
+		URL:    success.RawURL,
+	}).Times(1)
+	webhookMgr.EXPECT().Notify(gomock.Any(), &types.WebhookEvent{
+		Event:  types.WebhookEventEventCDNFAILED,
+		TaskID: failed.ID,
+		Status: types.TaskInfoCdnStatusSOURCEERROR,
+		URL:    failed.RawURL,
+	}).Times(1)
+
+	c.Assert(tm.updateTask(success.ID, &types.TaskInfo{
+		CdnStatus:  types.TaskInfoCdnStatusSUCCESS,
+		FileLength: 8,
+	}), check.IsNil)
+	// the successful task will not be updated to failed
+	c.Assert(tm.updateTask(success.ID, &types.TaskInfo{CdnStatus: types.TaskInfoCdnStatusFAILED}), check.IsNil)
+
+	c.Assert(tm.updateTask(failed.ID, &types.TaskInfo{CdnStatus: types.TaskInfoCdnStatusSOURCEERROR}), check.IsNil)
+	// the webhooks are only notified when the status changes
+	c.Assert(tm.updateTask(failed.ID, &types.TaskInfo{CdnStatus: types.TaskInfoCdnStatusSOURCEERROR}), check.IsNil)
+}
+
+func (s *TaskUtilTestSuite) TestEncodePieceBitmap(c *check.C) {
+	var cases = []struct {
+		pieceNums  []int
+		pieceTotal int
+		bitmap     string
+		rle        string
+	}{
+		{pieceNums: nil, pieceTotal: 0, bitmap: "", rle: ""},
+		{pieceNums: nil, pieceTotal: 4, bitmap: "0000", rle: "4"},
+		{pieceNums: []int{0, 1, 2, 3}, pieceTotal: 4, bitmap: "1111", rle: "0,4"},
+		{pieceNums: []int{1, 0, 5}, pieceTotal: 6, bitmap: "110001", rle: "0,2,3,1"},
+		{pieceNums: []int{2, 3, 9}, pieceTotal: 6, bitmap: "001100", rle: "2,2,2"},
+	}
+
+	for _, tc := range cases {
+		c.Check(encodePieceBitmap(tc.pieceNums, tc.pieceTotal, types.TaskPieceHoldersEncodingBitmap), check.Equals, tc.bitmap)
+		c.Check(encodePieceBitmap(tc.pieceNums, tc.pieceTotal, types.TaskPieceHoldersEncodingRle), check.Equals, tc.rle)
+	}
+}
+
+func (s *TaskUtilTestSuite) TestAppendPieceHolders(c *check.C) {
+	var pieces []*types.PieceHolders
+	pieces = appendPieceHolders(pieces, 0, []string{"b", "a"}, nil, true)
+	pieces = appendPieceHolders(pieces, 1, []string{"a", "b"}, nil, true)
+	pieces = appendPieceHolders(pieces, 2, []string{"a"}, []string{"c"}, true)
+	pieces = appendPieceHolders(pieces, 3, []string{"a"}, []string{"c"}, false)
+
+	c.Assert(pieces, check.HasLen, 3)
+	c.Check(pieces[0], check.DeepEquals, &types.PieceHolders{
+		StartPieceNum: 0,
+		EndPieceNum:   1,
+		Holders:       []string{"a", "b"},
+		Downloaders:   []string{},
+	})
+	c.Check(pieces[1].StartPieceNum, check.Equals, int32(2))
+	c.Check(pieces[1].EndPieceNum, check.Equals, int32(2))
+	c.Check(pieces[2].StartPieceNum, check.Equals, int32(3))
+	c.Check(pieces[2].Downloaders, check.DeepEquals, []string{"c"})
+}
+
+func (s *TaskUtilTestSuite) TestGetPieceHolders(c *check.C) {
+	ctx := context.Background()
+	taskID := "holdersTaskID"
+	s.taskManager.taskStore.Put(taskID, &types.TaskInfo{
+		ID:         taskID,
+		PieceTotal: 3,
+	})
+	defer s.taskManager.taskStore.Delete(taskID)
+
+	// peer1 downloads the task again with cid2, and cid0 has been gc.
+	s.mockProgressMgr.EXPECT().GetClientsByTaskID(gomock.Any(), taskID).
+		Return(map[string]string{"cid0": "peer0", "cid1": "peer1", "cid2": "peer1"}, nil).AnyTimes()
+	s.mockProgressMgr.EXPECT().GetPieceProgressByCID(gomock.Any(), taskID, "cid0", gomock.Any()).
+		Return(nil, errortypes.ErrDataNotFound).AnyTimes()
+	s.mockProgressMgr.EXPECT().GetPieceProgressByCID(gomock.Any(), taskID, "cid2", gomock.Any()).
+		Return(nil, nil).AnyTimes()
+	s.mockProgressMgr.EXPECT().GetPieceProgressByCID(gomock.Any(), taskID, "cid1", "success").
+		Return([]int{0}, nil).AnyTimes()
+	s.mockProgressMgr.EXPECT().GetPieceProgressByCID(gomock.Any(), taskID, "cid1", "running").
+		Return([]int{1}, nil).AnyTimes()
+	s.mockProgressMgr.EXPECT().GetPieceProgressByCID(gomock.Any(), taskID, "cid1", "failed").
+		Return([]int{2}, nil).AnyTimes()
+	s.mockProgressMgr.EXPECT().GetPeerIDsByPieceNum(gomock.Any(), taskID, 0).
+		Return([]string{"superPID", "peer1"}, nil).AnyTimes()
+	s.mockProgressMgr.EXPECT().GetPeerIDsByPieceNum(gomock.Any(), taskID, 1).
+		Return([]string{"superPID"}, nil).AnyTimes()
+	s.mockProgressMgr.EXPECT().GetPeerIDsByPieceNum(gomock.Any(), taskID, 2).
+		Return(nil, errortypes.ErrDataNotFound).AnyTimes()
+
+	holders, err := s.taskManager.GetPieceHolders(ctx, taskID, "")
+	c.Assert(err, check.IsNil)
+	c.Check(holders.Encoding, check.Equals, types.TaskPieceHoldersEncodingBitmap)
+	c.Check(holders.PieceTotal, check.Equals, int32(3))
+	c.Check(holders.Clients, check.DeepEquals, []*types.ClientPieceBitmap{{
+		Cid:     "cid1",
+		PeerID:  "peer1",
+		Success: "100",
+		Running: "010",
+		Failed:  "001",
+	}, {
+		Cid:     "cid2",
+		PeerID:  "peer1",
+		Success: "000",
+		Running: "000",
+		Failed:  "000",
+	}})
+	c.Assert(holders.Pieces, check.HasLen, 3)
+	c.Check(holders.Pieces[0].Holders, check.DeepEquals, []string{"peer1", "superPID"})
+	c.Check(holders.Pieces[1].Downloaders, check.DeepEquals, []string{"peer1"})
+	c.Check(holders.Pieces[2].Holders, check.HasLen, 0)
+
+	holders, err = s.taskManager.GetPieceHolders(ctx, taskID, types.TaskPieceHoldersEncodingRle)
+	c.Assert(err, check.IsNil)
+	c.Check(holders.Clients[0].Success, check.Equals, "0,1,2")
+	c.Check(holders.Clients[0].Running, check.Equals, "1,1,1")
+	c.Check(holders.Pieces, check.HasLen, 3)
+
+	_, err = s.taskManager.GetPieceHolders(ctx, taskID, "foo")
+	c.Check(errortypes.IsInvalidValue(err), check.Equals, true)
+
+	_, err = s.taskManager.GetPieceHolders(ctx, "foo", "")
+	c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
+}
+
+func (s *TaskUtilTestSuite) TestCancelCDN(c *check.C) {
+	ctx := context.Background()
+	startDownload := func(taskID string) *cdnDownload {
+		download := newCDNDownload()
+		s.taskManager.cdnDownloads.Add(taskID, download)
+		go func() {
+			<-download.ctx.Done()
+			s.taskManager.cdnDownloads.Remove(taskID)
+			close(download.done)
+		}()
+		return download
+	}
+
+	download := startDownload("cancelTaskID")
+	c.Assert(s.taskManager.CancelCDN(ctx, "cancelTaskID"), check.IsNil)
+	c.Check(download.ctx.Err(), check.Equals, context.Canceled)
+	_, err := s.taskManager.cdnDownloads.Get("cancelTaskID")
+	c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
+
+	err = s.taskManager.CancelCDN(ctx, "cancelTaskID")
+	c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
+
+	downloads := []*cdnDownload{startDownload("cancelTaskID1"), startDownload("cancelTaskID2")}
+	c.Assert(s.taskManager.CancelAllCDN(ctx), check.IsNil)
+	for _, d := range downloads {
+		c.Check(d.ctx.Err(), check.Equals, context.Canceled)
+	}
+	c.Check(s.taskManager.cdnDownloads.ListKeyAsStringSlice(), check.HasLen, 0)
+
+	// the download which never exits
+	stuck := newCDNDownload()
+	s.taskManager.cdnDownloads.Add("stuckTaskID", stuck)
+	defer s.taskManager.cdnDownloads.Remove("stuckTaskID")
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	c.Check(s.taskManager.CancelCDN(timeoutCtx, "stuckTaskID"), check.Equals, context.DeadlineExceeded)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package task
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namespaceCounter counts the tasks of each namespace to enforce the task limits.
+type namespaceCounter struct {
+	sync.Mutex
+	counts map[string]int
+
+	tasks    *prometheus.GaugeVec
+	rejected *prometheus.CounterVec
+}
+
+func newNamespaceCounter(tasks *prometheus.GaugeVec, rejected *prometheus.CounterVec) *namespaceCounter {
+	return &namespaceCounter{
+		counts:   make(map[string]int),
+		tasks:    tasks,
+		rejected: rejected,
+	}
+}
+
+// acquire counts a new task in the namespace, and false will be returned
+// if the namespace already has limit tasks. There is no limit if limit is 0.
+func (nc *namespaceCounter) acquire(namespace string, limit int) bool {
+	nc.Lock()
+	defer nc.Unlock()
+
+	if limit > 0 && nc.counts[namespace] >= limit {
+		nc.rejected.WithLabelValues(namespace).Inc()
+		return false
+	}
+	nc.counts[namespace]++
+	nc.tasks.WithLabelValues(namespace).Inc()
+	return true
+}
+
+// release removes a task from the namespace.
+func (nc *namespaceCounter) release(namespace string) {
+	nc.Lock()
+	defer nc.Unlock()
+
+	if nc.counts[namespace] <= 0 {
+		return
+	}
+	nc.counts[namespace]--
+	if nc.counts[namespace] == 0 {
+		delete(nc.counts, namespace)
+	}
+	nc.tasks.WithLabelValues(namespace).Dec()
+}
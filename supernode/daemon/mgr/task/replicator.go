@@ -0,0 +1,312 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/httpclient"
+	"github.com/dragonflyoss/Dragonfly/version"
+
+	strfmt "github.com/go-openapi/strfmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// hotTaskWindow is the time window to count the demands of a task.
+	hotTaskWindow = time.Minute
+
+	// replicaCheckInterval is the interval time to check whether a replica is completed.
+	replicaCheckInterval = 3 * time.Second
+
+	// replicaTimeout is the max time to wait for a replica to be completed.
+	replicaTimeout = 30 * time.Minute
+
+	// replicaVerifyInterval is the interval time to verify whether a completed replica
+	// is still held by the sibling.
+	replicaVerifyInterval = time.Minute
+
+	// replicaCIDPrefix is the prefix of the clientIDs which represent the replicas.
+	replicaCIDPrefix = "replica:"
+)
+
+// hotTask is the demand state of a task.
+type hotTask struct {
+	windowStart time.Time
+	demands     int
+	replicated  bool
+}
+
+// hotTaskDetector detects the hot tasks by counting their demands within a time window.
+type hotTaskDetector struct {
+	threshold int
+	window    time.Duration
+	now       func() time.Time
+
+	lock  sync.Mutex
+	tasks map[string]*hotTask
+}
+
+func newHotTaskDetector(threshold int, window time.Duration) *hotTaskDetector {
+	return &hotTaskDetector{
+		threshold: threshold,
+		window:    window,
+		now:       time.Now,
+		tasks:     make(map[string]*hotTask),
+	}
+}
+
+// record records a demand of the task, and returns true only once when the task is hot
+// and ready, which means that the task should be replicated.
+func (d *hotTaskDetector) record(taskID string, ready bool) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.now()
+	ht, ok := d.tasks[taskID]
+	if !ok {
+		ht = &hotTask{windowStart: now}
+		d.tasks[taskID] = ht
+	}
+	if ht.replicated {
+		return false
+	}
+
+	if now.Sub(ht.windowStart) > d.window {
+		ht.windowStart = now
+		ht.demands = 0
+	}
+	ht.demands++
+
+	if !ready || ht.demands < d.threshold {
+		return false
+	}
+	ht.replicated = true
+	return true
+}
+
+// reset makes the task able to be replicated again, and its demands are counted from now on.
+func (d *hotTaskDetector) reset(taskID string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if ht, ok := d.tasks[taskID]; ok {
+		ht.windowStart = d.now()
+		ht.demands = 0
+		ht.replicated = false
+	}
+}
+
+// remove removes the demand state of the task.
+func (d *hotTaskDetector) remove(taskID string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.tasks, taskID)
+}
+
+// recordDemand records a demand of the task, which is either a registration or
+// a client waiting for the upload slots, and starts to replicate the task to
+// the sibling supernodes once it becomes hot.
+func (tm *Manager) recordDemand(task *types.TaskInfo) {
+	if tm.cfg.ReplicaCount <= 0 || len(tm.cfg.CDNSiblings) == 0 {
+		return
+	}
+
+	if tm.hotTasks.record(task.ID, isSuccessCDN(task.CdnStatus)) {
+		logrus.Infof("taskID(%s) is hot and start to replicate it to %d siblings", task.ID, tm.cfg.ReplicaCount)
+		go tm.replicate(task)
+	}
+}
+
+// replicate requests the sibling supernodes to download the task as replicas
+// until ReplicaCount of them accept, and advertises each replica once it's completed.
+func (tm *Manager) replicate(task *types.TaskInfo) {
+	req := &types.TaskCreateRequest{
+		RawURL:     task.RawURL,
+		TaskURL:    task.TaskURL,
+		Headers:    task.Headers,
+		Md5:        task.Md5,
+		Identifier: task.Identifier,
+		Priority:   task.Priority,
+		Namespace:  task.Namespace,
+	}
+
+	var count int
+	for _, sibling := range tm.cfg.CDNSiblings {
+		if count >= tm.cfg.ReplicaCount {
+			break
+		}
+
+		resp, err := httpclient.CreateReplica(sibling, req)
+		if err == nil && (resp.ID != task.ID || resp.PieceSize != task.PieceSize) {
+			err = fmt.Errorf("replica mismatches the task with taskID(%s) pieceSize(%d)", resp.ID, resp.PieceSize)
+		}
+		if err != nil {
+			tm.metrics.replicasCount.WithLabelValues("failed").Inc()
+			logrus.Errorf("failed to replicate taskID(%s) to the sibling %s: %v", task.ID, sibling, err)
+			continue
+		}
+		count++
+
+		go func(sibling string) {
+			if err := tm.waitReplica(task.ID, sibling); err != nil {
+				tm.metrics.replicasCount.WithLabelValues("failed").Inc()
+				logrus.Errorf("failed to replicate taskID(%s) to the sibling %s: %v", task.ID, sibling, err)
+				return
+			}
+			tm.metrics.replicasCount.WithLabelValues("success").Inc()
+			logrus.Infof("success to replicate taskID(%s) to the sibling %s", task.ID, sibling)
+			tm.verifyReplica(task.ID, sibling)
+		}(sibling)
+	}
+
+	// the task can be replicated again when it's still hot if no sibling accepts it.
+	if count == 0 {
+		tm.hotTasks.reset(task.ID)
+	}
+}
+
+// waitReplica waits until the replica of the task on the sibling is completed, and then advertises it.
+// It stops waiting if the task has been deleted.
+func (tm *Manager) waitReplica(taskID, sibling string) error {
+	ticker := time.NewTicker(replicaCheckInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(replicaTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			return fmt.Errorf("replica is not completed within %v", replicaTimeout)
+		}
+
+		task, err := tm.getTask(taskID)
+		if err != nil {
+			return err
+		}
+
+		info, err := httpclient.GetSiblingCache(sibling, taskID, "")
+		if err != nil {
+			logrus.Debugf("replica of taskID(%s) on the sibling %s is not completed: %v", taskID, sibling, err)
+			continue
+		}
+
+		return tm.addReplica(context.Background(), task, sibling, info)
+	}
+}
+
+// verifyReplica periodically verifies that the sibling still holds the advertised replica,
+// and removes the replica once the sibling doesn't, so that the scheduler won't assign
+// the pieces to it any more.
+// It stops verifying if the task or the replica has been deleted.
+func (tm *Manager) verifyReplica(taskID, sibling string) {
+	ticker := time.NewTicker(replicaVerifyInterval)
+	defer ticker.Stop()
+
+	cid := replicaCID(sibling, taskID)
+	for range ticker.C {
+		if _, err := tm.getTask(taskID); err != nil {
+			return
+		}
+		if _, err := tm.dfgetTaskMgr.Get(context.Background(), cid, taskID); err != nil {
+			return
+		}
+
+		if _, err := httpclient.GetSiblingCache(sibling, taskID, ""); err != nil {
+			logrus.Warnf("replica of taskID(%s) is not held by the sibling %s any more: %v", taskID, sibling, err)
+			tm.removeReplica(context.Background(), taskID, cid)
+			return
+		}
+	}
+}
+
+// removeReplica removes the advertised replica of the task.
+func (tm *Manager) removeReplica(ctx context.Context, taskID, cid string) {
+	if err := tm.progressMgr.DeleteCID(ctx, cid); err != nil {
+		logrus.Errorf("failed to delete the progress of the replica %s: %v", cid, err)
+	}
+	if err := tm.dfgetTaskMgr.Delete(ctx, cid, taskID); err != nil {
+		logrus.Errorf("failed to delete the dfgetTask of the replica %s: %v", cid, err)
+	}
+}
+
+// replicaCID returns the clientID which represents the replica of the task on the sibling.
+func replicaCID(sibling, taskID string) string {
+	return fmt.Sprintf("%s%s~%s", replicaCIDPrefix, sibling, taskID)
+}
+
+// addReplica advertises the completed replica on the sibling as a peer which holds all
+// the pieces of the task, so that the scheduler assigns the pieces to it like a super-peer.
+func (tm *Manager) addReplica(ctx context.Context, task *types.TaskInfo, sibling string, info *types.CacheInfo) error {
+	host, _, err := net.SplitHostPort(sibling)
+	if err != nil {
+		return err
+	}
+
+	// the sibling is registered as a peer only once and shared by all its replicas,
+	// and it's registered again if the peer has been removed by gc.
+	peerID, err := tm.replicaPeers.GetAsString(sibling)
+	if err == nil {
+		if _, err = tm.peerMgr.Get(ctx, peerID); err != nil {
+			tm.replicaPeers.Remove(sibling)
+		}
+	}
+	if err != nil {
+		peer, err := tm.peerMgr.Register(ctx, &types.PeerCreateRequest{
+			IP:       strfmt.IPv4(host),
+			HostName: strfmt.Hostname(host),
+			Port:     info.DownloadPort,
+			Version:  version.DFGetVersion,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to register the peer of the sibling %s", sibling)
+		}
+		peerID = peer.ID
+		tm.replicaPeers.Add(sibling, peerID)
+	}
+
+	cid := replicaCID(sibling, task.ID)
+	if err := tm.dfgetTaskMgr.Add(ctx, &types.DfGetTask{
+		CID:       cid,
+		Path:      info.DownloadPath,
+		PeerID:    peerID,
+		PieceSize: task.PieceSize,
+		Status:    types.DfGetTaskStatusSUCCESS,
+		TaskID:    task.ID,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to add the dfgetTask of the replica")
+	}
+
+	if err := tm.progressMgr.InitProgress(ctx, task.ID, peerID, cid); err != nil {
+		return err
+	}
+	for pieceNum := 0; pieceNum < int(task.PieceTotal); pieceNum++ {
+		if err := tm.progressMgr.UpdateProgress(ctx, task.ID, cid, peerID, "", pieceNum, config.PieceSUCCESS); err != nil {
+			return errors.Wrapf(err, "failed to update the progress of the replica")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/syncmap"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/mock"
+
+	"github.com/go-check/check"
+	"github.com/golang/mock/gomock"
+)
+
+func init() {
+	check.Suite(&ReplicatorTestSuite{})
+}
+
+type ReplicatorTestSuite struct{}
+
+func (s *ReplicatorTestSuite) TestHotTaskDetector(c *check.C) {
+	now := time.Now()
+	d := newHotTaskDetector(3, time.Minute)
+	d.now = func() time.Time { return now }
+
+	c.Check(d.record("foo", true), check.Equals, false)
+	c.Check(d.record("foo", true), check.Equals, false)
+
+	// the demands are counted again in a new window.
+	now = now.Add(2 * time.Minute)
+	c.Check(d.record("foo", true), check.Equals, false)
+	c.Check(d.record("foo", true), check.Equals, false)
+	// the task is hot but not ready.
+	c.Check(d.record("foo", false), check.Equals, false)
+	c.Check(d.record("foo", true), check.Equals, true)
+	// the hot task is replicated only once.
+	c.Check(d.record("foo", true), check.Equals, false)
+
+	// the task can be replicated again after it's reset.
+	d.reset("foo")
+	c.Check(d.record("foo", true), check.Equals, false)
+	c.Check(d.record("foo", true), check.Equals, false)
+	c.Check(d.record("foo", true), check.Equals, true)
+
+	d.remove("foo")
+	c.Check(d.record("foo", true), check.Equals, false)
+}
+
+func (s *ReplicatorTestSuite) TestAddReplica(c *check.C) {
+	ctl := gomock.NewController(c)
+	defer ctl.Finish()
+	peerMgr := mock.NewMockPeerMgr(ctl)
+	dfgetTaskMgr := mock.NewMockDfgetTaskMgr(ctl)
+	progressMgr := mock.NewMockProgressMgr(ctl)
+	tm := &Manager{
+		peerMgr:      peerMgr,
+		dfgetTaskMgr: dfgetTaskMgr,
+		progressMgr:  progressMgr,
+		replicaPeers: syncmap.NewSyncMap(),
+	}
+
+	info := &types.CacheInfo{DownloadPort: 8001, DownloadPath: "/download/foo/foo"}
+	peerMgr.EXPECT().Register(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *types.PeerCreateRequest) (*types.PeerCreateResponse, error) {
+			c.Check(req.IP.String(), check.Equals, "192.168.1.1")
+			c.Check(req.Port, check.Equals, int32(8001))
+			return &types.PeerCreateResponse{ID: "replicaPeer"}, nil
+		}).Times(2)
+	gomock.InOrder(
+		peerMgr.EXPECT().Get(gomock.Any(), "replicaPeer").Return(&types.PeerInfo{ID: "replicaPeer"}, nil),
+		// the peer has been removed by gc, and it should be registered again.
+		peerMgr.EXPECT().Get(gomock.Any(), "replicaPeer").Return(nil, errortypes.ErrDataNotFound),
+	)
+
+	for _, task := range []*types.TaskInfo{
+		{ID: "foo", PieceSize: 4, PieceTotal: 3},
+		{ID: "bar", PieceSize: 4, PieceTotal: 1},
+		{ID: "baz", PieceSize: 4, PieceTotal: 2},
+	} {
+		cid := "replica:192.168.1.1:8002~" + task.ID
+		dfgetTaskMgr.EXPECT().Add(gomock.Any(), &types.DfGetTask{
+			CID:       cid,
+			Path:      info.DownloadPath,
+			PeerID:    "replicaPeer",
+			PieceSize: task.PieceSize,
+			Status:    types.DfGetTaskStatusSUCCESS,
+			TaskID:    task.ID,
+		}).Return(nil)
+		progressMgr.EXPECT().InitProgress(gomock.Any(), task.ID, "replicaPeer", cid).Return(nil)
+		progressMgr.EXPECT().UpdateProgress(gomock.Any(), task.ID, cid, "replicaPeer", "", gomock.Any(), config.PieceSUCCESS).
+			Return(nil).Times(int(task.PieceTotal))
+
+		c.Check(tm.addReplica(context.Background(), task, "192.168.1.1:8002", info), check.IsNil)
+	}
+}
+
+func (s *ReplicatorTestSuite) TestRemoveReplica(c *check.C) {
+	ctl := gomock.NewController(c)
+	defer ctl.Finish()
+	dfgetTaskMgr := mock.NewMockDfgetTaskMgr(ctl)
+	progressMgr := mock.NewMockProgressMgr(ctl)
+	tm := &Manager{
+		dfgetTaskMgr: dfgetTaskMgr,
+		progressMgr:  progressMgr,
+	}
+
+	cid := replicaCID("192.168.1.1:8002", "foo")
+	c.Check(cid, check.Equals, "replica:192.168.1.1:8002~foo")
+	progressMgr.EXPECT().DeleteCID(gomock.Any(), cid).Return(nil)
+	dfgetTaskMgr.EXPECT().Delete(gomock.Any(), cid, "foo").Return(nil)
+	tm.removeReplica(context.Background(), "foo", cid)
+}
@@ -31,6 +31,24 @@ var PieceStatusMap = map[string]int{
 	types.PieceUpdateRequestPieceStatusSUCCESS: config.PieceSUCCESS,
 }
 
+// PriorityWeightMap maintains the weight of each task priority.
+// When the resources of supernode are contended, such as the bandwidth of CDN and
+// the upload slots of supernode, each task gets a share in proportion to its weight.
+var PriorityWeightMap = map[string]int{
+	types.TaskInfoPriorityCritical:   4,
+	types.TaskInfoPriorityNormal:     2,
+	types.TaskInfoPriorityBackground: 1,
+}
+
+// GetPriorityWeight returns the weight of the priority.
+// And the weight of the normal priority will be returned if the priority is unknown.
+func GetPriorityWeight(priority string) int {
+	if weight, ok := PriorityWeightMap[priority]; ok {
+		return weight
+	}
+	return PriorityWeightMap[types.TaskInfoPriorityNormal]
+}
+
 // TaskMgr as an interface defines all operations against Task.
 // A Task will store some meta info about the taskFile, pieces and something else.
 // A Task has a one-to-one correspondence with a file on the disk which is identified by taskID.
@@ -40,6 +58,10 @@ type TaskMgr interface {
 	// NOTE: If supernode cannot find the task file, the CDN download will be triggered.
 	Register(ctx context.Context, taskCreateRequest *types.TaskCreateRequest) (taskCreateResponse *types.TaskCreateResponse, err error)
 
+	// Replicate registers a task without any client as a replica for a sibling supernode,
+	// and triggers the CDN download of the task.
+	Replicate(ctx context.Context, taskCreateRequest *types.TaskCreateRequest) (*types.TaskCreateResponse, error)
+
 	// Get the task Info with specified taskID.
 	Get(ctx context.Context, taskID string) (*types.TaskInfo, error)
 
@@ -47,6 +69,7 @@ type TaskMgr interface {
 	GetAccessTime(ctx context.Context) (*syncmap.SyncMap, error)
 
 	// List returns the list tasks with filter.
+	// The filter supports the key "cdnStatus", and all the tasks will be returned if it's empty.
 	List(ctx context.Context, filter map[string]string) ([]*types.TaskInfo, error)
 
 	// CheckTaskStatus checks whether the taskID corresponding file exists.
@@ -71,4 +94,20 @@ type TaskMgr interface {
 	// We use a sting called pieceRange to identify a piece.
 	// A pieceRange is separated by a dash, like this: 0-45565, etc.
 	UpdatePieceStatus(ctx context.Context, taskID, pieceRange string, pieceUpdateRequest *types.PieceUpdateRequest) error
+
+	// GetPieceHolders gets the peers which hold or are downloading each piece of the task
+	// and the piece bitmaps of each client, which are encoded with the specified encoding.
+	// And an empty encoding means that it will be determined by the number of pieces.
+	GetPieceHolders(ctx context.Context, taskID, encoding string) (*types.TaskPieceHolders, error)
+
+	// CancelCDN cancels the in-flight CDN download of the task and cleans up the partial files.
+	// It returns an ErrDataNotFound error if there is no CDN download of the task in flight.
+	CancelCDN(ctx context.Context, taskID string) error
+
+	// CancelAllCDN cancels all the in-flight CDN downloads and cleans up the partial files,
+	// which is used when the supernode is shutting down.
+	CancelAllCDN(ctx context.Context) error
+
+	// CheckHealth checks the depth of the CDN download queue.
+	CheckHealth(ctx context.Context) *types.ComponentHealth
 }
@@ -0,0 +1,274 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/metricsutils"
+	"github.com/dragonflyoss/Dragonfly/pkg/netutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+var _ mgr.WebhookMgr = &Manager{}
+
+const (
+	// SignatureHeader is the header of the HMAC-SHA256 signature of the body.
+	SignatureHeader = "X-Dragonfly-Signature"
+
+	// EventHeader is the header of the type of the event.
+	EventHeader = "X-Dragonfly-Event"
+
+	// queueSize is the max number of the events queued for each webhook.
+	queueSize = 1024
+
+	// initialBackoff is the time to wait before the first retry, and it doubles after each retry.
+	initialBackoff = time.Second
+
+	// maxBackoff is the max time to wait before a retry.
+	maxBackoff = time.Minute
+)
+
+// events are all the types of the events which can be subscribed.
+var events = map[string]bool{
+	types.WebhookEventEventCDNSUCCESS:       true,
+	types.WebhookEventEventCDNFAILED:        true,
+	types.WebhookEventEventDFGETSUCCESS:     true,
+	types.WebhookEventEventDFGETFAILED:      true,
+	types.WebhookEventEventCLIENTSCOMPLETED: true,
+	types.WebhookEventEventEVICTED:          true,
+}
+
+type metrics struct {
+	deliveries *prometheus.CounterVec
+}
+
+func newMetrics(register prometheus.Registerer) *metrics {
+	return &metrics{
+		deliveries: metricsutils.NewCounter(config.SubsystemSupernode, "webhook_deliveries_total",
+			"Total times of delivering the events to the webhooks by result", []string{"event", "result"}, register),
+	}
+}
+
+// hook is a webhook with the queue of the events to be delivered to it.
+type hook struct {
+	url        string
+	events     map[string]bool
+	secret     []byte
+	maxRetries int
+	client     *http.Client
+	queue      chan *types.WebhookEvent
+}
+
+// subscribes returns whether the hook wants the event.
+func (h *hook) subscribes(event string) bool {
+	return len(h.events) == 0 || h.events[event]
+}
+
+// Manager is an implementation of the interface of WebhookMgr.
+type Manager struct {
+	cfg     *config.Config
+	hooks   []*hook
+	metrics *metrics
+
+	// backoff is the time to wait before the first retry.
+	backoff time.Duration
+}
+
+// NewManager returns a new Manager with the webhooks of the config.
+func NewManager(cfg *config.Config, register prometheus.Registerer) (*Manager, error) {
+	var hooks []*hook
+	for _, wc := range cfg.Webhooks {
+		h, err := newHook(wc)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, h)
+	}
+
+	return &Manager{
+		cfg:     cfg,
+		hooks:   hooks,
+		metrics: newMetrics(register),
+		backoff: initialBackoff,
+	}, nil
+}
+
+func newHook(wc *config.WebhookConfig) (*hook, error) {
+	if !netutils.IsValidURL(wc.URL) {
+		return nil, errors.Wrapf(errortypes.ErrInvalidValue, "webhook url: %s", wc.URL)
+	}
+
+	subscribed := make(map[string]bool)
+	for _, e := range wc.Events {
+		if !events[e] {
+			return nil, errors.Wrapf(errortypes.ErrInvalidValue, "event %s of webhook %s", e, wc.URL)
+		}
+		subscribed[e] = true
+	}
+
+	timeout := wc.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultWebhookTimeout
+	}
+	maxRetries := wc.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = config.DefaultWebhookMaxRetries
+	}
+
+	return &hook{
+		url:        wc.URL,
+		events:     subscribed,
+		secret:     []byte(wc.Secret),
+		maxRetries: maxRetries,
+		client:     &http.Client{Timeout: timeout},
+		queue:      make(chan *types.WebhookEvent, queueSize),
+	}, nil
+}
+
+// StartDeliver starts a goroutine for each webhook to deliver the events in order.
+func (m *Manager) StartDeliver(ctx context.Context) {
+	for _, h := range m.hooks {
+		go m.deliverLoop(ctx, h)
+	}
+}
+
+// Notify queues the event for the webhooks which subscribe to it.
+func (m *Manager) Notify(ctx context.Context, event *types.WebhookEvent) {
+	if len(m.hooks) == 0 {
+		return
+	}
+
+	event.Supernode = m.cfg.AdvertiseIP
+	if time.Time(event.Time).IsZero() {
+		event.Time = strfmt.DateTime(time.Now())
+	}
+
+	for _, h := range m.hooks {
+		if !h.subscribes(event.Event) {
+			continue
+		}
+		select {
+		case h.queue <- event:
+		default:
+			m.metrics.deliveries.WithLabelValues(event.Event, "dropped").Inc()
+			logrus.Warnf("drop the webhook event %s of task %s for %s: %d events are queued already",
+				event.Event, event.TaskID, h.url, queueSize)
+		}
+	}
+}
+
+func (m *Manager) deliverLoop(ctx context.Context, h *hook) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-h.queue:
+			m.deliver(ctx, h, event)
+		}
+	}
+}
+
+// deliver posts the event to the webhook, and retries with exponential backoff
+// when it fails with a network error, a 5xx or a 429 response.
+func (m *Manager) deliver(ctx context.Context, h *hook, event *types.WebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("failed to marshal the webhook event %+v: %v", event, err)
+		return
+	}
+
+	backoff := m.backoff
+	for retries := 0; ; retries++ {
+		retryable, err := h.post(ctx, event.Event, body)
+		if err == nil {
+			m.metrics.deliveries.WithLabelValues(event.Event, "success").Inc()
+			logrus.Debugf("success to deliver the webhook event %s of task %s to %s", event.Event, event.TaskID, h.url)
+			return
+		}
+		if !retryable || retries >= h.maxRetries {
+			m.metrics.deliveries.WithLabelValues(event.Event, "failed").Inc()
+			logrus.Errorf("failed to deliver the webhook event %s of task %s to %s after %d retries: %v",
+				event.Event, event.TaskID, h.url, retries, err)
+			return
+		}
+
+		logrus.Warnf("failed to deliver the webhook event %s of task %s to %s, will retry after %v: %v",
+			event.Event, event.TaskID, h.url, backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// post posts the body to the webhook, and returns whether the request is retryable if it fails.
+func (h *hook) post(ctx context.Context, event string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if len(h.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(h.secret, body))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+	retryable := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+}
+
+// Sign returns the signature of the body with HMAC-SHA256 in the format of sha256=<hex>,
+// which can be used by the receivers to verify the header X-Dragonfly-Signature.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/go-check/check"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+func init() {
+	check.Suite(&WebhookMgrTestSuite{})
+}
+
+type WebhookMgrTestSuite struct{}
+
+// delivery is a request received by the webhook.
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhook starts a webhook which responds the requests with the codes in order,
+// and the last code is used when the codes run out.
+func newWebhook(codes ...int) (*httptest.Server, chan *delivery) {
+	deliveries := make(chan *delivery, 10)
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		deliveries <- &delivery{header: r.Header, body: body}
+
+		i := int(atomic.AddInt32(&count, 1)) - 1
+		if i >= len(codes) {
+			i = len(codes) - 1
+		}
+		w.WriteHeader(codes[i])
+	}))
+	return server, deliveries
+}
+
+func (s *WebhookMgrTestSuite) newManager(c *check.C, webhooks ...*config.WebhookConfig) *Manager {
+	cfg := config.NewConfig()
+	cfg.AdvertiseIP = "127.0.0.1"
+	cfg.Webhooks = webhooks
+	m, err := NewManager(cfg, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+	m.backoff = time.Millisecond
+	return m
+}
+
+func (s *WebhookMgrTestSuite) TestNewManagerWithInvalidConfig(c *check.C) {
+	for _, wc := range []*config.WebhookConfig{
+		{URL: "foo"},
+		{URL: "http://release.example.com/events", Events: []string{"CDN_SUCCESS", "foo"}},
+	} {
+		cfg := config.NewConfig()
+		cfg.Webhooks = []*config.WebhookConfig{wc}
+		_, err := NewManager(cfg, prometheus.NewRegistry())
+		c.Check(errortypes.IsInvalidValue(err), check.Equals, true)
+	}
+}
+
+func (s *WebhookMgrTestSuite) TestNotify(c *check.C) {
+	signed, signedDeliveries := newWebhook(http.StatusOK)
+	defer signed.Close()
+	all, allDeliveries := newWebhook(http.StatusOK)
+	defer all.Close()
+
+	m := s.newManager(c, &config.WebhookConfig{
+		URL:    signed.URL,
+		Events: []string{types.WebhookEventEventCLIENTSCOMPLETED},
+		Secret: "secret",
+	}, &config.WebhookConfig{
+		URL: all.URL,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.StartDeliver(ctx)
+
+	m.Notify(ctx, &types.WebhookEvent{Event: types.WebhookEventEventDFGETSUCCESS, TaskID: "foo", CID: "bar"})
+	m.Notify(ctx, &types.WebhookEvent{Event: types.WebhookEventEventCLIENTSCOMPLETED, TaskID: "foo",
+		SucceededClients: 1})
+
+	// the events are delivered in order
+	for _, expected := range []string{types.WebhookEventEventDFGETSUCCESS, types.WebhookEventEventCLIENTSCOMPLETED} {
+		d := <-allDeliveries
+		c.Check(d.header.Get(EventHeader), check.Equals, expected)
+		c.Check(d.header.Get(SignatureHeader), check.Equals, "")
+	}
+
+	d := <-signedDeliveries
+	c.Check(d.header.Get(EventHeader), check.Equals, types.WebhookEventEventCLIENTSCOMPLETED)
+	c.Check(d.header.Get(SignatureHeader), check.Equals, Sign([]byte("secret"), d.body))
+	event := &types.WebhookEvent{}
+	c.Assert(json.Unmarshal(d.body, event), check.IsNil)
+	c.Check(event.TaskID, check.Equals, "foo")
+	c.Check(event.SucceededClients, check.Equals, int64(1))
+	c.Check(event.Supernode, check.Equals, "127.0.0.1")
+	c.Check(time.Time(event.Time).IsZero(), check.Equals, false)
+
+	select {
+	case d := <-signedDeliveries:
+		c.Errorf("unexpected delivery of the event %s", d.header.Get(EventHeader))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (s *WebhookMgrTestSuite) TestDeliverWithRetries(c *check.C) {
+	var cases = []struct {
+		codes    []int
+		attempts int
+		result   string
+	}{
+		{[]int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK}, 3, "success"},
+		{[]int{http.StatusServiceUnavailable}, 3, "failed"},
+		{[]int{http.StatusBadRequest}, 1, "failed"},
+	}
+
+	for _, tc := range cases {
+		server, deliveries := newWebhook(tc.codes...)
+		m := s.newManager(c, &config.WebhookConfig{URL: server.URL, MaxRetries: 2})
+
+		m.deliver(context.Background(), m.hooks[0], &types.WebhookEvent{Event: types.WebhookEventEventCDNFAILED})
+		server.Close()
+
+		c.Check(deliveries, check.HasLen, tc.attempts)
+		c.Check(int(prom_testutil.ToFloat64(m.metrics.deliveries.WithLabelValues(
+			types.WebhookEventEventCDNFAILED, tc.result))), check.Equals, 1)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mgr
+
+import (
+	"context"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+)
+
+// WebhookMgr as an interface defines all operations about the webhooks
+// which the events of the task lifecycle are posted to.
+type WebhookMgr interface {
+	// StartDeliver starts to deliver the queued events to the webhooks with new goroutines.
+	StartDeliver(ctx context.Context)
+
+	// Notify queues the event for the webhooks which subscribe to it, and it never blocks.
+	// The event will be dropped if the queue of a webhook is full.
+	Notify(ctx context.Context, event *types.WebhookEvent)
+}
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	context "context"
 	http "net/http"
 	reflect "reflect"
 
@@ -94,16 +95,16 @@ func (mr *MockOriginHTTPClientMockRecorder) IsExpired(url, headers, lastModified
 }
 
 // Download mocks base method
-func (m *MockOriginHTTPClient) Download(url string, headers map[string]string, checkCode int) (*http.Response, error) {
+func (m *MockOriginHTTPClient) Download(ctx context.Context, url string, headers map[string]string, checkCode int) (*http.Response, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Download", url, headers, checkCode)
+	ret := m.ctrl.Call(m, "Download", ctx, url, headers, checkCode)
 	ret0, _ := ret[0].(*http.Response)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Download indicates an expected call of Download
-func (mr *MockOriginHTTPClientMockRecorder) Download(url, headers, checkCode interface{}) *gomock.Call {
+func (mr *MockOriginHTTPClientMockRecorder) Download(ctx, url, headers, checkCode interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockOriginHTTPClient)(nil).Download), url, headers, checkCode)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockOriginHTTPClient)(nil).Download), ctx, url, headers, checkCode)
 }
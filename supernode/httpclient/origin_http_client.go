@@ -35,25 +35,47 @@ import (
 	"github.com/pkg/errors"
 )
 
+// maxRedirects is the max number of the redirections followed by a request to the origin,
+// which is the same as the default of the http client.
+const maxRedirects = 10
+
+// proxyFromEnvironment returns the proxy of the request, and it's replaced in the tests.
+var proxyFromEnvironment = http.ProxyFromEnvironment
+
 // OriginHTTPClient supply apis that interact with the source.
 type OriginHTTPClient interface {
 	RegisterTLSConfig(rawURL string, insecure bool, caBlock []strfmt.Base64)
 	GetContentLength(url string, headers map[string]string) (int64, int, error)
 	IsSupportRange(url string, headers map[string]string) (bool, error)
 	IsExpired(url string, headers map[string]string, lastModified int64, eTag string) (bool, error)
-	Download(url string, headers map[string]string, checkCode int) (*http.Response, error)
+	Download(ctx context.Context, url string, headers map[string]string, checkCode int) (*http.Response, error)
 }
 
 // OriginClient is an implementation of the interface of OriginHTTPClient.
 type OriginClient struct {
 	clientMap *sync.Map
+	// policy is checked before every request to the origin and every connection it makes.
+	policy        *OriginPolicy
+	defaultClient *http.Client
+
+	// proxies records the addresses of the proxies used by the requests,
+	// and the connections to them are not checked by the policy.
+	proxies *sync.Map
 }
 
-// NewOriginClient returns a new OriginClient.
-func NewOriginClient() OriginHTTPClient {
-	return &OriginClient{
-		clientMap: &sync.Map{},
+// NewOriginClient returns a new OriginClient which only requests the origins allowed
+// by the policy, and all the origins are allowed if the policy is nil.
+func NewOriginClient(policy *OriginPolicy) OriginHTTPClient {
+	client := &OriginClient{
+		clientMap:     &sync.Map{},
+		policy:        policy,
+		defaultClient: http.DefaultClient,
+		proxies:       &sync.Map{},
+	}
+	if policy != nil {
+		client.defaultClient = client.newHTTPClient(nil)
 	}
+	return client
 }
 
 // RegisterTLSConfig saves tls config into map as http client.
@@ -77,21 +99,75 @@ func (client *OriginClient) RegisterTLSConfig(rawURL string, insecure bool, caBl
 		tlsConfig.RootCAs = roots
 	}
 
-	client.clientMap.Store(url.Host, &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   3 * time.Second,
-				KeepAlive: 30 * time.Second,
-				DualStack: true,
-			}).DialContext,
-			MaxIdleConns:          100,
-			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-			TLSClientConfig:       tlsConfig,
-		},
-	})
+	client.clientMap.Store(url.Host, client.newHTTPClient(tlsConfig))
+}
+
+// newHTTPClient returns a new http client with the tlsConfig, and the addresses it connects to
+// and the redirections it follows are checked by the policy of the OriginClient.
+//
+// The connections to the proxies are not checked, because their addresses are not the origins.
+// In that case the origin is only checked by resolving it before the request, and the proxy
+// is trusted to connect to the same address.
+func (client *OriginClient) newHTTPClient(tlsConfig *tls.Config) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   3 * time.Second,
+		KeepAlive: 30 * time.Second,
+		DualStack: true,
+	}
+	transport := &http.Transport{
+		Proxy:                 proxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}
+	httpClient := &http.Client{
+		Transport: transport,
+	}
+
+	if client.policy != nil {
+		checkedDialer := *dialer
+		checkedDialer.Control = client.policy.control
+		transport.Proxy = client.proxy
+		transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			if _, ok := client.proxies.Load(address); ok {
+				return dialer.DialContext(ctx, network, address)
+			}
+			return checkedDialer.DialContext(ctx, network, address)
+		}
+		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return client.policy.Check(req.URL.String())
+		}
+	}
+	return httpClient
+}
+
+// proxy returns the proxy of the req from the environment, and records its address
+// which the transport connects to.
+func (client *OriginClient) proxy(req *http.Request) (*netUrl.URL, error) {
+	proxyURL, err := proxyFromEnvironment(req)
+	if err != nil || proxyURL == nil {
+		return proxyURL, err
+	}
+
+	port := proxyURL.Port()
+	if port == "" {
+		port = proxyDefaultPorts[proxyURL.Scheme]
+	}
+	client.proxies.Store(net.JoinHostPort(proxyURL.Hostname(), port), true)
+	return proxyURL, nil
+}
+
+// proxyDefaultPorts are the ports which the transport connects to when the proxy URL has no port.
+var proxyDefaultPorts = map[string]string{
+	"http":   "80",
+	"https":  "443",
+	"socks5": "1080",
 }
 
 // GetContentLength sends a head request to get file length.
@@ -155,10 +231,15 @@ func (client *OriginClient) IsExpired(url string, headers map[string]string, las
 	return resp.StatusCode != http.StatusNotModified, nil
 }
 
-// Download downloads the file from the original address
-func (client *OriginClient) Download(url string, headers map[string]string, checkCode int) (*http.Response, error) {
-	// TODO: add timeout
-	resp, err := client.HTTPWithHeaders("GET", url, headers, 0)
+// Download downloads the file from the original address,
+// and the download will be aborted once the ctx is done.
+func (client *OriginClient) Download(ctx context.Context, url string, headers map[string]string, checkCode int) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.do(req.WithContext(ctx), headers)
 	if err != nil {
 		return nil, err
 	}
@@ -166,6 +247,7 @@ func (client *OriginClient) Download(url string, headers map[string]string, chec
 	if resp.StatusCode == checkCode {
 		return resp, nil
 	}
+	resp.Body.Close()
 	return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 }
 
@@ -182,13 +264,22 @@ func (client *OriginClient) HTTPWithHeaders(method, url string, headers map[stri
 		defer cancel()
 	}
 
+	return client.do(req, headers)
+}
+
+// do sends the req with the headers by the host-matched client.
+func (client *OriginClient) do(req *http.Request, headers map[string]string) (*http.Response, error) {
+	if err := client.policy.Check(req.URL.String()); err != nil {
+		return nil, err
+	}
+
 	for k, v := range headers {
 		req.Header.Add(k, v)
 	}
 
 	httpClientObject, existed := client.clientMap.Load(req.Host)
 	if !existed {
-		httpClientObject = http.DefaultClient
+		httpClientObject = client.defaultClient
 	}
 
 	httpClient, ok := httpClientObject.(*http.Client)
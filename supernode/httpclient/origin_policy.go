@@ -0,0 +1,266 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpclient
+
+import (
+	"context"
+	"net"
+	netUrl "net/url"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/pkg/errors"
+)
+
+// resolveTimeout is the timeout of resolving the host name of an origin URL.
+const resolveTimeout = 3 * time.Second
+
+// lookupIPAddr resolves the host name, and it's replaced in the tests.
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// OriginPolicy decides whether the supernode is allowed to download from an origin URL
+// according to the configured OriginAllow and OriginDeny rules.
+// A nil OriginPolicy allows all the origins.
+type OriginPolicy struct {
+	allow *originRule
+	deny  *originRule
+}
+
+type originRule struct {
+	schemes map[string]bool
+	hosts   []string
+	nets    []*net.IPNet
+	ports   map[int]bool
+}
+
+// NewOriginPolicy returns a new OriginPolicy with the allow and deny rules,
+// and it returns nil if neither of them is set.
+func NewOriginPolicy(allow, deny *config.OriginRule) (*OriginPolicy, error) {
+	if allow == nil && deny == nil {
+		return nil, nil
+	}
+
+	allowRule, err := newOriginRule(allow)
+	if err != nil {
+		return nil, errors.Wrap(err, "originAllow")
+	}
+	denyRule, err := newOriginRule(deny)
+	if err != nil {
+		return nil, errors.Wrap(err, "originDeny")
+	}
+	return &OriginPolicy{
+		allow: allowRule,
+		deny:  denyRule,
+	}, nil
+}
+
+func newOriginRule(rule *config.OriginRule) (*originRule, error) {
+	r := &originRule{
+		schemes: make(map[string]bool),
+		ports:   make(map[int]bool),
+	}
+	if rule == nil {
+		return r, nil
+	}
+
+	for _, scheme := range rule.Schemes {
+		r.schemes[strings.ToLower(scheme)] = true
+	}
+	for _, host := range rule.Hosts {
+		host = strings.ToLower(host)
+		if _, err := path.Match(hostToPath(host), ""); err != nil {
+			return nil, errors.Wrapf(errortypes.ErrInvalidValue, "host pattern %s: %v", host, err)
+		}
+		r.hosts = append(r.hosts, host)
+	}
+	for _, cidr := range rule.CIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(errortypes.ErrInvalidValue, "cidr %s: %v", cidr, err)
+		}
+		r.nets = append(r.nets, ipNet)
+	}
+	for _, port := range rule.Ports {
+		if port <= 0 || port > 65535 {
+			return nil, errors.Wrapf(errortypes.ErrInvalidValue, "port %d", port)
+		}
+		r.ports[port] = true
+	}
+	return r, nil
+}
+
+// Check returns an ErrInvalidValue error if the supernode is not allowed to download from the rawURL.
+// The CIDRs are checked against all the IPs which the host of the rawURL is resolved to.
+func (p *OriginPolicy) Check(rawURL string) error {
+	if p == nil {
+		return nil
+	}
+
+	u, err := netUrl.Parse(rawURL)
+	if err != nil {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "origin url %s: %v", rawURL, err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if stringutils.IsEmptyStr(host) {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "origin url %s: empty host", rawURL)
+	}
+	port, err := getPort(scheme, u.Port())
+	if err != nil {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "origin url %s: %v", rawURL, err)
+	}
+
+	if len(p.allow.schemes) > 0 && !p.allow.schemes[scheme] {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "origin url %s: scheme %s is not allowed", rawURL, scheme)
+	}
+	if p.deny.schemes[scheme] {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "origin url %s: scheme %s is denied", rawURL, scheme)
+	}
+	if len(p.allow.hosts) > 0 && !p.allow.matchHost(host) {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "origin url %s: host %s is not allowed", rawURL, host)
+	}
+	if p.deny.matchHost(host) {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "origin url %s: host %s is denied", rawURL, host)
+	}
+	if err := p.checkPort(port); err != nil {
+		return errors.Wrapf(err, "origin url %s", rawURL)
+	}
+
+	if len(p.allow.nets) == 0 && len(p.deny.nets) == 0 {
+		return nil
+	}
+	ips, err := resolve(host)
+	if err != nil {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "origin url %s: failed to resolve host %s: %v", rawURL, host, err)
+	}
+	for _, ip := range ips {
+		if err := p.checkIP(ip); err != nil {
+			return errors.Wrapf(err, "origin url %s", rawURL)
+		}
+	}
+	return nil
+}
+
+// control checks the address which the supernode is about to connect to,
+// so that a host name can't be resolved to a denied IP after it's checked.
+// It's used as the Control of the net.Dialer of the origin clients.
+func (p *OriginPolicy) control(network, address string, c syscall.RawConn) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "address %s: %v", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "address %s: invalid ip", address)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "address %s: invalid port", address)
+	}
+
+	if err := p.checkPort(port); err != nil {
+		return errors.Wrapf(err, "address %s", address)
+	}
+	if err := p.checkIP(ip); err != nil {
+		return errors.Wrapf(err, "address %s", address)
+	}
+	return nil
+}
+
+func (p *OriginPolicy) checkPort(port int) error {
+	if len(p.allow.ports) > 0 && !p.allow.ports[port] {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "port %d is not allowed", port)
+	}
+	if p.deny.ports[port] {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "port %d is denied", port)
+	}
+	return nil
+}
+
+func (p *OriginPolicy) checkIP(ip net.IP) error {
+	if len(p.allow.nets) > 0 && !p.allow.containsIP(ip) {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "ip %s is not allowed", ip)
+	}
+	if p.deny.containsIP(ip) {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "ip %s is denied", ip)
+	}
+	return nil
+}
+
+func (r *originRule) matchHost(host string) bool {
+	for _, pattern := range r.hosts {
+		if matched, _ := path.Match(hostToPath(pattern), hostToPath(host)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *originRule) containsIP(ip net.IP) bool {
+	for _, ipNet := range r.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostToPath replaces the dots of the host with the slashes,
+// so that * in a host pattern matched by path.Match doesn't match across the dots.
+func hostToPath(host string) string {
+	return strings.Replace(host, ".", "/", -1)
+}
+
+// getPort returns the port of the URL, or the default port of the scheme if it's empty.
+func getPort(scheme, port string) (int, error) {
+	if stringutils.IsEmptyStr(port) {
+		switch scheme {
+		case "http":
+			return 80, nil
+		case "https":
+			return 443, nil
+		}
+		return 0, errors.Errorf("no default port of scheme %s", scheme)
+	}
+	return strconv.Atoi(port)
+}
+
+// resolve returns the IPs of the host, which is returned directly if it's an IP.
+func resolve(host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	addrs, err := lookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/go-check/check"
+)
+
+type OriginPolicyTestSuite struct{}
+
+func init() {
+	check.Suite(&OriginPolicyTestSuite{})
+}
+
+func (s *OriginPolicyTestSuite) TestNewOriginPolicy(c *check.C) {
+	p, err := NewOriginPolicy(nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(p, check.IsNil)
+	c.Assert(p.Check("http://127.0.0.1/foo"), check.IsNil)
+
+	for _, rule := range []*config.OriginRule{
+		{CIDRs: []string{"10.0.0.0"}},
+		{Hosts: []string{"[.example.com"}},
+		{Ports: []int{0}},
+	} {
+		_, err := NewOriginPolicy(nil, rule)
+		c.Assert(errortypes.IsInvalidValue(err), check.Equals, true)
+	}
+}
+
+func (s *OriginPolicyTestSuite) TestCheck(c *check.C) {
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) { lookupIPAddr = lookup }(lookupIPAddr)
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "cdn.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		case "metadata.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("169.254.169.254")}}, nil
+		}
+		return nil, fmt.Errorf("no such host: %s", host)
+	}
+
+	p, err := NewOriginPolicy(&config.OriginRule{
+		Schemes: []string{"http", "https"},
+		Hosts:   []string{"*.example.com", "127.0.0.1"},
+	}, &config.OriginRule{
+		Hosts: []string{"internal.example.com"},
+		CIDRs: []string{"169.254.0.0/16", "::1/128"},
+		Ports: []int{22},
+	})
+	c.Assert(err, check.IsNil)
+
+	var cases = []struct {
+		url     string
+		allowed bool
+	}{
+		{"http://cdn.example.com/foo", true},
+		{"https://CDN.example.com:8443/foo", true},
+		{"http://127.0.0.1:8080/foo", true},
+		{"ftp://cdn.example.com/foo", false},
+		{"http://a.cdn.example.com/foo", false},
+		{"http://example.org/foo", false},
+		{"http://internal.example.com/foo", false},
+		{"http://cdn.example.com:22/foo", false},
+		{"http://metadata.example.com/foo", false},
+		{"http://unknown.example.com/foo", false},
+		{"http://[::1]/foo", false},
+	}
+	for _, v := range cases {
+		err := p.Check(v.url)
+		c.Assert(err == nil, check.Equals, v.allowed, check.Commentf("url: %s, err: %v", v.url, err))
+		if err != nil {
+			c.Assert(errortypes.IsInvalidValue(err), check.Equals, true)
+		}
+	}
+}
+
+func (s *OriginPolicyTestSuite) TestControl(c *check.C) {
+	p, err := NewOriginPolicy(nil, &config.OriginRule{
+		CIDRs: []string{"127.0.0.0/8"},
+		Ports: []int{22},
+	})
+	c.Assert(err, check.IsNil)
+
+	c.Assert(p.control("tcp", "93.184.216.34:80", nil), check.IsNil)
+	c.Assert(p.control("tcp", "127.0.0.1:80", nil), check.NotNil)
+	c.Assert(p.control("tcp", "93.184.216.34:22", nil), check.NotNil)
+}
+
+func (s *OriginPolicyTestSuite) TestOriginClient(c *check.C) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("foo"))
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	targetURL, _ := url.Parse(target.URL)
+	targetPort, _ := strconv.Atoi(targetURL.Port())
+
+	// the origins are only restricted by the policy of the client.
+	_, code, err := NewOriginClient(nil).GetContentLength(redirect.URL, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(code, check.Equals, http.StatusOK)
+
+	p, err := NewOriginPolicy(nil, &config.OriginRule{Ports: []int{targetPort}})
+	c.Assert(err, check.IsNil)
+	client := NewOriginClient(p)
+	_, _, err = client.GetContentLength(target.URL, nil)
+	c.Assert(errortypes.IsInvalidValue(err), check.Equals, true)
+	_, _, err = client.GetContentLength(redirect.URL, nil)
+	c.Assert(err, check.NotNil)
+
+	p, err = NewOriginPolicy(nil, &config.OriginRule{CIDRs: []string{"127.0.0.0/8"}})
+	c.Assert(err, check.IsNil)
+	client = NewOriginClient(p)
+	client.RegisterTLSConfig(redirect.URL, true, nil)
+	_, err = client.Download(context.Background(), redirect.URL, nil, http.StatusOK)
+	c.Assert(errortypes.IsInvalidValue(err), check.Equals, true)
+}
+
+func (s *OriginPolicyTestSuite) TestOriginClientWithProxy(c *check.C) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte("foo"))
+	}))
+	defer proxy.Close()
+
+	defer func(f func(*http.Request) (*url.URL, error)) { proxyFromEnvironment = f }(proxyFromEnvironment)
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyFromEnvironment = http.ProxyURL(proxyURL)
+
+	// the proxy in the denied network is still used, but the origins are checked before the requests.
+	p, err := NewOriginPolicy(nil, &config.OriginRule{
+		CIDRs: []string{"127.0.0.0/8"},
+		Ports: []int{22},
+	})
+	c.Assert(err, check.IsNil)
+	client := NewOriginClient(p)
+
+	_, code, err := client.GetContentLength("http://93.184.216.34/foo", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(code, check.Equals, http.StatusOK)
+	c.Check(proxied, check.DeepEquals, []string{"http://93.184.216.34/foo"})
+
+	for _, rawURL := range []string{"http://127.0.0.2/foo", "http://93.184.216.34:22/foo"} {
+		_, _, err = client.GetContentLength(rawURL, nil)
+		c.Check(errortypes.IsInvalidValue(err), check.Equals, true)
+	}
+	c.Check(proxied, check.HasLen, 1)
+}
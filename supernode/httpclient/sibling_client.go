@@ -0,0 +1,153 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/httputils"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+
+	"github.com/pkg/errors"
+)
+
+// siblingTimeout is the timeout of the requests to the sibling supernodes.
+const siblingTimeout = 3 * time.Second
+
+var (
+	// siblingReadTimeout is the timeout of each read of the cached file from the sibling supernode,
+	// so that a stalled sibling doesn't hang the CDN download.
+	siblingReadTimeout = 30 * time.Second
+
+	// siblingHTTPClient downloads the cached files from the sibling supernodes.
+	// There is no timeout of the whole download because the file may be large,
+	// and the download is aborted when reading the body stalls for siblingReadTimeout.
+	siblingHTTPClient = &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   siblingTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+)
+
+// GetSiblingCache queries the completed cache of taskID or md5 from the sibling supernode
+// whose address is in the format of ip:port, and the port is the ListenPort of the sibling.
+func GetSiblingCache(address, taskID, md5 string) (*types.CacheInfo, error) {
+	params := url.Values{}
+	params.Set("taskId", taskID)
+	if !stringutils.IsEmptyStr(md5) {
+		params.Set("md5", md5)
+	}
+
+	code, body, err := httputils.Get(fmt.Sprintf("http://%s/caches?%s", address, params.Encode()), siblingTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if !httputils.HTTPStatusOk(code) {
+		return nil, errors.Wrapf(errortypes.ErrDataNotFound, "code: %d, body: %s", code, string(body))
+	}
+
+	info := &types.CacheInfo{}
+	if err := json.Unmarshal(body, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// CreateReplica requests the sibling supernode to download the file of the task
+// as a replica, and it returns once the download is triggered.
+func CreateReplica(address string, req *types.TaskCreateRequest) (*types.TaskCreateResponse, error) {
+	code, body, err := httputils.PostJSON(fmt.Sprintf("http://%s/replicas", address), req, siblingTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if !httputils.HTTPStatusOk(code) {
+		return nil, fmt.Errorf("failed to create replica, code: %d, body: %s", code, string(body))
+	}
+
+	resp := &types.TaskCreateResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DownloadSiblingFile downloads the cached file from the sibling supernode, and the download
+// will be aborted once the ctx is done. Unlike the origins, the siblings are trusted,
+// so the file isn't downloaded by the OriginHTTPClient restricted by the OriginPolicy.
+// Reading the body fails if the sibling stalls for siblingReadTimeout, and then the CDN
+// falls back to the source as other failures of the sibling.
+func DownloadSiblingFile(ctx context.Context, fileURL string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := siblingHTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	resp.Body = newIdleTimeoutBody(resp.Body, siblingReadTimeout)
+	return resp, nil
+}
+
+// idleTimeoutBody closes the body and fails the read when a read takes longer than the timeout.
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timeout  time.Duration
+	timedOut int32
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration) *idleTimeoutBody {
+	return &idleTimeoutBody{
+		ReadCloser: body,
+		timeout:    timeout,
+	}
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	timer := time.AfterFunc(b.timeout, func() {
+		atomic.StoreInt32(&b.timedOut, 1)
+		b.ReadCloser.Close()
+	})
+	n, err := b.ReadCloser.Read(p)
+	if !timer.Stop() && atomic.LoadInt32(&b.timedOut) == 1 {
+		return n, fmt.Errorf("no data is read from the sibling within %v", b.timeout)
+	}
+	return n, err
+}
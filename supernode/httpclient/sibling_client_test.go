@@ -0,0 +1,113 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+
+	"github.com/go-check/check"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type SiblingClientTestSuite struct {
+	server *httptest.Server
+	cache  *types.CacheInfo
+}
+
+func init() {
+	check.Suite(&SiblingClientTestSuite{})
+}
+
+func (s *SiblingClientTestSuite) SetUpSuite(c *check.C) {
+	s.cache = &types.CacheInfo{TaskID: "foo", RealMd5: "bar", PieceMd5s: []string{"baz:5"}}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/caches" && r.URL.Query().Get("taskId") == s.cache.TaskID:
+			json.NewEncoder(w).Encode(s.cache)
+		case r.Method == http.MethodPost && r.URL.Path == "/replicas":
+			req := &types.TaskCreateRequest{}
+			json.NewDecoder(r.Body).Decode(req)
+			json.NewEncoder(w).Encode(&types.TaskCreateResponse{ID: req.RawURL, PieceSize: 4})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+}
+
+func (s *SiblingClientTestSuite) TearDownSuite(c *check.C) {
+	s.server.Close()
+}
+
+func (s *SiblingClientTestSuite) TestGetSiblingCache(c *check.C) {
+	address := s.server.Listener.Addr().String()
+
+	info, err := GetSiblingCache(address, "foo", "bar")
+	c.Assert(err, check.IsNil)
+	c.Check(info, check.DeepEquals, s.cache)
+
+	_, err = GetSiblingCache(address, "unknown", "")
+	c.Check(errortypes.IsDataNotFound(err), check.Equals, true)
+}
+
+func (s *SiblingClientTestSuite) TestCreateReplica(c *check.C) {
+	resp, err := CreateReplica(s.server.Listener.Addr().String(), &types.TaskCreateRequest{RawURL: "http://a.b/c"})
+	c.Assert(err, check.IsNil)
+	c.Check(resp, check.DeepEquals, &types.TaskCreateResponse{ID: "http://a.b/c", PieceSize: 4})
+
+	_, err = CreateReplica("127.0.0.1:1", &types.TaskCreateRequest{})
+	c.Check(err, check.NotNil)
+}
+
+func (s *SiblingClientTestSuite) TestDownloadSiblingFileStalled(c *check.C) {
+	stop := make(chan struct{})
+	defer close(stop)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("foo"))
+		w.(http.Flusher).Flush()
+		// the sibling stalls in the middle of the body
+		select {
+		case <-stop:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	readTimeout := siblingReadTimeout
+	siblingReadTimeout = 100 * time.Millisecond
+	defer func() {
+		siblingReadTimeout = readTimeout
+	}()
+
+	resp, err := DownloadSiblingFile(context.Background(), server.URL)
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	c.Check(string(content), check.Equals, "foo")
+	c.Check(err, check.ErrorMatches, "no data is read from the sibling within .*")
+}
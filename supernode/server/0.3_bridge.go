@@ -20,12 +20,15 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/constants"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/pkg/netutils"
 	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/task"
 	sutil "github.com/dragonflyoss/Dragonfly/supernode/util"
 
 	"github.com/go-openapi/strfmt"
@@ -39,6 +42,10 @@ type RegisterResponseData struct {
 	TaskID     string `json:"taskId"`
 	FileLength int64  `json:"fileLength"`
 	PieceSize  int32  `json:"pieceSize"`
+
+	// Node is the address of the supernode which owns the task,
+	// and it's only set when the registration is redirected.
+	Node string `json:"node,omitempty"`
 }
 
 // PullPieceTaskResponseContinueData is the data when successfully pulling piece task
@@ -69,6 +76,14 @@ var resultMap = map[string]string{
 }
 
 func (s *Server) registry(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	// reject the registration during draining, and dfget will register to the next supernode.
+	if s.IsDraining() {
+		return EncodeResponse(rw, http.StatusOK, &types.ResultInfo{
+			Code: constants.CodeSupernodeDraining,
+			Msg:  constants.GetMsgByCode(constants.CodeSupernodeDraining),
+		})
+	}
+
 	request := &types.TaskRegisterRequest{}
 
 	// parse request.Body to the types.TaskRegisterRequest struct
@@ -94,37 +109,70 @@ func (s *Server) registry(ctx context.Context, rw http.ResponseWriter, req *http
 		return errors.Wrap(errortypes.ErrInvalidValue, err.Error())
 	}
 
-	peerCreateRequest := &types.PeerCreateRequest{
-		IP:       request.IP,
-		HostName: strfmt.Hostname(request.HostName),
-		Port:     request.Port,
-		Version:  request.Version,
-	}
-	peerCreateResponse, err := s.PeerMgr.Register(ctx, peerCreateRequest)
+	// the namespace is resolved by supernode from the bearer token specified by dfget --token,
+	// or else from the callSystem. Any client can claim a callSystem, so only the tokens
+	// keep the clients out of a namespace.
+	namespace, err := s.Config.GetNamespace(request.CallSystem, getBearerToken(req))
 	if err != nil {
-		logrus.Errorf("failed to register peer %+v: %v", peerCreateRequest, err)
-		return errors.Wrapf(errortypes.ErrSystemError, "failed to register peer: %v", err)
+		return err
 	}
-	logrus.Infof("success to register peer %+v", peerCreateRequest)
 
-	peerID := peerCreateResponse.ID
 	taskCreateRequest := &types.TaskCreateRequest{
 		CID:         request.CID,
 		CallSystem:  request.CallSystem,
+		Namespace:   namespace,
 		Dfdaemon:    request.Dfdaemon,
 		Headers:     netutils.ConvertHeaders(request.Headers),
 		Identifier:  request.Identifier,
 		Md5:         request.Md5,
 		Path:        request.Path,
-		PeerID:      peerID,
 		RawURL:      request.RawURL,
 		TaskURL:     request.TaskURL,
 		SupernodeIP: request.SuperNodeIP,
+		Priority:    request.Priority,
+	}
+
+	// redirect the registration to the member of the cluster which owns the task,
+	// and the redirected one will be accepted to avoid redirecting in a loop.
+	if !request.Redirected {
+		if owner, isSelf := s.ClusterMgr.GetOwner(ctx, task.GenerateTaskID(taskCreateRequest)); !isSelf {
+			logrus.Infof("redirect the registration of %s from %s to %s", request.RawURL, request.IP, owner)
+			return EncodeResponse(rw, http.StatusOK, &types.ResultInfo{
+				Code: constants.CodeTaskRedirect,
+				Msg:  constants.GetMsgByCode(constants.CodeTaskRedirect),
+				Data: &RegisterResponseData{
+					Node: owner,
+				},
+			})
+		}
+	}
+
+	peerCreateRequest := &types.PeerCreateRequest{
+		IP:       request.IP,
+		HostName: strfmt.Hostname(request.HostName),
+		Port:     request.Port,
+		Version:  request.Version,
+	}
+	peerCreateResponse, err := s.PeerMgr.Register(ctx, peerCreateRequest)
+	if err != nil {
+		logrus.Errorf("failed to register peer %+v: %v", peerCreateRequest, err)
+		return errors.Wrapf(errortypes.ErrSystemError, "failed to register peer: %v", err)
 	}
+	logrus.Infof("success to register peer %+v", peerCreateRequest)
+
+	taskCreateRequest.PeerID = peerCreateResponse.ID
 	s.originClient.RegisterTLSConfig(taskCreateRequest.RawURL, request.Insecure, request.RootCAs)
 	resp, err := s.TaskMgr.Register(ctx, taskCreateRequest)
 	if err != nil {
 		logrus.Errorf("failed to register task %+v: %v", taskCreateRequest, err)
+		// reject the registration with a code, and dfget will register to the next supernode.
+		if errortypes.IsNamespaceQuotaExceeded(err) {
+			resultInfo := NewResultInfoWithError(err)
+			return EncodeResponse(rw, http.StatusOK, &types.ResultInfo{
+				Code: int32(resultInfo.code),
+				Msg:  resultInfo.msg,
+			})
+		}
 		return err
 	}
 	logrus.Debugf("success to register task %+v", taskCreateRequest)
@@ -139,6 +187,16 @@ func (s *Server) registry(ctx context.Context, rw http.ResponseWriter, req *http
 	})
 }
 
+// getBearerToken returns the token in the header "Authorization: Bearer <token>" of the request.
+func getBearerToken(req *http.Request) string {
+	const prefix = "Bearer "
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(auth, prefix))
+}
+
 func (s *Server) pullPieceTask(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
 	params := req.URL.Query()
 	taskID := params.Get("taskId")
@@ -220,6 +278,14 @@ func (s *Server) reportPiece(ctx context.Context, rw http.ResponseWriter, req *h
 	dstCID := params.Get("dstCid")
 	pieceRange := params.Get("pieceRange")
 
+	// cost is optional and an old dfget client will not report it.
+	var cost int64
+	if costStr := params.Get("cost"); !stringutils.IsEmptyStr(costStr) {
+		if cost, err = strconv.ParseInt(costStr, 10, 64); err != nil {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "cost: %s", costStr)
+		}
+	}
+
 	dstDfgetTask, err := s.DfgetTaskMgr.Get(ctx, dstCID, taskID)
 	if err != nil {
 		return err
@@ -234,6 +300,7 @@ func (s *Server) reportPiece(ctx context.Context, rw http.ResponseWriter, req *h
 		ClientID:    srcCID,
 		DstPID:      dstDfgetTask.PeerID,
 		PieceStatus: types.PieceUpdateRequestPieceStatusSUCCESS,
+		Cost:        cost,
 	}
 
 	if err := s.TaskMgr.UpdatePieceStatus(ctx, taskID, pieceRange, request); err != nil {
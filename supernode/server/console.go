@@ -0,0 +1,183 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/supernode/store"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// consoleEvictionLimit is the max number of the eviction records shown in the console.
+	consoleEvictionLimit = 50
+
+	// consoleTaskLimit is the max number of the tasks shown in the console.
+	consoleTaskLimit = 200
+
+	// consolePeerLimit is the max number of the peers shown in the console.
+	consolePeerLimit = 200
+)
+
+// ConsoleStatus collects the status of the tasks, peers, bandwidth, gc and cache disk
+// from the managers, which is shown in the web console.
+func (s *Server) ConsoleStatus(ctx context.Context) (*types.ConsoleStatus, error) {
+	youngGCThreshold, fullGCThreshold := s.Config.GetGCThresholds()
+	status := &types.ConsoleStatus{
+		MaxBandwidth:     int64(s.Config.GetUploadRate()),
+		YoungGCThreshold: int64(youngGCThreshold),
+		FullGCThreshold:  int64(fullGCThreshold),
+		Time:             strfmt.DateTime(time.Now()),
+	}
+	if s.fileServer != nil {
+		status.SentBytes = s.fileServer.sentBytes()
+	}
+
+	tasks, err := s.consoleTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if task.CdnStatus == types.TaskInfoCdnStatusSUCCESS {
+			status.CachedBytes += task.FileLength
+		}
+	}
+	status.TotalTasks = int64(len(tasks))
+	if len(tasks) > consoleTaskLimit {
+		tasks = tasks[:consoleTaskLimit]
+	}
+	status.Tasks = tasks
+
+	peers, err := s.consolePeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.TotalPeers = int64(len(peers))
+	if len(peers) > consolePeerLimit {
+		peers = peers[:consolePeerLimit]
+	}
+	status.Peers = peers
+
+	if status.Evictions, err = s.GCMgr.ListEvictionRecords(ctx, "", consoleEvictionLimit); err != nil {
+		return nil, err
+	}
+
+	if s.store != nil {
+		availSpace, err := s.store.GetAvailSpace(ctx, &store.Raw{})
+		if err != nil {
+			logrus.Warnf("console: failed to get the available space: %v", err)
+		}
+		status.AvailableSpace = int64(availSpace)
+	}
+	return status, nil
+}
+
+// consoleTasks returns the tasks with their CDN progress and the number of their clients,
+// and the ones with more active clients come first.
+// The tasks and the dfgetTasks are the copies returned by the managers, so they can be read without locks.
+func (s *Server) consoleTasks(ctx context.Context) ([]*types.ConsoleTask, error) {
+	taskInfos, err := s.TaskMgr.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	dfgetTasks, err := s.DfgetTaskMgr.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make(map[string]*types.ConsoleTask, len(taskInfos))
+	result := make([]*types.ConsoleTask, 0, len(taskInfos))
+	for _, info := range taskInfos {
+		task := &types.ConsoleTask{
+			TaskID:     info.ID,
+			URL:        info.RawURL,
+			CdnStatus:  info.CdnStatus,
+			FileLength: info.FileLength,
+			PieceTotal: info.PieceTotal,
+		}
+		if info.CdnStatus == types.TaskInfoCdnStatusSUCCESS {
+			task.CdnPieces = info.PieceTotal
+		} else if pieces, err := s.ProgressMgr.GetCDNPieceProgress(ctx, info.ID); err == nil {
+			task.CdnPieces = int32(len(pieces))
+		}
+		tasks[info.ID] = task
+		result = append(result, task)
+	}
+
+	for _, dfgetTask := range dfgetTasks {
+		task, ok := tasks[dfgetTask.TaskID]
+		if !ok || s.Config.IsSuperCID(dfgetTask.CID) {
+			continue
+		}
+		switch dfgetTask.Status {
+		case types.DfGetTaskStatusSUCCESS:
+			task.SucceededClients++
+		case types.DfGetTaskStatusFAILED:
+			task.FailedClients++
+		default:
+			task.ActiveClients++
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ActiveClients != result[j].ActiveClients {
+			return result[i].ActiveClients > result[j].ActiveClients
+		}
+		return result[i].TaskID < result[j].TaskID
+	})
+	return result, nil
+}
+
+// consolePeers returns the peers with their load and error counts,
+// and the ones with higher load come first.
+func (s *Server) consolePeers(ctx context.Context) ([]*types.ConsolePeer, error) {
+	peerInfos, err := s.PeerMgr.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*types.ConsolePeer, 0, len(peerInfos))
+	for _, info := range peerInfos {
+		peer := &types.ConsolePeer{
+			ID:       info.ID,
+			IP:       info.IP.String(),
+			HostName: info.HostName.String(),
+		}
+		if state, err := s.ProgressMgr.GetPeerStateByPeerID(ctx, info.ID); err == nil {
+			peer.Load = int64(state.ProducerLoad.Get())
+			peer.ClientErrors = int64(state.ClientErrorCount.Get())
+			peer.ServiceErrors = int64(state.ServiceErrorCount.Get())
+			peer.Throughput = state.Throughput
+			peer.Reputation = state.Reputation
+		}
+		result = append(result, peer)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Load != result[j].Load {
+			return result[i].Load > result[j].Load
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
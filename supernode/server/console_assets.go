@@ -0,0 +1,349 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+// consoleAsset is a static file of the web console which is embedded in supernode,
+// so that the console works without accessing any external resource.
+type consoleAsset struct {
+	contentType string
+	content     string
+}
+
+// consoleIndex is the name of the entry page of the web console.
+const consoleIndex = "index.html"
+
+// consoleAssets are the static files of the web console indexed by their names.
+var consoleAssets = map[string]*consoleAsset{
+	consoleIndex: {contentType: "text/html; charset=utf-8", content: consoleIndexHTML},
+	"style.css":  {contentType: "text/css; charset=utf-8", content: consoleStyleCSS},
+	"app.js":     {contentType: "application/javascript; charset=utf-8", content: consoleAppJS},
+}
+
+const consoleIndexHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Dragonfly Supernode</title>
+  <link rel="stylesheet" href="/console/assets/style.css">
+</head>
+<body>
+  <header>
+    <h1>Dragonfly Supernode</h1>
+    <span id="updated"></span>
+    <label><input type="checkbox" id="paused"> pause</label>
+  </header>
+  <div id="error" class="error" hidden></div>
+
+  <section class="cards">
+    <div class="card"><h3>Upload Rate</h3><p id="uploadRate">-</p><small id="maxBandwidth"></small></div>
+    <div class="card"><h3>Uploaded</h3><p id="sentBytes">-</p></div>
+    <div class="card"><h3>Available Disk</h3><p id="availableSpace">-</p><small id="gcThresholds"></small></div>
+    <div class="card"><h3>Cached</h3><p id="cachedBytes">-</p></div>
+  </section>
+
+  <section>
+    <h2>Tasks <span id="taskCount" class="count"></span></h2>
+    <table>
+      <thead><tr>
+        <th>Task ID</th><th>URL</th><th>CDN Status</th><th>Size</th><th>CDN Progress</th>
+        <th>Active</th><th>Succeeded</th><th>Failed</th>
+      </tr></thead>
+      <tbody id="tasks"></tbody>
+    </table>
+  </section>
+
+  <section>
+    <h2>Peers <span id="peerCount" class="count"></span></h2>
+    <table>
+      <thead><tr>
+        <th>Peer ID</th><th>IP</th><th>Host Name</th><th>Load</th>
+        <th>Client Errors</th><th>Service Errors</th><th>Throughput</th><th>Reputation</th>
+      </tr></thead>
+      <tbody id="peers"></tbody>
+    </table>
+  </section>
+
+  <section>
+    <h2>GC History <span id="evictionCount" class="count"></span></h2>
+    <table>
+      <thead><tr>
+        <th>Time</th><th>Type</th><th>ID</th><th>Reason</th><th>Freed</th><th>Detail</th>
+      </tr></thead>
+      <tbody id="evictions"></tbody>
+    </table>
+  </section>
+
+  <script src="/console/assets/app.js"></script>
+</body>
+</html>
+`
+
+const consoleStyleCSS = `body {
+  margin: 0;
+  font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif;
+  font-size: 14px;
+  color: #24292e;
+  background: #f6f8fa;
+}
+header {
+  display: flex;
+  align-items: center;
+  padding: 12px 24px;
+  color: #fff;
+  background: #24292e;
+}
+header h1 {
+  flex: 1;
+  margin: 0;
+  font-size: 20px;
+}
+header span {
+  margin-right: 16px;
+  color: #d1d5da;
+}
+section {
+  margin: 16px 24px;
+}
+h2 {
+  font-size: 16px;
+}
+.count {
+  color: #6a737d;
+  font-weight: normal;
+}
+.cards {
+  display: flex;
+  flex-wrap: wrap;
+}
+.card {
+  min-width: 180px;
+  margin: 0 16px 16px 0;
+  padding: 12px 16px;
+  background: #fff;
+  border: 1px solid #e1e4e8;
+  border-radius: 4px;
+}
+.card h3 {
+  margin: 0;
+  color: #6a737d;
+  font-size: 12px;
+  text-transform: uppercase;
+}
+.card p {
+  margin: 8px 0 4px;
+  font-size: 22px;
+}
+.card small {
+  color: #6a737d;
+}
+table {
+  width: 100%;
+  border-collapse: collapse;
+  background: #fff;
+  border: 1px solid #e1e4e8;
+}
+th, td {
+  padding: 6px 8px;
+  text-align: left;
+  border-bottom: 1px solid #e1e4e8;
+  white-space: nowrap;
+}
+th {
+  background: #f1f3f5;
+}
+td.wrap {
+  max-width: 480px;
+  overflow: hidden;
+  text-overflow: ellipsis;
+}
+.status-SUCCESS {
+  color: #22863a;
+}
+.status-FAILED, .status-SOURCE_ERROR {
+  color: #cb2431;
+}
+.status-RUNNING, .status-WAITING {
+  color: #b08800;
+}
+.progress {
+  display: inline-block;
+  width: 120px;
+  height: 8px;
+  margin-right: 6px;
+  background: #e1e4e8;
+  border-radius: 4px;
+}
+.progress div {
+  height: 100%;
+  background: #2188ff;
+  border-radius: 4px;
+}
+.error {
+  margin: 16px 24px;
+  padding: 8px 12px;
+  color: #86181d;
+  background: #ffdce0;
+  border: 1px solid #cb2431;
+  border-radius: 4px;
+}
+`
+
+const consoleAppJS = `(function () {
+  "use strict";
+
+  var refreshInterval = 5000;
+  var last = null;
+
+  function $(id) {
+    return document.getElementById(id);
+  }
+
+  function formatBytes(bytes) {
+    var units = ["B", "KB", "MB", "GB", "TB"];
+    var value = bytes || 0;
+    var i = 0;
+    while (value >= 1024 && i < units.length - 1) {
+      value /= 1024;
+      i++;
+    }
+    return value.toFixed(i === 0 ? 0 : 1) + " " + units[i];
+  }
+
+  function cell(text, className) {
+    var td = document.createElement("td");
+    td.textContent = text === undefined || text === null ? "" : String(text);
+    if (className) {
+      td.className = className;
+    }
+    if (className === "wrap") {
+      td.title = td.textContent;
+    }
+    return td;
+  }
+
+  function progressCell(done, total) {
+    var td = document.createElement("td");
+    var percent = total > 0 ? Math.min(100, Math.round(done * 100 / total)) : 0;
+    var bar = document.createElement("span");
+    var fill = document.createElement("div");
+    bar.className = "progress";
+    fill.style.width = percent + "%";
+    bar.appendChild(fill);
+    td.appendChild(bar);
+    td.appendChild(document.createTextNode((done || 0) + "/" + (total || "?")));
+    return td;
+  }
+
+  function render(id, items, countID, rowFunc, total) {
+    var tbody = $(id);
+    tbody.innerHTML = "";
+    (items || []).forEach(function (item) {
+      var tr = document.createElement("tr");
+      rowFunc(item).forEach(function (td) {
+        tr.appendChild(td);
+      });
+      tbody.appendChild(tr);
+    });
+    var count = (items || []).length;
+    $(countID).textContent = total > count ? "(" + count + " of " + total + ")" : "(" + count + ")";
+  }
+
+  function update(status) {
+    var now = Date.parse(status.time) || Date.now();
+    if (last && now > last.time && status.sentBytes >= last.sentBytes) {
+      $("uploadRate").textContent =
+        formatBytes((status.sentBytes - last.sentBytes) * 1000 / (now - last.time)) + "/s";
+    }
+    last = {time: now, sentBytes: status.sentBytes || 0};
+
+    $("maxBandwidth").textContent = "limit " + formatBytes(status.maxBandwidth) + "/s";
+    $("sentBytes").textContent = formatBytes(status.sentBytes);
+    $("availableSpace").textContent = formatBytes(status.availableSpace);
+    $("gcThresholds").textContent = "young gc " + formatBytes(status.youngGCThreshold) +
+      ", full gc " + formatBytes(status.fullGCThreshold);
+    $("cachedBytes").textContent = formatBytes(status.cachedBytes);
+
+    render("tasks", status.tasks, "taskCount", function (t) {
+      return [
+        cell(t.taskId, "wrap"),
+        cell(t.url, "wrap"),
+        cell(t.cdnStatus, "status-" + t.cdnStatus),
+        cell(formatBytes(t.fileLength)),
+        progressCell(t.cdnPieces, t.pieceTotal),
+        cell(t.activeClients || 0),
+        cell(t.succeededClients || 0),
+        cell(t.failedClients || 0)
+      ];
+    }, status.totalTasks);
+
+    render("peers", status.peers, "peerCount", function (p) {
+      return [
+        cell(p.ID, "wrap"),
+        cell(p.ip),
+        cell(p.hostName),
+        cell(p.load || 0),
+        cell(p.clientErrors || 0),
+        cell(p.serviceErrors || 0),
+        cell(p.throughput ? formatBytes(p.throughput) + "/s" : "-"),
+        cell(p.reputation ? p.reputation.toFixed(2) : "-")
+      ];
+    }, status.totalPeers);
+
+    render("evictions", status.evictions, "evictionCount", function (e) {
+      return [
+        cell(new Date(e.time).toLocaleString()),
+        cell(e.type),
+        cell(e.ID, "wrap"),
+        cell(e.reason),
+        cell(formatBytes(e.freedBytes)),
+        cell(e.detail, "wrap")
+      ];
+    });
+
+    $("updated").textContent = "updated at " + new Date(now).toLocaleTimeString();
+  }
+
+  function refresh() {
+    if ($("paused").checked) {
+      return;
+    }
+    var xhr = new XMLHttpRequest();
+    xhr.open("GET", "/console/status");
+    xhr.onload = function () {
+      if (xhr.status !== 200) {
+        showError("failed to get the status: " + xhr.status + " " + xhr.responseText);
+        return;
+      }
+      $("error").hidden = true;
+      update(JSON.parse(xhr.responseText));
+    };
+    xhr.onerror = function () {
+      showError("failed to get the status: supernode is unreachable");
+    };
+    xhr.send();
+  }
+
+  function showError(message) {
+    $("error").textContent = message;
+    $("error").hidden = false;
+  }
+
+  refresh();
+  setInterval(refresh, refreshInterval);
+})();
+`
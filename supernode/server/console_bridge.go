@@ -0,0 +1,51 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) getConsoleStatus(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	status, err := s.ConsoleStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	return EncodeResponse(rw, http.StatusOK, status)
+}
+
+func (s *Server) getConsoleAsset(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	name := mux.Vars(req)["name"]
+	if name == "" {
+		name = consoleIndex
+	}
+
+	asset, ok := consoleAssets[name]
+	if !ok {
+		http.NotFound(rw, req)
+		return nil
+	}
+
+	rw.Header().Set("Content-Type", asset.contentType)
+	rw.WriteHeader(http.StatusOK)
+	_, err = rw.Write([]byte(asset.content))
+	return err
+}
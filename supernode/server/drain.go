@@ -0,0 +1,127 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/sirupsen/logrus"
+)
+
+// drainCheckInterval is the interval time to check whether the active downloads finish during draining.
+const drainCheckInterval = time.Second
+
+// drainState is the drain status of the server.
+type drainState struct {
+	sync.Mutex
+	draining bool
+	deadline time.Time
+
+	// drained will be closed when all the active downloads finish or the deadline passes.
+	drained chan struct{}
+}
+
+// Drain makes the server reject the new task registrations and wait for the active downloads
+// to finish within the timeout, and the DrainTimeout will be used if the timeout is not positive.
+// It only updates the deadline if the server is already draining.
+func (s *Server) Drain(ctx context.Context, timeout time.Duration) *types.DrainStatus {
+	if timeout <= 0 {
+		timeout = s.Config.GetDrainTimeout()
+	}
+
+	s.drain.Lock()
+	draining := s.drain.draining
+	s.drain.draining = true
+	s.drain.deadline = time.Now().Add(timeout)
+	s.drain.Unlock()
+
+	if !draining {
+		logrus.Infof("start to drain, and wait for the active downloads to finish within %v", timeout)
+		go s.waitForDrained(context.Background())
+	}
+	return s.DrainStatus(ctx)
+}
+
+// IsDraining returns whether the server is draining.
+func (s *Server) IsDraining() bool {
+	s.drain.Lock()
+	defer s.drain.Unlock()
+	return s.drain.draining
+}
+
+// Drained returns a channel which will be closed when the drain completes.
+func (s *Server) Drained() <-chan struct{} {
+	return s.drain.drained
+}
+
+// DrainStatus returns the drain status of the server.
+func (s *Server) DrainStatus(ctx context.Context) *types.DrainStatus {
+	s.drain.Lock()
+	status := &types.DrainStatus{Draining: s.drain.draining}
+	if s.drain.draining {
+		status.Deadline = strfmt.DateTime(s.drain.deadline)
+	}
+	s.drain.Unlock()
+
+	status.ActiveDownloads = s.countActiveDownloads(ctx)
+	return status
+}
+
+// waitForDrained closes the drained channel once all the active downloads finish or the deadline passes.
+func (s *Server) waitForDrained(ctx context.Context) {
+	for {
+		active := s.countActiveDownloads(ctx)
+		if active == 0 {
+			logrus.Info("success to drain, all the active downloads finish")
+			break
+		}
+
+		s.drain.Lock()
+		deadline := s.drain.deadline
+		s.drain.Unlock()
+		if time.Now().After(deadline) {
+			logrus.Warnf("drain deadline passes, and there are still %d active downloads", active)
+			break
+		}
+		time.Sleep(drainCheckInterval)
+	}
+	close(s.drain.drained)
+}
+
+// countActiveDownloads returns the number of the dfget tasks which are waiting or running,
+// excluding the ones of the supernode itself.
+func (s *Server) countActiveDownloads(ctx context.Context) int64 {
+	var count int64
+	for _, status := range []string{types.DfGetTaskStatusWAITING, types.DfGetTaskStatusRUNNING} {
+		dfgetTasks, err := s.DfgetTaskMgr.List(ctx, map[string]string{"status": status})
+		if err != nil {
+			logrus.Errorf("failed to list the %s dfget tasks: %v", status, err)
+			continue
+		}
+		for _, dfgetTask := range dfgetTasks {
+			if !s.Config.IsSuperCID(dfgetTask.CID) {
+				count++
+			}
+		}
+	}
+	return count
+}
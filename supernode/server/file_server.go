@@ -0,0 +1,311 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/metricsutils"
+	"github.com/dragonflyoss/Dragonfly/pkg/ratelimiter"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// fileServerChunkSize is the max number of bytes sent to a connection
+// after acquiring the tokens from the rate limiter once.
+const fileServerChunkSize = 256 * 1024
+
+// fileServerMetrics defines some prometheus metrics for monitoring the file server.
+type fileServerMetrics struct {
+	requests           *prometheus.CounterVec
+	sentBytes          *prometheus.CounterVec
+	namespaceSentBytes *prometheus.CounterVec
+	activeRequests     *prometheus.GaugeVec
+}
+
+func newFileServerMetrics(register prometheus.Registerer) *fileServerMetrics {
+	return &fileServerMetrics{
+		requests: metricsutils.NewCounter(config.SubsystemSupernode, "file_server_requests_total",
+			"Total number of requests served by the file server", []string{"code"}, register),
+		sentBytes: metricsutils.NewCounter(config.SubsystemSupernode, "file_server_sent_bytes_total",
+			"Total number of bytes sent by the file server", []string{}, register),
+		namespaceSentBytes: metricsutils.NewCounter(config.SubsystemSupernode, "file_server_namespace_sent_bytes_total",
+			"Total number of bytes sent by the file server by namespace", []string{"namespace"}, register),
+		activeRequests: metricsutils.NewGauge(config.SubsystemSupernode, "file_server_active_requests",
+			"Current number of requests being served by the file server", []string{}, register),
+	}
+}
+
+// fileServer serves the files downloaded by CDN on the DownloadPort,
+// which are requested by the path returned from CDNMgr.GetHTTPPath.
+// The bandwidth of MaxBandwidth-SystemReservedBandwidth is shared fairly by the connections,
+// and the files of the namespace with a bandwidthShare can only use that percentage of it.
+type fileServer struct {
+	cfg     *config.Config
+	limiter *ratelimiter.WeightedFairLimiter
+	metrics *fileServerMetrics
+
+	// taskMgr finds the namespaces of the files, and it's nil if the namespaces are not limited.
+	taskMgr mgr.TaskMgr
+	// namespaceLimiters limit the bandwidth of the namespaces with a bandwidthShare.
+	namespaceLimiters map[string]*ratelimiter.RateLimiter
+
+	// sent is the total number of bytes sent by the file server.
+	sent int64
+
+	// httpServer is the running http server which will be nil until the file server starts.
+	httpServer *http.Server
+	lock       sync.Mutex
+}
+
+func newFileServer(cfg *config.Config, taskMgr mgr.TaskMgr, register prometheus.Registerer) *fileServer {
+	rate := int64(cfg.GetUploadRate())
+	namespaceLimiters := make(map[string]*ratelimiter.RateLimiter)
+	for _, ns := range cfg.Namespaces {
+		if ns.BandwidthShare > 0 {
+			namespaceLimiters[ns.Name] = ratelimiter.NewRateLimiter(
+				ratelimiter.TransRate(rate*int64(ns.BandwidthShare)/100), 2)
+		}
+	}
+
+	return &fileServer{
+		cfg:               cfg,
+		limiter:           ratelimiter.NewWeightedFairLimiter(ratelimiter.NewRateLimiter(ratelimiter.TransRate(rate), 2)),
+		metrics:           newFileServerMetrics(register),
+		taskMgr:           taskMgr,
+		namespaceLimiters: namespaceLimiters,
+	}
+}
+
+// setRate sets the bandwidth in bytes per second shared by the connections.
+func (fs *fileServer) setRate(rate int64) {
+	fs.limiter.SetRate(ratelimiter.TransRate(rate))
+	for _, ns := range fs.cfg.Namespaces {
+		if limiter, ok := fs.namespaceLimiters[ns.Name]; ok {
+			limiter.SetRate(ratelimiter.TransRate(rate * int64(ns.BandwidthShare) / 100))
+		}
+	}
+}
+
+// start listens on the DownloadPort and serves the files in background.
+func (fs *fileServer) start() error {
+	address := fmt.Sprintf("0.0.0.0:%d", fs.cfg.DownloadPort)
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		logrus.Errorf("failed to listen download port %d: %v", fs.cfg.DownloadPort, err)
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           fs,
+		ReadHeaderTimeout: time.Minute,
+		IdleTimeout:       time.Minute,
+	}
+	fs.lock.Lock()
+	fs.httpServer = server
+	fs.lock.Unlock()
+
+	go func() {
+		if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("failed to serve files on download port %d: %v", fs.cfg.DownloadPort, err)
+		}
+	}()
+	return nil
+}
+
+// stop stops accepting new requests and waits for the active requests to finish.
+func (fs *fileServer) stop(ctx context.Context) error {
+	fs.lock.Lock()
+	server := fs.httpServer
+	fs.lock.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+// ServeHTTP serves the file with Range support.
+func (fs *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fs.metrics.activeRequests.WithLabelValues().Inc()
+	defer fs.metrics.activeRequests.WithLabelValues().Dec()
+
+	namespace := fs.getNamespace(r.URL.Path)
+	lw := &limitedResponseWriter{
+		ResponseWriter:   w,
+		limiter:          fs.limiter,
+		namespaceLimiter: fs.namespaceLimiters[namespace],
+		flow:             r.RemoteAddr,
+		code:             http.StatusOK,
+	}
+	fs.serveFile(lw, r)
+
+	fs.metrics.requests.WithLabelValues(strconv.Itoa(lw.code)).Inc()
+	fs.metrics.sentBytes.WithLabelValues().Add(float64(lw.written))
+	fs.metrics.namespaceSentBytes.WithLabelValues(namespace).Add(float64(lw.written))
+	atomic.AddInt64(&fs.sent, lw.written)
+}
+
+// sentBytes returns the total number of bytes sent by the file server.
+func (fs *fileServer) sentBytes() int64 {
+	return atomic.LoadInt64(&fs.sent)
+}
+
+func (fs *fileServer) serveFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	filePath, ok := fs.getFilePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		logrus.Errorf("failed to open file %s: %v", filePath, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	// set the content type to avoid sniffing the content of the file.
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+}
+
+// getNamespace returns the namespace of the task whose file is requested by the url path,
+// and the file of the task which is not found belongs to the default namespace.
+func (fs *fileServer) getNamespace(urlPath string) string {
+	if fs.taskMgr == nil {
+		return config.DefaultNamespace
+	}
+
+	task, err := fs.taskMgr.Get(context.Background(), path.Base(urlPath))
+	if err != nil || task.Namespace == "" {
+		return config.DefaultNamespace
+	}
+	return task.Namespace
+}
+
+// getFilePath returns the path of the file in DownloadPath according to the url path.
+// And false will be returned if the url path isn't under the DownloadHome.
+func (fs *fileServer) getFilePath(urlPath string) (string, bool) {
+	prefix := "/" + config.DownloadHome + "/"
+	p := path.Clean("/" + urlPath)
+	if !strings.HasPrefix(p, prefix) {
+		return "", false
+	}
+	return filepath.Join(fs.cfg.DownloadPath, filepath.FromSlash(strings.TrimPrefix(p, prefix))), true
+}
+
+// limitedResponseWriter limits the rate of writing the response,
+// and records the status code and the number of the written bytes.
+type limitedResponseWriter struct {
+	http.ResponseWriter
+
+	limiter *ratelimiter.WeightedFairLimiter
+	// namespaceLimiter limits the bandwidth of the namespace of the file, and it's nil if unlimited.
+	namespaceLimiter *ratelimiter.RateLimiter
+	// flow identifies the connection which shares the bandwidth with the others fairly.
+	flow string
+
+	code    int
+	written int64
+}
+
+func (w *limitedResponseWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *limitedResponseWriter) Write(p []byte) (int, error) {
+	w.acquire(int64(len(p)))
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// acquire blocks until the tokens of size bytes are acquired from the limiters.
+func (w *limitedResponseWriter) acquire(size int64) {
+	if w.namespaceLimiter != nil {
+		w.namespaceLimiter.AcquireBlocking(size)
+	}
+	w.limiter.AcquireBlocking(w.flow, 1, size)
+}
+
+// ReadFrom sends the content of r by chunks after acquiring the tokens of each chunk.
+// The underlying ResponseWriter reads from the file with sendfile if possible,
+// so r should be an *os.File or an *io.LimitedReader of an *os.File.
+func (w *limitedResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	src, remaining := r, int64(-1)
+	lr, ok := r.(*io.LimitedReader)
+	if ok {
+		src, remaining = lr.R, lr.N
+	}
+
+	var total int64
+	for remaining != 0 {
+		size := int64(fileServerChunkSize)
+		if remaining > 0 && remaining < size {
+			size = remaining
+		}
+		w.acquire(size)
+
+		n, err := io.Copy(w.ResponseWriter, &io.LimitedReader{R: src, N: size})
+		total += n
+		w.written += n
+		if remaining > 0 {
+			remaining -= n
+		}
+		if ok {
+			lr.N = remaining
+		}
+		if err != nil || n < size {
+			return total, err
+		}
+	}
+	return total, nil
+}
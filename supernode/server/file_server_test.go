@@ -0,0 +1,169 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/rate"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+
+	"github.com/go-check/check"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func init() {
+	check.Suite(&FileServerTestSuite{})
+}
+
+type FileServerTestSuite struct {
+	workHome   string
+	fileServer *fileServer
+	server     *httptest.Server
+}
+
+func (s *FileServerTestSuite) SetUpSuite(c *check.C) {
+	var err error
+	s.workHome, err = ioutil.TempDir("/tmp", "supernode-FileServerTestSuite-")
+	c.Assert(err, check.IsNil)
+
+	cfg := config.NewConfig()
+	cfg.DownloadPath = filepath.Join(s.workHome, "repo", config.DownloadHome)
+	cfg.MaxBandwidth = 10 * rate.MB
+	cfg.SystemReservedBandwidth = 0
+	c.Assert(os.MkdirAll(filepath.Join(cfg.DownloadPath, "abc"), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(cfg.DownloadPath, "abc", "abcdef"),
+		[]byte("0123456789"), 0644), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.workHome, "repo", "secret"),
+		[]byte("secret"), 0644), check.IsNil)
+
+	s.fileServer = newFileServer(cfg, nil, prometheus.NewRegistry())
+	s.server = httptest.NewServer(s.fileServer)
+}
+
+func (s *FileServerTestSuite) TearDownSuite(c *check.C) {
+	s.server.Close()
+	if s.workHome != "" {
+		os.RemoveAll(s.workHome)
+	}
+}
+
+func (s *FileServerTestSuite) get(c *check.C, method, path, rangeHeader string) (int, string) {
+	req, err := http.NewRequest(method, s.server.URL+path, nil)
+	c.Assert(err, check.IsNil)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, check.IsNil)
+	return resp.StatusCode, string(body)
+}
+
+func (s *FileServerTestSuite) TestServeFile(c *check.C) {
+	var cases = []struct {
+		method      string
+		path        string
+		rangeHeader string
+		code        int
+		body        string
+	}{
+		{http.MethodGet, "/download/abc/abcdef", "", http.StatusOK, "0123456789"},
+		{http.MethodGet, "/download/abc/abcdef", "bytes=2-5", http.StatusPartialContent, "2345"},
+		{http.MethodGet, "/download/abc/abcdef", "bytes=8-", http.StatusPartialContent, "89"},
+		{http.MethodGet, "/download/abc/abcdef", "bytes=20-30", http.StatusRequestedRangeNotSatisfiable, ""},
+		{http.MethodHead, "/download/abc/abcdef", "", http.StatusOK, ""},
+		{http.MethodGet, "/download/abc/notExist", "", http.StatusNotFound, ""},
+		{http.MethodGet, "/download/abc", "", http.StatusNotFound, ""},
+		{http.MethodGet, "/secret", "", http.StatusNotFound, ""},
+		{http.MethodGet, "/download/../secret", "", http.StatusNotFound, ""},
+		{http.MethodPost, "/download/abc/abcdef", "", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, ca := range cases {
+		code, body := s.get(c, ca.method, ca.path, ca.rangeHeader)
+		c.Check(code, check.Equals, ca.code, check.Commentf("%s %s %s", ca.method, ca.path, ca.rangeHeader))
+		if ca.body != "" {
+			c.Check(body, check.Equals, ca.body)
+		}
+		c.Check(strings.Contains(body, "secret"), check.Equals, false)
+	}
+}
+
+func (s *FileServerTestSuite) TestMetrics(c *check.C) {
+	m := s.fileServer.metrics
+	requests := prom_testutil.ToFloat64(m.requests.WithLabelValues("206"))
+	sentBytes := prom_testutil.ToFloat64(m.sentBytes.WithLabelValues())
+
+	req := httptest.NewRequest(http.MethodGet, "/download/abc/abcdef", nil)
+	req.Header.Set("Range", "bytes=0-3")
+	rw := httptest.NewRecorder()
+	s.fileServer.ServeHTTP(rw, req)
+	c.Assert(rw.Code, check.Equals, http.StatusPartialContent)
+	c.Check(prom_testutil.ToFloat64(m.requests.WithLabelValues("206")), check.Equals, requests+1)
+	c.Check(prom_testutil.ToFloat64(m.sentBytes.WithLabelValues()), check.Equals, sentBytes+4)
+	c.Check(prom_testutil.ToFloat64(m.activeRequests.WithLabelValues()), check.Equals, float64(0))
+}
+
+// namespaceTaskMgr is a TaskMgr which only gets the tasks with their namespaces.
+type namespaceTaskMgr struct {
+	mgr.TaskMgr
+	namespaces map[string]string
+}
+
+func (tm *namespaceTaskMgr) Get(ctx context.Context, taskID string) (*types.TaskInfo, error) {
+	if namespace, ok := tm.namespaces[taskID]; ok {
+		return &types.TaskInfo{ID: taskID, Namespace: namespace}, nil
+	}
+	return nil, errortypes.ErrDataNotFound
+}
+
+func (s *FileServerTestSuite) TestNamespace(c *check.C) {
+	cfg := config.NewConfig()
+	cfg.DownloadPath = s.fileServer.cfg.DownloadPath
+	cfg.Namespaces = []*config.NamespaceConfig{
+		{Name: "datasets", BandwidthShare: 50},
+		{Name: "images"},
+	}
+	taskMgr := &namespaceTaskMgr{namespaces: map[string]string{"abcdef": "datasets"}}
+	fs := newFileServer(cfg, taskMgr, prometheus.NewRegistry())
+	c.Check(fs.namespaceLimiters, check.HasLen, 1)
+	c.Check(fs.namespaceLimiters["datasets"], check.NotNil)
+	fs.setRate(int64(20 * rate.MB))
+
+	c.Check(fs.getNamespace("/download/abc/abcdef"), check.Equals, "datasets")
+	c.Check(fs.getNamespace("/download/abc/abcxyz"), check.Equals, config.DefaultNamespace)
+
+	rw := httptest.NewRecorder()
+	fs.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/download/abc/abcdef", nil))
+	c.Assert(rw.Code, check.Equals, http.StatusOK)
+	c.Check(rw.Body.String(), check.Equals, "0123456789")
+	c.Check(prom_testutil.ToFloat64(fs.metrics.namespaceSentBytes.WithLabelValues("datasets")), check.Equals, float64(10))
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+
+	"github.com/pkg/errors"
+)
+
+func (s *Server) gcDryRun(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	result, err := s.GCMgr.DryRun(ctx)
+	if err != nil {
+		return err
+	}
+
+	return EncodeResponse(rw, http.StatusOK, result)
+}
+
+func (s *Server) listEvictionRecords(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	params := req.URL.Query()
+
+	var limit int
+	if v := params.Get("limit"); !stringutils.IsEmptyStr(v) {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "limit: %v", err)
+		}
+	}
+
+	records, err := s.GCMgr.ListEvictionRecords(ctx, params.Get("id"), limit)
+	if err != nil {
+		return err
+	}
+
+	return EncodeResponse(rw, http.StatusOK, records)
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/store"
+)
+
+// healthProbeRaw is the probe file written to check whether the store is writable.
+var healthProbeRaw = &store.Raw{
+	Bucket: config.HealthHome,
+	Key:    "probe",
+	Trunc:  true,
+}
+
+// healthSeverity ranks the health status to find the worst one.
+var healthSeverity = map[string]int{
+	types.ComponentHealthStatusHealthy:   0,
+	types.ComponentHealthStatusDegraded:  1,
+	types.ComponentHealthStatusUnhealthy: 2,
+}
+
+// CheckHealth checks the health of the components of supernode,
+// and the overall status is the worst status of them.
+func (s *Server) CheckHealth(ctx context.Context) *types.HealthStatus {
+	components := []*types.ComponentHealth{
+		s.checkStore(ctx),
+		s.GCMgr.CheckHealth(ctx),
+		s.PieceErrorMgr.CheckHealth(ctx),
+		s.TaskMgr.CheckHealth(ctx),
+	}
+	components = append(components, s.checkOrigins(ctx)...)
+
+	status := types.HealthStatusStatusHealthy
+	for _, c := range components {
+		if healthSeverity[c.Status] > healthSeverity[status] {
+			status = c.Status
+		}
+	}
+	return &types.HealthStatus{
+		Status:     status,
+		Components: components,
+	}
+}
+
+// checkStore checks whether the store is writable and has enough available space.
+// It's degraded if the available space is less than YoungGCThreshold,
+// and unhealthy if it's less than FullGCThreshold.
+func (s *Server) checkStore(ctx context.Context) *types.ComponentHealth {
+	health := &types.ComponentHealth{
+		Name:    "store",
+		Status:  types.ComponentHealthStatusHealthy,
+		Details: make(map[string]string),
+	}
+	if s.store == nil {
+		health.Status = types.ComponentHealthStatusUnhealthy
+		health.Message = "the store is not initialized"
+		return health
+	}
+
+	if err := s.store.PutBytes(ctx, healthProbeRaw, []byte("OK")); err != nil {
+		health.Status = types.ComponentHealthStatusUnhealthy
+		health.Message = fmt.Sprintf("the store is not writable: %v", err)
+		return health
+	}
+	s.store.Remove(ctx, healthProbeRaw)
+
+	availSpace, err := s.store.GetAvailSpace(ctx, &store.Raw{})
+	if err != nil {
+		health.Status = types.ComponentHealthStatusUnhealthy
+		health.Message = fmt.Sprintf("failed to get the available space: %v", err)
+		return health
+	}
+	health.Details["availSpace"] = fileutils.FsizeToString(availSpace)
+	youngGCThreshold, fullGCThreshold := s.Config.GetGCThresholds()
+	if availSpace <= fullGCThreshold {
+		health.Status = types.ComponentHealthStatusUnhealthy
+		health.Message = fmt.Sprintf("the available space is less than %s", fileutils.FsizeToString(fullGCThreshold))
+	} else if availSpace <= youngGCThreshold {
+		health.Status = types.ComponentHealthStatusDegraded
+		health.Message = fmt.Sprintf("the available space is less than %s", fileutils.FsizeToString(youngGCThreshold))
+	}
+	return health
+}
+
+// checkOrigins probes the HealthOriginProbes concurrently,
+// and the origin is degraded if it's unreachable or responds with a server error.
+func (s *Server) checkOrigins(ctx context.Context) []*types.ComponentHealth {
+	probes := s.Config.GetHealthOriginProbes()
+	components := make([]*types.ComponentHealth, len(probes))
+
+	var wg sync.WaitGroup
+	for i, url := range probes {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			health := &types.ComponentHealth{
+				Name:    "origin",
+				Status:  types.ComponentHealthStatusHealthy,
+				Details: map[string]string{"url": url},
+			}
+			_, code, err := s.originClient.GetContentLength(url, nil)
+			if err != nil {
+				health.Status = types.ComponentHealthStatusDegraded
+				health.Message = fmt.Sprintf("the origin is unreachable: %v", err)
+			} else if code >= http.StatusInternalServerError {
+				health.Status = types.ComponentHealthStatusDegraded
+				health.Message = fmt.Sprintf("the origin responds with status code %d", code)
+			}
+			components[i] = health
+		}(i, url)
+	}
+	wg.Wait()
+	return components
+}
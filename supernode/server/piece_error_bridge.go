@@ -20,16 +20,24 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
 	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/pieceerror"
 
 	"github.com/go-openapi/strfmt"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 )
 
+const (
+	defaultPieceErrorsWindow = time.Hour
+	defaultPieceErrorsLimit  = 20
+)
+
 func (s *Server) handlePieceError(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
 	taskID := mux.Vars(req)["id"]
 	pieceRange := mux.Vars(req)["pieceRange"]
@@ -63,3 +71,33 @@ func (s *Server) handlePieceError(ctx context.Context, rw http.ResponseWriter, r
 	rw.WriteHeader(http.StatusOK)
 	return nil
 }
+
+func (s *Server) listTopPieceErrors(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	params := req.URL.Query()
+
+	groupBy := pieceerror.GroupByDstPid
+	if v := params.Get("groupBy"); !stringutils.IsEmptyStr(v) {
+		groupBy = v
+	}
+
+	window := defaultPieceErrorsWindow
+	if v := params.Get("window"); !stringutils.IsEmptyStr(v) {
+		if window, err = time.ParseDuration(v); err != nil {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "window: %v", err)
+		}
+	}
+
+	limit := defaultPieceErrorsLimit
+	if v := params.Get("limit"); !stringutils.IsEmptyStr(v) {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "limit: %v", err)
+		}
+	}
+
+	stats, err := s.PieceErrorMgr.ListTopPieceErrors(ctx, params.Get("errorType"), groupBy, window, limit)
+	if err != nil {
+		return err
+	}
+
+	return EncodeResponse(rw, http.StatusOK, stats)
+}
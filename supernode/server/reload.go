@@ -0,0 +1,104 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ConfigLoader loads the latest configuration of supernode,
+// which is usually read from the configuration file and the command line flags.
+type ConfigLoader func() (*config.Config, error)
+
+// SetConfigLoader sets the loader used to reload the configuration.
+func (s *Server) SetConfigLoader(loader ConfigLoader) {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+	s.configLoader = loader
+}
+
+// ReloadConfig loads the latest configuration and applies the changed properties
+// which can be reloaded at runtime. The other changed properties are only reported
+// and will take effect after a restart.
+func (s *Server) ReloadConfig(ctx context.Context) (*types.ConfigReloadResult, error) {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	if s.configLoader == nil {
+		return nil, errors.Wrap(errortypes.ErrNotInitialized, "config loader")
+	}
+	cfg, err := s.configLoader()
+	if err != nil {
+		return nil, errors.Wrapf(errortypes.ErrInvalidValue, "load config: %v", err)
+	}
+
+	applied, restartRequired := s.Config.Diff(cfg)
+	s.Config.Apply(cfg, applied)
+	s.applyConfig(ctx, applied)
+
+	if len(applied) > 0 {
+		logrus.Infof("success to reload config, applied: %v", applied)
+	}
+	if len(restartRequired) > 0 {
+		logrus.Warnf("the changed config %v will take effect after a restart", restartRequired)
+	}
+	return &types.ConfigReloadResult{
+		Applied:         applied,
+		RestartRequired: restartRequired,
+	}, nil
+}
+
+// applyConfig notifies the components which don't read the given properties on each use.
+func (s *Server) applyConfig(ctx context.Context, names []string) {
+	var bandwidthChanged, watermarksChanged bool
+	for _, name := range names {
+		switch name {
+		case "maxBandwidth", "systemReservedBandwidth":
+			bandwidthChanged = true
+		case "youngGCThreshold", "fullGCThreshold":
+			watermarksChanged = true
+		case "debug":
+			level := logrus.InfoLevel
+			if s.Config.GetDebug() {
+				level = logrus.DebugLevel
+			}
+			logrus.SetLevel(level)
+			if dfgetLogger != nil {
+				dfgetLogger.SetLevel(level)
+			}
+		}
+	}
+
+	if bandwidthChanged {
+		rate := int64(s.Config.GetUploadRate())
+		s.CDNMgr.SetRate(rate)
+		if s.fileServer != nil {
+			s.fileServer.setRate(rate)
+		}
+	}
+	if watermarksChanged && s.store != nil {
+		youngGCThreshold, fullGCThreshold := s.Config.GetGCThresholds()
+		s.store.UpdateSpaceWatermarks(ctx, youngGCThreshold, fullGCThreshold)
+	}
+}
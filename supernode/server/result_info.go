@@ -54,6 +54,14 @@ func NewResultInfoWithError(err error) ResultInfo {
 		return NewResultInfoWithCodeError(constants.CodeURLNotReachable, err)
 	}
 
+	if errortypes.IsSupernodeDraining(err) {
+		return NewResultInfoWithCodeError(constants.CodeSupernodeDraining, err)
+	}
+
+	if errortypes.IsNamespaceQuotaExceeded(err) {
+		return NewResultInfoWithCodeError(constants.CodeNamespaceQuotaExceeded, err)
+	}
+
 	// IsConvertFailed
 	return NewResultInfoWithCodeError(constants.CodeSystemError, err)
 }
@@ -40,7 +40,11 @@ func initRoute(s *Server) *mux.Router {
 	handlers := []*HandlerSpec{
 		// system
 		{Method: http.MethodGet, Path: "/_ping", HandlerFunc: s.ping},
+		{Method: http.MethodGet, Path: "/healthz", HandlerFunc: s.healthz},
 		{Method: http.MethodGet, Path: "/version", HandlerFunc: version.HandlerWithCtx},
+		{Method: http.MethodPut, Path: "/config", HandlerFunc: s.reloadConfig},
+		{Method: http.MethodPost, Path: "/drain", HandlerFunc: s.startDrain},
+		{Method: http.MethodGet, Path: "/drain", HandlerFunc: s.getDrainStatus},
 
 		// v0.3
 		{Method: http.MethodPost, Path: "/peer/registry", HandlerFunc: s.registry},
@@ -58,9 +62,28 @@ func initRoute(s *Server) *mux.Router {
 
 		// task
 		{Method: http.MethodDelete, Path: "/tasks/{id}", HandlerFunc: s.deleteTask},
+		{Method: http.MethodDelete, Path: "/tasks/{id}/cdn", HandlerFunc: s.cancelCDN},
+		{Method: http.MethodGet, Path: "/tasks/{id}/holders", HandlerFunc: s.getPieceHolders},
 
 		// piece
 		{Method: http.MethodGet, Path: "/tasks/{id}/pieces/{pieceRange}/error", HandlerFunc: s.handlePieceError},
+		{Method: http.MethodGet, Path: "/pieces/errors/top", HandlerFunc: s.listTopPieceErrors},
+
+		// cdn
+		{Method: http.MethodGet, Path: "/caches", HandlerFunc: s.getCache},
+		{Method: http.MethodPost, Path: "/replicas", HandlerFunc: s.createReplica},
+
+		// cluster
+		{Method: http.MethodGet, Path: "/cluster/members", HandlerFunc: s.listClusterMembers},
+
+		// gc
+		{Method: http.MethodGet, Path: "/gc/dryrun", HandlerFunc: s.gcDryRun},
+		{Method: http.MethodGet, Path: "/gc/evictions", HandlerFunc: s.listEvictionRecords},
+
+		// console
+		{Method: http.MethodGet, Path: "/console", HandlerFunc: s.getConsoleAsset},
+		{Method: http.MethodGet, Path: "/console/status", HandlerFunc: s.getConsoleStatus},
+		{Method: http.MethodGet, Path: "/console/assets/{name}", HandlerFunc: s.getConsoleAsset},
 
 		// metrics
 		{Method: http.MethodGet, Path: "/metrics", HandlerFunc: handleMetrics},
@@ -75,7 +98,7 @@ func initRoute(s *Server) *mux.Router {
 		}
 	}
 
-	if s.Config.Debug || s.Config.EnableProfiler {
+	if s.Config.GetDebug() || s.Config.EnableProfiler {
 		r.PathPrefix("/debug/pprof/cmdline").HandlerFunc(pprof.Cmdline)
 		r.PathPrefix("/debug/pprof/profile").HandlerFunc(pprof.Profile)
 		r.PathPrefix("/debug/pprof/symbol").HandlerFunc(pprof.Symbol)
@@ -17,19 +17,34 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/constants"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
 	"github.com/dragonflyoss/Dragonfly/pkg/httputils"
+	"github.com/dragonflyoss/Dragonfly/pkg/rate"
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/cluster"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/dfgettask"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/gc"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/peer"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/progress"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/webhook"
+	"github.com/dragonflyoss/Dragonfly/supernode/httpclient"
 	"github.com/dragonflyoss/Dragonfly/version"
 
 	"github.com/go-check/check"
@@ -52,6 +67,7 @@ type RouterTestSuite struct {
 	addr     string
 	listener net.Listener
 	router   *mux.Router
+	server   *Server
 }
 
 func (rs *RouterTestSuite) SetUpSuite(c *check.C) {
@@ -79,6 +95,7 @@ func (rs *RouterTestSuite) SetUpSuite(c *check.C) {
 		GoVersion: runtime.Version(),
 	}
 
+	rs.server = s
 	rs.router = initRoute(s)
 	rs.listener, err = net.Listen("tcp", rs.addr)
 	c.Check(err, check.IsNil)
@@ -148,3 +165,392 @@ func (rs *RouterTestSuite) TestHTTPMetrics(c *check.C) {
 			int(prom_testutil.ToFloat64(counter.WithLabelValues(strconv.Itoa(http.StatusOK), "/_ping"))))
 	}
 }
+
+func (rs *RouterTestSuite) TestGCHandler(c *check.C) {
+	code, res, err := httputils.Get("http://"+rs.addr+"/gc/dryrun", 0)
+	c.Check(err, check.IsNil)
+	c.Assert(code, check.Equals, 200)
+	result := &types.GCDryRunResult{}
+	c.Assert(json.Unmarshal(res, result), check.IsNil)
+	c.Check(result.Candidates, check.HasLen, 0)
+
+	code, res, err = httputils.Get("http://"+rs.addr+"/gc/evictions?limit=10", 0)
+	c.Check(err, check.IsNil)
+	c.Assert(code, check.Equals, 200)
+	var records []*types.EvictionRecord
+	c.Assert(json.Unmarshal(res, &records), check.IsNil)
+	c.Check(records, check.HasLen, 0)
+
+	code, _, err = httputils.Get("http://"+rs.addr+"/gc/evictions?limit=foo", 0)
+	c.Check(err, check.IsNil)
+	c.Assert(code, check.Equals, 500)
+}
+
+func (rs *RouterTestSuite) TestPieceErrorsHandler(c *check.C) {
+	code, res, err := httputils.Get("http://"+rs.addr+"/pieces/errors/top?errorType=FILE_MD5_NOT_MATCH&window=30m", 0)
+	c.Check(err, check.IsNil)
+	c.Assert(code, check.Equals, 200)
+	var stats []*types.PieceErrorStat
+	c.Assert(json.Unmarshal(res, &stats), check.IsNil)
+	c.Check(stats, check.HasLen, 0)
+
+	for _, query := range []string{"groupBy=foo", "window=foo", "window=48h", "limit=foo"} {
+		code, _, err = httputils.Get("http://"+rs.addr+"/pieces/errors/top?"+query, 0)
+		c.Check(err, check.IsNil)
+		c.Assert(code, check.Equals, 500)
+	}
+}
+
+func (rs *RouterTestSuite) TestReloadConfigHandler(c *check.C) {
+	resp, err := httputils.HTTPWithHeaders(http.MethodPut, "http://"+rs.addr+"/config", nil, 0, nil)
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, 500)
+
+	base := *rs.server.Config.BaseProperties
+	defer func() {
+		*rs.server.Config.BaseProperties = base
+	}()
+	rs.server.SetConfigLoader(func() (*config.Config, error) {
+		properties := base
+		properties.PeerUpLimit = base.PeerUpLimit + 1
+		properties.MaxBandwidth = 100 * rate.MB
+		properties.ListenPort = base.ListenPort + 1
+		return &config.Config{BaseProperties: &properties}, nil
+	})
+	defer rs.server.SetConfigLoader(nil)
+
+	resp, err = httputils.HTTPWithHeaders(http.MethodPut, "http://"+rs.addr+"/config", nil, 0, nil)
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, 200)
+	result := &types.ConfigReloadResult{}
+	c.Assert(json.NewDecoder(resp.Body).Decode(result), check.IsNil)
+	c.Check(result.Applied, check.DeepEquals, []string{"peerUpLimit", "maxBandwidth"})
+	c.Check(result.RestartRequired, check.DeepEquals, []string{"listenPort"})
+	c.Check(rs.server.Config.PeerUpLimit, check.Equals, base.PeerUpLimit+1)
+	c.Check(rs.server.Config.MaxBandwidth, check.Equals, 100*rate.MB)
+	c.Check(rs.server.Config.ListenPort, check.Equals, base.ListenPort)
+}
+
+func (rs *RouterTestSuite) TestDrainHandler(c *check.C) {
+	ctx := context.Background()
+	cfg := config.NewConfig()
+	cfg.SetCIDPrefix("127.0.0.1")
+	webhookMgr, err := webhook.NewManager(cfg, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+	dfgetTaskMgr, err := dfgettask.NewManager(cfg, webhookMgr, prometheus.NewRegistry())
+	c.Assert(err, check.IsNil)
+	s := &Server{
+		Config:       cfg,
+		DfgetTaskMgr: dfgetTaskMgr,
+		drain:        drainState{drained: make(chan struct{})},
+	}
+	serve := func(handler Handler, method, url string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		filter(handler).ServeHTTP(rw, httptest.NewRequest(method, url, nil))
+		return rw
+	}
+
+	c.Assert(dfgetTaskMgr.Add(ctx, &types.DfGetTask{CID: "foo", Path: "/peer/file/foo", TaskID: "task",
+		PeerID: "peer", Status: types.DfGetTaskStatusRUNNING}), check.IsNil)
+	c.Assert(dfgetTaskMgr.Add(ctx, &types.DfGetTask{CID: cfg.GetSuperCID("task"), Path: "/peer/file/foo",
+		TaskID: "task", PeerID: "super"}), check.IsNil)
+
+	c.Check(serve(s.ping, http.MethodGet, "/_ping").Code, check.Equals, 200)
+	status := &types.DrainStatus{}
+	rw := serve(s.getDrainStatus, http.MethodGet, "/drain")
+	c.Assert(rw.Code, check.Equals, 200)
+	c.Assert(json.Unmarshal(rw.Body.Bytes(), status), check.IsNil)
+	c.Check(status, check.DeepEquals, &types.DrainStatus{ActiveDownloads: 1})
+
+	c.Check(serve(s.startDrain, http.MethodPost, "/drain?timeout=foo").Code, check.Equals, 500)
+	c.Check(s.IsDraining(), check.Equals, false)
+
+	rw = serve(s.startDrain, http.MethodPost, "/drain?timeout=1m")
+	c.Assert(rw.Code, check.Equals, 200)
+	c.Assert(json.Unmarshal(rw.Body.Bytes(), status), check.IsNil)
+	c.Check(status.Draining, check.Equals, true)
+	c.Check(status.ActiveDownloads, check.Equals, int64(1))
+	c.Check(time.Time(status.Deadline).After(time.Now()), check.Equals, true)
+
+	rw = serve(s.ping, http.MethodGet, "/_ping")
+	c.Check(rw.Code, check.Equals, 503)
+	c.Check(rw.Body.String(), check.Equals, "DRAINING")
+
+	rw = serve(s.registry, http.MethodPost, "/peer/registry")
+	c.Assert(rw.Code, check.Equals, 200)
+	result := &types.ResultInfo{}
+	c.Assert(json.Unmarshal(rw.Body.Bytes(), result), check.IsNil)
+	c.Check(result.Code, check.Equals, int32(constants.CodeSupernodeDraining))
+
+	c.Check(serve(s.createReplica, http.MethodPost, "/replicas").Code, check.Equals, 500)
+
+	select {
+	case <-s.Drained():
+		c.Fatal("drained with active downloads")
+	default:
+	}
+	c.Assert(dfgetTaskMgr.UpdateStatus(ctx, "foo", "task", types.DfGetTaskStatusSUCCESS), check.IsNil)
+	select {
+	case <-s.Drained():
+	case <-time.After(5 * drainCheckInterval):
+		c.Fatal("not drained after the active downloads finish")
+	}
+}
+
+func (rs *RouterTestSuite) TestHealthHandler(c *check.C) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer origin.Close()
+	rs.server.Config.HealthOriginProbes = []string{origin.URL}
+	defer func() {
+		rs.server.Config.HealthOriginProbes = nil
+	}()
+
+	code, res, err := httputils.Get("http://"+rs.addr+"/healthz", 0)
+	c.Assert(err, check.IsNil)
+	c.Check(code, check.Equals, 503)
+	health := &types.HealthStatus{}
+	c.Assert(json.Unmarshal(res, health), check.IsNil)
+	c.Check(health.Status, check.Equals, types.HealthStatusStatusUnhealthy)
+
+	statuses := make(map[string]string)
+	for _, component := range health.Components {
+		statuses[component.Name] = component.Status
+	}
+	c.Check(statuses, check.DeepEquals, map[string]string{
+		"store":             types.ComponentHealthStatusHealthy,
+		"gc":                types.ComponentHealthStatusUnhealthy,
+		"pieceErrorWorkers": types.ComponentHealthStatusUnhealthy,
+		"cdnQueue":          types.ComponentHealthStatusHealthy,
+		"origin":            types.ComponentHealthStatusDegraded,
+	})
+	c.Check(health.Components[0].Details["availSpace"], check.Not(check.Equals), "")
+}
+
+// consoleTaskMgr is a TaskMgr which only lists the tasks.
+type consoleTaskMgr struct {
+	mgr.TaskMgr
+	tasks []*types.TaskInfo
+}
+
+func (tm *consoleTaskMgr) List(ctx context.Context, filter map[string]string) ([]*types.TaskInfo, error) {
+	return tm.tasks, nil
+}
+
+func (rs *RouterTestSuite) TestConsoleHandler(c *check.C) {
+	ctx := context.Background()
+	cfg := config.NewConfig()
+	cfg.SetCIDPrefix("127.0.0.1")
+	register := prometheus.NewRegistry()
+
+	webhookMgr, err := webhook.NewManager(cfg, register)
+	c.Assert(err, check.IsNil)
+	dfgetTaskMgr, err := dfgettask.NewManager(cfg, webhookMgr, register)
+	c.Assert(err, check.IsNil)
+	peerMgr, err := peer.NewManager(register)
+	c.Assert(err, check.IsNil)
+	progressMgr, err := progress.NewManager(cfg, register)
+	c.Assert(err, check.IsNil)
+	gcMgr, err := gc.NewManager(cfg, nil, nil, nil, nil, nil, nil, nil, register)
+	c.Assert(err, check.IsNil)
+	s := &Server{
+		Config:       cfg,
+		PeerMgr:      peerMgr,
+		DfgetTaskMgr: dfgetTaskMgr,
+		ProgressMgr:  progressMgr,
+		GCMgr:        gcMgr,
+		TaskMgr: &consoleTaskMgr{tasks: []*types.TaskInfo{
+			{ID: "success", RawURL: "http://a.b.com/success", CdnStatus: types.TaskInfoCdnStatusSUCCESS,
+				FileLength: 100, PieceTotal: 1},
+			{ID: "running", RawURL: "http://a.b.com/running", CdnStatus: types.TaskInfoCdnStatusRUNNING,
+				FileLength: 300, PieceTotal: 3},
+		}},
+	}
+
+	superCID := cfg.GetSuperCID("running")
+	c.Assert(progressMgr.InitProgress(ctx, "running", "super", superCID), check.IsNil)
+	c.Assert(progressMgr.UpdateProgress(ctx, "running", superCID, "super", "", 0, config.PieceSUCCESS), check.IsNil)
+	resp, err := peerMgr.Register(ctx, &types.PeerCreateRequest{IP: "192.168.1.2", HostName: "foo", Port: 65000})
+	c.Assert(err, check.IsNil)
+	c.Assert(progressMgr.InitProgress(ctx, "running", resp.ID, "foo"), check.IsNil)
+	for _, dfgetTask := range []*types.DfGetTask{
+		{CID: "foo", Path: "/peer/file/foo", TaskID: "running", PeerID: resp.ID},
+		{CID: "bar", Path: "/peer/file/bar", TaskID: "running", PeerID: "bar", Status: types.DfGetTaskStatusFAILED},
+		{CID: superCID, Path: "/peer/file/super", TaskID: "running", PeerID: "super"},
+	} {
+		c.Assert(dfgetTaskMgr.Add(ctx, dfgetTask), check.IsNil)
+	}
+
+	rw := httptest.NewRecorder()
+	filter(s.getConsoleStatus).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/console/status", nil))
+	c.Assert(rw.Code, check.Equals, http.StatusOK)
+	status := &types.ConsoleStatus{}
+	c.Assert(json.Unmarshal(rw.Body.Bytes(), status), check.IsNil)
+
+	c.Check(status.MaxBandwidth, check.Equals, int64(cfg.MaxBandwidth-cfg.SystemReservedBandwidth))
+	c.Check(status.CachedBytes, check.Equals, int64(100))
+	c.Check(status.Evictions, check.HasLen, 0)
+	c.Assert(status.Tasks, check.HasLen, 2)
+	c.Check(status.Tasks[0], check.DeepEquals, &types.ConsoleTask{TaskID: "running", URL: "http://a.b.com/running",
+		CdnStatus: types.TaskInfoCdnStatusRUNNING, FileLength: 300, PieceTotal: 3, CdnPieces: 1,
+		ActiveClients: 1, FailedClients: 1})
+	c.Check(status.Tasks[1].CdnPieces, check.Equals, int32(1))
+	c.Assert(status.Peers, check.HasLen, 1)
+	c.Check(status.Peers[0].ID, check.Equals, resp.ID)
+	c.Check(status.Peers[0].IP, check.Equals, "192.168.1.2")
+	c.Check(status.TotalTasks, check.Equals, int64(2))
+	c.Check(status.TotalPeers, check.Equals, int64(1))
+
+	// the tasks shown in the console are limited.
+	var tasks []*types.TaskInfo
+	for i := 0; i <= consoleTaskLimit; i++ {
+		tasks = append(tasks, &types.TaskInfo{ID: fmt.Sprintf("task%d", i), CdnStatus: types.TaskInfoCdnStatusWAITING})
+	}
+	s.TaskMgr = &consoleTaskMgr{tasks: tasks}
+	rw = httptest.NewRecorder()
+	filter(s.getConsoleStatus).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/console/status", nil))
+	c.Assert(rw.Code, check.Equals, http.StatusOK)
+	status = &types.ConsoleStatus{}
+	c.Assert(json.Unmarshal(rw.Body.Bytes(), status), check.IsNil)
+	c.Check(status.Tasks, check.HasLen, consoleTaskLimit)
+	c.Check(status.TotalTasks, check.Equals, int64(consoleTaskLimit+1))
+
+	router := mux.NewRouter()
+	router.Path("/console").Handler(filter(s.getConsoleAsset))
+	router.Path("/console/assets/{name}").Handler(filter(s.getConsoleAsset))
+	for _, tc := range []struct {
+		url         string
+		code        int
+		contentType string
+	}{
+		{"/console", http.StatusOK, "text/html; charset=utf-8"},
+		{"/console/assets/app.js", http.StatusOK, "application/javascript; charset=utf-8"},
+		{"/console/assets/style.css", http.StatusOK, "text/css; charset=utf-8"},
+		{"/console/assets/foo.js", http.StatusNotFound, "text/plain; charset=utf-8"},
+	} {
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tc.url, nil))
+		c.Check(rw.Code, check.Equals, tc.code, check.Commentf("url: %s", tc.url))
+		c.Check(rw.Header().Get("Content-Type"), check.Equals, tc.contentType)
+	}
+}
+
+// registryTaskMgr is a TaskMgr which records the requests of registering tasks.
+type registryTaskMgr struct {
+	mgr.TaskMgr
+	requests []*types.TaskCreateRequest
+	err      error
+}
+
+func (tm *registryTaskMgr) Register(ctx context.Context, req *types.TaskCreateRequest) (*types.TaskCreateResponse, error) {
+	tm.requests = append(tm.requests, req)
+	if tm.err != nil {
+		return nil, tm.err
+	}
+	return &types.TaskCreateResponse{ID: "task"}, nil
+}
+
+func (tm *registryTaskMgr) Replicate(ctx context.Context, req *types.TaskCreateRequest) (*types.TaskCreateResponse, error) {
+	return tm.Register(ctx, req)
+}
+
+func (rs *RouterTestSuite) TestReplicaNamespace(c *check.C) {
+	cfg := config.NewConfig()
+	cfg.Namespaces = []*config.NamespaceConfig{
+		{Name: "datasets", CallSystems: []string{"spark"}, Tokens: []string{"secret"}, MaxTasks: 1},
+	}
+	cfg.CDNSiblings = []string{"192.168.1.3:8002"}
+	taskMgr := &registryTaskMgr{}
+	s := &Server{
+		Config:  cfg,
+		TaskMgr: taskMgr,
+		drain:   drainState{drained: make(chan struct{})},
+	}
+	replicate := func(remoteAddr, namespace, token string) int {
+		body := `{"rawURL":"http://a.b.com/foo","namespace":"` + namespace + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/replicas", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rw := httptest.NewRecorder()
+		filter(s.createReplica).ServeHTTP(rw, req)
+		return rw.Code
+	}
+
+	// the sibling specifies the namespace of the replica.
+	c.Check(replicate("192.168.1.3:40000", "datasets", ""), check.Equals, http.StatusOK)
+	// the others can't forge a namespace which they don't belong to.
+	c.Check(replicate("192.168.1.4:40000", "datasets", ""), check.Equals, http.StatusInternalServerError)
+	c.Check(replicate("192.168.1.4:40000", "", ""), check.Equals, http.StatusOK)
+	c.Check(replicate("192.168.1.4:40000", "datasets", "secret"), check.Equals, http.StatusOK)
+	c.Assert(taskMgr.requests, check.HasLen, 3)
+	c.Check(taskMgr.requests[0].Namespace, check.Equals, "datasets")
+	c.Check(taskMgr.requests[1].Namespace, check.Equals, config.DefaultNamespace)
+	c.Check(taskMgr.requests[2].Namespace, check.Equals, "datasets")
+}
+
+func (rs *RouterTestSuite) TestRegistryNamespace(c *check.C) {
+	cfg := config.NewConfig()
+	cfg.Namespaces = []*config.NamespaceConfig{
+		{Name: "datasets", CallSystems: []string{"spark"}, Tokens: []string{"secret"}, MaxTasks: 1},
+	}
+	register := prometheus.NewRegistry()
+	peerMgr, err := peer.NewManager(register)
+	c.Assert(err, check.IsNil)
+	clusterMgr, err := cluster.NewManager(cfg, register)
+	c.Assert(err, check.IsNil)
+	taskMgr := &registryTaskMgr{}
+	s := &Server{
+		Config:       cfg,
+		PeerMgr:      peerMgr,
+		TaskMgr:      taskMgr,
+		ClusterMgr:   clusterMgr,
+		originClient: httpclient.NewOriginClient(nil),
+		drain:        drainState{drained: make(chan struct{})},
+	}
+	registry := func(callSystem, token string) *types.ResultInfo {
+		body := `{"rawURL":"http://a.b.com/foo","cID":"foo","IP":"192.168.1.2","hostName":"foo",` +
+			`"port":15001,"path":"/peer/file/foo","callSystem":"` + callSystem + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/peer/registry", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rw := httptest.NewRecorder()
+		filter(s.registry).ServeHTTP(rw, req)
+		if rw.Code != http.StatusOK {
+			return nil
+		}
+		result := &types.ResultInfo{}
+		c.Assert(json.Unmarshal(rw.Body.Bytes(), result), check.IsNil)
+		return result
+	}
+
+	c.Check(registry("dfdaemon", "").Code, check.Equals, int32(constants.Success))
+	c.Check(registry("spark", "").Code, check.Equals, int32(constants.Success))
+	c.Check(registry("dfdaemon", "secret").Code, check.Equals, int32(constants.Success))
+	c.Check(registry("dfdaemon", "unknown"), check.IsNil)
+	c.Assert(taskMgr.requests, check.HasLen, 3)
+	c.Check(taskMgr.requests[0].Namespace, check.Equals, config.DefaultNamespace)
+	c.Check(taskMgr.requests[1].Namespace, check.Equals, "datasets")
+	c.Check(taskMgr.requests[2].Namespace, check.Equals, "datasets")
+
+	// the unknown priority is rejected.
+	body := `{"rawURL":"http://a.b.com/foo","cID":"foo","IP":"192.168.1.2","hostName":"foo",` +
+		`"port":15001,"path":"/peer/file/foo","priority":"urgent"}`
+	req := httptest.NewRequest(http.MethodPost, "/peer/registry", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rw := httptest.NewRecorder()
+	filter(s.registry).ServeHTTP(rw, req)
+	c.Check(rw.Code, check.Equals, http.StatusInternalServerError)
+	c.Check(strings.Contains(rw.Body.String(), "priority"), check.Equals, true)
+	c.Assert(taskMgr.requests, check.HasLen, 3)
+
+	taskMgr.err = errortypes.ErrNamespaceQuotaExceeded
+	c.Check(registry("spark", "").Code, check.Equals, int32(constants.CodeNamespaceQuotaExceeded))
+}
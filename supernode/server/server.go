@@ -21,11 +21,13 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/dragonflyoss/Dragonfly/supernode/config"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/cdn"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/cluster"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/dfgettask"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/gc"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/peer"
@@ -33,6 +35,7 @@ import (
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/progress"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/scheduler"
 	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/task"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/webhook"
 	"github.com/dragonflyoss/Dragonfly/supernode/httpclient"
 	"github.com/dragonflyoss/Dragonfly/supernode/store"
 	"github.com/dragonflyoss/Dragonfly/version"
@@ -48,12 +51,29 @@ type Server struct {
 	Config        *config.Config
 	PeerMgr       mgr.PeerMgr
 	TaskMgr       mgr.TaskMgr
+	CDNMgr        mgr.CDNMgr
 	DfgetTaskMgr  mgr.DfgetTaskMgr
 	ProgressMgr   mgr.ProgressMgr
 	GCMgr         mgr.GCMgr
 	PieceErrorMgr mgr.PieceErrorMgr
+	ClusterMgr    mgr.ClusterMgr
+	WebhookMgr    mgr.WebhookMgr
 
 	originClient httpclient.OriginHTTPClient
+	store        *store.Store
+
+	// fileServer serves the downloaded files on DownloadPort, and it's nil if disabled.
+	fileServer *fileServer
+
+	// httpServer is the running http server which will be nil until the server starts.
+	httpServer *http.Server
+	lock       sync.Mutex
+
+	// configLoader loads the latest configuration when reloading.
+	configLoader ConfigLoader
+	reloadLock   sync.Mutex
+
+	drain drainState
 }
 
 // New creates a brand new server instance.
@@ -64,6 +84,10 @@ func New(cfg *config.Config, logger *logrus.Logger, register prometheus.Register
 
 	dfgetLogger = logger
 
+	if err := cfg.CheckNamespaces(); err != nil {
+		return nil, err
+	}
+
 	sm, err := store.NewManager(cfg)
 	if err != nil {
 		return nil, err
@@ -72,19 +96,30 @@ func New(cfg *config.Config, logger *logrus.Logger, register prometheus.Register
 	if err != nil {
 		return nil, err
 	}
+	youngGCThreshold, fullGCThreshold := cfg.GetGCThresholds()
+	storeLocal.StartSpaceMonitor(context.Background(), youngGCThreshold, fullGCThreshold)
+
+	originPolicy, err := httpclient.NewOriginPolicy(cfg.OriginAllow, cfg.OriginDeny)
+	if err != nil {
+		return nil, err
+	}
+	originClient := httpclient.NewOriginClient(originPolicy)
+	webhookMgr, err := webhook.NewManager(cfg, register)
+	if err != nil {
+		return nil, err
+	}
 
-	originClient := httpclient.NewOriginClient()
 	peerMgr, err := peer.NewManager(register)
 	if err != nil {
 		return nil, err
 	}
 
-	dfgetTaskMgr, err := dfgettask.NewManager(cfg, register)
+	dfgetTaskMgr, err := dfgettask.NewManager(cfg, webhookMgr, register)
 	if err != nil {
 		return nil, err
 	}
 
-	progressMgr, err := progress.NewManager(cfg)
+	progressMgr, err := progress.NewManager(cfg, register)
 	if err != nil {
 		return nil, err
 	}
@@ -100,31 +135,48 @@ func New(cfg *config.Config, logger *logrus.Logger, register prometheus.Register
 	}
 
 	taskMgr, err := task.NewManager(cfg, peerMgr, dfgetTaskMgr, progressMgr, cdnMgr,
-		schedulerMgr, originClient, register)
+		schedulerMgr, webhookMgr, originClient, register)
+	if err != nil {
+		return nil, err
+	}
+
+	gcMgr, err := gc.NewManager(cfg, taskMgr, peerMgr, dfgetTaskMgr, progressMgr, cdnMgr, webhookMgr,
+		storeLocal, register)
 	if err != nil {
 		return nil, err
 	}
 
-	gcMgr, err := gc.NewManager(cfg, taskMgr, peerMgr, dfgetTaskMgr, progressMgr, cdnMgr, register)
+	pieceErrorMgr, err := pieceerror.NewManager(cfg, gcMgr, cdnMgr, progressMgr, register)
 	if err != nil {
 		return nil, err
 	}
 
-	pieceErrorMgr, err := pieceerror.NewManager(cfg, gcMgr, cdnMgr)
+	clusterMgr, err := cluster.NewManager(cfg, register)
 	if err != nil {
 		return nil, err
 	}
 
+	var fs *fileServer
+	if cfg.EnableFileServer {
+		fs = newFileServer(cfg, taskMgr, register)
+	}
+
 	return &Server{
 		Config:        cfg,
 		PeerMgr:       peerMgr,
 		TaskMgr:       taskMgr,
+		CDNMgr:        cdnMgr,
 		DfgetTaskMgr:  dfgetTaskMgr,
 		ProgressMgr:   progressMgr,
 		GCMgr:         gcMgr,
 		PieceErrorMgr: pieceErrorMgr,
+		ClusterMgr:    clusterMgr,
+		WebhookMgr:    webhookMgr,
 
 		originClient: originClient,
+		store:        storeLocal,
+		fileServer:   fs,
+		drain:        drainState{drained: make(chan struct{})},
 	}, nil
 }
 
@@ -140,9 +192,18 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	if s.fileServer != nil {
+		if err := s.fileServer.start(); err != nil {
+			l.Close()
+			return err
+		}
+	}
+
 	// start to handle piece error
 	s.PieceErrorMgr.StartHandleError(context.Background())
 	s.GCMgr.StartGC(context.Background())
+	s.ClusterMgr.StartHealthCheck(context.Background())
+	s.WebhookMgr.StartDeliver(context.Background())
 
 	server := &http.Server{
 		Handler:           router,
@@ -150,5 +211,39 @@ func (s *Server) Start() error {
 		ReadHeaderTimeout: time.Minute * 10,
 		IdleTimeout:       time.Minute * 10,
 	}
+	s.lock.Lock()
+	s.httpServer = server
+	s.lock.Unlock()
+
 	return server.Serve(l)
 }
+
+// Stop stops the supernode server gracefully.
+// It stops accepting new requests and waits for the active requests to finish
+// including the ones of the file server, and then cancels all the in-flight CDN downloads to clean up the partial files.
+func (s *Server) Stop(ctx context.Context) error {
+	s.lock.Lock()
+	server := s.httpServer
+	s.lock.Unlock()
+
+	var result error
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			logrus.Errorf("failed to shutdown HTTP server: %v", err)
+			result = err
+		}
+	}
+
+	if s.fileServer != nil {
+		if err := s.fileServer.stop(ctx); err != nil {
+			logrus.Errorf("failed to shutdown file server: %v", err)
+			result = err
+		}
+	}
+
+	if err := s.TaskMgr.CancelAllCDN(ctx); err != nil {
+		logrus.Errorf("failed to cancel the cdn downloads: %v", err)
+		result = err
+	}
+	return result
+}
@@ -19,10 +19,59 @@ package server
 import (
 	"context"
 	"net/http"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+
+	"github.com/pkg/errors"
 )
 
 func (s *Server) ping(context context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	// report unavailable during draining to take the supernode out of rotation.
+	if s.IsDraining() {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		_, err = rw.Write([]byte("DRAINING"))
+		return err
+	}
+
 	rw.WriteHeader(http.StatusOK)
 	_, err = rw.Write([]byte{'O', 'K'})
 	return err
 }
+
+func (s *Server) reloadConfig(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	result, err := s.ReloadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	return EncodeResponse(rw, http.StatusOK, result)
+}
+
+func (s *Server) startDrain(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	var timeout time.Duration
+	if v := req.URL.Query().Get("timeout"); !stringutils.IsEmptyStr(v) {
+		if timeout, err = time.ParseDuration(v); err != nil {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "timeout: %v", err)
+		}
+	}
+
+	return EncodeResponse(rw, http.StatusOK, s.Drain(ctx, timeout))
+}
+
+func (s *Server) getDrainStatus(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	return EncodeResponse(rw, http.StatusOK, s.DrainStatus(ctx))
+}
+
+func (s *Server) healthz(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	health := s.CheckHealth(ctx)
+
+	// orchestrators can tell the unhealthy supernode by the status code.
+	code := http.StatusOK
+	if health.Status == types.HealthStatusStatusUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+	return EncodeResponse(rw, code, health)
+}
@@ -18,10 +18,18 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"net"
 	"net/http"
 	"strconv"
 
+	"github.com/dragonflyoss/Dragonfly/apis/types"
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+
+	"github.com/go-openapi/strfmt"
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 )
 
 func (s *Server) deleteTask(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
@@ -34,3 +42,77 @@ func (s *Server) deleteTask(ctx context.Context, rw http.ResponseWriter, req *ht
 	rw.WriteHeader(http.StatusOK)
 	return nil
 }
+
+func (s *Server) cancelCDN(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	id := mux.Vars(req)["id"]
+
+	if err := s.TaskMgr.CancelCDN(ctx, id); err != nil {
+		return err
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) getPieceHolders(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	id := mux.Vars(req)["id"]
+	params := req.URL.Query()
+
+	holders, err := s.TaskMgr.GetPieceHolders(ctx, id, params.Get("encoding"))
+	if err != nil {
+		return err
+	}
+
+	return EncodeResponse(rw, http.StatusOK, holders)
+}
+
+func (s *Server) createReplica(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+	if s.IsDraining() {
+		return errors.Wrap(errortypes.ErrSupernodeDraining, "refuse to create replica")
+	}
+
+	request := &types.TaskCreateRequest{}
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		return errors.Wrap(errortypes.ErrInvalidValue, err.Error())
+	}
+
+	if err := request.Validate(strfmt.NewFormats()); err != nil {
+		return errors.Wrap(errortypes.ErrInvalidValue, err.Error())
+	}
+
+	// only the siblings are trusted to specify the namespace of the replica, which is the namespace
+	// of the task on the sibling. And the others are limited to the namespace of their token or callSystem
+	// as the registrations, so that they can't charge the quota of another namespace.
+	if !s.isSibling(req) {
+		namespace, err := s.Config.GetNamespace(request.CallSystem, getBearerToken(req))
+		if err != nil {
+			return err
+		}
+		if !stringutils.IsEmptyStr(request.Namespace) && request.Namespace != namespace {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "namespace %s is not allowed", request.Namespace)
+		}
+		request.Namespace = namespace
+	}
+
+	resp, err := s.TaskMgr.Replicate(ctx, request)
+	if err != nil {
+		return err
+	}
+	return EncodeResponse(rw, http.StatusOK, resp)
+}
+
+// isSibling returns whether the request comes from one of the CDNSiblings or the ClusterMembers.
+func (s *Server) isSibling(req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	for _, addresses := range [][]string{s.Config.CDNSiblings, s.Config.ClusterMembers} {
+		for _, address := range addresses {
+			if siblingHost, _, err := net.SplitHostPort(address); err == nil && siblingHost == host {
+				return true
+			}
+		}
+	}
+	return false
+}
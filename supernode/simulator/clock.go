@@ -0,0 +1,99 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"container/heap"
+	"time"
+)
+
+// virtualClock is a discrete event clock, whose time only advances
+// when the next scheduled event is run.
+type virtualClock struct {
+	now    time.Time
+	seq    uint64
+	events eventHeap
+}
+
+func newVirtualClock(start time.Time) *virtualClock {
+	return &virtualClock{
+		now: start,
+	}
+}
+
+// Now returns the current virtual time.
+func (vc *virtualClock) Now() time.Time {
+	return vc.now
+}
+
+// after schedules f to be run after d.
+// The events scheduled at the same time will be run in the order of scheduling.
+func (vc *virtualClock) after(d time.Duration, f func()) {
+	if d < 0 {
+		d = 0
+	}
+	heap.Push(&vc.events, &scheduledEvent{
+		at:  vc.now.Add(d),
+		seq: vc.seq,
+		run: f,
+	})
+	vc.seq++
+}
+
+// step advances the time to the next event and runs it.
+// It returns false if there is no event before the deadline.
+func (vc *virtualClock) step(deadline time.Time) bool {
+	if vc.events.Len() == 0 || vc.events[0].at.After(deadline) {
+		return false
+	}
+
+	e := heap.Pop(&vc.events).(*scheduledEvent)
+	vc.now = e.at
+	e.run()
+	return true
+}
+
+type scheduledEvent struct {
+	at  time.Time
+	seq uint64
+	run func()
+}
+
+// eventHeap is a min-heap of events ordered by the scheduled time.
+type eventHeap []*scheduledEvent
+
+func (h eventHeap) Len() int { return len(h) }
+
+func (h eventHeap) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+
+func (h eventHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *eventHeap) Push(x interface{}) { *h = append(*h, x.(*scheduledEvent)) }
+
+func (h *eventHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
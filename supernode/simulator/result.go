@@ -0,0 +1,120 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Result is the statistics of a simulation.
+type Result struct {
+	// CompletionTimes maintains the time for each peer to download the whole file
+	// since it joined the swarm.
+	// key:PeerID string, value:completionTime time.Duration
+	CompletionTimes map[string]time.Duration
+
+	// Unfinished are the peers which left or timed out before downloading the whole file.
+	Unfinished []string
+
+	// SupernodeEgress is the bytes uploaded by supernode.
+	SupernodeEgress int64
+
+	// PeerEgress is the bytes uploaded by the peers.
+	PeerEgress int64
+
+	// FailedPieces is the count of the pieces failed to be downloaded.
+	FailedPieces int
+
+	// Elapsed is the virtual time elapsed until all the peers finished or the simulation timed out.
+	Elapsed time.Duration
+}
+
+// Distribution is the distribution of the completion times.
+type Distribution struct {
+	Count int
+	Min   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+func newResult() *Result {
+	return &Result{
+		CompletionTimes: make(map[string]time.Duration),
+	}
+}
+
+// Distribution returns the distribution of the completion times of the finished peers.
+func (r *Result) Distribution() Distribution {
+	times := make([]time.Duration, 0, len(r.CompletionTimes))
+	for _, t := range r.CompletionTimes {
+		times = append(times, t)
+	}
+	if len(times) == 0 {
+		return Distribution{}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	var total time.Duration
+	for _, t := range times {
+		total += t
+	}
+	return Distribution{
+		Count: len(times),
+		Min:   times[0],
+		Mean:  total / time.Duration(len(times)),
+		P50:   percentile(times, 50),
+		P90:   percentile(times, 90),
+		P99:   percentile(times, 99),
+		Max:   times[len(times)-1],
+	}
+}
+
+// SupernodeEgressRatio returns the ratio of bytes uploaded by supernode to all the bytes uploaded.
+func (r *Result) SupernodeEgressRatio() float64 {
+	total := r.SupernodeEgress + r.PeerEgress
+	if total == 0 {
+		return 0
+	}
+	return float64(r.SupernodeEgress) / float64(total)
+}
+
+func (r *Result) String() string {
+	d := r.Distribution()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "finished: %d, unfinished: %d, elapsed: %v\n", d.Count, len(r.Unfinished), r.Elapsed)
+	fmt.Fprintf(&buf, "completion time: min=%v mean=%v p50=%v p90=%v p99=%v max=%v\n",
+		d.Min, d.Mean, d.P50, d.P90, d.P99, d.Max)
+	fmt.Fprintf(&buf, "egress: supernode=%d peers=%d supernodeRatio=%.3f\n",
+		r.SupernodeEgress, r.PeerEgress, r.SupernodeEgressRatio())
+	fmt.Fprintf(&buf, "failed pieces: %d", r.FailedPieces)
+	return buf.String()
+}
+
+// percentile returns the p-th percentile of the sorted durations with the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,394 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package simulator replays the swarm traces with the real progress and scheduler managers
+// of supernode, so that the effect of the scheduling strategy and the limits can be evaluated.
+//
+// The simulation is driven by a virtual clock and a seeded random source,
+// so the same trace, config and seed always produce the same result.
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/progress"
+	"github.com/dragonflyoss/Dragonfly/supernode/daemon/mgr/scheduler"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	taskID        = "simulator-task"
+	superPID      = "simulator-supernode"
+	superNodeIP   = "127.0.0.1"
+	renewInterval = 2 * time.Second
+)
+
+// Simulator simulates a swarm downloading a file from supernode and the other peers.
+type Simulator struct {
+	cfg   *config.Config
+	trace *Trace
+	ctx   context.Context
+
+	clock        *virtualClock
+	random       *rand.Rand
+	progressMgr  *progress.Manager
+	schedulerMgr *scheduler.Manager
+
+	supernode *peer
+	// peers maintains the peers that have joined the swarm.
+	// key:PeerID string, value:peer *peer
+	peers map[string]*peer
+
+	// remaining is the count of the peers which are going to join
+	// or are downloading the file.
+	remaining int
+	result    *Result
+}
+
+type peer struct {
+	id       string
+	cid      string
+	spec     PeerSpec
+	joinTime time.Time
+	left     bool
+	finished bool
+	waiting  bool
+
+	// successPieces maintains the pieces that have been downloaded successfully.
+	successPieces map[int]bool
+	// runningPieces maintains the downloads in progress of each piece,
+	// there may be more than one downloads for a piece in the endgame.
+	runningPieces map[int][]*download
+	// uploads is the count of the pieces being uploaded by the peer.
+	uploads int
+}
+
+type download struct {
+	pieceNum  int
+	dst       *peer
+	startTime time.Time
+	cancelled bool
+}
+
+// NewSimulator creates a Simulator to replay the trace with the config of supernode.
+// The cfg will not be modified, and the seed determines all the random choices.
+func NewSimulator(cfg *config.Config, trace *Trace, seed int64) (*Simulator, error) {
+	if err := trace.Validate(); err != nil {
+		return nil, err
+	}
+
+	properties := *cfg.BaseProperties
+	simCfg := &config.Config{BaseProperties: &properties}
+	simCfg.SetCIDPrefix(superNodeIP)
+	simCfg.SetSuperPID(superPID)
+
+	clock := newVirtualClock(time.Unix(0, 0))
+	progressMgr, err := progress.NewManagerWithClock(simCfg, clock.Now, prometheus.NewRegistry())
+	if err != nil {
+		return nil, err
+	}
+	schedulerMgr, err := scheduler.NewManagerWithSeed(simCfg, progressMgr, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Simulator{
+		cfg:          simCfg,
+		trace:        trace,
+		ctx:          context.Background(),
+		clock:        clock,
+		random:       rand.New(rand.NewSource(seed)),
+		progressMgr:  progressMgr,
+		schedulerMgr: schedulerMgr,
+		supernode: &peer{
+			id:   superPID,
+			cid:  simCfg.GetSuperCID(taskID),
+			spec: trace.Supernode,
+		},
+		peers:  make(map[string]*peer),
+		result: newResult(),
+	}, nil
+}
+
+// Run runs the simulation until all the peers finish downloading or leave,
+// or the simulation times out. A Simulator can be run only once.
+func (s *Simulator) Run() (*Result, error) {
+	if err := s.progressMgr.InitProgress(s.ctx, taskID, s.supernode.id, s.supernode.cid); err != nil {
+		return nil, err
+	}
+	s.startCDN()
+
+	for _, e := range s.trace.Events {
+		e := e
+		if e.Type == EventJoin {
+			s.remaining++
+		}
+		s.clock.after(millis(e.Time), func() {
+			s.handleEvent(e)
+		})
+	}
+	s.renewSuperLoad()
+
+	start := s.clock.Now()
+	deadline := start.Add(millis(s.trace.Timeout))
+	for s.remaining > 0 && s.clock.step(deadline) {
+	}
+	if s.remaining > 0 {
+		s.clock.now = deadline
+	}
+	s.result.Elapsed = s.clock.Now().Sub(start)
+
+	for id, p := range s.peers {
+		if !p.finished {
+			s.result.Unfinished = append(s.result.Unfinished, id)
+		}
+	}
+	sort.Strings(s.result.Unfinished)
+	return s.result, nil
+}
+
+// startCDN makes the pieces available on supernode one by one.
+func (s *Simulator) startCDN() {
+	for i := 0; i < s.trace.PieceCount; i++ {
+		pieceNum := i
+		s.clock.after(time.Duration(i+1)*millis(s.trace.CDNPieceCost), func() {
+			if err := s.progressMgr.UpdateProgress(s.ctx, taskID, s.supernode.cid, s.supernode.id, "",
+				pieceNum, config.PieceSUCCESS); err != nil {
+				logrus.Warnf("simulator: failed to update cdn progress for pieceNum(%d): %v", pieceNum, err)
+			}
+		})
+	}
+}
+
+// renewSuperLoad renews the superload periodically as supernode does in the background.
+func (s *Simulator) renewSuperLoad() {
+	s.clock.after(renewInterval, func() {
+		s.progressMgr.RenewSuperLoad()
+		s.renewSuperLoad()
+	})
+}
+
+func (s *Simulator) handleEvent(e *Event) {
+	switch e.Type {
+	case EventJoin:
+		s.join(e.PeerID, e.PeerSpec)
+	case EventLeave:
+		s.leave(s.peers[e.PeerID])
+	case EventUpdate:
+		s.peers[e.PeerID].spec = e.PeerSpec
+	}
+}
+
+func (s *Simulator) join(peerID string, spec PeerSpec) {
+	p := &peer{
+		id:            peerID,
+		cid:           fmt.Sprintf("%s-%s", peerID, taskID),
+		spec:          spec,
+		joinTime:      s.clock.Now(),
+		successPieces: make(map[int]bool),
+		runningPieces: make(map[int][]*download),
+	}
+	s.peers[peerID] = p
+
+	if err := s.progressMgr.InitProgress(s.ctx, taskID, p.id, p.cid); err != nil {
+		logrus.Warnf("simulator: failed to init progress for peer(%s): %v", p.id, err)
+		s.leave(p)
+		return
+	}
+	s.pull(p)
+}
+
+func (s *Simulator) leave(p *peer) {
+	if p.left {
+		return
+	}
+	p.left = true
+	if !p.finished {
+		s.remaining--
+	}
+
+	// the downloads of the peer are aborted, and the uploads will fail when they finish.
+	for _, downloads := range p.runningPieces {
+		for _, d := range downloads {
+			s.cancel(d)
+		}
+	}
+	p.runningPieces = make(map[int][]*download)
+
+	if err := s.progressMgr.UpdatePeerServiceDown(s.ctx, p.id); err != nil {
+		logrus.Warnf("simulator: failed to update service down for peer(%s): %v", p.id, err)
+	}
+}
+
+// pull asks the scheduler for the pieces to download as dfget does.
+func (s *Simulator) pull(p *peer) {
+	if p.left || p.finished {
+		return
+	}
+
+	pieceResults, err := s.schedulerMgr.Schedule(s.ctx, taskID, p.cid, p.id, s.trace.PieceCount)
+	if err != nil {
+		// the peer will pull again when a running piece finishes.
+		if errortypes.IsPeerContinue(err) {
+			return
+		}
+		s.waitAndPull(p)
+		return
+	}
+	if len(pieceResults) == 0 {
+		s.waitAndPull(p)
+		return
+	}
+
+	for _, pr := range pieceResults {
+		s.startDownload(p, pr.PieceNum, pr.DstPID)
+	}
+}
+
+func (s *Simulator) waitAndPull(p *peer) {
+	if p.waiting {
+		return
+	}
+	p.waiting = true
+	s.clock.after(millis(s.trace.WaitInterval), func() {
+		p.waiting = false
+		s.pull(p)
+	})
+}
+
+func (s *Simulator) startDownload(p *peer, pieceNum int, dstPID string) {
+	dst := s.supernode
+	if dstPID != superPID {
+		dst = s.peers[dstPID]
+	}
+	if dst == nil {
+		logrus.Warnf("simulator: unknown dstPID(%s) for pieceNum(%d)", dstPID, pieceNum)
+		return
+	}
+
+	d := &download{
+		pieceNum:  pieceNum,
+		dst:       dst,
+		startTime: s.clock.Now(),
+	}
+	p.runningPieces[pieceNum] = append(p.runningPieces[pieceNum], d)
+	dst.uploads++
+
+	// the bandwidth of dst is shared by the pieces being uploaded.
+	cost := millis(dst.spec.Latency) +
+		time.Duration(float64(s.trace.PieceSize*int64(dst.uploads))/float64(dst.spec.UploadRate)*float64(time.Second))
+	success := s.random.Float64() >= dst.spec.FailureRate
+	s.clock.after(cost, func() {
+		s.finishDownload(p, d, success)
+	})
+}
+
+func (s *Simulator) cancel(d *download) {
+	if d.cancelled {
+		return
+	}
+	d.cancelled = true
+	d.dst.uploads--
+}
+
+func (s *Simulator) finishDownload(p *peer, d *download, success bool) {
+	if d.cancelled {
+		return
+	}
+	s.cancel(d)
+	p.runningPieces[d.pieceNum] = removeDownload(p.runningPieces[d.pieceNum], d)
+	if len(p.runningPieces[d.pieceNum]) == 0 {
+		delete(p.runningPieces, d.pieceNum)
+	}
+
+	// it fails to download from a peer which has left.
+	if d.dst.left {
+		success = false
+	}
+	s.report(p, d, success)
+
+	if !success {
+		s.result.FailedPieces++
+		s.pull(p)
+		return
+	}
+
+	if d.dst == s.supernode {
+		s.result.SupernodeEgress += s.trace.PieceSize
+	} else {
+		s.result.PeerEgress += s.trace.PieceSize
+	}
+	p.successPieces[d.pieceNum] = true
+
+	// cancel the other downloads of the same piece.
+	for _, other := range p.runningPieces[d.pieceNum] {
+		s.cancel(other)
+	}
+	delete(p.runningPieces, d.pieceNum)
+
+	if len(p.successPieces) == s.trace.PieceCount {
+		p.finished = true
+		s.remaining--
+		s.result.CompletionTimes[p.id] = s.clock.Now().Sub(p.joinTime)
+		return
+	}
+	s.pull(p)
+}
+
+// report updates the piece status as the task manager of supernode does
+// when receiving the piece result from dfget.
+func (s *Simulator) report(p *peer, d *download, success bool) {
+	pieceStatus := config.PieceSUCCESS
+	if !success {
+		pieceStatus = config.PieceFAILED
+	}
+
+	if d.dst == s.supernode {
+		if _, err := s.progressMgr.UpdateSuperLoad(s.ctx, taskID, -1, -1); err != nil {
+			logrus.Warnf("simulator: failed to update superLoad: %v", err)
+		}
+	} else if success {
+		cost := s.clock.Now().Sub(d.startTime)
+		if err := s.progressMgr.UpdatePeerThroughput(s.ctx, d.dst.id, s.trace.PieceSize, cost); err != nil {
+			logrus.Warnf("simulator: failed to update throughput of peer(%s): %v", d.dst.id, err)
+		}
+	}
+
+	if err := s.progressMgr.UpdateProgress(s.ctx, taskID, p.cid, p.id, d.dst.id, d.pieceNum, pieceStatus); err != nil {
+		logrus.Warnf("simulator: failed to update progress for pieceNum(%d) peer(%s): %v", d.pieceNum, p.id, err)
+	}
+}
+
+func removeDownload(downloads []*download, d *download) []*download {
+	for i, v := range downloads {
+		if v == d {
+			return append(downloads[:i], downloads[i+1:]...)
+		}
+	}
+	return downloads
+}
+
+func millis(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/supernode/config"
+
+	"github.com/go-check/check"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+func init() {
+	check.Suite(&SimulatorTestSuite{})
+}
+
+type SimulatorTestSuite struct {
+}
+
+func (s *SimulatorTestSuite) TestReplay(c *check.C) {
+	trace, err := LoadTrace("testdata/swarm.json")
+	c.Assert(err, check.IsNil)
+
+	result := runSimulation(c, trace, 1)
+	c.Check(result.Unfinished, check.DeepEquals, []string{"peer-07"})
+	c.Check(result.Distribution().Count, check.Equals, 19)
+
+	// every peer should download the whole file, and most of them are shared by the peers.
+	totalSize := trace.PieceSize * int64(trace.PieceCount)
+	c.Check(result.SupernodeEgress >= totalSize, check.Equals, true)
+	c.Check(result.SupernodeEgress+result.PeerEgress >= 19*totalSize, check.Equals, true)
+	c.Check(result.PeerEgress > result.SupernodeEgress, check.Equals, true)
+}
+
+func (s *SimulatorTestSuite) TestDeterministic(c *check.C) {
+	trace, err := LoadTrace("testdata/swarm.json")
+	c.Assert(err, check.IsNil)
+
+	expected := runSimulation(c, trace, 42)
+	for i := 0; i < 3; i++ {
+		c.Check(runSimulation(c, trace, 42), check.DeepEquals, expected)
+	}
+}
+
+func (s *SimulatorTestSuite) TestTimeout(c *check.C) {
+	trace := &Trace{
+		PieceCount: 10,
+		PieceSize:  1024,
+		Supernode:  PeerSpec{UploadRate: 1024},
+		Timeout:    3000,
+		Events: []*Event{
+			{Time: 0, Type: EventJoin, PeerID: "foo", PeerSpec: PeerSpec{UploadRate: 1024}},
+		},
+	}
+
+	result := runSimulation(c, trace, 1)
+	c.Check(result.Unfinished, check.DeepEquals, []string{"foo"})
+	c.Check(result.Distribution(), check.Equals, Distribution{})
+	c.Check(result.Elapsed.Seconds(), check.Equals, float64(3))
+}
+
+func (s *SimulatorTestSuite) TestParseTrace(c *check.C) {
+	var cases = []struct {
+		trace    string
+		errCheck func(error) bool
+	}{
+		{
+			trace:    `{"pieceCount":1,"pieceSize":1,"supernode":{"uploadRate":1},"events":[{"type":"join","peerID":"a","uploadRate":1}]}`,
+			errCheck: errortypes.IsNilError,
+		},
+		{
+			trace:    `{"pieceCount":0,"pieceSize":1,"supernode":{"uploadRate":1}}`,
+			errCheck: errortypes.IsInvalidValue,
+		},
+		{
+			trace:    `{"pieceCount":1,"pieceSize":1,"supernode":{}}`,
+			errCheck: errortypes.IsInvalidValue,
+		},
+		{
+			trace:    `{"pieceCount":1,"pieceSize":1,"supernode":{"uploadRate":1},"events":[{"type":"leave","peerID":"a"}]}`,
+			errCheck: errortypes.IsInvalidValue,
+		},
+		{
+			trace: `{"pieceCount":1,"pieceSize":1,"supernode":{"uploadRate":1},"events":[` +
+				`{"time":1,"type":"join","peerID":"a","uploadRate":1},{"time":0,"type":"leave","peerID":"a"}]}`,
+			errCheck: errortypes.IsInvalidValue,
+		},
+		{
+			trace:    `{"pieceCount":1,"pieceSize":1,"supernode":{"uploadRate":1},"events":[{"type":"join","peerID":"a","uploadRate":1,"failureRate":2}]}`,
+			errCheck: errortypes.IsInvalidValue,
+		},
+		{
+			trace:    `{"pieceCount":1,"pieceSize":1,"supernode":{"uploadRate":1},"events":[{"type":"foo","peerID":"a"}]}`,
+			errCheck: errortypes.IsInvalidValue,
+		},
+	}
+
+	for _, v := range cases {
+		_, err := ParseTrace(strings.NewReader(v.trace))
+		c.Check(v.errCheck(err), check.Equals, true, check.Commentf("trace: %s, err: %v", v.trace, err))
+	}
+}
+
+func (s *SimulatorTestSuite) TestDistribution(c *check.C) {
+	result := newResult()
+	for i := 1; i <= 100; i++ {
+		result.CompletionTimes[string(rune(i))] = millis(int64(i))
+	}
+
+	d := result.Distribution()
+	c.Check(d.Count, check.Equals, 100)
+	c.Check(d.Min, check.Equals, millis(1))
+	c.Check(d.P50, check.Equals, millis(50))
+	c.Check(d.P90, check.Equals, millis(90))
+	c.Check(d.P99, check.Equals, millis(99))
+	c.Check(d.Max, check.Equals, millis(100))
+}
+
+func runSimulation(c *check.C, trace *Trace, seed int64) *Result {
+	sim, err := NewSimulator(config.NewConfig(), trace, seed)
+	c.Assert(err, check.IsNil)
+	result, err := sim.Run()
+	c.Assert(err, check.IsNil)
+	return result
+}
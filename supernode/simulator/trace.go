@@ -0,0 +1,205 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/errortypes"
+	"github.com/dragonflyoss/Dragonfly/pkg/stringutils"
+
+	"github.com/pkg/errors"
+)
+
+// EventType is the type of the event happened in the swarm.
+type EventType string
+
+const (
+	// EventJoin means that a peer joins the swarm and starts to download the file.
+	EventJoin EventType = "join"
+
+	// EventLeave means that a peer leaves the swarm and stops providing the service.
+	EventLeave EventType = "leave"
+
+	// EventUpdate means that the capability of a peer changes.
+	EventUpdate EventType = "update"
+)
+
+// Trace describes a swarm downloading a file, which can be recorded
+// from the real world or generated and then be replayed by the Simulator.
+//
+// All the durations and time offsets are in milliseconds.
+type Trace struct {
+	// PieceCount is the count of pieces of the file.
+	PieceCount int `json:"pieceCount"`
+
+	// PieceSize is the size of each piece in bytes.
+	PieceSize int64 `json:"pieceSize"`
+
+	// Supernode describes the capability of supernode as an uploader.
+	Supernode PeerSpec `json:"supernode"`
+
+	// CDNPieceCost is the time for supernode to download a piece from the source.
+	// And zero means that the file has been cached by supernode already.
+	CDNPieceCost int64 `json:"cdnPieceCost,omitempty"`
+
+	// WaitInterval is the interval for a peer to pull the piece tasks again
+	// when there is no piece can be downloaded.
+	// default: 200
+	WaitInterval int64 `json:"waitInterval,omitempty"`
+
+	// Timeout is the max time of the simulation, the peers which haven't finished
+	// downloading will be considered as unfinished.
+	// default: 3600000
+	Timeout int64 `json:"timeout,omitempty"`
+
+	// Events are the events happened in the swarm, and they will be replayed in order of time.
+	Events []*Event `json:"events"`
+}
+
+// PeerSpec describes the capability of a peer as an uploader.
+type PeerSpec struct {
+	// UploadRate is the upload bandwidth in bytes per second,
+	// which is shared by the pieces being uploaded at the same time.
+	UploadRate int64 `json:"uploadRate,omitempty"`
+
+	// Latency is the time to start to transfer a piece.
+	Latency int64 `json:"latency,omitempty"`
+
+	// FailureRate is the probability that a piece uploaded fails.
+	FailureRate float64 `json:"failureRate,omitempty"`
+}
+
+// Event is an event happened to a peer.
+type Event struct {
+	// Time is the offset from the start of the simulation.
+	Time int64 `json:"time"`
+
+	// Type is the type of the event.
+	Type EventType `json:"type"`
+
+	// PeerID identifies the peer.
+	PeerID string `json:"peerID"`
+
+	// PeerSpec is the capability of the peer when the event is join or update.
+	PeerSpec
+}
+
+const (
+	defaultWaitInterval = 200
+	defaultTimeout      = 3600 * 1000
+)
+
+// LoadTrace loads a trace from the JSON file.
+func LoadTrace(path string) (*Trace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseTrace(f)
+}
+
+// ParseTrace parses a trace from the JSON stream and validates it.
+func ParseTrace(r io.Reader) (*Trace, error) {
+	trace := &Trace{}
+	if err := json.NewDecoder(r).Decode(trace); err != nil {
+		return nil, errors.Wrap(err, "failed to decode trace")
+	}
+	if err := trace.Validate(); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// Validate checks the trace and fills the default values,
+// and the events will be sorted in order of time.
+func (t *Trace) Validate() error {
+	if t.PieceCount <= 0 {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "pieceCount: %d", t.PieceCount)
+	}
+	if t.PieceSize <= 0 {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "pieceSize: %d", t.PieceSize)
+	}
+	if err := t.Supernode.validate(); err != nil {
+		return errors.Wrap(err, "supernode")
+	}
+	if t.CDNPieceCost < 0 {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "cdnPieceCost: %d", t.CDNPieceCost)
+	}
+	if t.WaitInterval <= 0 {
+		t.WaitInterval = defaultWaitInterval
+	}
+	if t.Timeout <= 0 {
+		t.Timeout = defaultTimeout
+	}
+
+	sort.SliceStable(t.Events, func(i, j int) bool {
+		return t.Events[i].Time < t.Events[j].Time
+	})
+
+	joined := make(map[string]bool)
+	for _, e := range t.Events {
+		if stringutils.IsEmptyStr(e.PeerID) || e.PeerID == superPID {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "peerID: %q", e.PeerID)
+		}
+		if e.Time < 0 {
+			return errors.Wrapf(errortypes.ErrInvalidValue, "time of peer %s: %d", e.PeerID, e.Time)
+		}
+
+		switch e.Type {
+		case EventJoin:
+			if _, ok := joined[e.PeerID]; ok {
+				return errors.Wrapf(errortypes.ErrInvalidValue, "peer %s joins more than once", e.PeerID)
+			}
+			joined[e.PeerID] = true
+		case EventLeave, EventUpdate:
+			if !joined[e.PeerID] {
+				return errors.Wrapf(errortypes.ErrInvalidValue, "peer %s is not in the swarm when %s", e.PeerID, e.Type)
+			}
+			if e.Type == EventLeave {
+				joined[e.PeerID] = false
+			}
+		default:
+			return errors.Wrapf(errortypes.ErrInvalidValue, "type of event: %s", e.Type)
+		}
+
+		if e.Type != EventLeave {
+			if err := e.PeerSpec.validate(); err != nil {
+				return errors.Wrapf(err, "peer %s", e.PeerID)
+			}
+		}
+	}
+	return nil
+}
+
+func (ps PeerSpec) validate() error {
+	if ps.UploadRate <= 0 {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "uploadRate: %d", ps.UploadRate)
+	}
+	if ps.Latency < 0 {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "latency: %d", ps.Latency)
+	}
+	if ps.FailureRate < 0 || ps.FailureRate > 1 {
+		return errors.Wrapf(errortypes.ErrInvalidValue, "failureRate: %f", ps.FailureRate)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// spaceCheckBytes is the number of bytes written between two checks of the available space.
+const spaceCheckBytes = 16 * 1024 * 1024
+
+// spaceRecheckInterval is the interval of checking the available space again while waiting for it,
+// so that the space released outside the store, such as by the other processes, is noticed.
+var spaceRecheckInterval = 5 * time.Second
+
+// SpaceLevel represents how much available space is left in the storage.
+type SpaceLevel int
+
+const (
+	// SpaceNormal means that the available space is more than the high watermark.
+	SpaceNormal SpaceLevel = iota
+
+	// SpaceLow means that the available space is less than the high watermark,
+	// and the garbage should be collected.
+	SpaceLow
+
+	// SpaceCritical means that the available space is less than the low watermark,
+	// and no more new data should be written until the space is released.
+	SpaceCritical
+)
+
+func (l SpaceLevel) String() string {
+	switch l {
+	case SpaceNormal:
+		return "normal"
+	case SpaceLow:
+		return "low"
+	case SpaceCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// SpaceEvent is emitted when the level of the available space is changed.
+type SpaceEvent struct {
+	Level      SpaceLevel
+	AvailSpace fileutils.Fsize
+}
+
+// spaceMonitor checks the available space of the storage as the data is written or removed,
+// and emits a SpaceEvent to the subscribers once it crosses the watermarks.
+type spaceMonitor struct {
+	driver        StorageDriver
+	highWatermark fileutils.Fsize
+	lowWatermark  fileutils.Fsize
+
+	// written is the number of bytes written since the last check.
+	written int64
+
+	// checkLock makes the checks serial to emit the events in order.
+	checkLock sync.Mutex
+
+	sync.RWMutex
+	level       SpaceLevel
+	subscribers []chan SpaceEvent
+
+	// available will be closed when the level leaves SpaceCritical.
+	available chan struct{}
+}
+
+func newSpaceMonitor(driver StorageDriver, highWatermark, lowWatermark fileutils.Fsize) *spaceMonitor {
+	available := make(chan struct{})
+	close(available)
+	return &spaceMonitor{
+		driver:        driver,
+		highWatermark: highWatermark,
+		lowWatermark:  lowWatermark,
+		level:         SpaceNormal,
+		available:     available,
+	}
+}
+
+// setWatermarks updates the watermarks which will be used from the next check.
+func (sm *spaceMonitor) setWatermarks(highWatermark, lowWatermark fileutils.Fsize) {
+	sm.checkLock.Lock()
+	defer sm.checkLock.Unlock()
+
+	sm.highWatermark = highWatermark
+	sm.lowWatermark = lowWatermark
+}
+
+// subscribe returns a channel to receive the SpaceEvents.
+// The stale events will be dropped if the subscriber can't receive them in time.
+func (sm *spaceMonitor) subscribe() <-chan SpaceEvent {
+	sm.Lock()
+	defer sm.Unlock()
+
+	ch := make(chan SpaceEvent, 1)
+	sm.subscribers = append(sm.subscribers, ch)
+	return ch
+}
+
+func (sm *spaceMonitor) getLevel() SpaceLevel {
+	sm.RLock()
+	defer sm.RUnlock()
+	return sm.level
+}
+
+// waitForSpace blocks until the level is not SpaceCritical or the ctx is done,
+// and it checks the available space periodically in the meantime.
+func (sm *spaceMonitor) waitForSpace(ctx context.Context) error {
+	ticker := time.NewTicker(spaceRecheckInterval)
+	defer ticker.Stop()
+
+	for {
+		sm.RLock()
+		available := sm.available
+		sm.RUnlock()
+
+		select {
+		case <-available:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			sm.check(ctx)
+		}
+	}
+}
+
+// onWrite checks the available space when enough data has been written since the last check.
+func (sm *spaceMonitor) onWrite(ctx context.Context, n int64) {
+	if atomic.AddInt64(&sm.written, n) < spaceCheckBytes {
+		return
+	}
+	atomic.StoreInt64(&sm.written, 0)
+	sm.check(ctx)
+}
+
+// check gets the available space and emits a SpaceEvent if the level is changed.
+func (sm *spaceMonitor) check(ctx context.Context) {
+	sm.checkLock.Lock()
+	defer sm.checkLock.Unlock()
+
+	availSpace, err := sm.driver.GetAvailSpace(ctx, &Raw{})
+	if err != nil {
+		logrus.Errorf("failed to get available space: %v", err)
+		return
+	}
+
+	level := SpaceNormal
+	if availSpace <= sm.lowWatermark {
+		level = SpaceCritical
+	} else if availSpace <= sm.highWatermark {
+		level = SpaceLow
+	}
+
+	sm.Lock()
+	if level == sm.level {
+		sm.Unlock()
+		return
+	}
+	if level == SpaceCritical {
+		sm.available = make(chan struct{})
+	} else if sm.level == SpaceCritical {
+		close(sm.available)
+	}
+	sm.level = level
+	subscribers := sm.subscribers
+	sm.Unlock()
+
+	logrus.Infof("the available space(%s) of storage becomes %s", fileutils.FsizeToString(availSpace), level)
+	event := SpaceEvent{Level: level, AvailSpace: availSpace}
+	for _, ch := range subscribers {
+		// replace the stale event which has not been received.
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// countReader counts the bytes read from the underlying reader.
+type countReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.reader.Read(p)
+	cr.count += int64(n)
+	return n, err
+}
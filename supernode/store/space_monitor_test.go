@@ -0,0 +1,142 @@
+/*
+ * Copyright The Dragonfly Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/dragonflyoss/Dragonfly/pkg/fileutils"
+
+	"github.com/go-check/check"
+)
+
+type SpaceMonitorSuite struct{}
+
+func init() {
+	check.Suite(&SpaceMonitorSuite{})
+}
+
+// fakeSpaceDriver consumes the available space as the data is written.
+type fakeSpaceDriver struct {
+	StorageDriver
+	availSpace fileutils.Fsize
+}
+
+func (d *fakeSpaceDriver) Put(ctx context.Context, raw *Raw, data io.Reader) error {
+	n, err := io.Copy(ioutil.Discard, data)
+	d.availSpace -= fileutils.Fsize(n)
+	return err
+}
+
+func (d *fakeSpaceDriver) PutBytes(ctx context.Context, raw *Raw, data []byte) error {
+	d.availSpace -= fileutils.Fsize(len(data))
+	return nil
+}
+
+func (d *fakeSpaceDriver) Remove(ctx context.Context, raw *Raw) error {
+	d.availSpace = 100 * fileutils.MB
+	return nil
+}
+
+func (d *fakeSpaceDriver) GetAvailSpace(ctx context.Context, raw *Raw) (fileutils.Fsize, error) {
+	return d.availSpace, nil
+}
+
+func (s *SpaceMonitorSuite) TestSpaceMonitor(c *check.C) {
+	ctx := context.Background()
+	driver := &fakeSpaceDriver{availSpace: 100 * fileutils.MB}
+	store := &Store{driverName: "fake", driver: driver}
+
+	// the space monitor is not started
+	c.Check(store.SubscribeSpaceEvents(), check.IsNil)
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceNormal)
+	c.Check(store.WaitForSpace(ctx), check.IsNil)
+
+	store.StartSpaceMonitor(ctx, 60*fileutils.MB, 30*fileutils.MB)
+	events := store.SubscribeSpaceEvents()
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceNormal)
+
+	data := make([]byte, spaceCheckBytes)
+	for i := 0; i < 3; i++ {
+		c.Assert(store.PutBytes(ctx, &Raw{Key: "foo"}, data), check.IsNil)
+	}
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceLow)
+	c.Check(<-events, check.Equals, SpaceEvent{Level: SpaceLow, AvailSpace: 52 * fileutils.MB})
+
+	for i := 0; i < 2; i++ {
+		c.Assert(store.Put(ctx, &Raw{Key: "foo"}, bytes.NewReader(data)), check.IsNil)
+	}
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceCritical)
+	c.Check(<-events, check.Equals, SpaceEvent{Level: SpaceCritical, AvailSpace: 20 * fileutils.MB})
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	c.Check(store.WaitForSpace(timeoutCtx), check.NotNil)
+
+	done := make(chan error)
+	go func() {
+		done <- store.WaitForSpace(ctx)
+	}()
+	c.Assert(store.Remove(ctx, &Raw{Key: "foo"}), check.IsNil)
+	c.Check(<-done, check.IsNil)
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceNormal)
+	c.Check(<-events, check.Equals, SpaceEvent{Level: SpaceNormal, AvailSpace: 100 * fileutils.MB})
+}
+
+func (s *SpaceMonitorSuite) TestWaitForSpaceReleasedOutside(c *check.C) {
+	defer func(interval time.Duration) { spaceRecheckInterval = interval }(spaceRecheckInterval)
+	spaceRecheckInterval = 10 * time.Millisecond
+
+	ctx := context.Background()
+	driver := &fakeSpaceDriver{availSpace: 20 * fileutils.MB}
+	store := &Store{driverName: "fake", driver: driver}
+	store.StartSpaceMonitor(ctx, 60*fileutils.MB, 30*fileutils.MB)
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceCritical)
+
+	// the space is released without removing anything from the store.
+	driver.availSpace = 100 * fileutils.MB
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	c.Check(store.WaitForSpace(timeoutCtx), check.IsNil)
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceNormal)
+}
+
+func (s *SpaceMonitorSuite) TestUpdateSpaceWatermarks(c *check.C) {
+	ctx := context.Background()
+	driver := &fakeSpaceDriver{availSpace: 50 * fileutils.MB}
+	store := &Store{driverName: "fake", driver: driver}
+
+	// the space monitor is not started
+	store.UpdateSpaceWatermarks(ctx, 60*fileutils.MB, 30*fileutils.MB)
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceNormal)
+
+	store.StartSpaceMonitor(ctx, 60*fileutils.MB, 30*fileutils.MB)
+	events := store.SubscribeSpaceEvents()
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceLow)
+
+	store.UpdateSpaceWatermarks(ctx, 80*fileutils.MB, 60*fileutils.MB)
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceCritical)
+	c.Check(<-events, check.Equals, SpaceEvent{Level: SpaceCritical, AvailSpace: 50 * fileutils.MB})
+
+	store.UpdateSpaceWatermarks(ctx, 40*fileutils.MB, 20*fileutils.MB)
+	c.Check(store.GetSpaceLevel(), check.Equals, SpaceNormal)
+	c.Check(<-events, check.Equals, SpaceEvent{Level: SpaceNormal, AvailSpace: 50 * fileutils.MB})
+}
@@ -36,6 +36,8 @@ type Store struct {
 	config interface{}
 	// driver holds a storage which implements the interface of StorageDriver.
 	driver StorageDriver
+	// monitor watches the available space of the storage, and it's nil until StartSpaceMonitor is called.
+	monitor *spaceMonitor
 }
 
 // NewStore creates a new Store instance.
@@ -88,7 +90,14 @@ func (s *Store) Put(ctx context.Context, raw *Raw, data io.Reader) error {
 	if err := checkEmptyKey(raw); err != nil {
 		return err
 	}
-	return s.driver.Put(ctx, raw, data)
+	if s.monitor == nil || data == nil {
+		return s.driver.Put(ctx, raw, data)
+	}
+
+	reader := &countReader{reader: data}
+	err := s.driver.Put(ctx, raw, reader)
+	s.monitor.onWrite(ctx, reader.count)
+	return err
 }
 
 // PutBytes puts data into the storage in bytes.
@@ -96,7 +105,11 @@ func (s *Store) PutBytes(ctx context.Context, raw *Raw, data []byte) error {
 	if err := checkEmptyKey(raw); err != nil {
 		return err
 	}
-	return s.driver.PutBytes(ctx, raw, data)
+	err := s.driver.PutBytes(ctx, raw, data)
+	if s.monitor != nil {
+		s.monitor.onWrite(ctx, int64(len(data)))
+	}
+	return err
 }
 
 // Remove the data from the storage based on raw information.
@@ -105,7 +118,11 @@ func (s *Store) Remove(ctx context.Context, raw *Raw) error {
 		stringutils.IsEmptyStr(raw.Bucket)) {
 		return errors.Wrapf(ErrEmptyKey, "cannot set both key and bucket empty at the same time")
 	}
-	return s.driver.Remove(ctx, raw)
+	err := s.driver.Remove(ctx, raw)
+	if err == nil && s.monitor != nil {
+		s.monitor.check(ctx)
+	}
+	return err
 }
 
 // Stat determines whether the data exists based on raw information.
@@ -129,6 +146,52 @@ func (s *Store) Walk(ctx context.Context, raw *Raw) error {
 	return s.driver.Walk(ctx, raw)
 }
 
+// StartSpaceMonitor starts to check the available space of the storage as the data is written or removed.
+// The level of the available space will be SpaceLow when it's less than the highWatermark,
+// and be SpaceCritical when it's less than the lowWatermark.
+//
+// It should be called before the store is used.
+func (s *Store) StartSpaceMonitor(ctx context.Context, highWatermark, lowWatermark fileutils.Fsize) {
+	s.monitor = newSpaceMonitor(s.driver, highWatermark, lowWatermark)
+	s.monitor.check(ctx)
+}
+
+// UpdateSpaceWatermarks updates the watermarks of the space monitor and checks the available space again.
+// It does nothing if the space monitor is not started.
+func (s *Store) UpdateSpaceWatermarks(ctx context.Context, highWatermark, lowWatermark fileutils.Fsize) {
+	if s.monitor == nil {
+		return
+	}
+	s.monitor.setWatermarks(highWatermark, lowWatermark)
+	s.monitor.check(ctx)
+}
+
+// SubscribeSpaceEvents returns a channel to receive the events when the level of the available space is changed.
+// It returns nil if the space monitor is not started.
+func (s *Store) SubscribeSpaceEvents() <-chan SpaceEvent {
+	if s.monitor == nil {
+		return nil
+	}
+	return s.monitor.subscribe()
+}
+
+// GetSpaceLevel returns the current level of the available space.
+// It always returns SpaceNormal if the space monitor is not started.
+func (s *Store) GetSpaceLevel() SpaceLevel {
+	if s.monitor == nil {
+		return SpaceNormal
+	}
+	return s.monitor.getLevel()
+}
+
+// WaitForSpace blocks until the level of the available space is not SpaceCritical or the ctx is done.
+func (s *Store) WaitForSpace(ctx context.Context) error {
+	if s.monitor == nil {
+		return nil
+	}
+	return s.monitor.waitForSpace(ctx)
+}
+
 func checkEmptyKey(raw *Raw) error {
 	if raw == nil || stringutils.IsEmptyStr(raw.Key) {
 		return ErrEmptyKey
@@ -92,7 +92,7 @@ func (s *DFGetP2PTestSuite) TestDownloadFile(c *check.C) {
 			c.Assert(err, check.IsNil)
 		}
 		cmd, err := s.starter.DFGet(ca.timeout*time.Second,
-			"-u", fmt.Sprintf("http://127.0.0.1:%d/%s", environment.SupernodeDownloadPort, ca.filePath),
+			"-u", fmt.Sprintf("http://127.0.0.1:%d/%s", environment.OriginFileServerPort, ca.filePath),
 			"-o", ca.targetPath,
 			"--node", fmt.Sprintf("127.0.0.1:%d", environment.SupernodeListenPort),
 			"--notbs")
@@ -117,12 +117,12 @@ type Starter struct {
 	// is killed.
 	listMap map[*exec.Cmd]*list.Element
 
-	// fileSrv maps a supernode processes to a corresponding file server.
+	// fileSrv maps a supernode processes to a corresponding origin file server.
 	// It's used for shutdown the file server when a corresponding supernode
 	// is killed.
 	fileSrv map[*exec.Cmd]*http.Server
 
-	// supernodeFileServerHome is the home dir of supernode file server.
+	// supernodeFileServerHome is the home dir of the origin file server started with supernode.
 	supernodeFileServerHome string
 
 	lock sync.Mutex
@@ -154,14 +154,14 @@ func (s *Starter) Supernode(running time.Duration, args ...string) (
 		return nil, err
 	}
 	s.supernodeFileServerHome = fp.Join(dir, "repo")
-	if _, err = s.fileServer(cmd, s.supernodeFileServerHome, environment.SupernodeDownloadPort); err != nil {
+	if _, err = s.fileServer(cmd, s.supernodeFileServerHome, environment.OriginFileServerPort); err != nil {
 		s.Kill(cmd)
 		return nil, err
 	}
 	return cmd, err
 }
 
-// WriteSupernodeFileServer writes a file to the supernode file server.
+// WriteSupernodeFileServer writes a file to the origin file server started with supernode.
 func (s *Starter) WriteSupernodeFileServer(filePath string, data []byte, perm os.FileMode) error {
 	return ioutil.WriteFile(fp.Join(s.supernodeFileServerHome, filePath), data, perm)
 }
@@ -30,6 +30,9 @@ var (
 	// SupernodeDownloadPort is the port that supernode will listen.
 	SupernodeDownloadPort = 8009
 
+	// OriginFileServerPort is the port that the file server of the source files will listen.
+	OriginFileServerPort = 8010
+
 	// DragonflySupernodeBinary is the default binary path.
 	DragonflySupernodeBinary = "/usr/local/bin/supernode"
 